@@ -9,17 +9,6 @@ package CF
 import "C"
 import "unsafe"
 
-// The TypeRef type is an untyped reference to any Core Foundation object.
-//
-// https://developer.apple.com/library/mac/documentation/CoreFoundation/Reference/CFTypeRef/#//apple_ref/c/tdef/CFTypeRef
-type TypeRef uintptr
-
-// The TypeID type is used to provide a unique identifier to the type of Core
-// Foundation object.
-//
-// https://developer.apple.com/library/mac/documentation/CoreFoundation/Reference/CFTypeRef/#//apple_ref/c/tdef/CFTypeID
-type TypeID uint64
-
 // GetTypeID returns the TypeID representing the type of the Core Foundation
 // object passed as argument.
 //
@@ -52,13 +41,6 @@ func (obj TypeRef) CopyDescription() StringRef {
 	return StringRef(unsafe.Pointer(C.CFCopyDescription(C.CFTypeRef(obj))))
 }
 
-// String satisfies the fmt.Stringer interface.
-func (obj TypeRef) String() string {
-	s := obj.CopyDescription()
-	defer s.Release()
-	return GoString(s)
-}
-
 // CopyTypeIDDescription returns a string representation of the type id passed
 // as argument.
 //
@@ -67,13 +49,6 @@ func (id TypeID) CopyTypeIDDescription() StringRef {
 	return StringRef(unsafe.Pointer(C.CFCopyTypeIDDescription(C.CFTypeID(id))))
 }
 
-// String satisfies the fmt.Stringer interface.
-func (id TypeID) String() string {
-	s := id.CopyTypeIDDescription()
-	defer s.Release()
-	return GoString(s)
-}
-
 // Equal tests two object for equality.
 //
 // https://developer.apple.com/library/mac/documentation/CoreFoundation/Reference/CFTypeRef/#//apple_ref/c/func/CFEqual
@@ -0,0 +1,170 @@
+// +build !darwin
+
+package CF
+
+import (
+	"fmt"
+	"sync"
+)
+
+// On platforms without Core Foundation the object model is emulated in pure
+// Go, references are keys in a table of objects which track their retain
+// count, type and value. This lets the packages built on top of CF run their
+// logic and tests on any platform.
+
+// The TypeIDs of emulated objects, they match the values of Core Foundation on
+// macOS.
+const (
+	typeIDType   TypeID = 1
+	typeIDString TypeID = 7
+)
+
+var typeIDNames = map[TypeID]string{
+	typeIDType:   "CFType",
+	typeIDString: "CFString",
+}
+
+type object struct {
+	typeID TypeID
+	refs   int
+	value  interface{}
+}
+
+var objects = struct {
+	sync.Mutex
+	last  TypeRef
+	table map[TypeRef]*object
+}{
+	table: make(map[TypeRef]*object),
+}
+
+// objectAlign is the distance between the references of emulated objects, so
+// they look like the pointers Core Foundation would return.
+const objectAlign = 16
+
+func createObject(typeID TypeID, value interface{}) TypeRef {
+	objects.Lock()
+	defer objects.Unlock()
+
+	objects.last += objectAlign
+	objects.table[objects.last] = &object{
+		typeID: typeID,
+		refs:   1,
+		value:  value,
+	}
+	return objects.last
+}
+
+// lookupObject returns the object referenced by obj, it panics if the reference
+// is nil or if the object was already freed, where Core Foundation would
+// crash.
+func lookupObject(obj TypeRef) *object {
+	objects.Lock()
+	o := objects.table[obj]
+	objects.Unlock()
+
+	if o == nil {
+		panic(fmt.Sprintf("CF: invalid reference to an object that doesn't exist or was already released: %#x", uintptr(obj)))
+	}
+
+	return o
+}
+
+// GetTypeID returns the TypeID representing the type of the Core Foundation
+// object passed as argument.
+//
+// https://developer.apple.com/library/mac/documentation/CoreFoundation/Reference/CFTypeRef/#//apple_ref/c/func/CFGetTypeID
+func (obj TypeRef) GetTypeID() TypeID {
+	return lookupObject(obj).typeID
+}
+
+// Retain increases the refence counter of the Core Foundation object passed
+// as argument.
+//
+// https://developer.apple.com/library/mac/documentation/CoreFoundation/Reference/CFTypeRef/#//apple_ref/c/func/CFRetain
+func (obj TypeRef) Retain() {
+	objects.Lock()
+	defer objects.Unlock()
+
+	o := objects.table[obj]
+
+	if o == nil {
+		panic(fmt.Sprintf("CF: retaining an object that doesn't exist or was already released: %#x", uintptr(obj)))
+	}
+
+	o.refs++
+}
+
+// Release decreases the reference counter of the Core Foundation object
+// passed as argument.
+//
+// The emulated implementation panics if the object was already freed, which
+// detects over-releases.
+//
+// https://developer.apple.com/library/mac/documentation/CoreFoundation/Reference/CFTypeRef/#//apple_ref/c/func/CFRelease
+func (obj TypeRef) Release() {
+	objects.Lock()
+	defer objects.Unlock()
+
+	o := objects.table[obj]
+
+	if o == nil {
+		panic(fmt.Sprintf("CF: over-release of an object that doesn't exist or was already released: %#x", uintptr(obj)))
+	}
+
+	if o.refs--; o.refs == 0 {
+		delete(objects.table, obj)
+	}
+}
+
+// CopyDescription returns a string representation of the object passed as
+// argument.
+//
+// https://developer.apple.com/library/mac/documentation/CoreFoundation/Reference/CFTypeRef/#//apple_ref/c/func/CFCopyDescription
+func (obj TypeRef) CopyDescription() StringRef {
+	o := lookupObject(obj)
+
+	if s, ok := o.value.(string); ok && o.typeID == typeIDString {
+		return StringCreate(s)
+	}
+
+	return StringCreate(fmt.Sprintf("<%v %#x>", o.typeID, uintptr(obj)))
+}
+
+// CopyTypeIDDescription returns a string representation of the type id passed
+// as argument.
+//
+// https://developer.apple.com/library/mac/documentation/CoreFoundation/Reference/CFTypeRef/#//apple_ref/c/func/CFCopyTypeIDDescription
+func (id TypeID) CopyTypeIDDescription() StringRef {
+	name, ok := typeIDNames[id]
+
+	if !ok {
+		name = fmt.Sprintf("<unknown type id %d>", uint64(id))
+	}
+
+	return StringCreate(name)
+}
+
+// Equal tests two object for equality.
+//
+// https://developer.apple.com/library/mac/documentation/CoreFoundation/Reference/CFTypeRef/#//apple_ref/c/func/CFEqual
+func Equal(obj1 TypeRef, obj2 TypeRef) bool {
+	if obj1 == obj2 {
+		return true
+	}
+
+	o1, o2 := lookupObject(obj1), lookupObject(obj2)
+	return o1.typeID == o2.typeID && o1.value == o2.value
+}
+
+// retainCount returns the number of references to obj, or zero if it was freed.
+func retainCount(obj TypeRef) int {
+	objects.Lock()
+	defer objects.Unlock()
+
+	if o := objects.table[obj]; o != nil {
+		return o.refs
+	}
+
+	return 0
+}
@@ -0,0 +1,63 @@
+// +build !darwin
+
+package CF
+
+import "testing"
+
+func TestEmulatedRetainCount(t *testing.T) {
+	s := StringCreate("Hello World!")
+
+	if n := retainCount(TypeRef(s)); n != 1 {
+		t.Error("invalid retain count of a new string:", n)
+	}
+
+	s.Retain()
+
+	if n := retainCount(TypeRef(s)); n != 2 {
+		t.Error("invalid retain count after retaining a string:", n)
+	}
+
+	s.Release()
+	s.Release()
+
+	if n := retainCount(TypeRef(s)); n != 0 {
+		t.Error("invalid retain count after releasing a string:", n)
+	}
+}
+
+func TestEmulatedOverRelease(t *testing.T) {
+	s := StringCreate("Hello World!")
+	s.Release()
+
+	defer func() { recover() }()
+	s.Release()
+	t.Error("over-releasing a string did not panic!")
+}
+
+func TestEmulatedStringLength(t *testing.T) {
+	tests := []struct {
+		s string
+		n int
+	}{
+		{"", 0},
+		{"Hello World!", 12},
+		{"你好", 2},
+		{"😀", 2},
+	}
+
+	for _, test := range tests {
+		s := StringCreate(test.s)
+
+		if n := s.Length(); n != test.n {
+			t.Errorf("invalid length of %q: %d != %d", test.s, n, test.n)
+		}
+
+		s.Release()
+	}
+}
+
+func TestEmulatedStringInvalidUTF8(t *testing.T) {
+	if s := StringCreate("\xff"); s != 0 {
+		t.Error("creating a string from invalid UTF-8 returned a non-zero reference:", uintptr(s))
+	}
+}
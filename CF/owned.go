@@ -0,0 +1,186 @@
+package CF
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"runtime"
+	"sync/atomic"
+)
+
+// Object is the interface implemented by the reference types of Core
+// Foundation objects (TypeRef, StringRef, and the types of other packages like
+// CG.ImageRef or CT.FontRef).
+//
+// The ownership layer only depends on this interface, which makes it possible
+// to test it with fake objects.
+type Object interface {
+	Retain()
+	Release()
+}
+
+// ErrReleased is the value that Owned methods panic with when they are called
+// after the object was released.
+var ErrReleased = errors.New("CF: object was already released")
+
+// Owned holds a reference to a Core Foundation object and releases it when
+// Close is called, or when the Owned value is garbage collected if the program
+// forgot to close it.
+//
+// Owned values are safe to use from multiple goroutines.
+type Owned[T Object] struct {
+	ref      T
+	released int32
+	stack    string
+}
+
+// Own takes ownership of ref, which must be a reference that the program is
+// responsible for releasing (for example one returned by a function with
+// Create or Copy in its name). Ownership is transferred to the returned value,
+// the program must not release ref itself anymore.
+//
+// A zero reference can be owned, it is simply never released.
+func Own[T Object](ref T) *Owned[T] {
+	o := &Owned[T]{ref: ref}
+
+	if trackAllocations {
+		o.stack = callers(2)
+	}
+
+	runtime.SetFinalizer(o, (*Owned[T]).finalize)
+	return o
+}
+
+// RetainOwned retains ref and returns a value owning the new reference, the
+// program keeps ownership of the reference it passed to the function.
+func RetainOwned[T Object](ref T) *Owned[T] {
+	ref.Retain()
+	return Own(ref)
+}
+
+// Get returns the reference held by o, it panics with ErrReleased if o was
+// closed.
+//
+// The reference is only valid as long as o isn't closed, and the program must
+// keep o reachable while it uses the reference, for example with
+// runtime.KeepAlive, or the finalizer may release it.
+func (o *Owned[T]) Get() T {
+	if atomic.LoadInt32(&o.released) != 0 {
+		panic(ErrReleased)
+	}
+	return o.ref
+}
+
+// Close releases the object held by o, it is idempotent so it can be deferred
+// right after creating the Owned value and also called on error paths.
+//
+// The method always returns nil, it has an error return value so Owned values
+// satisfy the io.Closer interface.
+func (o *Owned[T]) Close() error {
+	o.release()
+	return nil
+}
+
+// Release releases the object held by o, unlike Close it panics with
+// ErrReleased if the object was already released, which helps detecting
+// double releases.
+func (o *Owned[T]) Release() {
+	if !o.release() {
+		panic(ErrReleased)
+	}
+}
+
+// Released returns true if the object held by o was released.
+func (o *Owned[T]) Released() bool {
+	return atomic.LoadInt32(&o.released) != 0
+}
+
+// String satisfies the fmt.Stringer interface.
+func (o *Owned[T]) String() string {
+	if o.Released() {
+		return fmt.Sprintf("Owned[%T](released)", o.ref)
+	}
+	return fmt.Sprintf("Owned[%T](%v)", o.ref, o.ref)
+}
+
+func (o *Owned[T]) release() bool {
+	if !atomic.CompareAndSwapInt32(&o.released, 0, 1) {
+		return false
+	}
+
+	runtime.SetFinalizer(o, nil)
+
+	if !isZero(o.ref) {
+		o.ref.Release()
+	}
+
+	return true
+}
+
+func (o *Owned[T]) finalize() {
+	if atomic.LoadInt32(&o.released) != 0 {
+		return
+	}
+
+	if trackAllocations && LeakHandler != nil {
+		LeakHandler(Leak{
+			Object: fmt.Sprintf("%T(%v)", o.ref, o.ref),
+			Stack:  o.stack,
+		})
+	}
+
+	o.release()
+}
+
+// Leak describes an owned object that was garbage collected without having
+// been closed.
+type Leak struct {
+	// A description of the leaked object.
+	Object string
+
+	// The stack trace of the call that took ownership of the object.
+	Stack string
+}
+
+// String satisfies the fmt.Stringer interface.
+func (leak Leak) String() string {
+	return fmt.Sprintf("CF: %s was not closed, it was owned at:\n%s", leak.Object, leak.Stack)
+}
+
+// LeakHandler is called when an owned object is garbage collected without
+// having been closed, the default handler prints the leak to stderr.
+//
+// Leaks are only reported by programs built with the cfdebug build tag, since
+// recording the allocation stack of every owned object is expensive. The
+// object is released after the handler returns.
+var LeakHandler = func(leak Leak) {
+	fmt.Fprintln(os.Stderr, leak)
+}
+
+// trackAllocations is true when the allocation stacks of owned objects are
+// recorded and leaks are reported, it is set by the cfdebug build tag.
+var trackAllocations = debugBuild
+
+func callers(skip int) string {
+	pc := make([]uintptr, 32)
+	n := runtime.Callers(skip+1, pc)
+	frames := runtime.CallersFrames(pc[:n])
+	s := &bytes.Buffer{}
+
+	for {
+		f, more := frames.Next()
+		fmt.Fprintf(s, "\t%s\n\t\t%s:%d\n", f.Function, f.File, f.Line)
+
+		if !more {
+			break
+		}
+	}
+
+	return s.String()
+}
+
+func isZero[T Object](ref T) bool {
+	var zero T
+	return any(ref) == any(zero)
+}
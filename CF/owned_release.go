@@ -0,0 +1,5 @@
+// +build !cfdebug
+
+package CF
+
+const debugBuild = false
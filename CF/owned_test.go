@@ -0,0 +1,207 @@
+package CF
+
+import (
+	"bytes"
+	"runtime"
+	"sync"
+	"testing"
+	"time"
+)
+
+// fakeObject is an Object implementation that counts the references to a
+// fake Core Foundation object.
+type fakeObject struct {
+	mutex    sync.Mutex
+	count    int
+	released chan struct{}
+}
+
+func newFakeObject() *fakeObject {
+	return &fakeObject{count: 1, released: make(chan struct{})}
+}
+
+func (obj *fakeObject) Retain() {
+	obj.mutex.Lock()
+	obj.count++
+	obj.mutex.Unlock()
+}
+
+func (obj *fakeObject) Release() {
+	obj.mutex.Lock()
+	defer obj.mutex.Unlock()
+
+	if obj.count == 0 {
+		panic("fake object over-released")
+	}
+
+	if obj.count--; obj.count == 0 {
+		close(obj.released)
+	}
+}
+
+func (obj *fakeObject) refs() int {
+	obj.mutex.Lock()
+	defer obj.mutex.Unlock()
+	return obj.count
+}
+
+func TestOwnedClose(t *testing.T) {
+	obj := newFakeObject()
+	o := Own(obj)
+
+	if o.Get() != obj {
+		t.Error("invalid owned reference:", o.Get())
+	}
+
+	if o.Released() {
+		t.Error("the owned object was reported as released before being closed")
+	}
+
+	o.Close()
+	o.Close()
+
+	if n := obj.refs(); n != 0 {
+		t.Error("invalid reference count after closing the owned object:", n)
+	}
+
+	if !o.Released() {
+		t.Error("the owned object was not reported as released after being closed")
+	}
+}
+
+func TestRetainOwned(t *testing.T) {
+	obj := newFakeObject()
+	o := RetainOwned(obj)
+
+	if n := obj.refs(); n != 2 {
+		t.Error("invalid reference count after retaining the owned object:", n)
+	}
+
+	o.Close()
+
+	if n := obj.refs(); n != 1 {
+		t.Error("invalid reference count after closing the owned object:", n)
+	}
+}
+
+func TestOwnedReleaseTwice(t *testing.T) {
+	o := Own(newFakeObject())
+	o.Release()
+
+	defer func() {
+		if err := recover(); err != ErrReleased {
+			t.Error("invalid panic value:", err)
+		}
+	}()
+
+	o.Release()
+	t.Error("releasing an owned object twice did not panic!")
+}
+
+func TestOwnedGetAfterClose(t *testing.T) {
+	o := Own(newFakeObject())
+	o.Close()
+
+	defer func() { recover() }()
+	o.Get()
+	t.Error("getting a released object did not panic!")
+}
+
+func TestOwnedZero(t *testing.T) {
+	o := Own[*fakeObject](nil)
+
+	if err := o.Close(); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestOwnedConcurrentClose(t *testing.T) {
+	obj := newFakeObject()
+	o := Own(obj)
+	wg := sync.WaitGroup{}
+
+	for i := 0; i != 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			o.Close()
+		}()
+	}
+
+	wg.Wait()
+
+	if n := obj.refs(); n != 0 {
+		t.Error("invalid reference count after closing the owned object concurrently:", n)
+	}
+}
+
+func TestOwnedFinalizer(t *testing.T) {
+	obj := newFakeObject()
+	Own(obj)
+
+	if !waitReleased(obj) {
+		t.Error("the finalizer did not release the owned object")
+	}
+}
+
+func TestOwnedLeakReport(t *testing.T) {
+	leaks := make(chan Leak, 1)
+	defer func(track bool, handler func(Leak)) {
+		trackAllocations, LeakHandler = track, handler
+	}(trackAllocations, LeakHandler)
+
+	trackAllocations = true
+	LeakHandler = func(leak Leak) { leaks <- leak }
+
+	obj := newFakeObject()
+	ownLeakedObject(obj)
+
+	if !waitReleased(obj) {
+		t.Fatal("the finalizer did not release the owned object")
+	}
+
+	leak := <-leaks
+
+	if !bytes.Contains([]byte(leak.Stack), []byte("ownLeakedObject")) {
+		t.Error("the leak report does not contain the allocation stack:", leak)
+	}
+
+	if !bytes.Contains([]byte(leak.Object), []byte("fakeObject")) {
+		t.Error("the leak report does not describe the object:", leak)
+	}
+}
+
+func TestOwnedNoLeakReportAfterClose(t *testing.T) {
+	defer func(track bool, handler func(Leak)) {
+		trackAllocations, LeakHandler = track, handler
+	}(trackAllocations, LeakHandler)
+
+	trackAllocations = true
+	LeakHandler = func(leak Leak) { t.Error("closed object reported as leaked:", leak) }
+
+	Own(newFakeObject()).Close()
+
+	for i := 0; i != 3; i++ {
+		runtime.GC()
+	}
+}
+
+//go:noinline
+func ownLeakedObject(obj *fakeObject) {
+	Own(obj)
+}
+
+// waitReleased runs the garbage collector until obj is released, or gives up
+// after a few seconds.
+func waitReleased(obj *fakeObject) bool {
+	for i := 0; i != 100; i++ {
+		runtime.GC()
+
+		select {
+		case <-obj.released:
+			return true
+		case <-time.After(20 * time.Millisecond):
+		}
+	}
+	return false
+}
@@ -5,16 +5,10 @@ package CF
 // #include <CoreFoundation/CFString.h>
 import "C"
 import (
-	"fmt"
 	"reflect"
 	"unsafe"
 )
 
-// The StringRef type is a reference to a Core Foundation string object.
-//
-// https://developer.apple.com/library/mac/documentation/CoreFoundation/Reference/CFStringRef/index.html#//apple_ref/c/tdef/CFStringRef
-type StringRef TypeRef
-
 // StringCreate takes a Go string as argument and creates a String object
 // that represents the same content, then returns a reference to the newly
 // created string.
@@ -59,35 +53,9 @@ func GoString(s StringRef) string {
 	return string(b[:n])
 }
 
-// Retain increases the refence counter of the Core Foundation string passed
-// as argument.
-//
-// https://developer.apple.com/library/mac/documentation/CoreFoundation/Reference/CFTypeRef/#//apple_ref/c/func/CFRetain
-func (s StringRef) Retain() {
-	TypeRef(s).Retain()
-}
-
-// Release decreases the reference counter of the Core Foundation string
-// passed as argument.
-//
-// https://developer.apple.com/library/mac/documentation/CoreFoundation/Reference/CFTypeRef/#//apple_ref/c/func/CFRelease
-func (s StringRef) Release() {
-	TypeRef(s).Release()
-}
-
 // Length returns the number of characters in the string it's called on.
 //
 // https://developer.apple.com/library/mac/documentation/CoreFoundation/Reference/CFStringRef/#//apple_ref/c/func/CFStringGetLength
 func (s StringRef) Length() int {
 	return int(C.CFStringGetLength(unsafe.Pointer(s)))
 }
-
-// String statisfies the fmt.Stringer interface.
-func (s StringRef) String() string {
-	return GoString(s)
-}
-
-// GoString satisfies the fmt.GoStringer interface.
-func (s StringRef) GoString() string {
-	return fmt.Sprintf("%v", s.String())
-}
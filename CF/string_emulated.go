@@ -0,0 +1,58 @@
+// +build !darwin
+
+package CF
+
+import (
+	"fmt"
+	"unicode/utf16"
+	"unicode/utf8"
+)
+
+// StringCreate takes a Go string as argument and creates a String object
+// that represents the same content, then returns a reference to the newly
+// created string.
+//
+// Like Core Foundation, the function returns a zero reference if s isn't a
+// valid UTF-8 string.
+//
+// It is the program's responsibility to release the object returned by this
+// function with a call to Release.
+//
+// https://developer.apple.com/library/mac/documentation/CoreFoundation/Reference/CFStringRef/index.html#//apple_ref/c/func/CFStringCreateWithBytes
+func StringCreate(s string) StringRef {
+	if !utf8.ValidString(s) {
+		return 0
+	}
+	return StringRef(createObject(typeIDString, s))
+}
+
+// GoString creates a new Go string value with a content equivalent to the
+// StringRef object passed as argument.
+func GoString(s StringRef) string {
+	return lookupString(s)
+}
+
+// Length returns the number of characters in the string it's called on.
+//
+// Characters are counted in UTF-16 code units, like Core Foundation does.
+//
+// https://developer.apple.com/library/mac/documentation/CoreFoundation/Reference/CFStringRef/#//apple_ref/c/func/CFStringGetLength
+func (s StringRef) Length() int {
+	n := 0
+
+	for _, r := range lookupString(s) {
+		n += len(utf16.Encode([]rune{r}))
+	}
+
+	return n
+}
+
+func lookupString(s StringRef) string {
+	o := lookupObject(TypeRef(s))
+
+	if o.typeID != typeIDString {
+		panic(fmt.Sprintf("CF: %#x is not a string object but a %v", uintptr(s), o.typeID))
+	}
+
+	return o.value.(string)
+}
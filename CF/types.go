@@ -0,0 +1,59 @@
+package CF
+
+import "fmt"
+
+// The TypeRef type is an untyped reference to any Core Foundation object.
+//
+// https://developer.apple.com/library/mac/documentation/CoreFoundation/Reference/CFTypeRef/#//apple_ref/c/tdef/CFTypeRef
+type TypeRef uintptr
+
+// The TypeID type is used to provide a unique identifier to the type of Core
+// Foundation object.
+//
+// https://developer.apple.com/library/mac/documentation/CoreFoundation/Reference/CFTypeRef/#//apple_ref/c/tdef/CFTypeID
+type TypeID uint64
+
+// String satisfies the fmt.Stringer interface.
+func (obj TypeRef) String() string {
+	s := obj.CopyDescription()
+	defer s.Release()
+	return GoString(s)
+}
+
+// String satisfies the fmt.Stringer interface.
+func (id TypeID) String() string {
+	s := id.CopyTypeIDDescription()
+	defer s.Release()
+	return GoString(s)
+}
+
+// The StringRef type is a reference to a Core Foundation string object.
+//
+// https://developer.apple.com/library/mac/documentation/CoreFoundation/Reference/CFStringRef/index.html#//apple_ref/c/tdef/CFStringRef
+type StringRef TypeRef
+
+// Retain increases the refence counter of the Core Foundation string passed
+// as argument.
+//
+// https://developer.apple.com/library/mac/documentation/CoreFoundation/Reference/CFTypeRef/#//apple_ref/c/func/CFRetain
+func (s StringRef) Retain() {
+	TypeRef(s).Retain()
+}
+
+// Release decreases the reference counter of the Core Foundation string
+// passed as argument.
+//
+// https://developer.apple.com/library/mac/documentation/CoreFoundation/Reference/CFTypeRef/#//apple_ref/c/func/CFRelease
+func (s StringRef) Release() {
+	TypeRef(s).Release()
+}
+
+// String statisfies the fmt.Stringer interface.
+func (s StringRef) String() string {
+	return GoString(s)
+}
+
+// GoString satisfies the fmt.GoStringer interface.
+func (s StringRef) GoString() string {
+	return fmt.Sprintf("%v", s.String())
+}
@@ -0,0 +1,193 @@
+package CG
+
+import (
+	"errors"
+	"math"
+)
+
+// ErrSingularMatrix is returned when attempting to invert an affine
+// transformation which has no inverse.
+var ErrSingularMatrix = errors.New("CG: singular affine transform matrix cannot be inverted")
+
+// The CGAffineTransform struct is a Go equivalent to the type of the same name
+// provided by Core Graphics.
+//
+// https://developer.apple.com/library/mac/documentation/GraphicsImaging/Reference/CGAffineTransform/index.html#//apple_ref/doc/c_ref/CGAffineTransform
+type AffineTransform struct {
+	A  Float
+	B  Float
+	C  Float
+	D  Float
+	Tx Float
+	Ty Float
+}
+
+// AffineTransformIdentity represents the identity matrix.
+//
+// https://developer.apple.com/library/mac/documentation/GraphicsImaging/Reference/CGAffineTransform/index.html#//apple_ref/doc/constant_group/CGAffineTransformIdentity
+var AffineTransformIdentity = AffineTransform{
+	1, 0, 0, 1, 0, 0,
+}
+
+// AffineTransformMake returns an affine transformation matrix constructed
+// from the values passed as arguments.
+//
+// https://developer.apple.com/library/mac/documentation/GraphicsImaging/Reference/CGAffineTransform/index.html#//apple_ref/c/func/CGAffineTransformMake
+func AffineTransformMake(a Float, b Float, c Float, d Float, tx Float, ty Float) AffineTransform {
+	return AffineTransform{
+		A:  a,
+		B:  b,
+		C:  c,
+		D:  d,
+		Tx: tx,
+		Ty: ty,
+	}
+}
+
+// AffineTransformMakeTranslation returns an affine transformation matrix
+// representing a translation by tx and ty.
+//
+// https://developer.apple.com/library/mac/documentation/GraphicsImaging/Reference/CGAffineTransform/index.html#//apple_ref/c/func/CGAffineTransformMakeTranslation
+func AffineTransformMakeTranslation(tx Float, ty Float) AffineTransform {
+	return AffineTransformMake(1, 0, 0, 1, tx, ty)
+}
+
+// AffineTransformMakeScale returns an affine transformation matrix
+// representing a scaling by sx and sy.
+//
+// https://developer.apple.com/library/mac/documentation/GraphicsImaging/Reference/CGAffineTransform/index.html#//apple_ref/c/func/CGAffineTransformMakeScale
+func AffineTransformMakeScale(sx Float, sy Float) AffineTransform {
+	return AffineTransformMake(sx, 0, 0, sy, 0, 0)
+}
+
+// AffineTransformMakeRotation returns an affine transformation matrix
+// representing a rotation by angle radians. In the default Core Graphics
+// coordinate space positive values rotate counterclockwise.
+//
+// https://developer.apple.com/library/mac/documentation/GraphicsImaging/Reference/CGAffineTransform/index.html#//apple_ref/c/func/CGAffineTransformMakeRotation
+func AffineTransformMakeRotation(angle Float) AffineTransform {
+	sin, cos := math.Sincos(float64(angle))
+	return AffineTransformMake(Float(cos), Float(sin), Float(-sin), Float(cos), 0, 0)
+}
+
+// IsIdentity returns true if t is the identity transformation.
+//
+// https://developer.apple.com/library/mac/documentation/GraphicsImaging/Reference/CGAffineTransform/index.html#//apple_ref/c/func/CGAffineTransformIsIdentity
+func (t AffineTransform) IsIdentity() bool {
+	return t == AffineTransformIdentity
+}
+
+// EqualToTransform returns true if t and t2 are the same transformation.
+//
+// https://developer.apple.com/library/mac/documentation/GraphicsImaging/Reference/CGAffineTransform/index.html#//apple_ref/c/func/CGAffineTransformEqualToTransform
+func (t AffineTransform) EqualToTransform(t2 AffineTransform) bool {
+	return t == t2
+}
+
+// Concat returns the transformation obtained by applying t first, then t2.
+//
+// https://developer.apple.com/library/mac/documentation/GraphicsImaging/Reference/CGAffineTransform/index.html#//apple_ref/c/func/CGAffineTransformConcat
+func (t AffineTransform) Concat(t2 AffineTransform) AffineTransform {
+	return AffineTransform{
+		A:  t.A*t2.A + t.B*t2.C,
+		B:  t.A*t2.B + t.B*t2.D,
+		C:  t.C*t2.A + t.D*t2.C,
+		D:  t.C*t2.B + t.D*t2.D,
+		Tx: t.Tx*t2.A + t.Ty*t2.C + t2.Tx,
+		Ty: t.Tx*t2.B + t.Ty*t2.D + t2.Ty,
+	}
+}
+
+// Invert returns the inverse of t.
+//
+// If the matrix is singular the function returns t unchanged and
+// ErrSingularMatrix.
+//
+// https://developer.apple.com/library/mac/documentation/GraphicsImaging/Reference/CGAffineTransform/index.html#//apple_ref/c/func/CGAffineTransformInvert
+func (t AffineTransform) Invert() (AffineTransform, error) {
+	det := t.A*t.D - t.B*t.C
+
+	if det == 0 {
+		return t, ErrSingularMatrix
+	}
+
+	return AffineTransform{
+		A:  t.D / det,
+		B:  -t.B / det,
+		C:  -t.C / det,
+		D:  t.A / det,
+		Tx: (t.C*t.Ty - t.D*t.Tx) / det,
+		Ty: (t.B*t.Tx - t.A*t.Ty) / det,
+	}, nil
+}
+
+// Translate modifies t so it translates by tx and ty before applying its
+// original transformation.
+//
+// https://developer.apple.com/library/mac/documentation/GraphicsImaging/Reference/CGAffineTransform/index.html#//apple_ref/c/func/CGAffineTransformTranslate
+func (t *AffineTransform) Translate(tx Float, ty Float) {
+	*t = AffineTransformMakeTranslation(tx, ty).Concat(*t)
+}
+
+// Scale modifies t so it scales by sx and sy before applying its original
+// transformation.
+//
+// https://developer.apple.com/library/mac/documentation/GraphicsImaging/Reference/CGAffineTransform/index.html#//apple_ref/c/func/CGAffineTransformScale
+func (t *AffineTransform) Scale(sx Float, sy Float) {
+	*t = AffineTransformMakeScale(sx, sy).Concat(*t)
+}
+
+// Rotate modifies t so it rotates by angle radians before applying its
+// original transformation.
+//
+// https://developer.apple.com/library/mac/documentation/GraphicsImaging/Reference/CGAffineTransform/index.html#//apple_ref/c/func/CGAffineTransformRotate
+func (t *AffineTransform) Rotate(angle Float) {
+	*t = AffineTransformMakeRotation(angle).Concat(*t)
+}
+
+// ApplyAffineTransform returns the point obtained by applying t to p.
+//
+// https://developer.apple.com/library/mac/documentation/GraphicsImaging/Reference/CGAffineTransform/index.html#//apple_ref/c/func/CGPointApplyAffineTransform
+func (p Point) ApplyAffineTransform(t AffineTransform) Point {
+	return Point{
+		X: t.A*p.X + t.C*p.Y + t.Tx,
+		Y: t.B*p.X + t.D*p.Y + t.Ty,
+	}
+}
+
+// ApplyAffineTransform returns the size obtained by applying t to s, the
+// translation part of the transformation is ignored.
+//
+// https://developer.apple.com/library/mac/documentation/GraphicsImaging/Reference/CGAffineTransform/index.html#//apple_ref/c/func/CGSizeApplyAffineTransform
+func (s Size) ApplyAffineTransform(t AffineTransform) Size {
+	return Size{
+		Width:  t.A*s.Width + t.C*s.Height,
+		Height: t.B*s.Width + t.D*s.Height,
+	}
+}
+
+// ApplyAffineTransform returns the smallest axis-aligned rectangle that
+// contains r after applying t to its four corners.
+//
+// The null and infinite rectangles are returned unchanged.
+//
+// https://developer.apple.com/library/mac/documentation/GraphicsImaging/Reference/CGAffineTransform/index.html#//apple_ref/c/func/CGRectApplyAffineTransform
+func (r Rect) ApplyAffineTransform(t AffineTransform) Rect {
+	if r.IsNull() || r.IsInfinite() {
+		return r
+	}
+
+	x0, y0 := r.GetMinX(), r.GetMinY()
+	x1, y1 := r.GetMaxX(), r.GetMaxY()
+
+	p0 := Point{x0, y0}.ApplyAffineTransform(t)
+	p1 := Point{x1, y0}.ApplyAffineTransform(t)
+	p2 := Point{x0, y1}.ApplyAffineTransform(t)
+	p3 := Point{x1, y1}.ApplyAffineTransform(t)
+
+	minX := min(p0.X, p1.X, p2.X, p3.X)
+	minY := min(p0.Y, p1.Y, p2.Y, p3.Y)
+	maxX := max(p0.X, p1.X, p2.X, p3.X)
+	maxY := max(p0.Y, p1.Y, p2.Y, p3.Y)
+	return RectMake(minX, minY, maxX-minX, maxY-minY)
+}
@@ -0,0 +1,202 @@
+package CG
+
+import (
+	"math"
+	"testing"
+)
+
+func TestAffineTransformIsIdentity(t *testing.T) {
+	if !AffineTransformIdentity.IsIdentity() {
+		t.Error("the identity matrix is not reported as identity")
+	}
+	if !AffineTransformMake(1, 0, 0, 1, 0, 0).IsIdentity() {
+		t.Error("a matrix made from identity values is not reported as identity")
+	}
+	if AffineTransformMakeTranslation(1, 0).IsIdentity() {
+		t.Error("a translation matrix was reported as identity")
+	}
+}
+
+func TestAffineTransformEqualToTransform(t *testing.T) {
+	if !AffineTransformMakeScale(2, 3).EqualToTransform(AffineTransformMake(2, 0, 0, 3, 0, 0)) {
+		t.Error("comparing transforms for equality failed")
+	}
+	if AffineTransformMakeScale(2, 3).EqualToTransform(AffineTransformMakeScale(3, 2)) {
+		t.Error("comparing transforms for difference failed")
+	}
+}
+
+func TestAffineTransformConcat(t *testing.T) {
+	tests := []struct {
+		t1, t2 AffineTransform
+		out    AffineTransform
+	}{
+		{
+			t1:  AffineTransformIdentity,
+			t2:  AffineTransformMake(1, 2, 3, 4, 5, 6),
+			out: AffineTransformMake(1, 2, 3, 4, 5, 6),
+		},
+		{
+			t1:  AffineTransformMakeTranslation(1, 2),
+			t2:  AffineTransformMakeScale(2, 3),
+			out: AffineTransformMake(2, 0, 0, 3, 2, 6),
+		},
+		{
+			t1:  AffineTransformMakeScale(2, 3),
+			t2:  AffineTransformMakeTranslation(1, 2),
+			out: AffineTransformMake(2, 0, 0, 3, 1, 2),
+		},
+		{
+			t1:  AffineTransformMake(1, 2, 3, 4, 5, 6),
+			t2:  AffineTransformMake(7, 8, 9, 10, 11, 12),
+			out: AffineTransformMake(25, 28, 57, 64, 100, 112),
+		},
+	}
+
+	for _, test := range tests {
+		if m := test.t1.Concat(test.t2); m != test.out {
+			t.Errorf("%v x %v: invalid concatenation: %v != %v", test.t1, test.t2, m, test.out)
+		}
+	}
+}
+
+func TestAffineTransformInvert(t *testing.T) {
+	tests := []AffineTransform{
+		AffineTransformIdentity,
+		AffineTransformMakeTranslation(10, -4),
+		AffineTransformMakeScale(2, 0.5),
+		AffineTransformMakeRotation(math.Pi / 3),
+		AffineTransformMake(1, 2, 3, 4, 5, 6),
+	}
+
+	for _, test := range tests {
+		inv, err := test.Invert()
+
+		if err != nil {
+			t.Errorf("%v: %s", test, err)
+			continue
+		}
+
+		if m := test.Concat(inv); !nearlyEqualTransforms(m, AffineTransformIdentity) {
+			t.Errorf("%v: multiplying by the inverse did not give the identity: %v", test, m)
+		}
+	}
+}
+
+func TestAffineTransformInvertSingular(t *testing.T) {
+	m := AffineTransformMake(1, 2, 2, 4, 5, 6)
+	inv, err := m.Invert()
+
+	if err != ErrSingularMatrix {
+		t.Error("inverting a singular matrix did not return ErrSingularMatrix:", err)
+	}
+
+	if inv != m {
+		t.Error("inverting a singular matrix did not return it unchanged:", inv)
+	}
+}
+
+func TestAffineTransformTranslate(t *testing.T) {
+	m := AffineTransformMakeScale(2, 3)
+	m.Translate(1, 2)
+
+	if p := PointZero.ApplyAffineTransform(m); p != PointMake(2, 6) {
+		t.Error("invalid translated point:", p)
+	}
+}
+
+func TestAffineTransformScale(t *testing.T) {
+	m := AffineTransformMakeTranslation(1, 2)
+	m.Scale(2, 3)
+
+	if p := PointMake(1, 1).ApplyAffineTransform(m); p != PointMake(3, 5) {
+		t.Error("invalid scaled point:", p)
+	}
+}
+
+func TestAffineTransformRotate(t *testing.T) {
+	m := AffineTransformMakeTranslation(1, 0)
+	m.Rotate(math.Pi / 2)
+
+	if p := PointMake(1, 0).ApplyAffineTransform(m); !nearlyEqualPoints(p, PointMake(1, 1)) {
+		t.Error("invalid rotated point:", p)
+	}
+}
+
+func TestAffineTransformMakeRotation(t *testing.T) {
+	m := AffineTransformMakeRotation(math.Pi / 2)
+
+	if p := PointMake(1, 0).ApplyAffineTransform(m); !nearlyEqualPoints(p, PointMake(0, 1)) {
+		t.Error("invalid rotated point:", p)
+	}
+}
+
+func TestSizeApplyAffineTransform(t *testing.T) {
+	m := AffineTransformMake(2, 0, 1, 3, 100, 100)
+
+	if s := SizeMake(4, 5).ApplyAffineTransform(m); s != SizeMake(13, 15) {
+		t.Error("invalid transformed size:", s)
+	}
+}
+
+func TestRectApplyAffineTransform(t *testing.T) {
+	tests := []struct {
+		rect      Rect
+		transform AffineTransform
+		out       Rect
+	}{
+		{
+			rect:      RectMake(1, 2, 3, 4),
+			transform: AffineTransformIdentity,
+			out:       RectMake(1, 2, 3, 4),
+		},
+		{
+			rect:      RectMake(1, 2, 3, 4),
+			transform: AffineTransformMakeTranslation(1, 1),
+			out:       RectMake(2, 3, 3, 4),
+		},
+		{
+			rect:      RectMake(1, 2, 3, 4),
+			transform: AffineTransformMakeScale(-1, 2),
+			out:       RectMake(-4, 4, 3, 8),
+		},
+		{
+			rect:      RectMake(0, 0, 2, 2),
+			transform: AffineTransformMake(1, 1, -1, 1, 0, 0),
+			out:       RectMake(-2, 0, 4, 4),
+		},
+		{
+			rect:      RectNull,
+			transform: AffineTransformMakeScale(2, 2),
+			out:       RectNull,
+		},
+		{
+			rect:      RectInfinite,
+			transform: AffineTransformMakeScale(2, 2),
+			out:       RectInfinite,
+		},
+	}
+
+	for _, test := range tests {
+		if r := test.rect.ApplyAffineTransform(test.transform); r != test.out {
+			t.Errorf("%v x %v: invalid transformed rectangle: %v != %v", test.rect, test.transform, r, test.out)
+		}
+	}
+}
+
+func nearlyEqual(a Float, b Float) bool {
+	return math.Abs(float64(a-b)) < 1e-6
+}
+
+func nearlyEqualPoints(p1 Point, p2 Point) bool {
+	return nearlyEqual(p1.X, p2.X) && nearlyEqual(p1.Y, p2.Y)
+}
+
+func nearlyEqualTransforms(t1 AffineTransform, t2 AffineTransform) bool {
+	return nearlyEqual(t1.A, t2.A) &&
+		nearlyEqual(t1.B, t2.B) &&
+		nearlyEqual(t1.C, t2.C) &&
+		nearlyEqual(t1.D, t2.D) &&
+		nearlyEqual(t1.Tx, t2.Tx) &&
+		nearlyEqual(t1.Ty, t2.Ty)
+}
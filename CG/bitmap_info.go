@@ -0,0 +1,157 @@
+package CG
+
+// ImageAlphaInfo is an enumeration representing the location and presence of
+// the alpha component of the pixels in an image.
+//
+// https://developer.apple.com/library/mac/documentation/GraphicsImaging/Reference/CGImage/#//apple_ref/c/tdef/CGImageAlphaInfo
+type ImageAlphaInfo uint32
+
+// These constants are all the possible values of the ImageAlphaInfo
+// enumeration.
+const (
+	ImageAlphaNone ImageAlphaInfo = iota
+	ImageAlphaPremultipliedLast
+	ImageAlphaPremultipliedFirst
+	ImageAlphaLast
+	ImageAlphaFirst
+	ImageAlphaNoneSkipLast
+	ImageAlphaNoneSkipFirst
+	ImageAlphaOnly
+)
+
+// HasAlpha returns true if the alpha info describes pixels that carry an alpha
+// component which isn't ignored.
+func (alpha ImageAlphaInfo) HasAlpha() bool {
+	switch alpha {
+	case ImageAlphaNone, ImageAlphaNoneSkipLast, ImageAlphaNoneSkipFirst:
+		return false
+	}
+	return true
+}
+
+// IsPremultiplied returns true if the alpha info describes pixels which have
+// their color components premultiplied by the alpha value.
+func (alpha ImageAlphaInfo) IsPremultiplied() bool {
+	return alpha == ImageAlphaPremultipliedLast || alpha == ImageAlphaPremultipliedFirst
+}
+
+// IsFirst returns true if the alpha info describes pixels where the alpha (or
+// skipped) component is stored before the color components.
+func (alpha ImageAlphaInfo) IsFirst() bool {
+	switch alpha {
+	case ImageAlphaPremultipliedFirst, ImageAlphaFirst, ImageAlphaNoneSkipFirst:
+		return true
+	}
+	return false
+}
+
+// String satisfies the fmt.Stringer interface.
+func (alpha ImageAlphaInfo) String() string {
+	switch alpha {
+	case ImageAlphaNone:
+		return "None"
+	case ImageAlphaPremultipliedLast:
+		return "PremultipliedLast"
+	case ImageAlphaPremultipliedFirst:
+		return "PremultipliedFirst"
+	case ImageAlphaLast:
+		return "Last"
+	case ImageAlphaFirst:
+		return "First"
+	case ImageAlphaNoneSkipLast:
+		return "NoneSkipLast"
+	case ImageAlphaNoneSkipFirst:
+		return "NoneSkipFirst"
+	case ImageAlphaOnly:
+		return "Only"
+	}
+	return "Unknown"
+}
+
+// BitmapInfo is a set of flags describing the memory layout of the pixels of
+// a bitmap image.
+//
+// https://developer.apple.com/library/mac/documentation/GraphicsImaging/Reference/CGImage/#//apple_ref/c/tdef/CGBitmapInfo
+type BitmapInfo uint32
+
+// These constants are all the possible flags of the BitmapInfo type.
+const (
+	BitmapAlphaInfoMask     BitmapInfo = 0x1F
+	BitmapFloatComponents   BitmapInfo = 1 << 8
+	BitmapByteOrderMask     BitmapInfo = 0x7000
+	BitmapByteOrderDefault  BitmapInfo = 0 << 12
+	BitmapByteOrder16Little BitmapInfo = 1 << 12
+	BitmapByteOrder32Little BitmapInfo = 2 << 12
+	BitmapByteOrder16Big    BitmapInfo = 3 << 12
+	BitmapByteOrder32Big    BitmapInfo = 4 << 12
+)
+
+// AlphaInfo returns the alpha info bits of the bitmap info.
+func (info BitmapInfo) AlphaInfo() ImageAlphaInfo {
+	return ImageAlphaInfo(info & BitmapAlphaInfoMask)
+}
+
+// ByteOrder returns the byte order bits of the bitmap info.
+func (info BitmapInfo) ByteOrder() BitmapInfo {
+	return info & BitmapByteOrderMask
+}
+
+// FloatComponents returns true if the bitmap info has the
+// BitmapFloatComponents flag set.
+func (info BitmapInfo) FloatComponents() bool {
+	return (info & BitmapFloatComponents) != 0
+}
+
+// ColorSpaceModel is an enumeration representing the models of color spaces
+// supported by Core Graphics.
+//
+// https://developer.apple.com/library/mac/documentation/GraphicsImaging/Reference/CGColorSpace/#//apple_ref/c/tdef/CGColorSpaceModel
+type ColorSpaceModel int32
+
+// These constants are all the possible values of the ColorSpaceModel
+// enumeration.
+const (
+	ColorSpaceModelUnknown    ColorSpaceModel = -1
+	ColorSpaceModelMonochrome ColorSpaceModel = 0
+	ColorSpaceModelRGB        ColorSpaceModel = 1
+	ColorSpaceModelCMYK       ColorSpaceModel = 2
+	ColorSpaceModelLab        ColorSpaceModel = 3
+	ColorSpaceModelDeviceN    ColorSpaceModel = 4
+	ColorSpaceModelIndexed    ColorSpaceModel = 5
+	ColorSpaceModelPattern    ColorSpaceModel = 6
+)
+
+// NumberOfComponents returns the number of color components of the model,
+// or zero if it isn't known.
+func (model ColorSpaceModel) NumberOfComponents() int {
+	switch model {
+	case ColorSpaceModelMonochrome, ColorSpaceModelIndexed:
+		return 1
+	case ColorSpaceModelRGB, ColorSpaceModelLab:
+		return 3
+	case ColorSpaceModelCMYK:
+		return 4
+	}
+	return 0
+}
+
+// String satisfies the fmt.Stringer interface.
+func (model ColorSpaceModel) String() string {
+	switch model {
+	case ColorSpaceModelMonochrome:
+		return "Monochrome"
+	case ColorSpaceModelRGB:
+		return "RGB"
+	case ColorSpaceModelCMYK:
+		return "CMYK"
+	case ColorSpaceModelLab:
+		return "Lab"
+	case ColorSpaceModelDeviceN:
+		return "DeviceN"
+	case ColorSpaceModelIndexed:
+		return "Indexed"
+	case ColorSpaceModelPattern:
+		return "Pattern"
+	}
+	return "Unknown"
+}
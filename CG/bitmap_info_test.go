@@ -0,0 +1,68 @@
+package CG
+
+import "testing"
+
+func TestAlphaInfo(t *testing.T) {
+	tests := []struct {
+		alpha   ImageAlphaInfo
+		has     bool
+		premul  bool
+		first   bool
+		display string
+	}{
+		{ImageAlphaNone, false, false, false, "None"},
+		{ImageAlphaPremultipliedLast, true, true, false, "PremultipliedLast"},
+		{ImageAlphaPremultipliedFirst, true, true, true, "PremultipliedFirst"},
+		{ImageAlphaLast, true, false, false, "Last"},
+		{ImageAlphaFirst, true, false, true, "First"},
+		{ImageAlphaNoneSkipLast, false, false, false, "NoneSkipLast"},
+		{ImageAlphaNoneSkipFirst, false, false, true, "NoneSkipFirst"},
+		{ImageAlphaOnly, true, false, false, "Only"},
+	}
+
+	for _, test := range tests {
+		info := BitmapByteOrder32Little | BitmapInfo(test.alpha)
+
+		if alpha := info.AlphaInfo(); alpha != test.alpha {
+			t.Errorf("%v: invalid alpha info extracted from bitmap info: %v", test.alpha, alpha)
+		}
+		if order := info.ByteOrder(); order != BitmapByteOrder32Little {
+			t.Errorf("%v: invalid byte order extracted from bitmap info: %#x", test.alpha, order)
+		}
+		if has := test.alpha.HasAlpha(); has != test.has {
+			t.Errorf("%v: HasAlpha returned %t", test.alpha, has)
+		}
+		if premul := test.alpha.IsPremultiplied(); premul != test.premul {
+			t.Errorf("%v: IsPremultiplied returned %t", test.alpha, premul)
+		}
+		if first := test.alpha.IsFirst(); first != test.first {
+			t.Errorf("%v: IsFirst returned %t", test.alpha, first)
+		}
+		if s := test.alpha.String(); s != test.display {
+			t.Errorf("%v: invalid string representation: %s", test.alpha, s)
+		}
+	}
+}
+
+func TestColorSpaceModel(t *testing.T) {
+	tests := []struct {
+		model      ColorSpaceModel
+		components int
+		display    string
+	}{
+		{ColorSpaceModelUnknown, 0, "Unknown"},
+		{ColorSpaceModelMonochrome, 1, "Monochrome"},
+		{ColorSpaceModelRGB, 3, "RGB"},
+		{ColorSpaceModelCMYK, 4, "CMYK"},
+		{ColorSpaceModelIndexed, 1, "Indexed"},
+	}
+
+	for _, test := range tests {
+		if n := test.model.NumberOfComponents(); n != test.components {
+			t.Errorf("%v: invalid number of components: %d", test.model, n)
+		}
+		if s := test.model.String(); s != test.display {
+			t.Errorf("%v: invalid string representation: %s", test.model, s)
+		}
+	}
+}
@@ -0,0 +1,17 @@
+package CG
+
+import "errors"
+
+var (
+	// ErrUnsupportedFormat is returned when creating a Core Graphics image
+	// from a Go image which has a pixel format that cannot be represented.
+	ErrUnsupportedFormat = errors.New("CG: unsupported image format")
+
+	// ErrEmptyImage is returned when creating a Core Graphics image from a Go
+	// image which has no pixels.
+	ErrEmptyImage = errors.New("CG: empty image")
+
+	// ErrCreateFailed is returned when a Core Graphics function used to create
+	// an object returned NULL.
+	ErrCreateFailed = errors.New("CG: failed to create the object")
+)
@@ -0,0 +1,18 @@
+// +build 386 arm mips mipsle
+
+package CG
+
+import "math"
+
+// Float is a floating point type used to represent numberic values in Core
+// Graphics.
+//
+// On 32 bits platforms the type is a float32, matching the definition of
+// CGFloat when __LP64__ is not set.
+//
+// https://developer.apple.com/library/mac/documentation/GraphicsImaging/Reference/CGGeometry/#//apple_ref/c/tdef/CGFloat
+type Float float32
+
+// maxFloat is the largest finite value that can be represented by a Float,
+// it matches CGFLOAT_MAX.
+const maxFloat = math.MaxFloat32
@@ -0,0 +1,18 @@
+// +build !386,!arm,!mips,!mipsle
+
+package CG
+
+import "math"
+
+// Float is a floating point type used to represent numberic values in Core
+// Graphics.
+//
+// On 64 bits platforms the type is a float64, matching the definition of
+// CGFloat when __LP64__ is set.
+//
+// https://developer.apple.com/library/mac/documentation/GraphicsImaging/Reference/CGGeometry/#//apple_ref/c/tdef/CGFloat
+type Float float64
+
+// maxFloat is the largest finite value that can be represented by a Float,
+// it matches CGFLOAT_MAX.
+const maxFloat = math.MaxFloat64
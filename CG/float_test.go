@@ -0,0 +1,14 @@
+package CG
+
+import (
+	"testing"
+	"unsafe"
+)
+
+func TestFloatSize(t *testing.T) {
+	// CGFloat is a double on platforms where pointers are 64 bits wide and a
+	// float otherwise.
+	if a, b := unsafe.Sizeof(Float(0)), unsafe.Sizeof(uintptr(0)); a != b {
+		t.Errorf("invalid CG.Float size: %d != %d", a, b)
+	}
+}
@@ -1,12 +1,6 @@
-// +build darwin
-
 package CG
 
-// #cgo CFLAGS: -Wno-unused-parameter
-// #cgo LDFLAGS: -framework CoreFoundation -framework CoreGraphics
-//
-// #include <CoreGraphics/CoreGraphics.h>
-import "C"
+import "math"
 
 // Point is a Go equivalent to the type of the same name provided by Core
 // Graphics.
@@ -35,22 +29,370 @@ type Rect struct {
 	Size   Size
 }
 
-// The CGAffineTransform struct is a Go equivalent to the type of the same name
-// provided by Core Graphics.
+// RectEdge is an enumeration representing the edges of a rectangle.
+//
+// https://developer.apple.com/library/mac/documentation/GraphicsImaging/Reference/CGGeometry/#//apple_ref/c/tdef/CGRectEdge
+type RectEdge int
+
+// These constants are all the possible values of the RectEdge enumeration.
+const (
+	RectMinXEdge RectEdge = iota
+	RectMinYEdge
+	RectMaxXEdge
+	RectMaxYEdge
+)
+
+var (
+	// PointZero is the point with zero coordinates.
+	//
+	// https://developer.apple.com/library/mac/documentation/GraphicsImaging/Reference/CGGeometry/#//apple_ref/c/data/CGPointZero
+	PointZero = Point{}
+
+	// SizeZero is the size with zero width and height.
+	//
+	// https://developer.apple.com/library/mac/documentation/GraphicsImaging/Reference/CGGeometry/#//apple_ref/c/data/CGSizeZero
+	SizeZero = Size{}
+
+	// RectZero is the rectangle at origin with zero width and height.
+	//
+	// https://developer.apple.com/library/mac/documentation/GraphicsImaging/Reference/CGGeometry/#//apple_ref/c/data/CGRectZero
+	RectZero = Rect{}
+
+	// RectNull is the null rectangle, it represents an invalid rectangle and
+	// is returned for example when intersecting two disjoint rectangles.
+	//
+	// https://developer.apple.com/library/mac/documentation/GraphicsImaging/Reference/CGGeometry/#//apple_ref/c/data/CGRectNull
+	RectNull = Rect{
+		Origin: Point{Float(math.Inf(+1)), Float(math.Inf(+1))},
+	}
+
+	// RectInfinite is a rectangle that has no defined bounds.
+	//
+	// https://developer.apple.com/library/mac/documentation/GraphicsImaging/Reference/CGGeometry/#//apple_ref/c/data/CGRectInfinite
+	RectInfinite = Rect{
+		Origin: Point{-maxFloat / 2, -maxFloat / 2},
+		Size:   Size{maxFloat, maxFloat},
+	}
+)
+
+// PointMake returns a point with the specified coordinates.
+//
+// https://developer.apple.com/library/mac/documentation/GraphicsImaging/Reference/CGGeometry/#//apple_ref/c/func/CGPointMake
+func PointMake(x Float, y Float) Point {
+	return Point{X: x, Y: y}
+}
+
+// SizeMake returns a size with the specified dimensions.
+//
+// https://developer.apple.com/library/mac/documentation/GraphicsImaging/Reference/CGGeometry/#//apple_ref/c/func/CGSizeMake
+func SizeMake(width Float, height Float) Size {
+	return Size{Width: width, Height: height}
+}
+
+// RectMake returns a rectangle with the specified coordinate and size values.
+//
+// https://developer.apple.com/library/mac/documentation/GraphicsImaging/Reference/CGGeometry/#//apple_ref/c/func/CGRectMake
+func RectMake(x Float, y Float, width Float, height Float) Rect {
+	return Rect{
+		Origin: Point{X: x, Y: y},
+		Size:   Size{Width: width, Height: height},
+	}
+}
+
+// EqualToPoint returns true if the two points have the same coordinates.
+//
+// https://developer.apple.com/library/mac/documentation/GraphicsImaging/Reference/CGGeometry/#//apple_ref/c/func/CGPointEqualToPoint
+func (p Point) EqualToPoint(p2 Point) bool {
+	return p == p2
+}
+
+// EqualToSize returns true if the two sizes have the same dimensions.
+//
+// https://developer.apple.com/library/mac/documentation/GraphicsImaging/Reference/CGGeometry/#//apple_ref/c/func/CGSizeEqualToSize
+func (s Size) EqualToSize(s2 Size) bool {
+	return s == s2
+}
+
+// GetMinX returns the smallest x-coordinate of the rectangle.
+//
+// https://developer.apple.com/library/mac/documentation/GraphicsImaging/Reference/CGGeometry/#//apple_ref/c/func/CGRectGetMinX
+func (r Rect) GetMinX() Float {
+	return r.Origin.X + min(r.Size.Width, 0)
+}
+
+// GetMidX returns the x-coordinate of the center of the rectangle.
+//
+// https://developer.apple.com/library/mac/documentation/GraphicsImaging/Reference/CGGeometry/#//apple_ref/c/func/CGRectGetMidX
+func (r Rect) GetMidX() Float {
+	return r.Origin.X + (r.Size.Width / 2)
+}
+
+// GetMaxX returns the largest x-coordinate of the rectangle.
+//
+// https://developer.apple.com/library/mac/documentation/GraphicsImaging/Reference/CGGeometry/#//apple_ref/c/func/CGRectGetMaxX
+func (r Rect) GetMaxX() Float {
+	return r.Origin.X + max(r.Size.Width, 0)
+}
+
+// GetMinY returns the smallest y-coordinate of the rectangle.
+//
+// https://developer.apple.com/library/mac/documentation/GraphicsImaging/Reference/CGGeometry/#//apple_ref/c/func/CGRectGetMinY
+func (r Rect) GetMinY() Float {
+	return r.Origin.Y + min(r.Size.Height, 0)
+}
+
+// GetMidY returns the y-coordinate of the center of the rectangle.
+//
+// https://developer.apple.com/library/mac/documentation/GraphicsImaging/Reference/CGGeometry/#//apple_ref/c/func/CGRectGetMidY
+func (r Rect) GetMidY() Float {
+	return r.Origin.Y + (r.Size.Height / 2)
+}
+
+// GetMaxY returns the largest y-coordinate of the rectangle.
+//
+// https://developer.apple.com/library/mac/documentation/GraphicsImaging/Reference/CGGeometry/#//apple_ref/c/func/CGRectGetMaxY
+func (r Rect) GetMaxY() Float {
+	return r.Origin.Y + max(r.Size.Height, 0)
+}
+
+// GetWidth returns the width of the rectangle, the value is always positive
+// even if the rectangle is not standardized.
+//
+// https://developer.apple.com/library/mac/documentation/GraphicsImaging/Reference/CGGeometry/#//apple_ref/c/func/CGRectGetWidth
+func (r Rect) GetWidth() Float {
+	return abs(r.Size.Width)
+}
+
+// GetHeight returns the height of the rectangle, the value is always positive
+// even if the rectangle is not standardized.
+//
+// https://developer.apple.com/library/mac/documentation/GraphicsImaging/Reference/CGGeometry/#//apple_ref/c/func/CGRectGetHeight
+func (r Rect) GetHeight() Float {
+	return abs(r.Size.Height)
+}
+
+// IsNull returns true if the rectangle is the null rectangle.
+//
+// https://developer.apple.com/library/mac/documentation/GraphicsImaging/Reference/CGGeometry/#//apple_ref/c/func/CGRectIsNull
+func (r Rect) IsNull() bool {
+	return math.IsInf(float64(r.Origin.X), +1) || math.IsInf(float64(r.Origin.Y), +1)
+}
+
+// IsEmpty returns true if the rectangle has a zero width or height, or is the
+// null rectangle.
 //
-// https://developer.apple.com/library/mac/documentation/GraphicsImaging/Reference/CGAffineTransform/index.html#//apple_ref/doc/c_ref/CGAffineTransform
-type AffineTransform struct {
-	A  Float
-	B  Float
-	C  Float
-	D  Float
-	Tx Float
-	Ty Float
+// https://developer.apple.com/library/mac/documentation/GraphicsImaging/Reference/CGGeometry/#//apple_ref/c/func/CGRectIsEmpty
+func (r Rect) IsEmpty() bool {
+	return r.IsNull() || r.Size.Width == 0 || r.Size.Height == 0
 }
 
-// AffineTransformIdentity represents the identity matrix.
+// IsInfinite returns true if the rectangle is the infinite rectangle.
 //
-// https://developer.apple.com/library/mac/documentation/GraphicsImaging/Reference/CGAffineTransform/index.html#//apple_ref/doc/constant_group/CGAffineTransformIdentity
-var AffineTransformIdentity = AffineTransform{
-	1, 0, 0, 1, 0, 0,
+// https://developer.apple.com/library/mac/documentation/GraphicsImaging/Reference/CGGeometry/#//apple_ref/c/func/CGRectIsInfinite
+func (r Rect) IsInfinite() bool {
+	return r == RectInfinite
+}
+
+// Standardize returns a rectangle equivalent to r but with a positive width
+// and height.
+//
+// https://developer.apple.com/library/mac/documentation/GraphicsImaging/Reference/CGGeometry/#//apple_ref/c/func/CGRectStandardize
+func (r Rect) Standardize() Rect {
+	if r.IsNull() {
+		return RectNull
+	}
+	return RectMake(r.GetMinX(), r.GetMinY(), r.GetWidth(), r.GetHeight())
+}
+
+// Integral returns the smallest rectangle with integer origin and size values
+// that contains r.
+//
+// https://developer.apple.com/library/mac/documentation/GraphicsImaging/Reference/CGGeometry/#//apple_ref/c/func/CGRectIntegral
+func (r Rect) Integral() Rect {
+	if r.IsNull() || r.IsInfinite() {
+		return r
+	}
+	x0 := floor(r.GetMinX())
+	y0 := floor(r.GetMinY())
+	x1 := ceil(r.GetMaxX())
+	y1 := ceil(r.GetMaxY())
+	return RectMake(x0, y0, x1-x0, y1-y0)
+}
+
+// Inset returns a rectangle with the same center point than r but with its
+// sides moved inward by dx and dy (or outward if the values are negative).
+//
+// If the resulting rectangle would have a negative width or height the null
+// rectangle is returned.
+//
+// https://developer.apple.com/library/mac/documentation/GraphicsImaging/Reference/CGGeometry/#//apple_ref/c/func/CGRectInset
+func (r Rect) Inset(dx Float, dy Float) Rect {
+	if r.IsNull() || r.IsInfinite() {
+		return r
+	}
+	r = r.Standardize()
+	r.Origin.X += dx
+	r.Origin.Y += dy
+	r.Size.Width -= 2 * dx
+	r.Size.Height -= 2 * dy
+
+	if r.Size.Width < 0 || r.Size.Height < 0 {
+		return RectNull
+	}
+	return r
+}
+
+// Offset returns a rectangle with the same size than r but with an origin
+// moved by dx and dy.
+//
+// https://developer.apple.com/library/mac/documentation/GraphicsImaging/Reference/CGGeometry/#//apple_ref/c/func/CGRectOffset
+func (r Rect) Offset(dx Float, dy Float) Rect {
+	if r.IsNull() || r.IsInfinite() {
+		return r
+	}
+	r = r.Standardize()
+	r.Origin.X += dx
+	r.Origin.Y += dy
+	return r
+}
+
+// Union returns the smallest rectangle that contains both r and r2.
+//
+// The null rectangle is ignored, so if one of the rectangles is null the
+// other one is returned.
+//
+// https://developer.apple.com/library/mac/documentation/GraphicsImaging/Reference/CGGeometry/#//apple_ref/c/func/CGRectUnion
+func (r Rect) Union(r2 Rect) Rect {
+	switch {
+	case r.IsNull():
+		return r2
+	case r2.IsNull():
+		return r
+	case r.IsInfinite() || r2.IsInfinite():
+		return RectInfinite
+	}
+	x0 := min(r.GetMinX(), r2.GetMinX())
+	y0 := min(r.GetMinY(), r2.GetMinY())
+	x1 := max(r.GetMaxX(), r2.GetMaxX())
+	y1 := max(r.GetMaxY(), r2.GetMaxY())
+	return RectMake(x0, y0, x1-x0, y1-y0)
+}
+
+// Intersection returns the rectangle representing the area shared by r and
+// r2, or the null rectangle if they don't overlap.
+//
+// https://developer.apple.com/library/mac/documentation/GraphicsImaging/Reference/CGGeometry/#//apple_ref/c/func/CGRectIntersection
+func (r Rect) Intersection(r2 Rect) Rect {
+	switch {
+	case r.IsNull() || r2.IsNull():
+		return RectNull
+	case r.IsInfinite():
+		return r2.Standardize()
+	case r2.IsInfinite():
+		return r.Standardize()
+	}
+	x0 := max(r.GetMinX(), r2.GetMinX())
+	y0 := max(r.GetMinY(), r2.GetMinY())
+	x1 := min(r.GetMaxX(), r2.GetMaxX())
+	y1 := min(r.GetMaxY(), r2.GetMaxY())
+
+	if x1 < x0 || y1 < y0 {
+		return RectNull
+	}
+	return RectMake(x0, y0, x1-x0, y1-y0)
+}
+
+// Divide splits r in two rectangles, the first one (slice) has the given
+// amount taken from the specified edge, the second one (remainder) is what
+// is left of r.
+//
+// https://developer.apple.com/library/mac/documentation/GraphicsImaging/Reference/CGGeometry/#//apple_ref/c/func/CGRectDivide
+func (r Rect) Divide(amount Float, edge RectEdge) (slice Rect, remainder Rect) {
+	if r.IsNull() {
+		return RectNull, RectNull
+	}
+	r = r.Standardize()
+	slice, remainder = r, r
+
+	switch edge {
+	case RectMinXEdge:
+		amount = clamp(amount, 0, r.Size.Width)
+		slice.Size.Width = amount
+		remainder.Origin.X += amount
+		remainder.Size.Width -= amount
+
+	case RectMaxXEdge:
+		amount = clamp(amount, 0, r.Size.Width)
+		slice.Origin.X += r.Size.Width - amount
+		slice.Size.Width = amount
+		remainder.Size.Width -= amount
+
+	case RectMinYEdge:
+		amount = clamp(amount, 0, r.Size.Height)
+		slice.Size.Height = amount
+		remainder.Origin.Y += amount
+		remainder.Size.Height -= amount
+
+	case RectMaxYEdge:
+		amount = clamp(amount, 0, r.Size.Height)
+		slice.Origin.Y += r.Size.Height - amount
+		slice.Size.Height = amount
+		remainder.Size.Height -= amount
+	}
+
+	return
+}
+
+// ContainsPoint returns true if p is located within the bounds of r.
+//
+// The minimum edges of the rectangle are considered part of its area, but the
+// maximum edges aren't.
+//
+// https://developer.apple.com/library/mac/documentation/GraphicsImaging/Reference/CGGeometry/#//apple_ref/c/func/CGRectContainsPoint
+func (r Rect) ContainsPoint(p Point) bool {
+	if r.IsEmpty() {
+		return false
+	}
+	return p.X >= r.GetMinX() && p.X < r.GetMaxX() && p.Y >= r.GetMinY() && p.Y < r.GetMaxY()
+}
+
+// ContainsRect returns true if r2 is completely contained by r.
+//
+// https://developer.apple.com/library/mac/documentation/GraphicsImaging/Reference/CGGeometry/#//apple_ref/c/func/CGRectContainsRect
+func (r Rect) ContainsRect(r2 Rect) bool {
+	return r.Union(r2).EqualToRect(r)
+}
+
+// IntersectsRect returns true if r and r2 share a non-empty area.
+//
+// https://developer.apple.com/library/mac/documentation/GraphicsImaging/Reference/CGGeometry/#//apple_ref/c/func/CGRectIntersectsRect
+func (r Rect) IntersectsRect(r2 Rect) bool {
+	return !r.Intersection(r2).IsEmpty()
+}
+
+// EqualToRect returns true if r and r2 represent the same area, the
+// comparison is made on the standardized versions of the rectangles.
+//
+// https://developer.apple.com/library/mac/documentation/GraphicsImaging/Reference/CGGeometry/#//apple_ref/c/func/CGRectEqualToRect
+func (r Rect) EqualToRect(r2 Rect) bool {
+	if r.IsNull() || r2.IsNull() {
+		return r.IsNull() && r2.IsNull()
+	}
+	return r.Standardize() == r2.Standardize()
+}
+
+func abs(x Float) Float {
+	return Float(math.Abs(float64(x)))
+}
+
+func floor(x Float) Float {
+	return Float(math.Floor(float64(x)))
+}
+
+func ceil(x Float) Float {
+	return Float(math.Ceil(float64(x)))
+}
+
+func clamp(x Float, lo Float, hi Float) Float {
+	return max(lo, min(x, hi))
 }
@@ -1,9 +0,0 @@
-// +build darwin
-
-package CG
-
-// Float is a floating point type used to represent numberic values in Core
-// Graphics.
-//
-// https://developer.apple.com/library/mac/documentation/GraphicsImaging/Reference/CGGeometry/#//apple_ref/c/tdef/CGFloat
-type Float float64
@@ -0,0 +1,323 @@
+package CG
+
+import "testing"
+
+func TestRectAccessors(t *testing.T) {
+	tests := []struct {
+		rect                     Rect
+		minX, midX, maxX, width  Float
+		minY, midY, maxY, height Float
+	}{
+		{
+			rect: RectMake(1, 2, 10, 20),
+			minX: 1, midX: 6, maxX: 11, width: 10,
+			minY: 2, midY: 12, maxY: 22, height: 20,
+		},
+		{
+			rect: RectMake(1, 2, -10, -20),
+			minX: -9, midX: -4, maxX: 1, width: 10,
+			minY: -18, midY: -8, maxY: 2, height: 20,
+		},
+	}
+
+	for _, test := range tests {
+		r := test.rect
+
+		if x := r.GetMinX(); x != test.minX {
+			t.Errorf("%v: invalid min x: %v != %v", r, x, test.minX)
+		}
+		if x := r.GetMidX(); x != test.midX {
+			t.Errorf("%v: invalid mid x: %v != %v", r, x, test.midX)
+		}
+		if x := r.GetMaxX(); x != test.maxX {
+			t.Errorf("%v: invalid max x: %v != %v", r, x, test.maxX)
+		}
+		if w := r.GetWidth(); w != test.width {
+			t.Errorf("%v: invalid width: %v != %v", r, w, test.width)
+		}
+		if y := r.GetMinY(); y != test.minY {
+			t.Errorf("%v: invalid min y: %v != %v", r, y, test.minY)
+		}
+		if y := r.GetMidY(); y != test.midY {
+			t.Errorf("%v: invalid mid y: %v != %v", r, y, test.midY)
+		}
+		if y := r.GetMaxY(); y != test.maxY {
+			t.Errorf("%v: invalid max y: %v != %v", r, y, test.maxY)
+		}
+		if h := r.GetHeight(); h != test.height {
+			t.Errorf("%v: invalid height: %v != %v", r, h, test.height)
+		}
+	}
+}
+
+func TestRectPredicates(t *testing.T) {
+	tests := []struct {
+		rect     Rect
+		null     bool
+		empty    bool
+		infinite bool
+	}{
+		{RectZero, false, true, false},
+		{RectNull, true, true, false},
+		{RectInfinite, false, false, true},
+		{RectMake(0, 0, 1, 0), false, true, false},
+		{RectMake(0, 0, 0, 1), false, true, false},
+		{RectMake(0, 0, 1, 1), false, false, false},
+		{RectMake(0, 0, -1, -1), false, false, false},
+		{Rect{Origin: Point{X: RectNull.Origin.X}}, true, true, false},
+	}
+
+	for _, test := range tests {
+		if null := test.rect.IsNull(); null != test.null {
+			t.Errorf("%v: IsNull returned %t", test.rect, null)
+		}
+		if empty := test.rect.IsEmpty(); empty != test.empty {
+			t.Errorf("%v: IsEmpty returned %t", test.rect, empty)
+		}
+		if infinite := test.rect.IsInfinite(); infinite != test.infinite {
+			t.Errorf("%v: IsInfinite returned %t", test.rect, infinite)
+		}
+	}
+}
+
+func TestRectStandardize(t *testing.T) {
+	tests := []struct {
+		in  Rect
+		out Rect
+	}{
+		{RectMake(1, 2, 3, 4), RectMake(1, 2, 3, 4)},
+		{RectMake(1, 2, -3, 4), RectMake(-2, 2, 3, 4)},
+		{RectMake(1, 2, 3, -4), RectMake(1, -2, 3, 4)},
+		{RectMake(1, 2, -3, -4), RectMake(-2, -2, 3, 4)},
+		{RectNull, RectNull},
+		{RectInfinite, RectInfinite},
+	}
+
+	for _, test := range tests {
+		if r := test.in.Standardize(); r != test.out {
+			t.Errorf("%v: invalid standardized rectangle: %v != %v", test.in, r, test.out)
+		}
+	}
+}
+
+func TestRectIntegral(t *testing.T) {
+	tests := []struct {
+		in  Rect
+		out Rect
+	}{
+		{RectMake(1, 2, 3, 4), RectMake(1, 2, 3, 4)},
+		{RectMake(0.5, 1.5, 2, 2), RectMake(0, 1, 3, 3)},
+		{RectMake(-0.5, -1.5, 1.25, 0.25), RectMake(-1, -2, 2, 1)},
+		{RectMake(1.5, 1.5, -1, -1), RectMake(0, 0, 2, 2)},
+		{RectNull, RectNull},
+		{RectInfinite, RectInfinite},
+	}
+
+	for _, test := range tests {
+		if r := test.in.Integral(); r != test.out {
+			t.Errorf("%v: invalid integral rectangle: %v != %v", test.in, r, test.out)
+		}
+	}
+}
+
+func TestRectInset(t *testing.T) {
+	tests := []struct {
+		in     Rect
+		dx, dy Float
+		out    Rect
+	}{
+		{RectMake(0, 0, 10, 10), 1, 2, RectMake(1, 2, 8, 6)},
+		{RectMake(0, 0, 10, 10), -1, -2, RectMake(-1, -2, 12, 14)},
+		{RectMake(10, 10, -10, -10), 1, 1, RectMake(1, 1, 8, 8)},
+		{RectMake(0, 0, 10, 10), 5, 5, RectMake(5, 5, 0, 0)},
+		{RectMake(0, 0, 10, 10), 6, 0, RectNull},
+		{RectNull, 1, 1, RectNull},
+		{RectInfinite, 1, 1, RectInfinite},
+	}
+
+	for _, test := range tests {
+		if r := test.in.Inset(test.dx, test.dy); r != test.out {
+			t.Errorf("%v: invalid inset rectangle: %v != %v", test.in, r, test.out)
+		}
+	}
+}
+
+func TestRectOffset(t *testing.T) {
+	tests := []struct {
+		in     Rect
+		dx, dy Float
+		out    Rect
+	}{
+		{RectMake(0, 0, 10, 10), 1, 2, RectMake(1, 2, 10, 10)},
+		{RectMake(10, 10, -10, -10), -1, -2, RectMake(-1, -2, 10, 10)},
+		{RectNull, 1, 1, RectNull},
+		{RectInfinite, 1, 1, RectInfinite},
+	}
+
+	for _, test := range tests {
+		if r := test.in.Offset(test.dx, test.dy); r != test.out {
+			t.Errorf("%v: invalid offset rectangle: %v != %v", test.in, r, test.out)
+		}
+	}
+}
+
+func TestRectUnion(t *testing.T) {
+	tests := []struct {
+		r1, r2 Rect
+		out    Rect
+	}{
+		{RectMake(0, 0, 1, 1), RectMake(2, 2, 1, 1), RectMake(0, 0, 3, 3)},
+		{RectMake(0, 0, 1, 1), RectMake(3, 3, -1, -1), RectMake(0, 0, 3, 3)},
+		{RectMake(0, 0, 4, 4), RectMake(1, 1, 1, 1), RectMake(0, 0, 4, 4)},
+		{RectMake(1, 1, 0, 0), RectMake(2, 2, 0, 0), RectMake(1, 1, 1, 1)},
+		{RectNull, RectMake(1, 2, 3, 4), RectMake(1, 2, 3, 4)},
+		{RectMake(1, 2, 3, 4), RectNull, RectMake(1, 2, 3, 4)},
+		{RectNull, RectNull, RectNull},
+		{RectInfinite, RectMake(1, 2, 3, 4), RectInfinite},
+	}
+
+	for _, test := range tests {
+		if r := test.r1.Union(test.r2); r != test.out {
+			t.Errorf("%v, %v: invalid union: %v != %v", test.r1, test.r2, r, test.out)
+		}
+	}
+}
+
+func TestRectIntersection(t *testing.T) {
+	tests := []struct {
+		r1, r2     Rect
+		out        Rect
+		intersects bool
+	}{
+		{RectMake(0, 0, 2, 2), RectMake(1, 1, 2, 2), RectMake(1, 1, 1, 1), true},
+		{RectMake(0, 0, 2, 2), RectMake(3, 3, -2, -2), RectMake(1, 1, 1, 1), true},
+		{RectMake(0, 0, 4, 4), RectMake(1, 1, 1, 1), RectMake(1, 1, 1, 1), true},
+		{RectMake(0, 0, 1, 1), RectMake(1, 0, 1, 1), RectMake(1, 0, 0, 1), false},
+		{RectMake(0, 0, 1, 1), RectMake(2, 2, 1, 1), RectNull, false},
+		{RectNull, RectMake(0, 0, 1, 1), RectNull, false},
+		{RectInfinite, RectMake(1, 1, -1, -1), RectMake(0, 0, 1, 1), true},
+		{RectMake(0, 0, 1, 1), RectInfinite, RectMake(0, 0, 1, 1), true},
+	}
+
+	for _, test := range tests {
+		if r := test.r1.Intersection(test.r2); r != test.out {
+			t.Errorf("%v, %v: invalid intersection: %v != %v", test.r1, test.r2, r, test.out)
+		}
+		if x := test.r1.IntersectsRect(test.r2); x != test.intersects {
+			t.Errorf("%v, %v: IntersectsRect returned %t", test.r1, test.r2, x)
+		}
+	}
+}
+
+func TestRectDivide(t *testing.T) {
+	tests := []struct {
+		in        Rect
+		amount    Float
+		edge      RectEdge
+		slice     Rect
+		remainder Rect
+	}{
+		{RectMake(0, 0, 10, 20), 3, RectMinXEdge, RectMake(0, 0, 3, 20), RectMake(3, 0, 7, 20)},
+		{RectMake(0, 0, 10, 20), 3, RectMaxXEdge, RectMake(7, 0, 3, 20), RectMake(0, 0, 7, 20)},
+		{RectMake(0, 0, 10, 20), 3, RectMinYEdge, RectMake(0, 0, 10, 3), RectMake(0, 3, 10, 17)},
+		{RectMake(0, 0, 10, 20), 3, RectMaxYEdge, RectMake(0, 17, 10, 3), RectMake(0, 0, 10, 17)},
+		{RectMake(0, 0, 10, 20), 15, RectMinXEdge, RectMake(0, 0, 10, 20), RectMake(10, 0, 0, 20)},
+		{RectMake(0, 0, 10, 20), -1, RectMinXEdge, RectMake(0, 0, 0, 20), RectMake(0, 0, 10, 20)},
+		{RectMake(10, 0, -10, 20), 3, RectMinXEdge, RectMake(0, 0, 3, 20), RectMake(3, 0, 7, 20)},
+		{RectNull, 1, RectMinXEdge, RectNull, RectNull},
+	}
+
+	for _, test := range tests {
+		slice, remainder := test.in.Divide(test.amount, test.edge)
+
+		if slice != test.slice {
+			t.Errorf("%v: invalid slice: %v != %v", test.in, slice, test.slice)
+		}
+		if remainder != test.remainder {
+			t.Errorf("%v: invalid remainder: %v != %v", test.in, remainder, test.remainder)
+		}
+	}
+}
+
+func TestRectContainsPoint(t *testing.T) {
+	tests := []struct {
+		rect     Rect
+		point    Point
+		contains bool
+	}{
+		{RectMake(0, 0, 2, 2), PointMake(1, 1), true},
+		{RectMake(0, 0, 2, 2), PointMake(0, 0), true},
+		{RectMake(0, 0, 2, 2), PointMake(2, 1), false},
+		{RectMake(0, 0, 2, 2), PointMake(1, 2), false},
+		{RectMake(2, 2, -2, -2), PointMake(1, 1), true},
+		{RectMake(0, 0, 0, 0), PointMake(0, 0), false},
+		{RectNull, PointMake(0, 0), false},
+		{RectInfinite, PointMake(1e30, -1e30), true},
+	}
+
+	for _, test := range tests {
+		if x := test.rect.ContainsPoint(test.point); x != test.contains {
+			t.Errorf("%v: ContainsPoint(%v) returned %t", test.rect, test.point, x)
+		}
+	}
+}
+
+func TestRectContainsRect(t *testing.T) {
+	tests := []struct {
+		r1, r2   Rect
+		contains bool
+	}{
+		{RectMake(0, 0, 4, 4), RectMake(1, 1, 2, 2), true},
+		{RectMake(0, 0, 4, 4), RectMake(0, 0, 4, 4), true},
+		{RectMake(4, 4, -4, -4), RectMake(3, 3, -2, -2), true},
+		{RectMake(0, 0, 4, 4), RectMake(3, 3, 2, 2), false},
+		{RectMake(0, 0, 4, 4), RectNull, true},
+		{RectNull, RectMake(0, 0, 1, 1), false},
+		{RectInfinite, RectMake(0, 0, 1, 1), true},
+	}
+
+	for _, test := range tests {
+		if x := test.r1.ContainsRect(test.r2); x != test.contains {
+			t.Errorf("%v: ContainsRect(%v) returned %t", test.r1, test.r2, x)
+		}
+	}
+}
+
+func TestRectEqualToRect(t *testing.T) {
+	tests := []struct {
+		r1, r2 Rect
+		equal  bool
+	}{
+		{RectMake(0, 0, 1, 1), RectMake(0, 0, 1, 1), true},
+		{RectMake(0, 0, 1, 1), RectMake(1, 1, -1, -1), true},
+		{RectMake(0, 0, 1, 1), RectMake(0, 0, 1, 2), false},
+		{RectNull, RectNull, true},
+		{RectNull, Rect{Origin: Point{Y: RectNull.Origin.Y}}, true},
+		{RectNull, RectZero, false},
+		{RectInfinite, RectInfinite, true},
+	}
+
+	for _, test := range tests {
+		if x := test.r1.EqualToRect(test.r2); x != test.equal {
+			t.Errorf("%v: EqualToRect(%v) returned %t", test.r1, test.r2, x)
+		}
+	}
+}
+
+func TestPointEqualToPoint(t *testing.T) {
+	if !PointMake(1, 2).EqualToPoint(Point{X: 1, Y: 2}) {
+		t.Error("comparing points for equality failed")
+	}
+	if PointMake(1, 2).EqualToPoint(PointZero) {
+		t.Error("comparing points for difference failed")
+	}
+}
+
+func TestSizeEqualToSize(t *testing.T) {
+	if !SizeMake(1, 2).EqualToSize(Size{Width: 1, Height: 2}) {
+		t.Error("comparing sizes for equality failed")
+	}
+	if SizeMake(1, 2).EqualToSize(SizeZero) {
+		t.Error("comparing sizes for difference failed")
+	}
+}
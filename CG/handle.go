@@ -0,0 +1,98 @@
+package CG
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+)
+
+// handle is an opaque identifier given to C code in place of a Go pointer, C
+// code cannot retain Go pointers so it passes the handle back to Go, which
+// looks up the value it was allocated for.
+//
+// The zero handle is never allocated, it can be used to represent the absence
+// of a value.
+type handle uintptr
+
+// handleRegistry maps handles to the Go values they were allocated for, it is
+// safe to use from multiple goroutines.
+type handleRegistry struct {
+	mutex   sync.Mutex
+	last    handle
+	entries map[handle]interface{}
+}
+
+// alloc returns a new handle referencing value, the value stays reachable
+// until the handle is released.
+func (r *handleRegistry) alloc(value interface{}) handle {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	if r.entries == nil {
+		r.entries = make(map[handle]interface{})
+	}
+
+	for {
+		r.last++
+
+		if _, used := r.entries[r.last]; !used && r.last != 0 {
+			break
+		}
+	}
+
+	r.entries[r.last] = value
+	return r.last
+}
+
+// lookup returns the value referenced by h, the boolean is false if h wasn't
+// allocated or was already released.
+func (r *handleRegistry) lookup(h handle) (interface{}, bool) {
+	r.mutex.Lock()
+	value, ok := r.entries[h]
+	r.mutex.Unlock()
+	return value, ok
+}
+
+// release removes h from the registry and returns the value it referenced, the
+// boolean is false if h wasn't allocated or was already released.
+func (r *handleRegistry) release(h handle) (interface{}, bool) {
+	r.mutex.Lock()
+	value, ok := r.entries[h]
+	delete(r.entries, h)
+	r.mutex.Unlock()
+	return value, ok
+}
+
+// len returns the number of handles that haven't been released.
+func (r *handleRegistry) len() int {
+	r.mutex.Lock()
+	n := len(r.entries)
+	r.mutex.Unlock()
+	return n
+}
+
+// live returns the values referenced by the handles that haven't been released
+// yet, ordered by allocation.
+func (r *handleRegistry) live() []interface{} {
+	r.mutex.Lock()
+	handles := make([]handle, 0, len(r.entries))
+
+	for h := range r.entries {
+		handles = append(handles, h)
+	}
+
+	sort.Slice(handles, func(i int, j int) bool { return handles[i] < handles[j] })
+	values := make([]interface{}, len(handles))
+
+	for i, h := range handles {
+		values[i] = r.entries[h]
+	}
+
+	r.mutex.Unlock()
+	return values
+}
+
+// String satisfies the fmt.Stringer interface.
+func (h handle) String() string {
+	return fmt.Sprintf("handle(%d)", uintptr(h))
+}
@@ -0,0 +1,104 @@
+package CG
+
+import (
+	"sync"
+	"testing"
+)
+
+func TestHandleRegistry(t *testing.T) {
+	var r handleRegistry
+
+	h1 := r.alloc("A")
+	h2 := r.alloc("B")
+
+	if h1 == 0 || h2 == 0 || h1 == h2 {
+		t.Fatalf("invalid handles allocated: %v, %v", h1, h2)
+	}
+
+	if v, ok := r.lookup(h1); !ok || v != "A" {
+		t.Errorf("invalid value found for %v: %v", h1, v)
+	}
+
+	if v, ok := r.lookup(h2); !ok || v != "B" {
+		t.Errorf("invalid value found for %v: %v", h2, v)
+	}
+
+	if n := r.len(); n != 2 {
+		t.Error("invalid number of live handles:", n)
+	}
+
+	if v, ok := r.release(h1); !ok || v != "A" {
+		t.Errorf("invalid value released for %v: %v", h1, v)
+	}
+
+	if _, ok := r.lookup(h1); ok {
+		t.Errorf("%v was found after being released", h1)
+	}
+
+	if _, ok := r.release(h1); ok {
+		t.Errorf("%v was released twice", h1)
+	}
+
+	if live := r.live(); len(live) != 1 || live[0] != "B" {
+		t.Error("invalid live values:", live)
+	}
+}
+
+func TestHandleRegistryZero(t *testing.T) {
+	var r handleRegistry
+
+	if _, ok := r.lookup(0); ok {
+		t.Error("the zero handle was found in an empty registry")
+	}
+
+	r.last = ^handle(0)
+
+	if h := r.alloc(nil); h == 0 {
+		t.Error("the zero handle was allocated after the handles wrapped around")
+	}
+}
+
+func TestHandleRegistryLiveOrder(t *testing.T) {
+	var r handleRegistry
+
+	for i := 0; i != 10; i++ {
+		r.alloc(i)
+	}
+
+	for i, v := range r.live() {
+		if v != i {
+			t.Errorf("live value at index %d is %v", i, v)
+		}
+	}
+}
+
+func TestHandleRegistryConcurrent(t *testing.T) {
+	var r handleRegistry
+	var wg sync.WaitGroup
+
+	for i := 0; i != 8; i++ {
+		wg.Add(1)
+
+		go func(i int) {
+			defer wg.Done()
+
+			for j := 0; j != 1000; j++ {
+				h := r.alloc(i)
+
+				if v, ok := r.lookup(h); !ok || v != i {
+					t.Errorf("invalid value found for %v: %v", h, v)
+				}
+
+				if _, ok := r.release(h); !ok {
+					t.Errorf("failed to release %v", h)
+				}
+			}
+		}(i)
+	}
+
+	wg.Wait()
+
+	if n := r.len(); n != 0 {
+		t.Error("handles were leaked:", n)
+	}
+}
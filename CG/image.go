@@ -2,7 +2,11 @@
 
 package CG
 
+// #cgo CFLAGS: -Wno-unused-parameter
+// #cgo LDFLAGS: -framework CoreFoundation -framework CoreGraphics
+//
 // #include <CoreGraphics/CGImage.h>
+// #include "image_release.h"
 import "C"
 import (
 	"fmt"
@@ -24,83 +28,161 @@ type ImageRef CF.TypeRef
 // to free the resources allocated by the returned ImageRef with a call to
 // CFRelease.
 //
-// The function supports any image types defined in the standard image package,
-// but will panic if the program attempts to create a ImageRef from an
-// unsupported value.
+// The function supports any image.Image value, images of types that have no
+// registered pixel format (see RegisterPixelFormat) are converted to the
+// smallest format that can represent their colors. It panics if the image
+// cannot be represented, see ImageCreateE for a version that returns an error
+// instead.
 //
 // https://developer.apple.com/library/mac/documentation/GraphicsImaging/Reference/CGImage/
 func ImageCreate(img image.Image) ImageRef {
-	data := extractImageData(img)
-
-	memory := C.CFDataCreate(
-		nil,
-		(*C.UInt8)(unsafe.Pointer(&data.pixels[0])),
-		C.CFIndex(len(data.pixels)),
-	)
+	return mustCreateImage(ImageCreateE(img))
+}
 
-	provider := C.CGDataProviderCreateWithCFData(memory)
+// ImageCreateE is like ImageCreate but returns an error instead of panicking
+// when the image cannot be created.
+//
+// The error wraps ErrEmptyImage if the image has no pixels,
+// ErrUnsupportedFormat if its pixel format cannot be represented, and
+// ErrCreateFailed if Core Graphics failed to create the image object.
+func ImageCreateE(img image.Image) (ImageRef, error) {
+	data, err := checkImage(img)
 
-	cgimg := C.CGImageCreate(
-		C.size_t(data.width),
-		C.size_t(data.height),
-		C.size_t(data.bpc),
-		C.size_t(data.bpp),
-		C.size_t(data.stride),
-		data.colors,
-		data.info,
-		provider,
-		nil,
-		false,
-		C.kCGRenderingIntentDefault,
-	)
+	if err != nil {
+		return 0, err
+	}
 
-	C.CFRelease(provider)
-	C.CFRelease(memory)
-	C.CFRelease(data.colors)
-	return ImageRef(unsafe.Pointer(cgimg))
+	return imageCreateWithCopy(data)
 }
 
 // ImageCreateNoCopy creates a new Core Graphics image object that represents the
 // same content than the Go image passed as argument.
 //
-// The image content is shared between the Go and Core Graphics images, so the
-// program must ensure that the image.Image value it passed to the function is
-// referenced and unmodified for as long as the returned ImageRef is in use.
+// The image content is shared between the Go and Core Graphics images (unless
+// it had to be converted, in which case it is copied), so the program must
+// ensure that the pixels of the image.Image value it passed to the function are
+// left unmodified for as long as the returned ImageRef is in use. The pixel
+// buffer is pinned and kept reachable until Core Graphics releases it, see
+// ImageCreateNoCopyWithRelease to be notified when that happens.
 // It's the program's responsibility to free the resources allocated by the
 // returned ImageRef with a call to CFRelease.
 //
-// The function supports any image types defined in the standard image package,
-// but will panic if the program attempts to create a ImageRef from an
-// unsupported value.
+// The function supports any image.Image value, images of types that have no
+// registered pixel format (see RegisterPixelFormat) are converted to the
+// smallest format that can represent their colors. It panics if the image
+// cannot be represented, see ImageCreateNoCopyE for a version that returns an
+// error instead.
 //
 // https://developer.apple.com/library/mac/documentation/GraphicsImaging/Reference/CGImage/
 func ImageCreateNoCopy(img image.Image) ImageRef {
-	data := extractImageData(img)
+	return mustCreateImage(ImageCreateNoCopyE(img))
+}
 
-	provider := C.CGDataProviderCreateWithData(
-		nil,
-		unsafe.Pointer(&data.pixels[0]),
-		C.size_t(len(data.pixels)),
-		nil,
+// ImageCreateNoCopyE is like ImageCreateNoCopy but returns an error instead of
+// panicking when the image cannot be created.
+//
+// The returned errors are the same than the ones of ImageCreateE.
+func ImageCreateNoCopyE(img image.Image) (ImageRef, error) {
+	return ImageCreateNoCopyWithRelease(img, nil)
+}
+
+// ImageCreateNoCopyWithRelease is like ImageCreateNoCopyE but calls release
+// with the image passed as argument once Core Graphics doesn't reference its
+// pixel buffer anymore, after which the program is free to modify or reuse it.
+//
+// The release function may be called from any thread, and is called before
+// the function returns if the image had to be copied or couldn't be created.
+// It may be nil.
+func ImageCreateNoCopyWithRelease(img image.Image, release func(image.Image)) (ImageRef, error) {
+	done := func() {
+		if release != nil {
+			release(img)
+		}
+	}
+
+	data, err := checkImage(img)
+
+	if err != nil {
+		done()
+		return 0, err
+	}
+
+	// Converted pixels aren't referenced by the Go image, they are copied so
+	// their lifetime is managed by Core Graphics.
+	if data.converted {
+		defer done()
+		return imageCreateWithCopy(data)
+	}
+
+	h := shareImageBuffer(img, data, release)
+
+	provider := C.CGDataProviderCreateWithGoBuffer__(
+		C.uintptr_t(h),
+		unsafe.Pointer(firstByte(data.pix)),
+		C.size_t(len(data.pix)),
 	)
 
-	cgimg := C.CGImageCreate(
-		C.size_t(data.width),
-		C.size_t(data.height),
-		C.size_t(data.bpc),
-		C.size_t(data.bpp),
-		C.size_t(data.stride),
-		data.colors,
-		data.info,
-		provider,
-		nil,
-		false,
-		C.kCGRenderingIntentDefault,
+	if provider == 0 {
+		// It isn't documented whether the release callback is called when
+		// the provider couldn't be created, the buffer is only released if
+		// it wasn't.
+		if _, ok := imageBuffers.lookup(h); ok {
+			releaseImageBuffer(h)
+		}
+		return 0, fmt.Errorf("%w: data provider (%v)", ErrCreateFailed, data.imageLayout)
+	}
+
+	// Releasing the provider calls the release function of the buffer if
+	// the image couldn't be created, otherwise it's called when the image is
+	// freed.
+	defer C.CFRelease(C.CFTypeRef(provider))
+	return imageCreate(data, provider)
+}
+
+// GoImage creates a new Go image with a content equivalent to the Core
+// Graphics image it is called on.
+//
+// The function inspects the bits per component, bitmap info and color space
+// of the image and returns a value of the type from the standard image
+// package that best represents its pixels (*image.RGBA, *image.NRGBA,
+// *image.Gray, ...). An error is returned if the pixel layout of the image
+// has no equivalent in Go.
+//
+// https://developer.apple.com/library/mac/documentation/GraphicsImaging/Reference/CGImage/
+func (img ImageRef) GoImage() (image.Image, error) {
+	ref := C.CGImageRef(unsafe.Pointer(img))
+	model := ColorSpaceModelUnknown
+
+	if colors := C.CGImageGetColorSpace(ref); colors != 0 {
+		model = ColorSpaceModel(C.CGColorSpaceGetModel(colors))
+	}
+
+	layout := imageLayout{
+		width:  int(C.CGImageGetWidth(ref)),
+		height: int(C.CGImageGetHeight(ref)),
+		format: PixelFormatMake(
+			int(C.CGImageGetBitsPerComponent(ref)),
+			int(C.CGImageGetBitsPerPixel(ref)),
+			int(C.CGImageGetBytesPerRow(ref)),
+			BitmapInfo(C.CGImageGetBitmapInfo(ref)),
+			model,
+		),
+	}
+
+	data := C.CGDataProviderCopyData(C.CGImageGetDataProvider(ref))
+
+	if data == 0 {
+		return nil, fmt.Errorf("CG: failed to copy the pixels of the image: %v", layout)
+	}
+
+	defer C.CFRelease(C.CFTypeRef(data))
+
+	pix := C.GoBytes(
+		unsafe.Pointer(C.CFDataGetBytePtr(data)),
+		C.int(C.CFDataGetLength(data)),
 	)
 
-	C.CFRelease(provider)
-	C.CFRelease(data.colors)
-	return ImageRef(unsafe.Pointer(cgimg))
+	return decodeImage(pix, layout)
 }
 
 // Retain increases the refence counter of the Core Graphics image passed
@@ -124,130 +206,96 @@ func (img ImageRef) String() string {
 	return CF.TypeRef(img).String()
 }
 
-type imageData struct {
-	pixels []byte
-	bpc    int
-	bpp    int
-	stride int
-	width  int
-	height int
-	colors C.CGColorSpaceRef
-	info   C.CGBitmapInfo
+func mustCreateImage(img ImageRef, err error) ImageRef {
+	if err != nil {
+		panic(err)
+	}
+	return img
 }
 
-func extractImageData(img image.Image) imageData {
-	bounds := img.Bounds()
-
-	switch i := img.(type) {
-	case *image.RGBA:
-		return imageData{
-			pixels: i.Pix,
-			bpc:    8,
-			bpp:    32,
-			stride: i.Stride,
-			width:  bounds.Dx(),
-			height: bounds.Dy(),
-			colors: C.CGColorSpaceCreateDeviceRGB(),
-			info:   C.CGBitmapInfo(C.kCGBitmapByteOrder32Big) | C.CGBitmapInfo(C.kCGImageAlphaPremultipliedLast),
-		}
+func imageCreateWithCopy(data pixelData) (ImageRef, error) {
+	// The rows of sub-images are padded with pixels of their parent image,
+	// packing them avoids copying bytes that aren't visible.
+	data = data.packed()
 
-	case *image.NRGBA:
-		return imageData{
-			pixels: i.Pix,
-			bpc:    8,
-			bpp:    32,
-			stride: i.Stride,
-			width:  bounds.Dx(),
-			height: bounds.Dy(),
-			colors: C.CGColorSpaceCreateDeviceRGB(),
-			info:   C.CGBitmapInfo(C.kCGBitmapByteOrder32Big) | C.CGBitmapInfo(C.kCGImageAlphaLast),
-		}
+	memory := C.CFDataCreate(
+		nil,
+		(*C.UInt8)(unsafe.Pointer(firstByte(data.pix))),
+		C.CFIndex(len(data.pix)),
+	)
 
-	case *image.RGBA64:
-		return imageData{
-			pixels: i.Pix,
-			bpc:    16,
-			bpp:    64,
-			stride: i.Stride,
-			width:  bounds.Dx(),
-			height: bounds.Dy(),
-			colors: C.CGColorSpaceCreateDeviceRGB(),
-			info:   C.CGBitmapInfo(C.kCGBitmapByteOrder32Big) | C.CGBitmapInfo(C.kCGImageAlphaPremultipliedLast),
-		}
+	if memory == 0 {
+		return 0, fmt.Errorf("%w: pixel data (%v)", ErrCreateFailed, data.imageLayout)
+	}
 
-	case *image.NRGBA64:
-		return imageData{
-			pixels: i.Pix,
-			bpc:    16,
-			bpp:    64,
-			stride: i.Stride,
-			width:  bounds.Dx(),
-			height: bounds.Dy(),
-			colors: C.CGColorSpaceCreateDeviceRGB(),
-			info:   C.CGBitmapInfo(C.kCGBitmapByteOrder32Big) | C.CGBitmapInfo(C.kCGImageAlphaLast),
-		}
+	defer C.CFRelease(C.CFTypeRef(memory))
+	provider := C.CGDataProviderCreateWithCFData(memory)
 
-	case *image.Alpha:
-		return imageData{
-			pixels: i.Pix,
-			bpc:    8,
-			bpp:    8,
-			stride: i.Stride,
-			width:  bounds.Dx(),
-			height: bounds.Dy(),
-			colors: C.CGColorSpaceCreateDeviceGray(),
-			info:   C.CGBitmapInfo(C.kCGImageAlphaNone),
-		}
+	if provider == 0 {
+		return 0, fmt.Errorf("%w: data provider (%v)", ErrCreateFailed, data.imageLayout)
+	}
 
-	case *image.Alpha16:
-		return imageData{
-			pixels: i.Pix,
-			bpc:    16,
-			bpp:    16,
-			stride: i.Stride,
-			width:  bounds.Dx(),
-			height: bounds.Dy(),
-			colors: C.CGColorSpaceCreateDeviceGray(),
-			info:   C.CGBitmapInfo(C.kCGImageAlphaNone),
-		}
+	defer C.CFRelease(C.CFTypeRef(provider))
+	return imageCreate(data, provider)
+}
 
-	case *image.Gray:
-		return imageData{
-			pixels: i.Pix,
-			bpc:    8,
-			bpp:    8,
-			stride: i.Stride,
-			width:  bounds.Dx(),
-			height: bounds.Dy(),
-			colors: C.CGColorSpaceCreateDeviceGray(),
-			info:   C.CGBitmapInfo(C.kCGImageAlphaNone),
-		}
+func imageCreate(data pixelData, provider C.CGDataProviderRef) (ImageRef, error) {
+	colors := colorSpaceCreate(data)
 
-	case *image.Gray16:
-		return imageData{
-			pixels: i.Pix,
-			bpc:    16,
-			bpp:    16,
-			stride: i.Stride,
-			width:  bounds.Dx(),
-			height: bounds.Dy(),
-			colors: C.CGColorSpaceCreateDeviceGray(),
-			info:   C.CGBitmapInfo(C.kCGImageAlphaNone),
-		}
+	if colors == 0 {
+		return 0, fmt.Errorf("%w: color space (%v)", ErrCreateFailed, data.imageLayout)
+	}
 
-	case *image.CMYK:
-		return imageData{
-			pixels: i.Pix,
-			bpc:    8,
-			bpp:    32,
-			stride: i.Stride,
-			width:  bounds.Dx(),
-			height: bounds.Dy(),
-			colors: C.CGColorSpaceCreateDeviceCMYK(),
-			info:   C.CGBitmapInfo(C.kCGBitmapByteOrder32Big) | C.CGBitmapInfo(C.kCGImageAlphaNone),
-		}
+	defer C.CFRelease(C.CFTypeRef(colors))
+
+	cgimg := C.CGImageCreate(
+		C.size_t(data.width),
+		C.size_t(data.height),
+		C.size_t(data.format.BitsPerComponent),
+		C.size_t(data.format.BitsPerPixel),
+		C.size_t(data.format.BytesPerRow),
+		colors,
+		C.CGBitmapInfo(data.format.BitmapInfo()),
+		provider,
+		nil,
+		false,
+		C.kCGRenderingIntentDefault,
+	)
+
+	if cgimg == 0 {
+		return 0, fmt.Errorf("%w: image (%v)", ErrCreateFailed, data.imageLayout)
+	}
+
+	return ImageRef(unsafe.Pointer(cgimg)), nil
+}
+
+func colorSpaceCreate(data pixelData) C.CGColorSpaceRef {
+	switch data.format.ColorModel {
+	case ColorSpaceModelMonochrome:
+		return C.CGColorSpaceCreateDeviceGray()
+
+	case ColorSpaceModelRGB:
+		return C.CGColorSpaceCreateDeviceRGB()
+
+	case ColorSpaceModelCMYK:
+		return C.CGColorSpaceCreateDeviceCMYK()
+
+	case ColorSpaceModelIndexed:
+		base := C.CGColorSpaceCreateDeviceRGB()
+		defer C.CFRelease(C.CFTypeRef(base))
+		return C.CGColorSpaceCreateIndexed(
+			base,
+			C.size_t(len(data.palette)/3-1),
+			(*C.uchar)(unsafe.Pointer(firstByte(data.palette))),
+		)
+	}
+	return 0
+}
 
-	default:
-		panic(fmt.Sprintf("%T: unsupported image format", img))
+// firstByte returns a pointer to the first byte of b, or nil if b is empty.
+func firstByte(b []byte) *byte {
+	if len(b) == 0 {
+		return nil
 	}
+	return &b[0]
 }
@@ -0,0 +1,101 @@
+package CG
+
+import (
+	"fmt"
+	"image"
+	"runtime"
+)
+
+// ImageBuffer describes a Go pixel buffer that is shared with a Core Graphics
+// image created by ImageCreateNoCopy.
+type ImageBuffer struct {
+	// The Go image that the pixels belong to.
+	Image image.Image
+
+	// The pixel format and size of the image, in pixels.
+	Format PixelFormat
+	Width  int
+	Height int
+
+	// The number of bytes of the shared buffer.
+	Bytes int
+}
+
+// String satisfies the fmt.Stringer interface.
+func (b ImageBuffer) String() string {
+	return fmt.Sprintf("%T %dx%d, %d bytes, %v", b.Image, b.Width, b.Height, b.Bytes, b.Format)
+}
+
+// SharedImageBuffers returns the Go pixel buffers that Core Graphics still
+// references, in the order they were shared.
+//
+// A buffer is released when Core Graphics frees the image created from it, so
+// the function can be used to detect leaks of ImageRef values, for example by
+// checking that it returns an empty slice at the end of a test.
+func SharedImageBuffers() []ImageBuffer {
+	live := imageBuffers.live()
+	buffers := make([]ImageBuffer, len(live))
+
+	for i, v := range live {
+		buffers[i] = v.(*imageBuffer).info()
+	}
+
+	return buffers
+}
+
+// imageBuffer holds a Go pixel buffer while it is referenced by Core Graphics,
+// the buffer is pinned so its address stays valid when it's used by C code.
+type imageBuffer struct {
+	image   image.Image
+	data    pixelData
+	pinner  runtime.Pinner
+	release func(image.Image)
+}
+
+var imageBuffers handleRegistry
+
+// shareImageBuffer pins the pixels of data and registers them so they stay
+// reachable until releaseImageBuffer is called with the returned handle.
+func shareImageBuffer(img image.Image, data pixelData, release func(image.Image)) handle {
+	b := &imageBuffer{
+		image:   img,
+		data:    data,
+		release: release,
+	}
+
+	if len(data.pix) != 0 {
+		b.pinner.Pin(&data.pix[0])
+	}
+
+	return imageBuffers.alloc(b)
+}
+
+// releaseImageBuffer unpins the pixel buffer referenced by h and calls its
+// release function, if any.
+//
+// Releasing the same handle twice is a bug, the function panics in that case
+// so it doesn't go unnoticed.
+func releaseImageBuffer(h handle) {
+	v, ok := imageBuffers.release(h)
+
+	if !ok {
+		panic(fmt.Sprintf("CG: releasing unknown image buffer %v", h))
+	}
+
+	b := v.(*imageBuffer)
+	b.pinner.Unpin()
+
+	if b.release != nil {
+		b.release(b.image)
+	}
+}
+
+func (b *imageBuffer) info() ImageBuffer {
+	return ImageBuffer{
+		Image:  b.image,
+		Format: b.data.format,
+		Width:  b.data.width,
+		Height: b.data.height,
+		Bytes:  len(b.data.pix),
+	}
+}
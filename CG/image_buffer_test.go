@@ -0,0 +1,54 @@
+package CG
+
+import (
+	"image"
+	"testing"
+)
+
+func TestShareImageBuffer(t *testing.T) {
+	img := image.NewRGBA(image.Rect(0, 0, 4, 4))
+	data, err := checkImage(img)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	released := 0
+	h := shareImageBuffer(img, data, func(i image.Image) {
+		if i != img {
+			t.Error("the release function was called with the wrong image:", i)
+		}
+		released++
+	})
+
+	buffers := SharedImageBuffers()
+
+	if len(buffers) != 1 {
+		t.Fatal("invalid number of shared image buffers:", len(buffers))
+	}
+
+	if b := buffers[0]; b.Image != img || b.Width != 4 || b.Height != 4 || b.Bytes != len(img.Pix) || b.Format != data.format {
+		t.Error("invalid shared image buffer:", b)
+	}
+
+	releaseImageBuffer(h)
+
+	if released != 1 {
+		t.Error("the release function was called", released, "times")
+	}
+
+	if buffers := SharedImageBuffers(); len(buffers) != 0 {
+		t.Error("image buffers were leaked:", buffers)
+	}
+}
+
+func TestReleaseImageBufferTwice(t *testing.T) {
+	img := image.NewGray(image.Rect(0, 0, 1, 1))
+	data, _ := checkImage(img)
+	h := shareImageBuffer(img, data, nil)
+	releaseImageBuffer(h)
+
+	defer func() { recover() }()
+	releaseImageBuffer(h)
+	t.Error("releasing an image buffer twice did not panic!")
+}
@@ -0,0 +1,288 @@
+package CG
+
+import (
+	"fmt"
+	"image"
+	"image/color"
+	"reflect"
+)
+
+// pixelData carries the raw pixels of a Go image and the information needed
+// to create an equivalent Core Graphics image.
+type pixelData struct {
+	imageLayout
+
+	// The pixel buffer, it starts at the first pixel of the image.
+	pix []byte
+
+	// The RGB color table of images in the indexed color model.
+	palette []byte
+
+	// Set to true when pix was allocated by a conversion, in which case it
+	// is not shared with the Go image.
+	converted bool
+}
+
+// imagePixels returns the pixel data of img.
+//
+// The pixels of image types with a registered pixel format are used directly,
+// paletted images with an opaque palette are exposed as an indexed color space
+// and all other images are converted to the smallest format that can represent
+// their colors.
+func imagePixels(img image.Image) (data pixelData, err error) {
+	size := img.Bounds().Size()
+
+	if img.Bounds().Empty() {
+		size = image.Point{}
+	}
+
+	pixelFormats.RLock()
+	f := pixelFormats.funcs[reflect.TypeOf(img)]
+	pixelFormats.RUnlock()
+
+	switch {
+	case f != nil:
+		data.format, data.pix = f(img)
+
+	case isIndexed(img):
+		p := img.(*image.Paletted)
+		data.format = PixelFormatMake(8, 8, p.Stride, BitmapInfo(ImageAlphaNone), ColorSpaceModelIndexed)
+		data.pix = p.Pix[p.PixOffset(p.Rect.Min.X, p.Rect.Min.Y):]
+		data.palette = paletteTable(p.Palette)
+
+	default:
+		// The bounds of custom images may not be canonical, those would
+		// give negative sizes to the converted pixels.
+		if size == (image.Point{}) {
+			return data, fmt.Errorf("%w: %T has no pixels (%v)", ErrEmptyImage, img, img.Bounds())
+		}
+
+		return convertImage(img), nil
+	}
+
+	data.width, data.height = size.X, size.Y
+
+	if err = data.format.Validate(); err != nil {
+		return
+	}
+
+	// The buffer of a sub-image extends to the end of its parent image, only
+	// the part holding the visible pixels is kept.
+	if data.pix, err = pixelWindow(data.pix, data.format.BytesPerRow, data.format.RowSize(data.width), data.height); err != nil {
+		err = fmt.Errorf("%w: %T: %s (%v)", ErrUnsupportedFormat, img, err, data.imageLayout)
+	}
+
+	return
+}
+
+// checkImage returns the pixel data of img, or an error if no Core Graphics
+// image can be created from it.
+func checkImage(img image.Image) (pixelData, error) {
+	data, err := imagePixels(img)
+
+	if err != nil {
+		return data, err
+	}
+
+	if data.width <= 0 || data.height <= 0 {
+		return data, fmt.Errorf("%w: %T has no pixels (%v)", ErrEmptyImage, img, img.Bounds())
+	}
+
+	// Alpha-only pixels are only supported by bitmap contexts, and images can
+	// only be created in color spaces that don't need extra parameters.
+	switch {
+	case data.format.AlphaInfo == ImageAlphaOnly:
+		return data, fmt.Errorf("%w: %T: images cannot have alpha-only pixels", ErrUnsupportedFormat, img)
+
+	case data.format.ColorModel == ColorSpaceModelIndexed && len(data.palette) == 0:
+		return data, fmt.Errorf("%w: %T: indexed pixels without a color table", ErrUnsupportedFormat, img)
+	}
+
+	switch data.format.ColorModel {
+	case ColorSpaceModelMonochrome, ColorSpaceModelRGB, ColorSpaceModelCMYK, ColorSpaceModelIndexed:
+	default:
+		return data, fmt.Errorf("%w: %T: images cannot be created in the %v color space", ErrUnsupportedFormat, img, data.format.ColorModel)
+	}
+
+	return data, nil
+}
+
+// isIndexed returns true if img is a paletted image that can be represented by
+// an indexed color space, which requires the palette to be opaque.
+func isIndexed(img image.Image) bool {
+	p, ok := img.(*image.Paletted)
+
+	if !ok || len(p.Palette) == 0 || len(p.Palette) > 256 {
+		return false
+	}
+
+	for _, c := range p.Palette {
+		if _, _, _, a := c.RGBA(); a != 0xFFFF {
+			return false
+		}
+	}
+
+	return true
+}
+
+func paletteTable(palette color.Palette) []byte {
+	table := make([]byte, 0, 3*len(palette))
+
+	for _, c := range palette {
+		r, g, b, _ := c.RGBA()
+		table = append(table, uint8(r>>8), uint8(g>>8), uint8(b>>8))
+	}
+
+	return table
+}
+
+// convertImage converts img to a new pixel buffer in the smallest format that
+// can represent its colors.
+//
+// Uniform images have infinite bounds, they are converted to a single pixel.
+func convertImage(img image.Image) pixelData {
+	bounds := img.Bounds()
+
+	if _, ok := img.(*image.Uniform); ok {
+		bounds = image.Rect(0, 0, 1, 1)
+	}
+
+	width, height := bounds.Dx(), bounds.Dy()
+
+	switch src := img.(type) {
+	case *image.YCbCr:
+		data := newPixelData(width, height, PixelFormatMake(8, 24, 3*width, BitmapInfo(ImageAlphaNone), ColorSpaceModelRGB))
+		convertYCbCr(data.pix, src)
+		return data
+
+	case *image.NYCbCrA:
+		data := newPixelData(width, height, PixelFormatMake(8, 32, 4*width, BitmapByteOrder32Big|BitmapInfo(ImageAlphaLast), ColorSpaceModelRGB))
+		convertNYCbCrA(data.pix, src)
+		return data
+	}
+
+	switch img.ColorModel() {
+	case color.GrayModel:
+		data := newPixelData(width, height, PixelFormatMake(8, 8, width, BitmapInfo(ImageAlphaNone), ColorSpaceModelMonochrome))
+		convertPixels(data, img, bounds, func(p []byte, c color.Color) {
+			p[0] = color.GrayModel.Convert(c).(color.Gray).Y
+		})
+		return data
+
+	case color.Gray16Model:
+		data := newPixelData(width, height, PixelFormatMake(16, 16, 2*width, BitmapInfo(ImageAlphaNone), ColorSpaceModelMonochrome))
+		convertPixels(data, img, bounds, func(p []byte, c color.Color) {
+			putUint16(p, color.Gray16Model.Convert(c).(color.Gray16).Y)
+		})
+		return data
+
+	case color.CMYKModel:
+		data := newPixelData(width, height, PixelFormatMake(8, 32, 4*width, BitmapByteOrder32Big|BitmapInfo(ImageAlphaNone), ColorSpaceModelCMYK))
+		convertPixels(data, img, bounds, func(p []byte, c color.Color) {
+			k := color.CMYKModel.Convert(c).(color.CMYK)
+			p[0], p[1], p[2], p[3] = k.C, k.M, k.Y, k.K
+		})
+		return data
+
+	case color.RGBA64Model, color.NRGBA64Model, color.Alpha16Model:
+		data := newPixelData(width, height, PixelFormatMake(16, 64, 8*width, BitmapByteOrder32Big|BitmapInfo(ImageAlphaPremultipliedLast), ColorSpaceModelRGB))
+		convertPixels(data, img, bounds, func(p []byte, c color.Color) {
+			r, g, b, a := c.RGBA()
+			putUint16(p[0:], uint16(r))
+			putUint16(p[2:], uint16(g))
+			putUint16(p[4:], uint16(b))
+			putUint16(p[6:], uint16(a))
+		})
+		return data
+	}
+
+	if isOpaque(img) {
+		data := newPixelData(width, height, PixelFormatMake(8, 24, 3*width, BitmapInfo(ImageAlphaNone), ColorSpaceModelRGB))
+		convertPixels(data, img, bounds, func(p []byte, c color.Color) {
+			r, g, b, _ := c.RGBA()
+			p[0], p[1], p[2] = uint8(r>>8), uint8(g>>8), uint8(b>>8)
+		})
+		return data
+	}
+
+	data := newPixelData(width, height, PixelFormatMake(8, 32, 4*width, BitmapByteOrder32Big|BitmapInfo(ImageAlphaPremultipliedLast), ColorSpaceModelRGB))
+	convertPixels(data, img, bounds, func(p []byte, c color.Color) {
+		r, g, b, a := c.RGBA()
+		p[0], p[1], p[2], p[3] = uint8(r>>8), uint8(g>>8), uint8(b>>8), uint8(a>>8)
+	})
+	return data
+}
+
+func newPixelData(width int, height int, format PixelFormat) pixelData {
+	return pixelData{
+		imageLayout: imageLayout{
+			width:  width,
+			height: height,
+			format: format,
+		},
+		pix:       make([]byte, height*format.BytesPerRow),
+		converted: true,
+	}
+}
+
+// convertPixels calls set for each pixel of img within bounds, passing the
+// slice of data.pix where the pixel is stored.
+func convertPixels(data pixelData, img image.Image, bounds image.Rectangle, set func([]byte, color.Color)) {
+	size := data.format.BitsPerPixel / 8
+
+	for y := 0; y != data.height; y++ {
+		p := data.pix[y*data.format.BytesPerRow:]
+
+		for x := 0; x != data.width; x++ {
+			set(p[x*size:], img.At(bounds.Min.X+x, bounds.Min.Y+y))
+		}
+	}
+}
+
+func convertYCbCr(pix []byte, src *image.YCbCr) {
+	r := src.Rect
+	i := 0
+
+	for y := r.Min.Y; y != r.Max.Y; y++ {
+		for x := r.Min.X; x != r.Max.X; x++ {
+			yi, ci := src.YOffset(x, y), src.COffset(x, y)
+			pix[i], pix[i+1], pix[i+2] = color.YCbCrToRGB(src.Y[yi], src.Cb[ci], src.Cr[ci])
+			i += 3
+		}
+	}
+}
+
+func convertNYCbCrA(pix []byte, src *image.NYCbCrA) {
+	r := src.Rect
+	i := 0
+
+	for y := r.Min.Y; y != r.Max.Y; y++ {
+		for x := r.Min.X; x != r.Max.X; x++ {
+			yi, ci, ai := src.YOffset(x, y), src.COffset(x, y), src.AOffset(x, y)
+			pix[i], pix[i+1], pix[i+2] = color.YCbCrToRGB(src.Y[yi], src.Cb[ci], src.Cr[ci])
+			pix[i+3] = src.A[ai]
+			i += 4
+		}
+	}
+}
+
+// isOpaque returns true if all the pixels of img are known to be opaque.
+func isOpaque(img image.Image) bool {
+	if i, ok := img.(interface{ Opaque() bool }); ok {
+		return i.Opaque()
+	}
+
+	// Images that don't report their opacity are checked one pixel at a
+	// time, the scan stops at the first translucent pixel.
+	b := img.Bounds()
+
+	for y := b.Min.Y; y != b.Max.Y; y++ {
+		for x := b.Min.X; x != b.Max.X; x++ {
+			if _, _, _, a := img.At(x, y).RGBA(); a != 0xFFFF {
+				return false
+			}
+		}
+	}
+
+	return true
+}
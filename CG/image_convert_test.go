@@ -0,0 +1,368 @@
+package CG
+
+import (
+	"errors"
+	"image"
+	"image/color"
+	"testing"
+)
+
+// wrappedImage hides the dynamic type of the image it wraps so it goes through
+// the generic conversion path.
+type wrappedImage struct {
+	image.Image
+}
+
+// reversedImage is an image with non-canonical bounds, where Max is before Min.
+type reversedImage struct {
+	image.Image
+}
+
+func (img reversedImage) Bounds() image.Rectangle {
+	b := img.Image.Bounds()
+	return image.Rectangle{Min: b.Max, Max: b.Min}
+}
+
+func TestImagePixelsPaletted(t *testing.T) {
+	img := image.NewPaletted(image.Rect(0, 0, 2, 2), color.Palette{
+		color.RGBA{0xFF, 0x00, 0x00, 0xFF},
+		color.RGBA{0x00, 0x80, 0xFF, 0xFF},
+	})
+	img.Pix = []byte{0, 1, 1, 0}
+
+	data, err := imagePixels(img)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if data.converted {
+		t.Error("the pixels of a paletted image with an opaque palette were converted")
+	}
+
+	if data.format != PixelFormatMake(8, 8, 2, BitmapInfo(ImageAlphaNone), ColorSpaceModelIndexed) {
+		t.Error("invalid pixel format of a paletted image:", data.format)
+	}
+
+	if string(data.palette) != "\xFF\x00\x00\x00\x80\xFF" {
+		t.Errorf("invalid color table: %x", data.palette)
+	}
+
+	if &data.pix[0] != &img.Pix[0] {
+		t.Error("the pixels of a paletted image are not shared")
+	}
+}
+
+func TestImagePixelsConverted(t *testing.T) {
+	r := image.Rect(0, 0, 5, 3)
+	translucent := image.NewPaletted(r, color.Palette{color.Transparent, color.White})
+	translucent.Pix[3] = 1
+
+	tests := []struct {
+		image  image.Image
+		format PixelFormat
+	}{
+		{
+			image:  image.NewYCbCr(r, image.YCbCrSubsampleRatio420),
+			format: PixelFormatMake(8, 24, 15, BitmapInfo(ImageAlphaNone), ColorSpaceModelRGB),
+		},
+		{
+			image:  image.NewNYCbCrA(r, image.YCbCrSubsampleRatio422),
+			format: PixelFormatMake(8, 32, 20, BitmapByteOrder32Big|BitmapInfo(ImageAlphaLast), ColorSpaceModelRGB),
+		},
+		{
+			image:  translucent,
+			format: PixelFormatMake(8, 32, 20, BitmapByteOrder32Big|BitmapInfo(ImageAlphaPremultipliedLast), ColorSpaceModelRGB),
+		},
+		{
+			image:  image.NewUniform(color.RGBA{1, 2, 3, 0xFF}),
+			format: PixelFormatMake(8, 24, 3, BitmapInfo(ImageAlphaNone), ColorSpaceModelRGB),
+		},
+		{
+			image:  wrappedImage{image.NewGray(r)},
+			format: PixelFormatMake(8, 8, 5, BitmapInfo(ImageAlphaNone), ColorSpaceModelMonochrome),
+		},
+		{
+			image:  wrappedImage{image.NewGray16(r)},
+			format: PixelFormatMake(16, 16, 10, BitmapInfo(ImageAlphaNone), ColorSpaceModelMonochrome),
+		},
+		{
+			image:  wrappedImage{image.NewCMYK(r)},
+			format: PixelFormatMake(8, 32, 20, BitmapByteOrder32Big|BitmapInfo(ImageAlphaNone), ColorSpaceModelCMYK),
+		},
+		{
+			image:  wrappedImage{image.NewNRGBA64(r)},
+			format: PixelFormatMake(16, 64, 40, BitmapByteOrder32Big|BitmapInfo(ImageAlphaPremultipliedLast), ColorSpaceModelRGB),
+		},
+		{
+			image:  wrappedImage{image.NewNRGBA(r)},
+			format: PixelFormatMake(8, 32, 20, BitmapByteOrder32Big|BitmapInfo(ImageAlphaPremultipliedLast), ColorSpaceModelRGB),
+		},
+		{
+			image:  wrappedImage{fillImage(image.NewNRGBA(r), color.White)},
+			format: PixelFormatMake(8, 24, 15, BitmapInfo(ImageAlphaNone), ColorSpaceModelRGB),
+		},
+	}
+
+	for _, test := range tests {
+		data, err := imagePixels(test.image)
+
+		if err != nil {
+			t.Errorf("%T: %s", test.image, err)
+			continue
+		}
+
+		if !data.converted {
+			t.Errorf("%T: the pixels were not converted", test.image)
+		}
+
+		if data.format != test.format {
+			t.Errorf("%T: invalid pixel format of the converted image:\n%v\n%v", test.image, data.format, test.format)
+		}
+
+		if err := data.format.Validate(); err != nil {
+			t.Errorf("%T: %s", test.image, err)
+		}
+	}
+}
+
+type alphaOnlyImage struct {
+	image.Alpha
+}
+
+type labImage struct {
+	image.RGBA
+}
+
+func TestCheckImage(t *testing.T) {
+	RegisterPixelFormat((*alphaOnlyImage)(nil), func(img image.Image) (PixelFormat, []byte) {
+		i := img.(*alphaOnlyImage)
+		return PixelFormatMake(8, 8, i.Stride, BitmapInfo(ImageAlphaOnly), ColorSpaceModelUnknown), i.Pix
+	})
+
+	RegisterPixelFormat((*labImage)(nil), func(img image.Image) (PixelFormat, []byte) {
+		i := img.(*labImage)
+		return PixelFormatMake(8, 32, i.Stride, BitmapInfo(ImageAlphaNoneSkipLast), ColorSpaceModelLab), i.Pix
+	})
+
+	r := image.Rect(0, 0, 2, 2)
+	tests := []struct {
+		image image.Image
+		err   error
+	}{
+		{image.NewRGBA(r), nil},
+		{image.NewPaletted(r, color.Palette{color.White}), nil},
+		{image.NewYCbCr(r, image.YCbCrSubsampleRatio420), nil},
+		{image.NewUniform(color.White), nil},
+		{image.NewRGBA(image.Rect(0, 0, 0, 0)), ErrEmptyImage},
+		{image.NewGray(image.Rect(1, 1, 10, 1)), ErrEmptyImage},
+		{image.NewYCbCr(image.Rect(0, 0, 0, 10), image.YCbCrSubsampleRatio444), ErrEmptyImage},
+		{wrappedImage{image.NewRGBA(image.Rect(0, 0, 3, 0))}, ErrEmptyImage},
+		{reversedImage{image.NewRGBA(r)}, ErrEmptyImage},
+		{&alphaOnlyImage{*image.NewAlpha(r)}, ErrUnsupportedFormat},
+		{&labImage{*image.NewRGBA(r)}, ErrUnsupportedFormat},
+	}
+
+	for _, test := range tests {
+		_, err := checkImage(test.image)
+
+		if !errors.Is(err, test.err) {
+			t.Errorf("%T %v: invalid error: %v (expected %v)", test.image, test.image.Bounds(), err, test.err)
+		}
+	}
+}
+
+func TestConvertImage(t *testing.T) {
+	gradient := newGradient(image.Rect(-3, 2, 29, 19))
+
+	tests := []image.Image{
+		ycbcrImage(gradient, image.YCbCrSubsampleRatio444),
+		ycbcrImage(gradient, image.YCbCrSubsampleRatio420),
+		nycbcraImage(gradient, image.YCbCrSubsampleRatio422),
+		palettedImage(gradient),
+		wrappedImage{gradient},
+		wrappedImage{fillImage(image.NewGray(gradient.Rect), gradient)},
+		wrappedImage{fillImage(image.NewGray16(gradient.Rect), gradient)},
+		wrappedImage{fillImage(image.NewCMYK(gradient.Rect), gradient)},
+		wrappedImage{fillImage(image.NewRGBA64(gradient.Rect), gradient)},
+		wrappedImage{fillImage(image.NewRGBA(gradient.Rect), color.Black)},
+	}
+
+	for _, test := range tests {
+		data := convertImage(test)
+		img, err := decodeImage(data.pix, data.imageLayout)
+
+		if err != nil {
+			t.Errorf("%T: %s", test, err)
+			continue
+		}
+
+		if img.Bounds().Size() != test.Bounds().Size() {
+			t.Errorf("%T: invalid size of the converted image: %v", test, img.Bounds().Size())
+			continue
+		}
+
+		if !similarPixels(img, test, 0x101) {
+			t.Errorf("%T: the converted image differs from the original", test)
+		}
+	}
+}
+
+func TestConvertUniform(t *testing.T) {
+	c := color.NRGBA{0x10, 0x20, 0x30, 0x80}
+	data := convertImage(image.NewUniform(c))
+	img, err := decodeImage(data.pix, data.imageLayout)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if size := img.Bounds().Size(); size != image.Pt(1, 1) {
+		t.Fatal("invalid size of the converted uniform image:", size)
+	}
+
+	if !similarColors(img.At(0, 0), c, 0x101) {
+		t.Errorf("invalid color of the converted uniform image: %v", img.At(0, 0))
+	}
+}
+
+func BenchmarkConvertYCbCr(b *testing.B) {
+	benchmarkConvertImage(b, ycbcrImage(newGradient(image.Rect(0, 0, 256, 256)), image.YCbCrSubsampleRatio420))
+}
+
+func BenchmarkConvertNYCbCrA(b *testing.B) {
+	benchmarkConvertImage(b, nycbcraImage(newGradient(image.Rect(0, 0, 256, 256)), image.YCbCrSubsampleRatio420))
+}
+
+func BenchmarkConvertPaletted(b *testing.B) {
+	benchmarkConvertImage(b, palettedImage(newGradient(image.Rect(0, 0, 256, 256))))
+}
+
+func BenchmarkConvertGeneric(b *testing.B) {
+	benchmarkConvertImage(b, wrappedImage{newGradient(image.Rect(0, 0, 256, 256))})
+}
+
+func benchmarkConvertImage(b *testing.B, img image.Image) {
+	b.SetBytes(int64(4 * img.Bounds().Dx() * img.Bounds().Dy()))
+	b.ReportAllocs()
+	b.ResetTimer()
+
+	for i := 0; i != b.N; i++ {
+		convertImage(img)
+	}
+}
+
+// newGradient returns a translucent image where each pixel has a different
+// color.
+func newGradient(r image.Rectangle) *image.NRGBA {
+	img := image.NewNRGBA(r)
+
+	for y := r.Min.Y; y != r.Max.Y; y++ {
+		for x := r.Min.X; x != r.Max.X; x++ {
+			img.SetNRGBA(x, y, color.NRGBA{uint8(8 * x), uint8(8 * y), uint8(x * y), uint8(0x80 + x)})
+		}
+	}
+
+	return img
+}
+
+func fillImage(img interface {
+	image.Image
+	Set(int, int, color.Color)
+}, src interface{}) image.Image {
+	b := img.Bounds()
+
+	for y := b.Min.Y; y != b.Max.Y; y++ {
+		for x := b.Min.X; x != b.Max.X; x++ {
+			switch s := src.(type) {
+			case color.Color:
+				img.Set(x, y, s)
+			case image.Image:
+				img.Set(x, y, s.At(x, y))
+			}
+		}
+	}
+
+	return img
+}
+
+func ycbcrImage(src image.Image, ratio image.YCbCrSubsampleRatio) *image.YCbCr {
+	b := src.Bounds()
+	img := image.NewYCbCr(b, ratio)
+
+	for y := b.Min.Y; y != b.Max.Y; y++ {
+		for x := b.Min.X; x != b.Max.X; x++ {
+			c := color.YCbCrModel.Convert(src.At(x, y)).(color.YCbCr)
+			img.Y[img.YOffset(x, y)] = c.Y
+			img.Cb[img.COffset(x, y)] = c.Cb
+			img.Cr[img.COffset(x, y)] = c.Cr
+		}
+	}
+
+	return img
+}
+
+func nycbcraImage(src image.Image, ratio image.YCbCrSubsampleRatio) *image.NYCbCrA {
+	b := src.Bounds()
+	img := image.NewNYCbCrA(b, ratio)
+
+	for y := b.Min.Y; y != b.Max.Y; y++ {
+		for x := b.Min.X; x != b.Max.X; x++ {
+			c := color.NYCbCrAModel.Convert(src.At(x, y)).(color.NYCbCrA)
+			img.Y[img.YOffset(x, y)] = c.Y
+			img.Cb[img.COffset(x, y)] = c.Cb
+			img.Cr[img.COffset(x, y)] = c.Cr
+			img.A[img.AOffset(x, y)] = c.A
+		}
+	}
+
+	return img
+}
+
+func palettedImage(src image.Image) *image.Paletted {
+	img := image.NewPaletted(src.Bounds(), color.Palette{
+		color.Transparent,
+		color.NRGBA{0xFF, 0x00, 0x00, 0x80},
+		color.NRGBA{0x00, 0xFF, 0x00, 0xFF},
+		color.NRGBA{0x00, 0x00, 0xFF, 0x40},
+	})
+	fillImage(img, src)
+	return img
+}
+
+// similarPixels returns true if the images have the same size and the color
+// components of their pixels differ by at most tolerance.
+func similarPixels(img1 image.Image, img2 image.Image, tolerance uint32) bool {
+	min1, min2 := img1.Bounds().Min, img2.Bounds().Min
+	size := img1.Bounds().Size()
+
+	if size != img2.Bounds().Size() {
+		return false
+	}
+
+	for y := 0; y != size.Y; y++ {
+		for x := 0; x != size.X; x++ {
+			if !similarColors(img1.At(min1.X+x, min1.Y+y), img2.At(min2.X+x, min2.Y+y), tolerance) {
+				return false
+			}
+		}
+	}
+
+	return true
+}
+
+func similarColors(c1 color.Color, c2 color.Color, tolerance uint32) bool {
+	r1, g1, b1, a1 := c1.RGBA()
+	r2, g2, b2, a2 := c2.RGBA()
+	return absDiff(r1, r2) <= tolerance &&
+		absDiff(g1, g2) <= tolerance &&
+		absDiff(b1, b2) <= tolerance &&
+		absDiff(a1, a2) <= tolerance
+}
+
+func absDiff(a uint32, b uint32) uint32 {
+	if a < b {
+		return b - a
+	}
+	return a - b
+}
@@ -0,0 +1,337 @@
+package CG
+
+import (
+	"encoding/binary"
+	"fmt"
+	"image"
+	"math"
+)
+
+// imageLayout describes the size and pixel format of a Core Graphics image, it
+// carries all the information needed to interpret a raw pixel buffer.
+type imageLayout struct {
+	width  int
+	height int
+	format PixelFormat
+}
+
+func (l imageLayout) String() string {
+	return fmt.Sprintf("%dx%d, %v", l.width, l.height, l.format)
+}
+
+// decodeImage converts the raw pixel buffer of an image with the given layout
+// to the image type of the standard library that best represents it.
+func decodeImage(pix []byte, layout imageLayout) (image.Image, error) {
+	dec, err := newPixelDecoder(layout)
+	if err != nil {
+		return nil, err
+	}
+
+	stride := layout.format.BytesPerRow
+	rowSize := layout.format.RowSize(layout.width)
+
+	if stride < rowSize {
+		return nil, fmt.Errorf("CG: invalid number of bytes per row: %v", layout)
+	}
+
+	if layout.height != 0 && len(pix) < ((layout.height-1)*stride+rowSize) {
+		return nil, fmt.Errorf("CG: pixel buffer of %d bytes is too short: %v", len(pix), layout)
+	}
+
+	img := dec.newImage(image.Rect(0, 0, layout.width, layout.height))
+	row := make([]byte, rowSize)
+
+	for y := 0; y != layout.height; y++ {
+		copy(row, pix[y*stride:])
+		swapBytes(row, layout.format.ByteOrder)
+		dec.decodeRow(img, y, row)
+	}
+
+	return img, nil
+}
+
+type pixelKind int
+
+const (
+	pixelAlpha pixelKind = iota
+	pixelGray
+	pixelCMYK
+	pixelColor
+)
+
+type pixelDecoder struct {
+	layout   imageLayout
+	kind     pixelKind
+	channels int
+	colors   int
+	alpha    int
+	hasAlpha bool
+	premul   bool
+}
+
+func newPixelDecoder(l imageLayout) (*pixelDecoder, error) {
+	f := l.format
+	alpha := f.AlphaInfo
+	order := f.ByteOrder
+	d := &pixelDecoder{
+		layout:   l,
+		alpha:    -1,
+		hasAlpha: alpha.HasAlpha(),
+		premul:   alpha.IsPremultiplied(),
+	}
+
+	unsupported := func() (*pixelDecoder, error) {
+		return nil, fmt.Errorf("CG: unsupported pixel layout: %v", l)
+	}
+
+	if l.width < 0 || l.height < 0 || alpha > ImageAlphaOnly {
+		return unsupported()
+	}
+
+	switch {
+	case alpha == ImageAlphaOnly:
+		d.kind, d.colors = pixelAlpha, 0
+
+	case f.ColorModel == ColorSpaceModelMonochrome && alpha == ImageAlphaNone:
+		d.kind, d.colors = pixelGray, 1
+
+	case f.ColorModel == ColorSpaceModelCMYK && alpha == ImageAlphaNone && f.BitsPerComponent == 8:
+		d.kind, d.colors = pixelCMYK, 4
+
+	case f.ColorModel == ColorSpaceModelMonochrome || f.ColorModel == ColorSpaceModelRGB:
+		d.kind, d.colors = pixelColor, f.ColorModel.NumberOfComponents()
+
+	default:
+		return unsupported()
+	}
+
+	d.channels = d.colors
+
+	if alpha != ImageAlphaNone {
+		d.channels++
+
+		if alpha.IsFirst() {
+			d.alpha = 0
+		} else {
+			d.alpha = d.channels - 1
+		}
+	}
+
+	switch f.BitsPerComponent {
+	case 5:
+		if f.BitsPerPixel != 16 || d.colors != 3 || d.hasAlpha || alpha == ImageAlphaNone {
+			return unsupported()
+		}
+	case 8, 16:
+		if f.FloatComponents || f.BitsPerPixel != f.BitsPerComponent*d.channels {
+			return unsupported()
+		}
+	case 32:
+		if !f.FloatComponents || f.BitsPerPixel != f.BitsPerComponent*d.channels {
+			return unsupported()
+		}
+	default:
+		return unsupported()
+	}
+
+	switch order {
+	case BitmapByteOrderDefault, BitmapByteOrder16Big, BitmapByteOrder32Big:
+	case BitmapByteOrder16Little:
+		if (f.BitsPerPixel % 16) != 0 {
+			return unsupported()
+		}
+	case BitmapByteOrder32Little:
+		if (f.BitsPerPixel % 32) != 0 {
+			return unsupported()
+		}
+	default:
+		return unsupported()
+	}
+
+	return d, nil
+}
+
+func (d *pixelDecoder) newImage(r image.Rectangle) image.Image {
+	wide := d.layout.format.BitsPerComponent > 8
+
+	switch d.kind {
+	case pixelAlpha:
+		if wide {
+			return image.NewAlpha16(r)
+		}
+		return image.NewAlpha(r)
+
+	case pixelGray:
+		if wide {
+			return image.NewGray16(r)
+		}
+		return image.NewGray(r)
+
+	case pixelCMYK:
+		return image.NewCMYK(r)
+	}
+
+	switch {
+	case wide && d.hasAlpha && !d.premul:
+		return image.NewNRGBA64(r)
+	case wide:
+		return image.NewRGBA64(r)
+	case d.hasAlpha && !d.premul:
+		return image.NewNRGBA(r)
+	default:
+		return image.NewRGBA(r)
+	}
+}
+
+func (d *pixelDecoder) decodeRow(img image.Image, y int, row []byte) {
+	width := d.layout.width
+
+	switch dst := img.(type) {
+	case *image.Alpha:
+		copy(dst.Pix[y*dst.Stride:], row[:width])
+
+	case *image.Gray:
+		copy(dst.Pix[y*dst.Stride:], row[:width])
+
+	case *image.CMYK:
+		copy(dst.Pix[y*dst.Stride:], row[:4*width])
+
+	case *image.Alpha16:
+		for x := 0; x != width; x++ {
+			putUint16(dst.Pix[y*dst.Stride+2*x:], d.component(row, x, 0))
+		}
+
+	case *image.Gray16:
+		for x := 0; x != width; x++ {
+			putUint16(dst.Pix[y*dst.Stride+2*x:], d.component(row, x, 0))
+		}
+
+	case *image.RGBA:
+		for x := 0; x != width; x++ {
+			r, g, b, a := d.color(row, x)
+			p := dst.Pix[y*dst.Stride+4*x:]
+			p[0], p[1], p[2], p[3] = uint8(r>>8), uint8(g>>8), uint8(b>>8), uint8(a>>8)
+		}
+
+	case *image.NRGBA:
+		for x := 0; x != width; x++ {
+			r, g, b, a := d.color(row, x)
+			p := dst.Pix[y*dst.Stride+4*x:]
+			p[0], p[1], p[2], p[3] = uint8(r>>8), uint8(g>>8), uint8(b>>8), uint8(a>>8)
+		}
+
+	case *image.RGBA64:
+		for x := 0; x != width; x++ {
+			r, g, b, a := d.color(row, x)
+			p := dst.Pix[y*dst.Stride+8*x:]
+			putUint16(p[0:], r)
+			putUint16(p[2:], g)
+			putUint16(p[4:], b)
+			putUint16(p[6:], a)
+		}
+
+	case *image.NRGBA64:
+		for x := 0; x != width; x++ {
+			r, g, b, a := d.color(row, x)
+			p := dst.Pix[y*dst.Stride+8*x:]
+			putUint16(p[0:], r)
+			putUint16(p[2:], g)
+			putUint16(p[4:], b)
+			putUint16(p[6:], a)
+		}
+	}
+}
+
+// color returns the 16 bits color components of the pixel at x in row, they
+// are premultiplied or not depending on the pixel layout.
+func (d *pixelDecoder) color(row []byte, x int) (r uint16, g uint16, b uint16, a uint16) {
+	first := 0
+
+	if d.alpha == 0 {
+		first = 1
+	}
+
+	if d.layout.format.BitsPerComponent == 5 {
+		return d.color555(row, x, first)
+	}
+
+	a = 0xFFFF
+
+	if d.hasAlpha {
+		a = d.component(row, x, d.alpha)
+	}
+
+	if d.colors == 1 {
+		r = d.component(row, x, first)
+		g, b = r, r
+	} else {
+		r = d.component(row, x, first)
+		g = d.component(row, x, first+1)
+		b = d.component(row, x, first+2)
+	}
+
+	return
+}
+
+func (d *pixelDecoder) color555(row []byte, x int, first int) (r uint16, g uint16, b uint16, a uint16) {
+	v := binary.BigEndian.Uint16(row[2*x:])
+
+	if first == 0 {
+		v >>= 1
+	}
+
+	r = expand5((v >> 10) & 0x1F)
+	g = expand5((v >> 5) & 0x1F)
+	b = expand5(v & 0x1F)
+	a = 0xFFFF
+	return
+}
+
+// component returns the i-th component of the pixel at x in row, scaled to
+// a 16 bits value.
+func (d *pixelDecoder) component(row []byte, x int, i int) uint16 {
+	index := x*d.channels + i
+
+	switch d.layout.format.BitsPerComponent {
+	case 8:
+		return uint16(row[index]) * 0x101
+
+	case 16:
+		return binary.BigEndian.Uint16(row[2*index:])
+
+	default:
+		f := math.Float32frombits(binary.BigEndian.Uint32(row[4*index:]))
+
+		switch {
+		case !(f > 0): // also catches NaN
+			return 0
+		case f >= 1:
+			return 0xFFFF
+		default:
+			return uint16(f*0xFFFF + 0.5)
+		}
+	}
+}
+
+func expand5(v uint16) uint16 {
+	return uint16((uint32(v) * 0xFFFF) / 0x1F)
+}
+
+func putUint16(b []byte, v uint16) {
+	binary.BigEndian.PutUint16(b, v)
+}
+
+// swapBytes converts the words of b from the given byte order to big endian.
+func swapBytes(b []byte, order BitmapInfo) {
+	switch order {
+	case BitmapByteOrder16Little:
+		for i := 0; (i + 1) < len(b); i += 2 {
+			b[i], b[i+1] = b[i+1], b[i]
+		}
+
+	case BitmapByteOrder32Little:
+		for i := 0; (i + 3) < len(b); i += 4 {
+			b[i], b[i+1], b[i+2], b[i+3] = b[i+3], b[i+2], b[i+1], b[i]
+		}
+	}
+}
@@ -0,0 +1,182 @@
+package CG
+
+import (
+	"image"
+	"reflect"
+	"testing"
+)
+
+func TestDecodeImage(t *testing.T) {
+	tests := []struct {
+		name   string
+		pix    []byte
+		layout imageLayout
+		image  image.Image
+	}{
+		{
+			name:   "RGBA premultiplied last, big endian",
+			pix:    []byte{1, 2, 3, 4, 5, 6, 7, 8},
+			layout: imageLayout{2, 1, PixelFormatMake(8, 32, 8, BitmapByteOrder32Big|BitmapInfo(ImageAlphaPremultipliedLast), ColorSpaceModelRGB)},
+			image:  rgba(2, 1, 1, 2, 3, 4, 5, 6, 7, 8),
+		},
+		{
+			name:   "BGRA premultiplied first, little endian",
+			pix:    []byte{3, 2, 1, 4, 7, 6, 5, 8},
+			layout: imageLayout{2, 1, PixelFormatMake(8, 32, 8, BitmapByteOrder32Little|BitmapInfo(ImageAlphaPremultipliedFirst), ColorSpaceModelRGB)},
+			image:  rgba(2, 1, 1, 2, 3, 4, 5, 6, 7, 8),
+		},
+		{
+			name:   "ARGB first, default byte order",
+			pix:    []byte{4, 1, 2, 3},
+			layout: imageLayout{1, 1, PixelFormatMake(8, 32, 4, BitmapInfo(ImageAlphaFirst), ColorSpaceModelRGB)},
+			image:  nrgba(1, 1, 1, 2, 3, 4),
+		},
+		{
+			name:   "RGBX skip last",
+			pix:    []byte{1, 2, 3, 99},
+			layout: imageLayout{1, 1, PixelFormatMake(8, 32, 4, BitmapInfo(ImageAlphaNoneSkipLast), ColorSpaceModelRGB)},
+			image:  rgba(1, 1, 1, 2, 3, 0xFF),
+		},
+		{
+			name:   "RGB without alpha and padded rows",
+			pix:    []byte{1, 2, 3, 0, 4, 5, 6, 0},
+			layout: imageLayout{1, 2, PixelFormatMake(8, 24, 4, BitmapInfo(ImageAlphaNone), ColorSpaceModelRGB)},
+			image:  rgba(1, 2, 1, 2, 3, 0xFF, 4, 5, 6, 0xFF),
+		},
+		{
+			name:   "gray with alpha last",
+			pix:    []byte{10, 20},
+			layout: imageLayout{1, 1, PixelFormatMake(8, 16, 2, BitmapInfo(ImageAlphaLast), ColorSpaceModelMonochrome)},
+			image:  nrgba(1, 1, 10, 10, 10, 20),
+		},
+		{
+			name:   "gray",
+			pix:    []byte{1, 2, 3, 4},
+			layout: imageLayout{2, 2, PixelFormatMake(8, 8, 2, BitmapInfo(ImageAlphaNone), ColorSpaceModelMonochrome)},
+			image:  &image.Gray{Pix: []byte{1, 2, 3, 4}, Stride: 2, Rect: image.Rect(0, 0, 2, 2)},
+		},
+		{
+			name:   "gray 16 bits, little endian",
+			pix:    []byte{0x02, 0x01, 0x04, 0x03},
+			layout: imageLayout{2, 1, PixelFormatMake(16, 16, 4, BitmapByteOrder16Little|BitmapInfo(ImageAlphaNone), ColorSpaceModelMonochrome)},
+			image:  &image.Gray16{Pix: []byte{0x01, 0x02, 0x03, 0x04}, Stride: 4, Rect: image.Rect(0, 0, 2, 1)},
+		},
+		{
+			name:   "alpha only",
+			pix:    []byte{1, 2},
+			layout: imageLayout{2, 1, PixelFormatMake(8, 8, 2, BitmapInfo(ImageAlphaOnly), ColorSpaceModelUnknown)},
+			image:  &image.Alpha{Pix: []byte{1, 2}, Stride: 2, Rect: image.Rect(0, 0, 2, 1)},
+		},
+		{
+			name:   "alpha only 16 bits",
+			pix:    []byte{1, 2},
+			layout: imageLayout{1, 1, PixelFormatMake(16, 16, 2, BitmapInfo(ImageAlphaOnly), ColorSpaceModelUnknown)},
+			image:  &image.Alpha16{Pix: []byte{1, 2}, Stride: 2, Rect: image.Rect(0, 0, 1, 1)},
+		},
+		{
+			name:   "CMYK",
+			pix:    []byte{1, 2, 3, 4},
+			layout: imageLayout{1, 1, PixelFormatMake(8, 32, 4, BitmapByteOrder32Big|BitmapInfo(ImageAlphaNone), ColorSpaceModelCMYK)},
+			image:  &image.CMYK{Pix: []byte{1, 2, 3, 4}, Stride: 4, Rect: image.Rect(0, 0, 1, 1)},
+		},
+		{
+			name:   "RGBA 16 bits premultiplied last, little endian",
+			pix:    []byte{0x02, 0x01, 0x04, 0x03, 0x06, 0x05, 0x08, 0x07},
+			layout: imageLayout{1, 1, PixelFormatMake(16, 64, 8, BitmapByteOrder16Little|BitmapInfo(ImageAlphaPremultipliedLast), ColorSpaceModelRGB)},
+			image:  &image.RGBA64{Pix: []byte{1, 2, 3, 4, 5, 6, 7, 8}, Stride: 8, Rect: image.Rect(0, 0, 1, 1)},
+		},
+		{
+			name: "RGBA float last, little endian",
+			pix: []byte{
+				0x00, 0x00, 0x80, 0x3F, // 1.0
+				0x00, 0x00, 0x00, 0x00, // 0.0
+				0x00, 0x00, 0x00, 0x3F, // 0.5
+				0x00, 0x00, 0x00, 0x40, // 2.0
+			},
+			layout: imageLayout{1, 1, PixelFormatMake(32, 128, 16, BitmapByteOrder32Little|BitmapFloatComponents|BitmapInfo(ImageAlphaLast), ColorSpaceModelRGB)},
+			image:  &image.NRGBA64{Pix: []byte{0xFF, 0xFF, 0x00, 0x00, 0x80, 0x00, 0xFF, 0xFF}, Stride: 8, Rect: image.Rect(0, 0, 1, 1)},
+		},
+		{
+			name:   "RGB 555 skip first, little endian",
+			pix:    []byte{0x1F, 0x7C},
+			layout: imageLayout{1, 1, PixelFormatMake(5, 16, 2, BitmapByteOrder16Little|BitmapInfo(ImageAlphaNoneSkipFirst), ColorSpaceModelRGB)},
+			image:  rgba(1, 1, 0xFF, 0x00, 0xFF, 0xFF),
+		},
+		{
+			name:   "empty",
+			pix:    nil,
+			layout: imageLayout{0, 0, PixelFormatMake(8, 32, 0, BitmapInfo(ImageAlphaPremultipliedLast), ColorSpaceModelRGB)},
+			image:  image.NewRGBA(image.Rect(0, 0, 0, 0)),
+		},
+	}
+
+	for _, test := range tests {
+		img, err := decodeImage(test.pix, test.layout)
+
+		if err != nil {
+			t.Errorf("%s: %s", test.name, err)
+			continue
+		}
+
+		if !reflect.DeepEqual(img, test.image) {
+			t.Errorf("%s: invalid decoded image:\n%#v\n%#v", test.name, img, test.image)
+		}
+	}
+}
+
+func TestDecodeImageError(t *testing.T) {
+	tests := []struct {
+		name   string
+		pix    []byte
+		layout imageLayout
+	}{
+		{
+			name:   "buffer too short",
+			pix:    []byte{1, 2, 3},
+			layout: imageLayout{1, 1, PixelFormatMake(8, 32, 4, BitmapInfo(ImageAlphaPremultipliedLast), ColorSpaceModelRGB)},
+		},
+		{
+			name:   "row too short",
+			pix:    []byte{1, 2, 3, 4, 5, 6, 7, 8},
+			layout: imageLayout{2, 1, PixelFormatMake(8, 32, 4, BitmapInfo(ImageAlphaPremultipliedLast), ColorSpaceModelRGB)},
+		},
+		{
+			name:   "bits per pixel mismatch",
+			pix:    []byte{1, 2, 3, 4},
+			layout: imageLayout{1, 1, PixelFormatMake(8, 24, 4, BitmapInfo(ImageAlphaPremultipliedLast), ColorSpaceModelRGB)},
+		},
+		{
+			name:   "unsupported color space",
+			pix:    []byte{1, 2, 3},
+			layout: imageLayout{1, 1, PixelFormatMake(8, 24, 3, BitmapInfo(ImageAlphaNone), ColorSpaceModelLab)},
+		},
+		{
+			name:   "integer components of 32 bits",
+			pix:    make([]byte, 16),
+			layout: imageLayout{1, 1, PixelFormatMake(32, 128, 16, BitmapInfo(ImageAlphaLast), ColorSpaceModelRGB)},
+		},
+		{
+			name:   "32 bits byte order on 24 bits pixels",
+			pix:    []byte{1, 2, 3, 0},
+			layout: imageLayout{1, 1, PixelFormatMake(8, 24, 4, BitmapByteOrder32Little|BitmapInfo(ImageAlphaNone), ColorSpaceModelRGB)},
+		},
+	}
+
+	for _, test := range tests {
+		if _, err := decodeImage(test.pix, test.layout); err == nil {
+			t.Errorf("%s: no error returned", test.name)
+		}
+	}
+}
+
+func rgba(w int, h int, pix ...byte) *image.RGBA {
+	img := image.NewRGBA(image.Rect(0, 0, w, h))
+	copy(img.Pix, pix)
+	return img
+}
+
+func nrgba(w int, h int, pix ...byte) *image.NRGBA {
+	img := image.NewNRGBA(image.Rect(0, 0, w, h))
+	copy(img.Pix, pix)
+	return img
+}
@@ -0,0 +1,16 @@
+// +build darwin
+
+#include "image_release.h"
+#include "_cgo_export.h"
+
+static void CGDataProviderReleaseGoBuffer__(void *info, const void *data,
+                                            size_t size) {
+  goReleaseImageBuffer((uintptr_t)info);
+}
+
+CGDataProviderRef CGDataProviderCreateWithGoBuffer__(uintptr_t handle,
+                                                     const void *data,
+                                                     size_t size) {
+  return CGDataProviderCreateWithData((void *)handle, data, size,
+                                      CGDataProviderReleaseGoBuffer__);
+}
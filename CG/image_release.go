@@ -0,0 +1,14 @@
+// +build darwin
+
+package CG
+
+// #include <stdint.h>
+import "C"
+
+// goReleaseImageBuffer is called by Core Graphics when it doesn't need a pixel
+// buffer shared by ImageCreateNoCopy anymore.
+//
+//export goReleaseImageBuffer
+func goReleaseImageBuffer(h C.uintptr_t) {
+	releaseImageBuffer(handle(h))
+}
@@ -0,0 +1,11 @@
+#ifndef GOVU_COCOA_IMAGE_RELEASE_H
+#define GOVU_COCOA_IMAGE_RELEASE_H
+
+#include <stdint.h>
+#include <CoreGraphics/CGDataProvider.h>
+
+CGDataProviderRef CGDataProviderCreateWithGoBuffer__(uintptr_t handle,
+                                                     const void *data,
+                                                     size_t size);
+
+#endif /* GOVU_COCOA_IMAGE_RELEASE_H */
@@ -4,8 +4,10 @@ package CG
 
 import (
 	"bufio"
+	"errors"
 	"image"
 	"image/color"
+	"image/color/palette"
 	"image/draw"
 	"os"
 	"testing"
@@ -31,10 +33,162 @@ func TestImageCreateNoCopy(t *testing.T) {
 	}
 }
 
+func TestImageCreateNoCopyWithRelease(t *testing.T) {
+	for _, test := range append(gopherImages[:], ycbcrImage(gopherNRGBA, image.YCbCrSubsampleRatio420)) {
+		released := 0
+		img, err := ImageCreateNoCopyWithRelease(test, func(image.Image) { released++ })
+
+		if err != nil {
+			t.Errorf("%T: %s", test, err)
+			continue
+		}
+
+		img.Retain()
+		img.Release()
+		img.Release()
+
+		if released != 1 {
+			t.Errorf("%T: the release function was called %d times", test, released)
+		}
+	}
+
+	if buffers := SharedImageBuffers(); len(buffers) != 0 {
+		t.Error("image buffers were leaked:", buffers)
+	}
+}
+
+func TestImageCreateConverted(t *testing.T) {
+	b := gopherNRGBA.Bounds()
+	tests := []image.Image{
+		copyImage(image.NewPaletted(b, palette.Plan9), gopherNRGBA),
+		copyImage(image.NewPaletted(b, palette.WebSafe), gopherNRGBA),
+		ycbcrImage(gopherNRGBA, image.YCbCrSubsampleRatio420),
+		nycbcraImage(gopherNRGBA, image.YCbCrSubsampleRatio444),
+		image.NewUniform(color.Black),
+		wrappedImage{gopherNRGBA},
+	}
+
+	for _, test := range tests {
+		for _, create := range []func(image.Image) ImageRef{ImageCreate, ImageCreateNoCopy} {
+			img := create(test)
+
+			if img == 0 {
+				t.Errorf("%T: failed to create an image", test)
+				continue
+			}
+
+			img.Release()
+		}
+	}
+}
+
+type invalidImage struct {
+	image.RGBA
+}
+
+func init() {
+	RegisterPixelFormat((*invalidImage)(nil), func(img image.Image) (PixelFormat, []byte) {
+		return PixelFormatMake(7, 32, 4, BitmapInfo(ImageAlphaLast), ColorSpaceModelRGB), nil
+	})
+}
+
 func TestImageCreatePanic(t *testing.T) {
 	defer func() { recover() }()
-	ImageCreate(image.NewUniform(color.Black))
-	t.Error("calling ImageCreate with an unsupported image type did not panic!")
+	ImageCreate(&invalidImage{*image.NewRGBA(image.Rect(0, 0, 1, 1))})
+	t.Error("calling ImageCreate with an invalid pixel format did not panic!")
+}
+
+func TestImageCreateEmptyPanic(t *testing.T) {
+	defer func() { recover() }()
+	ImageCreateNoCopy(image.NewRGBA(image.Rect(0, 0, 0, 0)))
+	t.Error("calling ImageCreateNoCopy with an empty image did not panic!")
+}
+
+func TestImageCreateE(t *testing.T) {
+	tests := []struct {
+		image image.Image
+		err   error
+	}{
+		{gopherRGBA, nil},
+		{image.NewRGBA(image.Rect(0, 0, 0, 0)), ErrEmptyImage},
+		{image.NewGray(image.Rect(0, 0, 10, 0)), ErrEmptyImage},
+		{&invalidImage{*image.NewRGBA(image.Rect(0, 0, 1, 1))}, ErrUnsupportedFormat},
+	}
+
+	for _, test := range tests {
+		for _, create := range []func(image.Image) (ImageRef, error){ImageCreateE, ImageCreateNoCopyE} {
+			img, err := create(test.image)
+
+			if !errors.Is(err, test.err) {
+				t.Errorf("%T: invalid error: %v (expected %v)", test.image, err, test.err)
+			}
+
+			if (img == 0) != (err != nil) {
+				t.Errorf("%T: invalid image returned along with error %v: %v", test.image, err, img)
+			}
+
+			if img != 0 {
+				img.Release()
+			}
+		}
+	}
+}
+
+func TestImageGoImage(t *testing.T) {
+	for _, test := range gopherImages {
+		img := ImageCreate(test)
+		res, err := img.GoImage()
+		img.Release()
+
+		if err != nil {
+			t.Errorf("%T: %s", test, err)
+			continue
+		}
+
+		if b1, b2 := res.Bounds().Size(), test.Bounds().Size(); b1 != b2 {
+			t.Errorf("%T: invalid size of the decoded image: %v != %v", test, b1, b2)
+			continue
+		}
+
+		// The pixels of alpha images are stored in a gray color space, they
+		// are decoded as gray images so the color values aren't compared.
+		if _, ok := test.(*image.Alpha); ok {
+			continue
+		}
+		if _, ok := test.(*image.Alpha16); ok {
+			continue
+		}
+
+		if !equalPixels(res, test) {
+			t.Errorf("%T: the decoded image differs from the original (%T)", test, res)
+		}
+	}
+}
+
+func TestImageCreateSubImage(t *testing.T) {
+	b := gopherNRGBA.Bounds()
+	r := image.Rect(b.Min.X+b.Dx()/4, b.Min.Y+b.Dy()/3, b.Max.X-b.Dx()/5, b.Max.Y-b.Dy()/6)
+
+	for _, test := range []image.Image{gopherRGBA, gopherGray, gopherCMYK, gopherNRGBA64} {
+		sub := test.(interface {
+			SubImage(image.Rectangle) image.Image
+		}).SubImage(r)
+
+		for _, create := range []func(image.Image) ImageRef{ImageCreate, ImageCreateNoCopy} {
+			img := create(sub)
+			res, err := img.GoImage()
+			img.Release()
+
+			if err != nil {
+				t.Errorf("%T: %s", sub, err)
+				continue
+			}
+
+			if res.Bounds().Size() != r.Size() || !equalPixels(res, sub) {
+				t.Errorf("%T: the decoded image differs from the sub-image", sub)
+			}
+		}
+	}
 }
 
 func TestImageString(t *testing.T) {
@@ -48,6 +202,24 @@ func TestImageString(t *testing.T) {
 	img.Release()
 }
 
+func equalPixels(img1 image.Image, img2 image.Image) bool {
+	min1, min2 := img1.Bounds().Min, img2.Bounds().Min
+	size := img1.Bounds().Size()
+
+	for y := 0; y != size.Y; y++ {
+		for x := 0; x != size.X; x++ {
+			r1, g1, b1, a1 := img1.At(min1.X+x, min1.Y+y).RGBA()
+			r2, g2, b2, a2 := img2.At(min2.X+x, min2.Y+y).RGBA()
+
+			if r1 != r2 || g1 != g2 || b1 != b2 || a1 != a2 {
+				return false
+			}
+		}
+	}
+
+	return true
+}
+
 func loadImage() image.Image {
 	f, _ := os.Open("fixtures/gopher.png")
 	i, _, _ := image.Decode(bufio.NewReader(f))
@@ -0,0 +1,267 @@
+package CG
+
+import (
+	"fmt"
+	"image"
+	"reflect"
+	"sync"
+)
+
+// PixelFormat describes the memory layout of the pixels of a bitmap image, it
+// carries the values that Core Graphics expects when creating an image from a
+// raw pixel buffer.
+//
+// PixelFormat values are comparable, two images with equal pixel formats can
+// share the same pixel buffers.
+//
+// https://developer.apple.com/library/mac/documentation/GraphicsImaging/Reference/CGImage/#//apple_ref/c/func/CGImageCreate
+type PixelFormat struct {
+	BitsPerComponent int
+	BitsPerPixel     int
+	BytesPerRow      int
+	AlphaInfo        ImageAlphaInfo
+	ByteOrder        BitmapInfo
+	FloatComponents  bool
+	ColorModel       ColorSpaceModel
+}
+
+// PixelFormatMake returns a pixel format constructed from the values passed as
+// arguments, the alpha info, byte order and float components flag are
+// extracted from info.
+func PixelFormatMake(bpc int, bpp int, bytesPerRow int, info BitmapInfo, model ColorSpaceModel) PixelFormat {
+	return PixelFormat{
+		BitsPerComponent: bpc,
+		BitsPerPixel:     bpp,
+		BytesPerRow:      bytesPerRow,
+		AlphaInfo:        info.AlphaInfo(),
+		ByteOrder:        info.ByteOrder(),
+		FloatComponents:  info.FloatComponents(),
+		ColorModel:       model,
+	}
+}
+
+// BitmapInfo returns the bitmap info flags representing the alpha info, byte
+// order and float components of the pixel format.
+func (f PixelFormat) BitmapInfo() BitmapInfo {
+	info := BitmapInfo(f.AlphaInfo) | f.ByteOrder
+
+	if f.FloatComponents {
+		info |= BitmapFloatComponents
+	}
+
+	return info
+}
+
+// Components returns the number of components of each pixel, including the
+// alpha or skipped component.
+func (f PixelFormat) Components() int {
+	if f.AlphaInfo == ImageAlphaOnly {
+		return 1
+	}
+
+	n := f.ColorModel.NumberOfComponents()
+
+	if f.AlphaInfo != ImageAlphaNone {
+		n++
+	}
+
+	return n
+}
+
+// RowSize returns the minimum number of bytes needed to store a row of width
+// pixels.
+func (f PixelFormat) RowSize(width int) int {
+	return (width*f.BitsPerPixel + 7) / 8
+}
+
+// Validate checks that the pixel format describes a layout that Core Graphics
+// can represent, it returns an error wrapping ErrUnsupportedFormat and
+// explaining why it can't otherwise.
+func (f PixelFormat) Validate() error {
+	invalid := func(reason string) error {
+		return fmt.Errorf("%w: %s (%v)", ErrUnsupportedFormat, reason, f)
+	}
+
+	switch f.BitsPerComponent {
+	case 1, 2, 4, 5, 8, 16, 32:
+	default:
+		return invalid("unsupported number of bits per component")
+	}
+
+	if f.AlphaInfo > ImageAlphaOnly {
+		return invalid("unknown alpha info")
+	}
+
+	switch f.ByteOrder {
+	case BitmapByteOrderDefault, BitmapByteOrder16Little, BitmapByteOrder16Big, BitmapByteOrder32Little, BitmapByteOrder32Big:
+	default:
+		return invalid("unknown byte order")
+	}
+
+	if f.AlphaInfo != ImageAlphaOnly && f.ColorModel.NumberOfComponents() == 0 {
+		return invalid("color model has no components")
+	}
+
+	if f.FloatComponents && f.BitsPerComponent != 16 && f.BitsPerComponent != 32 {
+		return invalid("float components must be 16 or 32 bits")
+	}
+
+	// Skipped components may be narrower than the others (like the padding
+	// bit of RGB 555 pixels), only the color and alpha bits are required.
+	bits := f.BitsPerComponent * f.ColorModel.NumberOfComponents()
+
+	if f.AlphaInfo.HasAlpha() {
+		bits = f.BitsPerComponent * f.Components()
+	}
+
+	if f.BitsPerPixel < bits {
+		return invalid("not enough bits per pixel to store all the components")
+	}
+
+	switch f.ByteOrder {
+	case BitmapByteOrder16Little, BitmapByteOrder16Big:
+		if f.BitsPerPixel%16 != 0 {
+			return invalid("16 bits byte order on pixels that aren't a multiple of 16 bits")
+		}
+	case BitmapByteOrder32Little, BitmapByteOrder32Big:
+		if f.BitsPerPixel%32 != 0 {
+			return invalid("32 bits byte order on pixels that aren't a multiple of 32 bits")
+		}
+	}
+
+	if f.BytesPerRow < 0 {
+		return invalid("negative number of bytes per row")
+	}
+
+	return nil
+}
+
+// String satisfies the fmt.Stringer interface.
+func (f PixelFormat) String() string {
+	s := fmt.Sprintf("%d bits per component, %d bits per pixel, %d bytes per row, alpha %v, byte order %s, %v color space",
+		f.BitsPerComponent, f.BitsPerPixel, f.BytesPerRow, f.AlphaInfo, byteOrderString(f.ByteOrder), f.ColorModel)
+
+	if f.FloatComponents {
+		s += ", float components"
+	}
+
+	return s
+}
+
+func byteOrderString(order BitmapInfo) string {
+	switch order {
+	case BitmapByteOrderDefault:
+		return "Default"
+	case BitmapByteOrder16Little:
+		return "16Little"
+	case BitmapByteOrder32Little:
+		return "32Little"
+	case BitmapByteOrder16Big:
+		return "16Big"
+	case BitmapByteOrder32Big:
+		return "32Big"
+	}
+	return fmt.Sprintf("%#x", uint32(order))
+}
+
+// PixelFormatFunc is the signature of functions that expose the pixel format
+// and pixel buffer of images of a specific type.
+//
+// The returned buffer must start at the first pixel of the image bounds (for
+// the types of the standard library that's at PixOffset(Rect.Min.X,
+// Rect.Min.Y)) and hold at least Bounds().Dy() rows of BytesPerRow bytes. The
+// last row may be shorter as long as it contains all its pixels, and bytes past
+// the last visible pixel are ignored, which is what sub-images need.
+type PixelFormatFunc func(img image.Image) (PixelFormat, []byte)
+
+var pixelFormats = struct {
+	sync.RWMutex
+	funcs map[reflect.Type]PixelFormatFunc
+}{
+	funcs: make(map[reflect.Type]PixelFormatFunc),
+}
+
+// RegisterPixelFormat registers f as the function used to expose the pixels of
+// images that have the same dynamic type as img, for example:
+//
+//	CG.RegisterPixelFormat((*MyImage)(nil), func(img image.Image) (CG.PixelFormat, []byte) {
+//		...
+//	})
+//
+// Registering a function for a type that already had one replaces it, which
+// makes it possible to override the mapping of the standard image types.
+func RegisterPixelFormat(img image.Image, f PixelFormatFunc) {
+	pixelFormats.Lock()
+	pixelFormats.funcs[reflect.TypeOf(img)] = f
+	pixelFormats.Unlock()
+}
+
+// PixelFormatOf returns the pixel format that is used when creating a Core
+// Graphics image from img.
+//
+// Images of types without a registered pixel format are converted by
+// ImageCreate, the function then returns the format of the converted pixels.
+// An error is returned if the registered function returns an invalid pixel
+// format, or if an image that would be converted has no pixels.
+func PixelFormatOf(img image.Image) (PixelFormat, error) {
+	data, err := imagePixels(img)
+	return data.format, err
+}
+
+func init() {
+	RegisterPixelFormat((*image.RGBA)(nil), func(img image.Image) (PixelFormat, []byte) {
+		i := img.(*image.RGBA)
+		pix := i.Pix[i.PixOffset(i.Rect.Min.X, i.Rect.Min.Y):]
+		return PixelFormatMake(8, 32, i.Stride, BitmapByteOrder32Big|BitmapInfo(ImageAlphaPremultipliedLast), ColorSpaceModelRGB), pix
+	})
+
+	RegisterPixelFormat((*image.NRGBA)(nil), func(img image.Image) (PixelFormat, []byte) {
+		i := img.(*image.NRGBA)
+		pix := i.Pix[i.PixOffset(i.Rect.Min.X, i.Rect.Min.Y):]
+		return PixelFormatMake(8, 32, i.Stride, BitmapByteOrder32Big|BitmapInfo(ImageAlphaLast), ColorSpaceModelRGB), pix
+	})
+
+	RegisterPixelFormat((*image.RGBA64)(nil), func(img image.Image) (PixelFormat, []byte) {
+		i := img.(*image.RGBA64)
+		pix := i.Pix[i.PixOffset(i.Rect.Min.X, i.Rect.Min.Y):]
+		return PixelFormatMake(16, 64, i.Stride, BitmapByteOrder32Big|BitmapInfo(ImageAlphaPremultipliedLast), ColorSpaceModelRGB), pix
+	})
+
+	RegisterPixelFormat((*image.NRGBA64)(nil), func(img image.Image) (PixelFormat, []byte) {
+		i := img.(*image.NRGBA64)
+		pix := i.Pix[i.PixOffset(i.Rect.Min.X, i.Rect.Min.Y):]
+		return PixelFormatMake(16, 64, i.Stride, BitmapByteOrder32Big|BitmapInfo(ImageAlphaLast), ColorSpaceModelRGB), pix
+	})
+
+	// Core Graphics doesn't support creating images from alpha-only pixels,
+	// the alpha values are exposed as a gray color space instead.
+	RegisterPixelFormat((*image.Alpha)(nil), func(img image.Image) (PixelFormat, []byte) {
+		i := img.(*image.Alpha)
+		pix := i.Pix[i.PixOffset(i.Rect.Min.X, i.Rect.Min.Y):]
+		return PixelFormatMake(8, 8, i.Stride, BitmapInfo(ImageAlphaNone), ColorSpaceModelMonochrome), pix
+	})
+
+	RegisterPixelFormat((*image.Alpha16)(nil), func(img image.Image) (PixelFormat, []byte) {
+		i := img.(*image.Alpha16)
+		pix := i.Pix[i.PixOffset(i.Rect.Min.X, i.Rect.Min.Y):]
+		return PixelFormatMake(16, 16, i.Stride, BitmapInfo(ImageAlphaNone), ColorSpaceModelMonochrome), pix
+	})
+
+	RegisterPixelFormat((*image.Gray)(nil), func(img image.Image) (PixelFormat, []byte) {
+		i := img.(*image.Gray)
+		pix := i.Pix[i.PixOffset(i.Rect.Min.X, i.Rect.Min.Y):]
+		return PixelFormatMake(8, 8, i.Stride, BitmapInfo(ImageAlphaNone), ColorSpaceModelMonochrome), pix
+	})
+
+	RegisterPixelFormat((*image.Gray16)(nil), func(img image.Image) (PixelFormat, []byte) {
+		i := img.(*image.Gray16)
+		pix := i.Pix[i.PixOffset(i.Rect.Min.X, i.Rect.Min.Y):]
+		return PixelFormatMake(16, 16, i.Stride, BitmapInfo(ImageAlphaNone), ColorSpaceModelMonochrome), pix
+	})
+
+	RegisterPixelFormat((*image.CMYK)(nil), func(img image.Image) (PixelFormat, []byte) {
+		i := img.(*image.CMYK)
+		pix := i.Pix[i.PixOffset(i.Rect.Min.X, i.Rect.Min.Y):]
+		return PixelFormatMake(8, 32, i.Stride, BitmapByteOrder32Big|BitmapInfo(ImageAlphaNone), ColorSpaceModelCMYK), pix
+	})
+}
@@ -0,0 +1,142 @@
+package CG
+
+import (
+	"errors"
+	"image"
+	"testing"
+)
+
+func TestPixelFormatOf(t *testing.T) {
+	r := image.Rect(0, 0, 3, 2)
+	tests := []struct {
+		image  image.Image
+		format PixelFormat
+	}{
+		{image.NewRGBA(r), PixelFormatMake(8, 32, 12, BitmapByteOrder32Big|BitmapInfo(ImageAlphaPremultipliedLast), ColorSpaceModelRGB)},
+		{image.NewNRGBA(r), PixelFormatMake(8, 32, 12, BitmapByteOrder32Big|BitmapInfo(ImageAlphaLast), ColorSpaceModelRGB)},
+		{image.NewRGBA64(r), PixelFormatMake(16, 64, 24, BitmapByteOrder32Big|BitmapInfo(ImageAlphaPremultipliedLast), ColorSpaceModelRGB)},
+		{image.NewNRGBA64(r), PixelFormatMake(16, 64, 24, BitmapByteOrder32Big|BitmapInfo(ImageAlphaLast), ColorSpaceModelRGB)},
+		{image.NewAlpha(r), PixelFormatMake(8, 8, 3, BitmapInfo(ImageAlphaNone), ColorSpaceModelMonochrome)},
+		{image.NewAlpha16(r), PixelFormatMake(16, 16, 6, BitmapInfo(ImageAlphaNone), ColorSpaceModelMonochrome)},
+		{image.NewGray(r), PixelFormatMake(8, 8, 3, BitmapInfo(ImageAlphaNone), ColorSpaceModelMonochrome)},
+		{image.NewGray16(r), PixelFormatMake(16, 16, 6, BitmapInfo(ImageAlphaNone), ColorSpaceModelMonochrome)},
+		{image.NewCMYK(r), PixelFormatMake(8, 32, 12, BitmapByteOrder32Big|BitmapInfo(ImageAlphaNone), ColorSpaceModelCMYK)},
+	}
+
+	for _, test := range tests {
+		format, err := PixelFormatOf(test.image)
+
+		if err != nil {
+			t.Errorf("%T: %s", test.image, err)
+			continue
+		}
+
+		if format != test.format {
+			t.Errorf("%T: invalid pixel format:\n%v\n%v", test.image, format, test.format)
+		}
+	}
+}
+
+func TestPixelFormatBitmapInfo(t *testing.T) {
+	info := BitmapByteOrder32Little | BitmapFloatComponents | BitmapInfo(ImageAlphaPremultipliedFirst)
+	format := PixelFormatMake(32, 128, 16, info, ColorSpaceModelRGB)
+
+	if format.AlphaInfo != ImageAlphaPremultipliedFirst {
+		t.Error("invalid alpha info:", format.AlphaInfo)
+	}
+	if format.ByteOrder != BitmapByteOrder32Little {
+		t.Errorf("invalid byte order: %#x", format.ByteOrder)
+	}
+	if !format.FloatComponents {
+		t.Error("float components flag not set")
+	}
+	if b := format.BitmapInfo(); b != info {
+		t.Errorf("invalid bitmap info: %#x != %#x", b, info)
+	}
+}
+
+func TestPixelFormatValidate(t *testing.T) {
+	tests := []struct {
+		name   string
+		format PixelFormat
+		valid  bool
+	}{
+		{"RGBA", PixelFormatMake(8, 32, 4, BitmapInfo(ImageAlphaPremultipliedLast), ColorSpaceModelRGB), true},
+		{"RGB padded", PixelFormatMake(8, 32, 4, BitmapInfo(ImageAlphaNoneSkipFirst), ColorSpaceModelRGB), true},
+		{"RGB 555", PixelFormatMake(5, 16, 2, BitmapByteOrder16Little|BitmapInfo(ImageAlphaNoneSkipFirst), ColorSpaceModelRGB), true},
+		{"gray float", PixelFormatMake(32, 32, 4, BitmapFloatComponents, ColorSpaceModelMonochrome), true},
+		{"alpha only", PixelFormatMake(8, 8, 1, BitmapInfo(ImageAlphaOnly), ColorSpaceModelUnknown), true},
+		{"bits per component", PixelFormatMake(7, 32, 4, BitmapInfo(ImageAlphaLast), ColorSpaceModelRGB), false},
+		{"bits per pixel", PixelFormatMake(8, 24, 3, BitmapInfo(ImageAlphaLast), ColorSpaceModelRGB), false},
+		{"alpha info", PixelFormatMake(8, 32, 4, BitmapInfo(8), ColorSpaceModelRGB), false},
+		{"byte order", PixelFormatMake(8, 32, 4, 7<<12, ColorSpaceModelRGB), false},
+		{"color model", PixelFormatMake(8, 8, 1, BitmapInfo(ImageAlphaNone), ColorSpaceModelPattern), false},
+		{"float components", PixelFormatMake(8, 8, 1, BitmapFloatComponents, ColorSpaceModelMonochrome), false},
+		{"16 bits byte order", PixelFormatMake(8, 24, 3, BitmapByteOrder16Little, ColorSpaceModelRGB), false},
+		{"32 bits byte order", PixelFormatMake(16, 48, 6, BitmapByteOrder32Big, ColorSpaceModelRGB), false},
+		{"bytes per row", PixelFormatMake(8, 8, -1, BitmapInfo(ImageAlphaNone), ColorSpaceModelMonochrome), false},
+	}
+
+	for _, test := range tests {
+		err := test.format.Validate()
+
+		if test.valid && err != nil {
+			t.Errorf("%s: %s", test.name, err)
+		}
+
+		if !test.valid && !errors.Is(err, ErrUnsupportedFormat) {
+			t.Errorf("%s: invalid error returned for an invalid pixel format: %v", test.name, err)
+		}
+	}
+}
+
+func TestPixelFormatString(t *testing.T) {
+	format := PixelFormatMake(8, 32, 12, BitmapByteOrder32Big|BitmapInfo(ImageAlphaLast), ColorSpaceModelRGB)
+	s := "8 bits per component, 32 bits per pixel, 12 bytes per row, alpha Last, byte order 32Big, RGB color space"
+
+	if format.String() != s {
+		t.Error("invalid string representation of a pixel format:", format)
+	}
+
+	format.FloatComponents = true
+
+	if format.String() != s+", float components" {
+		t.Error("invalid string representation of a pixel format:", format)
+	}
+}
+
+type bgrImage struct {
+	image.RGBA
+}
+
+func TestRegisterPixelFormat(t *testing.T) {
+	img := &bgrImage{*image.NewRGBA(image.Rect(0, 0, 2, 2))}
+
+	if format, _ := PixelFormatOf(img); format.ByteOrder != BitmapByteOrder32Big {
+		t.Error("the pixels of an image type that was not registered were not converted:", format)
+	}
+
+	RegisterPixelFormat((*bgrImage)(nil), func(img image.Image) (PixelFormat, []byte) {
+		i := img.(*bgrImage)
+		return PixelFormatMake(8, 32, i.Stride, BitmapByteOrder32Little|BitmapInfo(ImageAlphaPremultipliedFirst), ColorSpaceModelRGB), i.Pix
+	})
+
+	format, err := PixelFormatOf(img)
+
+	if err != nil {
+		t.Error(err)
+	}
+
+	if format.ByteOrder != BitmapByteOrder32Little || format.AlphaInfo != ImageAlphaPremultipliedFirst {
+		t.Error("the registered pixel format was not used:", format)
+	}
+
+	RegisterPixelFormat((*bgrImage)(nil), func(img image.Image) (PixelFormat, []byte) {
+		i := img.(*bgrImage)
+		return PixelFormatMake(8, 32, i.Stride, BitmapInfo(ImageAlphaPremultipliedFirst), ColorSpaceModelRGB), i.Pix[:4]
+	})
+
+	if _, err := PixelFormatOf(img); !errors.Is(err, ErrUnsupportedFormat) {
+		t.Error("invalid error returned for a pixel buffer that is too short:", err)
+	}
+}
@@ -0,0 +1,54 @@
+package CG
+
+import "fmt"
+
+// pixelWindow returns the slice of pix that holds height rows of rowSize bytes,
+// the first row starts at the beginning of pix and the following ones are
+// stride bytes apart.
+//
+// The window ends right after the last visible pixel, so the bytes of a parent
+// image that follow a sub-image aren't part of it.
+func pixelWindow(pix []byte, stride int, rowSize int, height int) ([]byte, error) {
+	if stride < rowSize || rowSize < 0 || height < 0 {
+		return nil, fmt.Errorf("invalid pixel window: stride %d, row size %d, height %d", stride, rowSize, height)
+	}
+
+	if height == 0 || rowSize == 0 {
+		return nil, nil
+	}
+
+	end := (height-1)*stride + rowSize
+
+	if end > len(pix) {
+		return nil, fmt.Errorf("pixel buffer of %d bytes is too short for a window ending at %d", len(pix), end)
+	}
+
+	return pix[:end:end], nil
+}
+
+// packPixels copies height rows of rowSize bytes that are stride bytes apart in
+// window to a new buffer where the rows are contiguous.
+func packPixels(window []byte, stride int, rowSize int, height int) []byte {
+	pix := make([]byte, rowSize*height)
+
+	for y := 0; y != height; y++ {
+		copy(pix[y*rowSize:(y+1)*rowSize], window[y*stride:])
+	}
+
+	return pix
+}
+
+// packed returns a copy of the pixel data where the rows have no padding, it
+// returns d unchanged if the rows were already contiguous.
+func (d pixelData) packed() pixelData {
+	rowSize := d.format.RowSize(d.width)
+
+	if d.format.BytesPerRow == rowSize {
+		return d
+	}
+
+	d.pix = packPixels(d.pix, d.format.BytesPerRow, rowSize, d.height)
+	d.format.BytesPerRow = rowSize
+	d.converted = true
+	return d
+}
@@ -0,0 +1,176 @@
+package CG
+
+import (
+	"image"
+	"image/color"
+	"reflect"
+	"testing"
+)
+
+func TestPixelWindow(t *testing.T) {
+	pix := []byte{
+		0, 1, 2, 3,
+		4, 5, 6, 7,
+		8, 9, 10, 11,
+	}
+
+	tests := []struct {
+		pix     []byte
+		stride  int
+		rowSize int
+		height  int
+		window  []byte
+	}{
+		{pix, 4, 4, 3, pix},
+		{pix, 4, 4, 2, pix[:8]},
+		{pix[5:], 4, 2, 2, []byte{5, 6, 7, 8, 9, 10}},
+		{pix[1:], 4, 1, 3, []byte{1, 2, 3, 4, 5, 6, 7, 8, 9}},
+		{pix, 4, 0, 3, nil},
+		{pix, 4, 4, 0, nil},
+	}
+
+	for _, test := range tests {
+		window, err := pixelWindow(test.pix, test.stride, test.rowSize, test.height)
+
+		if err != nil {
+			t.Errorf("%+v: %s", test, err)
+			continue
+		}
+
+		if !reflect.DeepEqual(window, test.window) {
+			t.Errorf("%+v: invalid pixel window: %v", test, window)
+		}
+
+		if len(window) != cap(window) {
+			t.Errorf("%+v: the capacity of the window extends past its last pixel", test)
+		}
+	}
+}
+
+func TestPixelWindowError(t *testing.T) {
+	pix := make([]byte, 12)
+
+	tests := []struct {
+		pix     []byte
+		stride  int
+		rowSize int
+		height  int
+	}{
+		{pix, 2, 4, 1},
+		{pix, 4, -1, 1},
+		{pix, 4, 4, -1},
+		{pix, 4, 4, 4},
+		{pix[9:], 4, 4, 1},
+	}
+
+	for _, test := range tests {
+		if _, err := pixelWindow(test.pix, test.stride, test.rowSize, test.height); err == nil {
+			t.Errorf("%+v: no error returned for an invalid pixel window", test)
+		}
+	}
+}
+
+func TestPackPixels(t *testing.T) {
+	pix := packPixels([]byte{1, 2, 0, 3, 4, 0, 5, 6}, 3, 2, 3)
+
+	if !reflect.DeepEqual(pix, []byte{1, 2, 3, 4, 5, 6}) {
+		t.Error("invalid packed pixels:", pix)
+	}
+}
+
+func TestSubImagePixels(t *testing.T) {
+	parent := newGradient(image.Rect(-4, -2, 21, 13))
+	window := image.Rect(-1, 3, 9, 11)
+
+	tests := []image.Image{
+		fillImage(image.NewRGBA(parent.Rect), parent),
+		fillImage(image.NewNRGBA(parent.Rect), parent),
+		fillImage(image.NewRGBA64(parent.Rect), parent),
+		fillImage(image.NewNRGBA64(parent.Rect), parent),
+		fillImage(image.NewAlpha(parent.Rect), parent),
+		fillImage(image.NewAlpha16(parent.Rect), parent),
+		fillImage(image.NewGray(parent.Rect), parent),
+		fillImage(image.NewGray16(parent.Rect), parent),
+		fillImage(image.NewCMYK(parent.Rect), parent),
+		fillImage(image.NewPaletted(parent.Rect, opaquePalette), parent),
+		palettedImage(parent),
+		ycbcrImage(parent, image.YCbCrSubsampleRatio420),
+		nycbcraImage(parent, image.YCbCrSubsampleRatio422),
+	}
+
+	for _, test := range tests {
+		sub := test.(interface {
+			SubImage(image.Rectangle) image.Image
+		}).SubImage(window)
+
+		data, err := checkImage(sub)
+
+		if err != nil {
+			t.Errorf("%T: %s", sub, err)
+			continue
+		}
+
+		if !data.converted {
+			rowSize := data.format.RowSize(data.width)
+
+			if n := (data.height-1)*data.format.BytesPerRow + rowSize; len(data.pix) != n {
+				t.Errorf("%T: the pixel window has %d bytes instead of %d", sub, len(data.pix), n)
+			}
+		}
+
+		for _, data := range []pixelData{data, data.packed()} {
+			if data.format.ColorModel == ColorSpaceModelIndexed {
+				data = expandIndexed(data)
+			}
+
+			img, err := decodeImage(data.pix, data.imageLayout)
+
+			if err != nil {
+				t.Errorf("%T: %s", sub, err)
+				continue
+			}
+
+			if !similarPixels(img, visibleColors{sub}, 0x101) {
+				t.Errorf("%T: the pixels of the sub-image differ from the original (stride = %d)", sub, data.format.BytesPerRow)
+			}
+		}
+	}
+}
+
+var opaquePalette = color.Palette{
+	color.Black,
+	color.White,
+	color.RGBA{0xFF, 0x00, 0x00, 0xFF},
+	color.RGBA{0x00, 0xFF, 0x00, 0xFF},
+	color.RGBA{0x00, 0x00, 0xFF, 0xFF},
+}
+
+// expandIndexed converts indexed pixel data to RGB using its color table.
+func expandIndexed(data pixelData) pixelData {
+	rgb := newPixelData(data.width, data.height, PixelFormatMake(8, 24, 3*data.width, BitmapInfo(ImageAlphaNone), ColorSpaceModelRGB))
+
+	for y := 0; y != data.height; y++ {
+		for x := 0; x != data.width; x++ {
+			i := int(data.pix[y*data.format.BytesPerRow+x])
+			copy(rgb.pix[y*rgb.format.BytesPerRow+3*x:], data.palette[3*i:3*i+3])
+		}
+	}
+
+	return rgb
+}
+
+// visibleColors exposes the alpha values of alpha images as gray levels, which
+// is how they are represented in Core Graphics.
+type visibleColors struct {
+	image.Image
+}
+
+func (img visibleColors) At(x int, y int) color.Color {
+	switch i := img.Image.(type) {
+	case *image.Alpha:
+		return color.Gray{Y: i.AlphaAt(x, y).A}
+	case *image.Alpha16:
+		return color.Gray16{Y: i.Alpha16At(x, y).A}
+	}
+	return img.Image.At(x, y)
+}
@@ -0,0 +1,471 @@
+// Package atlas implements a cache of rasterized glyphs packed into atlas
+// pages, which are alpha images meant to be uploaded to GPU textures.
+//
+// Glyphs are rasterized once per font, size and subpixel offset, and packed
+// into fixed-size pages with a skyline packer. When the pages are full the
+// page holding the least recently used glyph is cleared and reused. The cache
+// records the regions of the pages that were modified so that only those need
+// to be uploaded.
+//
+// The cache is written against the Font interface, which CT.FontRef and the
+// fonts of the fontfile package implement, and doesn't depend on Core Text.
+package atlas
+
+import (
+	"container/list"
+	"errors"
+	"fmt"
+	"image"
+	"math"
+	"sync"
+
+	"github.com/go-vu/cocoa/CG"
+	"github.com/go-vu/cocoa/CT"
+)
+
+// ErrGlyphTooLarge is returned when the mask of a glyph is larger than the
+// pages of the cache.
+var ErrGlyphTooLarge = errors.New("atlas: glyph too large for the atlas pages")
+
+// Font is the interface of the fonts that glyphs are rasterized with.
+//
+// Fonts are part of the keys of the cache so their dynamic values must be
+// comparable, like CT.FontRef and *fontfile.Font. The methods of the fonts are
+// called concurrently when the cache is used from multiple goroutines.
+type Font interface {
+	CT.GlyphDrawer
+
+	GetSize() CG.Float
+}
+
+// Key identifies a rasterized glyph in the cache.
+type Key struct {
+	Font  Font
+	Glyph CT.GlyphID
+
+	// The size of the font in points, as returned by its GetSize method.
+	Size CG.Float
+
+	// The index of the horizontal subpixel offset that the glyph is drawn at,
+	// from 0 to the number of subpixel positions of the cache.
+	Subpixel int
+}
+
+// Entry is the location of a rasterized glyph in the atlas.
+type Entry struct {
+	// The index of the page that holds the glyph.
+	Page int
+
+	// The rectangle of the page that holds the mask of the glyph, it's empty
+	// for glyphs that have no ink.
+	Rect image.Rectangle
+
+	// The bounds of the mask relative to the pixel that the origin of the
+	// glyph is in, the mask of a glyph drawn at (x, y) covers the rectangle
+	// Bounds.Add(image.Pt(floor(x), y)) of the destination.
+	Bounds image.Rectangle
+}
+
+// DirtyRegion is a region of a page that was modified since the last flush.
+type DirtyRegion struct {
+	Page int
+	Rect image.Rectangle
+}
+
+// Stats are counters describing the activity of a cache.
+type Stats struct {
+	// The number of lookups that found the glyph in the cache, and that had
+	// to rasterize it.
+	Hits, Misses int
+
+	// The number of glyphs evicted from the cache, and the number of pages
+	// that were cleared to make room for new glyphs.
+	Evictions, PageEvictions int
+
+	// The number of glyphs in the cache, and the number of allocated pages.
+	Glyphs, Pages int
+}
+
+// Options are the options of a cache, the zero value selects the defaults.
+type Options struct {
+	// PageSize is the width and height of the pages in pixels, the default is
+	// 1024.
+	PageSize int
+
+	// MaxPages is the maximum number of pages, the default is 4.
+	MaxPages int
+
+	// SubpixelPositions is the number of horizontal positions within a pixel
+	// that glyphs are rasterized at, the default is 4. It's 1 when the render
+	// options disable subpixel positioning.
+	SubpixelPositions int
+
+	// Padding is the number of transparent pixels kept between glyphs, to
+	// prevent texture filtering from bleeding neighbours into each other. The
+	// default is 1, a negative value disables padding.
+	Padding int
+
+	// Render are the options that glyphs are rasterized with, nil selects the
+	// defaults.
+	Render *CT.RenderOptions
+}
+
+const (
+	defaultPageSize          = 1024
+	defaultMaxPages          = 4
+	defaultSubpixelPositions = 4
+	defaultPadding           = 1
+	maxSubpixelPositions     = 64
+)
+
+// Cache is a cache of rasterized glyphs, it's safe for concurrent use.
+type Cache struct {
+	mutex     sync.Mutex
+	pageSize  int
+	maxPages  int
+	subpixels int
+	padding   int
+	render    *CT.RenderOptions
+	pages     []*page
+	entries   map[Key]*list.Element
+	lru       list.List
+	stats     Stats
+}
+
+// page is an atlas page, with its packer and the bounds of its dirty region.
+type page struct {
+	alpha  *image.Alpha
+	packer *skyline
+	dirty  image.Rectangle
+}
+
+type cacheEntry struct {
+	key   Key
+	entry Entry
+}
+
+// New returns a new cache, opts may be nil to use the default options. It
+// returns an error wrapping CT.ErrInvalidRenderOptions if the render options
+// are invalid.
+func New(opts *Options) (*Cache, error) {
+	o := Options{}
+
+	if opts != nil {
+		o = *opts
+	}
+
+	if err := o.Render.Validate(); err != nil {
+		return nil, err
+	}
+
+	if o.PageSize <= 0 {
+		o.PageSize = defaultPageSize
+	}
+
+	if o.MaxPages <= 0 {
+		o.MaxPages = defaultMaxPages
+	}
+
+	switch {
+	case o.Render != nil && !o.Render.SubpixelPositioning:
+		o.SubpixelPositions = 1
+	case o.SubpixelPositions <= 0:
+		o.SubpixelPositions = defaultSubpixelPositions
+	case o.SubpixelPositions > maxSubpixelPositions:
+		o.SubpixelPositions = maxSubpixelPositions
+	}
+
+	switch {
+	case o.Padding == 0:
+		o.Padding = defaultPadding
+	case o.Padding < 0:
+		o.Padding = 0
+	}
+
+	return &Cache{
+		pageSize:  o.PageSize,
+		maxPages:  o.MaxPages,
+		subpixels: o.SubpixelPositions,
+		padding:   o.Padding,
+		render:    o.Render,
+		entries:   make(map[Key]*list.Element),
+	}, nil
+}
+
+// SubpixelPositions returns the number of horizontal positions within a pixel
+// that glyphs are rasterized at.
+func (c *Cache) SubpixelPositions() int {
+	return c.subpixels
+}
+
+// Key returns the key of a glyph of the font drawn at the horizontal position
+// x, in pixels.
+func (c *Cache) Key(f Font, glyph CT.GlyphID, x CG.Float) Key {
+	return Key{Font: f, Glyph: glyph, Size: f.GetSize(), Subpixel: c.subpixel(x)}
+}
+
+// subpixel returns the index of the subpixel offset of the horizontal
+// position x within the pixel that it's in.
+func (c *Cache) subpixel(x CG.Float) int {
+	fraction := float64(x) - math.Floor(float64(x))
+
+	if subpixel := int(fraction * float64(c.subpixels)); subpixel < c.subpixels {
+		return subpixel
+	}
+
+	return c.subpixels - 1
+}
+
+// Glyph returns the location in the atlas of the glyph of the font drawn at
+// the horizontal position x, in pixels. The glyph is rasterized and packed in
+// a page if it's not already in the cache.
+//
+// Inserting a glyph may evict others, so the entries returned by the cache are
+// only valid until the next insertion: a frame is drawn by looking up all its
+// glyphs, then flushing the dirty regions, then drawing the glyphs.
+//
+// The method returns ErrGlyphTooLarge if the glyph doesn't fit in a page, or
+// the error returned by the font if the glyph could not be rasterized.
+func (c *Cache) Glyph(f Font, glyph CT.GlyphID, x CG.Float) (Entry, error) {
+	key := c.Key(f, glyph, x)
+
+	if e, ok := c.lookup(key); ok {
+		return e, nil
+	}
+
+	// The glyph is rasterized without holding the lock, so other goroutines
+	// can use the cache in the meantime. Two goroutines may rasterize the
+	// same glyph, in which case the first mask inserted is kept.
+	mask, bounds, err := c.rasterize(key)
+
+	if err != nil {
+		return Entry{}, err
+	}
+
+	return c.insert(key, mask, bounds)
+}
+
+// lookup returns the entry of the key and marks it as the most recently used,
+// the lookup is counted as a hit or a miss.
+func (c *Cache) lookup(key Key) (Entry, bool) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	e, ok := c.entries[key]
+
+	if !ok {
+		c.stats.Misses++
+		return Entry{}, false
+	}
+
+	c.stats.Hits++
+	c.lru.MoveToFront(e)
+	return e.Value.(*cacheEntry).entry, true
+}
+
+// rasterize draws the glyph of the key into a new mask, and returns the mask
+// with its bounds relative to the pixel of the glyph origin.
+func (c *Cache) rasterize(key Key) (*image.Alpha, image.Rectangle, error) {
+	offset := CG.Float(key.Subpixel) / CG.Float(c.subpixels)
+	_, r := key.Font.BoundingRectsForGlyphs([]CT.GlyphID{key.Glyph})
+	bounds := CT.GlyphMaskBounds(r, offset)
+
+	if bounds.Empty() {
+		return nil, image.Rectangle{}, nil
+	}
+
+	if bounds.Dx()+c.padding > c.pageSize || bounds.Dy()+c.padding > c.pageSize {
+		return nil, bounds, fmt.Errorf("%w: glyph %d is %dx%d pixels", ErrGlyphTooLarge, key.Glyph, bounds.Dx(), bounds.Dy())
+	}
+
+	mask := image.NewAlpha(image.Rectangle{Max: bounds.Size()})
+	origin := CG.Point{
+		X: offset - CG.Float(bounds.Min.X),
+		Y: CG.Float(-bounds.Min.Y),
+	}
+
+	if err := key.Font.DrawGlyphsWithOptions([]CT.GlyphID{key.Glyph}, []CG.Point{origin}, mask, c.render); err != nil {
+		return nil, bounds, err
+	}
+
+	return mask, bounds, nil
+}
+
+// insert packs the mask of the key in a page and adds it to the cache, unless
+// another goroutine has done it first.
+func (c *Cache) insert(key Key, mask *image.Alpha, bounds image.Rectangle) (Entry, error) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	if e, ok := c.entries[key]; ok {
+		c.lru.MoveToFront(e)
+		return e.Value.(*cacheEntry).entry, nil
+	}
+
+	entry := Entry{Bounds: bounds}
+
+	if mask != nil {
+		i, p := c.pack(mask.Rect.Dx()+c.padding, mask.Rect.Dy()+c.padding)
+		pg := c.pages[i]
+		entry.Page = i
+		entry.Rect = image.Rectangle{Min: p, Max: p.Add(mask.Rect.Size())}
+		copyMask(pg.alpha, entry.Rect.Min, mask)
+		pg.dirty = pg.dirty.Union(entry.Rect)
+	}
+
+	c.entries[key] = c.lru.PushFront(&cacheEntry{key: key, entry: entry})
+	return entry, nil
+}
+
+// pack finds a place for a rectangle of the given size in the pages, a page is
+// added or cleared if none of them has room for it. The size must fit in a
+// page.
+func (c *Cache) pack(width int, height int) (int, image.Point) {
+	for i, pg := range c.pages {
+		if p, ok := pg.packer.insert(width, height); ok {
+			return i, p
+		}
+	}
+
+	if len(c.pages) < c.maxPages {
+		c.pages = append(c.pages, &page{
+			alpha:  image.NewAlpha(image.Rect(0, 0, c.pageSize, c.pageSize)),
+			packer: newSkyline(c.pageSize, c.pageSize),
+		})
+	} else {
+		c.evictPage(c.leastRecentlyUsedPage())
+	}
+
+	// The last page is either new or was just cleared, the glyph fits in it
+	// if it's not larger than a page.
+	for i, pg := range c.pages {
+		if p, ok := pg.packer.insert(width, height); ok {
+			return i, p
+		}
+	}
+
+	panic("atlas: no room for a glyph in an empty page")
+}
+
+// leastRecentlyUsedPage returns the index of the page that holds the least
+// recently used glyph.
+func (c *Cache) leastRecentlyUsedPage() int {
+	for e := c.lru.Back(); e != nil; e = e.Prev() {
+		if entry := e.Value.(*cacheEntry).entry; !entry.Rect.Empty() {
+			return entry.Page
+		}
+	}
+	return 0
+}
+
+// evictPage removes the glyphs of the page from the cache and clears it.
+func (c *Cache) evictPage(i int) {
+	for e := c.lru.Front(); e != nil; {
+		next := e.Next()
+
+		if ce := e.Value.(*cacheEntry); ce.entry.Page == i && !ce.entry.Rect.Empty() {
+			c.lru.Remove(e)
+			delete(c.entries, ce.key)
+			c.stats.Evictions++
+		}
+
+		e = next
+	}
+
+	pg := c.pages[i]
+	pg.packer.reset()
+
+	for j := range pg.alpha.Pix {
+		pg.alpha.Pix[j] = 0
+	}
+
+	pg.dirty = pg.alpha.Rect
+	c.stats.PageEvictions++
+}
+
+// Stats returns the counters of the cache.
+func (c *Cache) Stats() Stats {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	s := c.stats
+	s.Glyphs = c.lru.Len()
+	s.Pages = len(c.pages)
+	return s
+}
+
+// DirtyRegions returns the regions of the pages that were modified since the
+// last flush, with one region per modified page.
+func (c *Cache) DirtyRegions() []DirtyRegion {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	return c.dirtyRegions()
+}
+
+func (c *Cache) dirtyRegions() []DirtyRegion {
+	var regions []DirtyRegion
+
+	for i, pg := range c.pages {
+		if !pg.dirty.Empty() {
+			regions = append(regions, DirtyRegion{Page: i, Rect: pg.dirty})
+		}
+	}
+
+	return regions
+}
+
+// Flush calls upload with each dirty region and the part of its page that it
+// covers, then marks the pages as clean.
+//
+// The lock of the cache is held while upload is called, the sub-images passed
+// to it share their pixels with the pages and must not be retained or
+// modified. Calling methods of the cache from upload deadlocks.
+func (c *Cache) Flush(upload func(region DirtyRegion, pixels *image.Alpha)) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	for _, r := range c.dirtyRegions() {
+		pg := c.pages[r.Page]
+		upload(r, pg.alpha.SubImage(r.Rect).(*image.Alpha))
+		pg.dirty = image.Rectangle{}
+	}
+}
+
+// CopyPage returns a copy of the page at index i, or nil if there is no such
+// page.
+func (c *Cache) CopyPage(i int) *image.Alpha {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	if i < 0 || i >= len(c.pages) {
+		return nil
+	}
+
+	src := c.pages[i].alpha
+	return &image.Alpha{
+		Pix:    append([]uint8(nil), src.Pix...),
+		Stride: src.Stride,
+		Rect:   src.Rect,
+	}
+}
+
+// Clear removes all the glyphs from the cache and releases the pages, the
+// stats are kept.
+func (c *Cache) Clear() {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	c.pages = nil
+	c.entries = make(map[Key]*list.Element)
+	c.lru.Init()
+}
+
+// copyMask copies the mask into the page at p.
+func copyMask(dst *image.Alpha, p image.Point, mask *image.Alpha) {
+	w := mask.Rect.Dx()
+
+	for y := 0; y != mask.Rect.Dy(); y++ {
+		i := dst.PixOffset(p.X, p.Y+y)
+		copy(dst.Pix[i:i+w], mask.Pix[y*mask.Stride:])
+	}
+}
@@ -0,0 +1,7 @@
+// +build darwin
+
+package atlas
+
+import "github.com/go-vu/cocoa/CT"
+
+var _ Font = CT.FontRef(0)
@@ -0,0 +1,396 @@
+package atlas
+
+import (
+	"errors"
+	"image"
+	"sync"
+	"sync/atomic"
+	"testing"
+
+	"github.com/go-vu/cocoa/CG"
+	"github.com/go-vu/cocoa/CT"
+	"github.com/go-vu/cocoa/CT/fontfile"
+)
+
+var _ Font = (*fontfile.Font)(nil)
+
+func newCache(t *testing.T, opts *Options) *Cache {
+	c, err := New(opts)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return c
+}
+
+func TestNew(t *testing.T) {
+	tests := []struct {
+		opts      *Options
+		subpixels int
+		padding   int
+		pageSize  int
+	}{
+		{nil, 4, 1, 1024},
+		{&Options{SubpixelPositions: 1, Padding: -1, PageSize: 256}, 1, 0, 256},
+		{&Options{SubpixelPositions: 100, Padding: 2}, 64, 2, 1024},
+		{&Options{SubpixelPositions: 8, Render: &CT.RenderOptions{Antialias: true}}, 1, 1, 1024},
+	}
+
+	for _, test := range tests {
+		c := newCache(t, test.opts)
+
+		if c.SubpixelPositions() != test.subpixels || c.padding != test.padding || c.pageSize != test.pageSize || c.maxPages != 4 {
+			t.Errorf("%+v: invalid options: %d, %d, %d, %d", test.opts, c.SubpixelPositions(), c.padding, c.pageSize, c.maxPages)
+		}
+	}
+
+	if _, err := New(&Options{Render: &CT.RenderOptions{Smoothing: true}}); !errors.Is(err, CT.ErrInvalidRenderOptions) {
+		t.Error("invalid error for invalid render options:", err)
+	}
+}
+
+func TestCacheKey(t *testing.T) {
+	c := newCache(t, nil)
+	f := &fakeFont{size: 12}
+
+	tests := []struct {
+		x        CG.Float
+		subpixel int
+	}{
+		{10, 0},
+		{10.24, 0},
+		{10.25, 1},
+		{10.5, 2},
+		{10.99, 3},
+		{-0.25, 3},
+	}
+
+	for _, test := range tests {
+		key := c.Key(f, 5, test.x)
+
+		if key != (Key{Font: f, Glyph: 5, Size: 12, Subpixel: test.subpixel}) {
+			t.Errorf("%v: invalid key: %+v", test.x, key)
+		}
+	}
+}
+
+func TestCacheGlyph(t *testing.T) {
+	c := newCache(t, &Options{PageSize: 64})
+	f := &fakeFont{size: 12}
+
+	e1, err := c.Glyph(f, 5, 10)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if e1 != (Entry{Page: 0, Rect: image.Rect(0, 0, 7, 7), Bounds: image.Rect(-1, -6, 6, 1)}) {
+		t.Errorf("invalid entry: %+v", e1)
+	}
+
+	if e, err := c.Glyph(f, 5, 11.1); err != nil || e != e1 {
+		t.Errorf("invalid entry of a cached glyph: %+v, %v", e, err)
+	}
+
+	e2, err := c.Glyph(f, 5, 10.5)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if e2.Bounds != image.Rect(-1, -6, 7, 1) || e2.Rect.Size() != e2.Bounds.Size() || e2.Rect.Overlaps(e1.Rect) {
+		t.Errorf("invalid entry of a glyph at a subpixel offset: %+v", e2)
+	}
+
+	// Glyphs without ink are cached but take no room in the pages.
+	if e, err := c.Glyph(f, 0, 10); err != nil || !e.Rect.Empty() || !e.Bounds.Empty() {
+		t.Errorf("invalid entry of a glyph without ink: %+v, %v", e, err)
+	}
+
+	if s := c.Stats(); s != (Stats{Hits: 1, Misses: 3, Glyphs: 3, Pages: 1}) {
+		t.Errorf("invalid stats: %+v", s)
+	}
+
+	if n := atomic.LoadInt32(&f.draws); n != 2 {
+		t.Error("invalid number of glyphs drawn:", n)
+	}
+
+	// The fake font draws the glyphs as squares, with the glyph identifier
+	// as value, inside the one pixel margin of the mask.
+	page := c.CopyPage(0)
+
+	for y := e1.Rect.Min.Y; y != e1.Rect.Max.Y; y++ {
+		for x := e1.Rect.Min.X; x != e1.Rect.Max.X; x++ {
+			expected := uint8(0)
+
+			if x > e1.Rect.Min.X && x < e1.Rect.Max.X-1 && y > e1.Rect.Min.Y && y < e1.Rect.Max.Y-1 {
+				expected = 5
+			}
+
+			if a := page.AlphaAt(x, y).A; a != expected {
+				t.Errorf("(%d, %d): invalid alpha: %d != %d", x, y, a, expected)
+			}
+		}
+	}
+
+	if page := c.CopyPage(1); page != nil {
+		t.Error("copy of a page that doesn't exist")
+	}
+}
+
+func TestCacheGlyphError(t *testing.T) {
+	c := newCache(t, &Options{PageSize: 64})
+	f := &fakeFont{size: 12}
+
+	if _, err := c.Glyph(f, 64, 0); !errors.Is(err, ErrGlyphTooLarge) {
+		t.Error("invalid error for a glyph larger than the pages:", err)
+	}
+
+	if _, err := c.Glyph(f, failingGlyph, 0); !errors.Is(err, CT.ErrDrawFailed) {
+		t.Error("invalid error for a glyph that failed to draw:", err)
+	}
+
+	if s := c.Stats(); s.Glyphs != 0 || s.Pages != 0 {
+		t.Errorf("glyphs that failed were cached: %+v", s)
+	}
+}
+
+func TestCacheEviction(t *testing.T) {
+	// Each glyph takes 8x8 pixels with the padding, so two pages of 16x16
+	// pixels hold 8 glyphs. The fonts are distinct so that the glyphs are.
+	c := newCache(t, &Options{PageSize: 16, MaxPages: 2})
+	fonts := make([]*fakeFont, 9)
+	entries := make([]Entry, len(fonts))
+
+	for i := range fonts {
+		fonts[i] = &fakeFont{size: 12}
+	}
+
+	for i, f := range fonts[:8] {
+		entries[i], _ = c.Glyph(f, 5, 0)
+	}
+
+	if s := c.Stats(); s.Glyphs != 8 || s.Pages != 2 || s.Evictions != 0 {
+		t.Fatalf("invalid stats: %+v", s)
+	}
+
+	c.Flush(func(DirtyRegion, *image.Alpha) {})
+
+	// Using the glyphs of the first page makes the glyphs of the second page
+	// the least recently used.
+	for i, f := range fonts[:4] {
+		if e, _ := c.Glyph(f, 5, 0); e != entries[i] || e.Page != 0 {
+			t.Errorf("invalid entry of glyph %d: %+v", i, e)
+		}
+	}
+
+	e, err := c.Glyph(fonts[8], 5, 0)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if e.Page != 1 || e.Rect.Min != (image.Point{}) {
+		t.Errorf("invalid entry of the glyph inserted after eviction: %+v", e)
+	}
+
+	if s := c.Stats(); s.Glyphs != 5 || s.Pages != 2 || s.Evictions != 4 || s.PageEvictions != 1 {
+		t.Errorf("invalid stats after eviction: %+v", s)
+	}
+
+	if regions := c.DirtyRegions(); len(regions) != 1 || regions[0] != (DirtyRegion{Page: 1, Rect: image.Rect(0, 0, 16, 16)}) {
+		t.Error("invalid dirty regions after eviction:", regions)
+	}
+
+	if e, _ := c.Glyph(fonts[0], 5, 0); e != entries[0] {
+		t.Error("glyph of the first page evicted:", e)
+	}
+
+	misses := c.Stats().Misses
+
+	if c.Glyph(fonts[4], 5, 0); c.Stats().Misses != misses+1 {
+		t.Error("evicted glyph found in the cache")
+	}
+}
+
+func TestCacheFlush(t *testing.T) {
+	c := newCache(t, &Options{PageSize: 64})
+	f := &fakeFont{size: 12}
+
+	if regions := c.DirtyRegions(); len(regions) != 0 {
+		t.Error("dirty regions in an empty cache:", regions)
+	}
+
+	e1, _ := c.Glyph(f, 5, 0)
+	e2, _ := c.Glyph(f, 3, 0)
+	expected := DirtyRegion{Page: 0, Rect: e1.Rect.Union(e2.Rect)}
+
+	if regions := c.DirtyRegions(); len(regions) != 1 || regions[0] != expected {
+		t.Error("invalid dirty regions:", regions)
+	}
+
+	calls := 0
+	c.Flush(func(r DirtyRegion, pixels *image.Alpha) {
+		calls++
+
+		if r != expected || pixels.Rect != expected.Rect {
+			t.Error("invalid region flushed:", r, pixels.Rect)
+		}
+
+		if a := pixels.AlphaAt(e2.Rect.Min.X+1, e2.Rect.Min.Y+1).A; a != 3 {
+			t.Error("invalid pixels flushed:", a)
+		}
+	})
+
+	if calls != 1 {
+		t.Error("invalid number of regions flushed:", calls)
+	}
+
+	if regions := c.DirtyRegions(); len(regions) != 0 {
+		t.Error("dirty regions after a flush:", regions)
+	}
+
+	c.Clear()
+
+	if s := c.Stats(); s.Glyphs != 0 || s.Pages != 0 || s.Misses != 2 {
+		t.Errorf("invalid stats after clearing the cache: %+v", s)
+	}
+}
+
+func TestCacheConcurrency(t *testing.T) {
+	// The cache is small enough to force evictions while the goroutines look
+	// up glyphs and flush the pages.
+	c := newCache(t, &Options{PageSize: 32, MaxPages: 2})
+	f := &fakeFont{size: 12}
+
+	const goroutines = 8
+	const lookups = 500
+	wg := sync.WaitGroup{}
+
+	for i := 0; i != goroutines; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+
+			for j := 0; j != lookups; j++ {
+				glyph := CT.GlyphID(1 + (i+j)%7)
+				e, err := c.Glyph(f, glyph, CG.Float(j)/7)
+
+				if err != nil {
+					t.Error(err)
+					return
+				}
+
+				if e.Rect.Size() != e.Bounds.Size() {
+					t.Errorf("invalid entry of glyph %d: %+v", glyph, e)
+					return
+				}
+
+				if j%50 == 0 {
+					c.Flush(func(DirtyRegion, *image.Alpha) {})
+				}
+			}
+		}(i)
+	}
+
+	wg.Wait()
+
+	if s := c.Stats(); s.Hits+s.Misses != goroutines*lookups || s.PageEvictions == 0 {
+		t.Errorf("invalid stats: %+v", s)
+	}
+}
+
+func TestCacheFontFile(t *testing.T) {
+	f, err := fontfile.Open("../sfnt/testdata/CFFTest.otf", 0, 16)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	c := newCache(t, &Options{PageSize: 64})
+	glyphs := f.GlyphsForRunes([]rune("0"))
+	e, err := c.Glyph(f, glyphs[0], 0)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The glyph is 6.4x12.8 pixels, from 1.6 to 8 on the x-axis and from 0
+	// to 12.8 above the baseline.
+	if e.Bounds != image.Rect(0, -14, 9, 1) {
+		t.Error("invalid bounds:", e.Bounds)
+	}
+
+	page := c.CopyPage(0)
+	ink := 0
+
+	for y := e.Rect.Min.Y; y != e.Rect.Max.Y; y++ {
+		for x := e.Rect.Min.X; x != e.Rect.Max.X; x++ {
+			if page.AlphaAt(x, y).A != 0 {
+				ink++
+			}
+		}
+	}
+
+	if ink == 0 {
+		t.Error("the glyph was not drawn")
+	}
+}
+
+// fakeFont draws glyphs as squares sitting on the baseline, the size of which
+// is the glyph identifier, filled with the glyph identifier as alpha. Glyph 0
+// has no ink.
+type fakeFont struct {
+	size  CG.Float
+	draws int32
+}
+
+// failingGlyph is a glyph that the fake font fails to draw.
+const failingGlyph = 1000
+
+func (f *fakeFont) GetSize() CG.Float {
+	return f.size
+}
+
+func (f *fakeFont) BoundingRectsForGlyphs(glyphs []CT.GlyphID) ([]CG.Rect, CG.Rect) {
+	rects := make([]CG.Rect, len(glyphs))
+	overall := CG.RectNull
+
+	for i, g := range glyphs {
+		switch g {
+		case 0:
+			rects[i] = CG.RectNull
+		case failingGlyph:
+			rects[i] = CG.RectMake(0, 0, 1, 1)
+		default:
+			rects[i] = CG.RectMake(0, 0, CG.Float(g), CG.Float(g))
+		}
+		overall = overall.Union(rects[i])
+	}
+
+	return rects, overall
+}
+
+func (f *fakeFont) DrawGlyphsWithOptions(glyphs []CT.GlyphID, positions []CG.Point, alpha *image.Alpha, opts *CT.RenderOptions) error {
+	atomic.AddInt32(&f.draws, 1)
+	positions = opts.AlignPositions(positions)
+
+	for i, g := range glyphs {
+		if g == failingGlyph {
+			return CT.ErrDrawFailed
+		}
+
+		// The squares are drawn at the pixel of their origin, the subpixel
+		// offsets are ignored.
+		p := image.Pt(int(positions[i].X), int(positions[i].Y))
+
+		for y := p.Y - int(g); y != p.Y; y++ {
+			for x := p.X; x != p.X+int(g); x++ {
+				alpha.Pix[alpha.PixOffset(alpha.Rect.Min.X+x, alpha.Rect.Min.Y+y)] = uint8(g)
+			}
+		}
+	}
+
+	return nil
+}
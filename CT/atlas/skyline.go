@@ -0,0 +1,118 @@
+package atlas
+
+import "image"
+
+// skyline is a rectangle packer which keeps track of the top edge of the
+// packed rectangles, the skyline, as a list of horizontal segments. Rectangles
+// are placed at the lowest position where they fit, which keeps the packing
+// tight for rectangles of similar heights like glyphs.
+type skyline struct {
+	width    int
+	height   int
+	segments []skylineSegment
+}
+
+// skylineSegment is a horizontal segment of the skyline starting at x, the
+// used area of the page is above y.
+type skylineSegment struct {
+	x, y, width int
+}
+
+func newSkyline(width int, height int) *skyline {
+	s := &skyline{width: width, height: height}
+	s.reset()
+	return s
+}
+
+// reset empties the packer.
+func (s *skyline) reset() {
+	s.segments = append(s.segments[:0], skylineSegment{x: 0, y: 0, width: s.width})
+}
+
+// insert finds a place for a rectangle of the given size and returns its
+// position, the boolean is false if the rectangle doesn't fit.
+func (s *skyline) insert(width int, height int) (image.Point, bool) {
+	if width <= 0 || height <= 0 || width > s.width || height > s.height {
+		return image.Point{}, false
+	}
+
+	best, bestY, bestWidth := -1, s.height, 0
+
+	for i := range s.segments {
+		y, ok := s.fit(i, width, height)
+
+		// The lowest position wins, ties are broken by the narrowest segment
+		// to keep wide segments for wide rectangles.
+		if ok && (y < bestY || (y == bestY && s.segments[i].width < bestWidth)) {
+			best, bestY, bestWidth = i, y, s.segments[i].width
+		}
+	}
+
+	if best < 0 {
+		return image.Point{}, false
+	}
+
+	p := image.Point{X: s.segments[best].x, Y: bestY}
+	s.add(best, p, width, height)
+	return p, true
+}
+
+// fit returns the height at which a rectangle placed at the start of segment i
+// would sit, which is the highest of the segments that it spans.
+func (s *skyline) fit(i int, width int, height int) (int, bool) {
+	x := s.segments[i].x
+
+	if x+width > s.width {
+		return 0, false
+	}
+
+	y := 0
+
+	for left := width; left > 0; i++ {
+		if seg := s.segments[i]; seg.y > y {
+			y = seg.y
+		}
+		left -= s.segments[i].width
+	}
+
+	return y, y+height <= s.height
+}
+
+// add raises the skyline over a rectangle placed at p, which starts at the
+// beginning of segment i.
+func (s *skyline) add(i int, p image.Point, width int, height int) {
+	seg := skylineSegment{x: p.X, y: p.Y + height, width: width}
+	end := p.X + width
+
+	// The segments covered by the rectangle are removed, the last one may be
+	// partially covered in which case it's shortened.
+	j := i
+
+	for j < len(s.segments) && s.segments[j].x < end {
+		if segEnd := s.segments[j].x + s.segments[j].width; segEnd > end {
+			s.segments[j].width = segEnd - end
+			s.segments[j].x = end
+			break
+		}
+		j++
+	}
+
+	s.segments = append(s.segments[:i], append([]skylineSegment{seg}, s.segments[j:]...)...)
+	s.merge()
+}
+
+// merge joins the consecutive segments that have the same height.
+func (s *skyline) merge() {
+	n := 0
+
+	for _, seg := range s.segments {
+		if n > 0 && s.segments[n-1].y == seg.y {
+			s.segments[n-1].width += seg.width
+			continue
+		}
+		s.segments[n] = seg
+		n++
+	}
+
+	s.segments = s.segments[:n]
+}
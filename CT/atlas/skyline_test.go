@@ -0,0 +1,104 @@
+package atlas
+
+import (
+	"image"
+	"math/rand"
+	"testing"
+)
+
+func TestSkylineInsert(t *testing.T) {
+	s := newSkyline(4, 4)
+
+	tests := []struct {
+		width, height int
+		p             image.Point
+		ok            bool
+	}{
+		{3, 1, image.Pt(0, 0), true},
+		{1, 3, image.Pt(3, 0), true},
+		{3, 1, image.Pt(0, 1), true},
+		{2, 2, image.Pt(0, 2), true},
+		{2, 2, image.Point{}, false},
+		{1, 2, image.Pt(2, 2), true},
+		{1, 1, image.Pt(3, 3), true},
+		{1, 1, image.Point{}, false},
+	}
+
+	for i, test := range tests {
+		p, ok := s.insert(test.width, test.height)
+
+		if p != test.p || ok != test.ok {
+			t.Errorf("#%d: %dx%d: invalid position: %v, %t", i, test.width, test.height, p, ok)
+		}
+	}
+
+	s.reset()
+
+	if p, ok := s.insert(4, 4); p != (image.Point{}) || !ok {
+		t.Error("failed to insert a rectangle of the size of the page after a reset:", p, ok)
+	}
+}
+
+func TestSkylineInvalidSize(t *testing.T) {
+	s := newSkyline(8, 8)
+
+	for _, size := range []image.Point{{0, 1}, {1, 0}, {-1, 1}, {9, 1}, {1, 9}} {
+		if _, ok := s.insert(size.X, size.Y); ok {
+			t.Errorf("rectangle of size %v inserted", size)
+		}
+	}
+}
+
+func TestSkylineSquares(t *testing.T) {
+	s := newSkyline(64, 64)
+	n := 0
+
+	for {
+		if _, ok := s.insert(8, 8); !ok {
+			break
+		}
+		n++
+	}
+
+	if n != 64 {
+		t.Error("invalid number of squares packed in the page:", n)
+	}
+}
+
+func TestSkylineNoOverlap(t *testing.T) {
+	const size = 256
+	s := newSkyline(size, size)
+	r := rand.New(rand.NewSource(1))
+	used := make([]bool, size*size)
+	area := 0
+
+	for i := 0; i != 2000; i++ {
+		w, h := 1+r.Intn(24), 1+r.Intn(24)
+		p, ok := s.insert(w, h)
+
+		if !ok {
+			continue
+		}
+
+		if p.X < 0 || p.Y < 0 || p.X+w > size || p.Y+h > size {
+			t.Fatalf("rectangle %dx%d placed out of the page at %v", w, h, p)
+		}
+
+		for y := p.Y; y != p.Y+h; y++ {
+			for x := p.X; x != p.X+w; x++ {
+				if used[y*size+x] {
+					t.Fatalf("rectangle %dx%d placed at %v overlaps another one", w, h, p)
+				}
+				used[y*size+x] = true
+			}
+		}
+
+		area += w * h
+	}
+
+	// The skyline wastes the space below the rectangles that are placed over
+	// gaps, which is expected to stay small with rectangles of random sizes.
+	if occupancy := float64(area) / (size * size); occupancy < 0.75 {
+		t.Error("low occupancy of the page:", occupancy)
+	}
+}
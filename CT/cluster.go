@@ -0,0 +1,113 @@
+package CT
+
+import (
+	"image"
+	"unicode"
+
+	"github.com/go-vu/cocoa/CG"
+)
+
+// ClusterShaper is implemented by font sources that shape grapheme clusters as
+// a unit, which substitutes the ligatures of the font for sequences like flags
+// and emoji joined by zero width joiners, and positions the combining marks of
+// clusters relative to their base character. FontRef implements it with Core
+// Text.
+//
+// The grapheme package segments text into clusters.
+type ClusterShaper interface {
+	// ClusterDraw draws the grapheme cluster into the alpha image, at the
+	// given position in the coordinate space of the image. It returns false
+	// if the cluster could not be drawn.
+	ClusterDraw(cluster string, origin CG.Point, alpha *image.Alpha) bool
+
+	// ClusterBounds returns the advance and bounds of the grapheme cluster,
+	// the bounds are in the Quartz space, relative to the origin of the
+	// cluster, and are CG.RectNull if the cluster has no ink.
+	ClusterBounds(cluster string) (advance CG.Float, bounds CG.Rect)
+}
+
+// ClusterDraw draws the grapheme cluster with the font f into the alpha image,
+// at the given position in the coordinate space of the image, and returns
+// false if it could not be drawn.
+//
+// Clusters are drawn with f.ClusterDraw if the font implements ClusterShaper,
+// including the clusters of a single rune, which lets the font fall back to
+// other fonts for the runes it has no glyph for. Otherwise clusters of a
+// single rune are drawn with f.GlyphDraw, and the runes of longer clusters are
+// drawn one after the other with their advances, which puts combining marks
+// that have no advance over their base character, and the default ignorable
+// runes, like zero width joiners and variation selectors, are skipped.
+func ClusterDraw(f FontSource, cluster string, origin CG.Point, alpha *image.Alpha) bool {
+	return clusterDraw(f, []rune(cluster), origin, alpha)
+}
+
+// ClusterBounds returns the advance and bounds of the grapheme cluster with
+// the font f. The bounds are in the Quartz space, relative to the origin of
+// the cluster, and are CG.RectNull if the cluster has no ink.
+//
+// The cluster is measured with the same methods of the font that ClusterDraw
+// draws it with.
+func ClusterBounds(f FontSource, cluster string) (advance CG.Float, bounds CG.Rect) {
+	return clusterBounds(f, []rune(cluster))
+}
+
+func clusterDraw(f FontSource, runes []rune, origin CG.Point, alpha *image.Alpha) bool {
+	if shaper, ok := f.(ClusterShaper); ok {
+		return shaper.ClusterDraw(string(runes), origin, alpha)
+	}
+
+	if len(runes) == 1 {
+		return f.GlyphDraw(runes[0], origin, alpha)
+	}
+
+	ok := len(runes) != 0
+
+	for _, r := range runes {
+		if isDefaultIgnorable(r) {
+			continue
+		}
+
+		ok = f.GlyphDraw(r, origin, alpha) && ok
+		origin.X += f.GlyphAdvance(r)
+	}
+
+	return ok
+}
+
+func clusterBounds(f FontSource, runes []rune) (advance CG.Float, bounds CG.Rect) {
+	if shaper, ok := f.(ClusterShaper); ok {
+		return shaper.ClusterBounds(string(runes))
+	}
+
+	bounds = CG.RectNull
+
+	for _, r := range runes {
+		if len(runes) > 1 && isDefaultIgnorable(r) {
+			continue
+		}
+
+		a, b := f.GlyphBounds(r)
+
+		if b.IsEmpty() {
+			a = f.GlyphAdvance(r)
+		} else {
+			b = b.Standardize()
+			b.Origin.X += advance
+			bounds = bounds.Union(b)
+		}
+
+		advance += a
+	}
+
+	return
+}
+
+// isDefaultIgnorable returns true if r is a format character or a variation
+// selector, which have no glyph of their own, except for the prepended
+// concatenation marks which are drawn over the digits that follow them.
+//
+// https://www.unicode.org/reports/tr44/#Default_Ignorable_Code_Point
+func isDefaultIgnorable(r rune) bool {
+	return unicode.In(r, unicode.Cf, unicode.Variation_Selector, unicode.Other_Default_Ignorable_Code_Point) &&
+		!unicode.Is(unicode.Prepended_Concatenation_Mark, r)
+}
@@ -0,0 +1,170 @@
+package CT
+
+import (
+	"image"
+	"testing"
+
+	"github.com/go-vu/cocoa/CG"
+)
+
+func TestClusterBounds(t *testing.T) {
+	tests := []struct {
+		cluster string
+		advance CG.Float
+		bounds  CG.Rect
+	}{
+		{
+			cluster: "",
+			bounds:  CG.RectNull,
+		},
+		{
+			cluster: "a",
+			advance: 10,
+			bounds:  CG.RectMake(1, 0, 8, 8),
+		},
+		{
+			cluster: " ",
+			advance: 5,
+			bounds:  CG.RectNull,
+		},
+		{
+			// The accent has no advance, it's over the base character.
+			cluster: "e\u0301",
+			advance: 10,
+			bounds:  CG.RectMake(1, 0, 8, 11),
+		},
+		{
+			// The zero width joiner and variation selector are skipped.
+			cluster: "a\u200dg\ufe0f",
+			advance: 20,
+			bounds:  CG.RectMake(1, -3, 18, 11),
+		},
+	}
+
+	for _, test := range tests {
+		advance, bounds := ClusterBounds(fakeFontSource{}, test.cluster)
+
+		if advance != test.advance || bounds != test.bounds {
+			t.Errorf("%q: invalid advance and bounds: %v, %v != %v, %v", test.cluster, advance, bounds, test.advance, test.bounds)
+		}
+	}
+}
+
+func TestClusterDraw(t *testing.T) {
+	tests := []struct {
+		cluster string
+		drawn   []drawnCluster
+	}{
+		{
+			cluster: "a",
+			drawn:   []drawnCluster{{"a", CG.Point{X: 1, Y: 2}}},
+		},
+		{
+			cluster: "e\u0301i",
+			drawn: []drawnCluster{
+				{"e", CG.Point{X: 1, Y: 2}},
+				{"\u0301", CG.Point{X: 11, Y: 2}},
+				{"i", CG.Point{X: 11, Y: 2}},
+			},
+		},
+		{
+			cluster: "a\u200db",
+			drawn: []drawnCluster{
+				{"a", CG.Point{X: 1, Y: 2}},
+				{"b", CG.Point{X: 11, Y: 2}},
+			},
+		},
+	}
+
+	for _, test := range tests {
+		f := &drawingFont{}
+
+		if !ClusterDraw(f, test.cluster, CG.Point{X: 1, Y: 2}, image.NewAlpha(image.Rect(0, 0, 1, 1))) {
+			t.Errorf("%q: the cluster was not drawn", test.cluster)
+		}
+
+		if !equalDrawnClusters(f.drawn, test.drawn) {
+			t.Errorf("%q: invalid glyphs drawn: %v != %v", test.cluster, f.drawn, test.drawn)
+		}
+	}
+
+	if ClusterDraw(&drawingFont{}, "", CG.Point{}, image.NewAlpha(image.Rect(0, 0, 1, 1))) {
+		t.Error("an empty cluster was drawn")
+	}
+}
+
+func TestClusterShaper(t *testing.T) {
+	f := &shapingFont{}
+	alpha := image.NewAlpha(image.Rect(0, 0, 1, 1))
+
+	// All the clusters are shaped by the font, including single runes.
+	ClusterDraw(f, "a", CG.Point{}, alpha)
+	ClusterDraw(f, "\U0001F1EB\U0001F1F7", CG.Point{X: 3}, alpha)
+	expected := []drawnCluster{
+		{"a", CG.Point{}},
+		{"\U0001F1EB\U0001F1F7", CG.Point{X: 3}},
+	}
+
+	if !equalDrawnClusters(f.drawn, expected) {
+		t.Errorf("invalid clusters drawn: %v != %v", f.drawn, expected)
+	}
+
+	if advance, bounds := ClusterBounds(f, "a"); advance != 12 || bounds != CG.RectMake(0, 0, 12, 12) {
+		t.Errorf("invalid bounds of a single rune: %v, %v", advance, bounds)
+	}
+
+	if advance, bounds := ClusterBounds(f, "\U0001F1EB\U0001F1F7"); advance != 12 || bounds != CG.RectMake(0, 0, 12, 12) {
+		t.Errorf("invalid bounds of a shaped cluster: %v, %v", advance, bounds)
+	}
+
+	if m := MeasureString(f, "\U0001F1EB\U0001F1F7a", nil); m.Advance != 24 {
+		t.Errorf("invalid advance of a string with a shaped cluster: %v", m.Advance)
+	}
+}
+
+type drawnCluster struct {
+	cluster string
+	origin  CG.Point
+}
+
+func equalDrawnClusters(d1 []drawnCluster, d2 []drawnCluster) bool {
+	if len(d1) != len(d2) {
+		return false
+	}
+
+	for i := range d1 {
+		if d1[i] != d2[i] {
+			return false
+		}
+	}
+
+	return true
+}
+
+// drawingFont is a fakeFontSource that records the glyphs it draws.
+type drawingFont struct {
+	fakeFontSource
+	drawn []drawnCluster
+}
+
+func (f *drawingFont) GlyphDraw(char rune, origin CG.Point, alpha *image.Alpha) bool {
+	f.drawn = append(f.drawn, drawnCluster{string(char), origin})
+	return true
+}
+
+// shapingFont is a drawingFont that shapes clusters as 12 points wide and high
+// glyphs.
+type shapingFont struct {
+	drawingFont
+}
+
+var _ ClusterShaper = (*shapingFont)(nil)
+
+func (f *shapingFont) ClusterDraw(cluster string, origin CG.Point, alpha *image.Alpha) bool {
+	f.drawn = append(f.drawn, drawnCluster{cluster, origin})
+	return true
+}
+
+func (f *shapingFont) ClusterBounds(cluster string) (CG.Float, CG.Rect) {
+	return 12, CG.RectMake(0, 0, 12, 12)
+}
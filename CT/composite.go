@@ -0,0 +1,175 @@
+package CT
+
+import (
+	"image"
+	"image/color"
+	"image/draw"
+	"math"
+
+	"github.com/go-vu/cocoa/CG"
+)
+
+// DrawOptions are the options of the functions and methods that draw glyphs
+// into color images, the zero value draws opaque black glyphs over the image
+// with no clipping.
+type DrawOptions struct {
+	// The color of the glyphs, nil selects opaque black.
+	Color color.Color
+
+	// The Porter-Duff operator used to composite the glyphs, which is either
+	// draw.Over or draw.Src. With draw.Src the pixels of the destination that
+	// the glyphs are drawn into are replaced by the color of the glyphs
+	// masked by their coverage, see CompositeMask for details.
+	Op draw.Op
+
+	// The rectangle that drawing is clipped to, in the coordinate space of the
+	// destination image. A nil clip rectangle clips to the bounds of the
+	// image.
+	Clip *image.Rectangle
+
+	// The options used to rasterize the glyphs, nil selects the defaults.
+	Render *RenderOptions
+}
+
+// GlyphDrawer is implemented by fonts that draw glyphs into alpha images, like
+// FontRef and the fonts of the fontfile package.
+type GlyphDrawer interface {
+	BoundingRectsForGlyphs(glyphs []GlyphID) (rects []CG.Rect, overall CG.Rect)
+
+	DrawGlyphsWithOptions(glyphs []GlyphID, positions []CG.Point, alpha *image.Alpha, opts *RenderOptions) error
+}
+
+// DrawGlyphsImage draws the glyphs of the font f at the given positions into
+// dst, the glyphs are rasterized into a coverage mask which is then composited
+// into dst with CompositeMask.
+//
+// The positions are in the coordinate space of the image relative to the
+// top-left corner of its bounds, like the positions passed to DrawGlyphs, so
+// the same positions draw glyphs at the same place in alpha and color images.
+// The function panics if there isn't one position per glyph, and returns false
+// if the glyphs could not be drawn or if the render options are invalid.
+//
+// With draw.Over only the pixels covered by the bounds of the glyphs are
+// modified, with draw.Src all the pixels of the clip rectangle are.
+func DrawGlyphsImage(f GlyphDrawer, glyphs []GlyphID, positions []CG.Point, dst draw.Image, opts *DrawOptions) bool {
+	checkGlyphSlices(len(glyphs), len(positions))
+	o := drawOptions(opts)
+
+	if o.Render.Validate() != nil {
+		return false
+	}
+
+	origin := dst.Bounds().Min
+	clip := clipRect(dst, o)
+	r := clip
+
+	if o.Op == draw.Over {
+		rects, _ := f.BoundingRectsForGlyphs(glyphs)
+		r = r.Intersect(maskRect(GlyphsBounds(positions, rects)).Add(origin))
+	}
+
+	if r.Empty() {
+		return true
+	}
+
+	// The mask covers the rectangle of the destination that is drawn into, the
+	// positions are moved to be relative to its top-left corner.
+	mask := image.NewAlpha(r)
+	offset := r.Min.Sub(origin)
+	moved := make([]CG.Point, len(positions))
+
+	for i, p := range positions {
+		moved[i] = CG.Point{X: p.X - CG.Float(offset.X), Y: p.Y - CG.Float(offset.Y)}
+	}
+
+	if f.DrawGlyphsWithOptions(glyphs, moved, mask, o.Render) != nil {
+		return false
+	}
+
+	CompositeMask(dst, mask, &o)
+	return true
+}
+
+// CompositeMask composites a coverage mask into dst, mask being placed at its
+// bounds in the coordinate space of dst. The color of the options is masked
+// by the coverage and composited into dst with the operator of the options,
+// within the intersection of the bounds of the mask and the clip rectangle.
+//
+// The operators follow the semantics of the image/draw package: draw.Over
+// blends the masked color over the destination and draw.Src replaces the
+// destination with the masked color, which makes the pixels that the mask
+// doesn't cover transparent.
+func CompositeMask(dst draw.Image, mask *image.Alpha, opts *DrawOptions) {
+	o := drawOptions(opts)
+	r := clipRect(dst, o).Intersect(mask.Rect)
+
+	if r.Empty() {
+		return
+	}
+
+	draw.DrawMask(dst, r, image.NewUniform(o.Color), image.Point{}, mask, r.Min, o.Op)
+}
+
+// drawOptions returns a copy of opts where the default values are set.
+func drawOptions(opts *DrawOptions) DrawOptions {
+	o := DrawOptions{}
+
+	if opts != nil {
+		o = *opts
+	}
+
+	if o.Color == nil {
+		o.Color = color.Black
+	}
+
+	if o.Op != draw.Src {
+		o.Op = draw.Over
+	}
+
+	return o
+}
+
+// clipRect returns the rectangle of dst that drawing is clipped to.
+func clipRect(dst draw.Image, o DrawOptions) image.Rectangle {
+	if o.Clip == nil {
+		return dst.Bounds()
+	}
+	return o.Clip.Intersect(dst.Bounds())
+}
+
+// maskRect returns the smallest rectangle of pixels that contains the bounds
+// of glyphs, with a margin of one pixel on each side since the rasterizers
+// may spread antialiasing slightly past the bounds.
+func maskRect(bounds CG.Rect) image.Rectangle {
+	if bounds.IsNull() || bounds.IsEmpty() {
+		return image.Rectangle{}
+	}
+
+	return image.Rect(
+		int(math.Floor(float64(bounds.GetMinX())))-1,
+		int(math.Floor(float64(bounds.GetMinY())))-1,
+		int(math.Ceil(float64(bounds.GetMaxX())))+1,
+		int(math.Ceil(float64(bounds.GetMaxY())))+1,
+	)
+}
+
+// GlyphMaskBounds returns the pixel bounds, relative to the glyph origin, of
+// the mask that a glyph with the given bounding rectangle in the Quartz space
+// is drawn into when its origin is moved right by offset, which is a fraction
+// of pixel for glyphs drawn at subpixel positions.
+//
+// The bounds have a margin of one pixel on each side, so the antialiased
+// edges of the glyph are not clipped. The zero rectangle is returned for null
+// and empty rectangles.
+func GlyphMaskBounds(r CG.Rect, offset CG.Float) image.Rectangle {
+	if r.IsEmpty() {
+		return image.Rectangle{}
+	}
+
+	return image.Rect(
+		int(math.Floor(float64(offset+r.GetMinX())))-1,
+		int(math.Floor(float64(-r.GetMaxY())))-1,
+		int(math.Ceil(float64(offset+r.GetMaxX())))+1,
+		int(math.Ceil(float64(-r.GetMinY())))+1,
+	)
+}
@@ -0,0 +1,309 @@
+package CT
+
+import (
+	"image"
+	"image/color"
+	"image/draw"
+	"testing"
+
+	"github.com/go-vu/cocoa/CG"
+)
+
+func TestCompositeMask(t *testing.T) {
+	red := color.NRGBA{R: 0xff, A: 0xff}
+	blue := color.NRGBA{B: 0xff, A: 0x80}
+	white := color.NRGBA{R: 0xff, G: 0xff, B: 0xff, A: 0xff}
+
+	tests := []struct {
+		name     string
+		dst      func() draw.Image
+		opts     *DrawOptions
+		expected []color.Color
+	}{
+		{
+			name: "RGBA over",
+			dst:  func() draw.Image { return image.NewRGBA(image.Rect(0, 0, 4, 1)) },
+			opts: &DrawOptions{Color: red},
+			expected: []color.Color{
+				color.RGBA{0xff, 0xff, 0xff, 0xff},
+				color.RGBA{0xff, 0x7f, 0x7f, 0xff},
+				color.RGBA{0xff, 0x00, 0x00, 0xff},
+				color.RGBA{0xff, 0xff, 0xff, 0xff},
+			},
+		},
+		{
+			name: "RGBA src",
+			dst:  func() draw.Image { return image.NewRGBA(image.Rect(0, 0, 4, 1)) },
+			opts: &DrawOptions{Color: red, Op: draw.Src},
+			expected: []color.Color{
+				color.RGBA{0x00, 0x00, 0x00, 0x00},
+				color.RGBA{0x80, 0x00, 0x00, 0x80},
+				color.RGBA{0xff, 0x00, 0x00, 0xff},
+				color.RGBA{0xff, 0xff, 0xff, 0xff},
+			},
+		},
+		{
+			name: "RGBA translucent over",
+			dst:  func() draw.Image { return image.NewRGBA(image.Rect(0, 0, 4, 1)) },
+			opts: &DrawOptions{Color: blue},
+			expected: []color.Color{
+				color.RGBA{0xff, 0xff, 0xff, 0xff},
+				color.RGBA{0xbf, 0xbf, 0xff, 0xff},
+				color.RGBA{0x7f, 0x7f, 0xff, 0xff},
+				color.RGBA{0xff, 0xff, 0xff, 0xff},
+			},
+		},
+		{
+			name: "NRGBA over",
+			dst:  func() draw.Image { return image.NewNRGBA(image.Rect(0, 0, 4, 1)) },
+			opts: &DrawOptions{Color: red},
+			expected: []color.Color{
+				color.NRGBA{0xff, 0xff, 0xff, 0xff},
+				color.NRGBA{0xff, 0x7f, 0x7f, 0xff},
+				color.NRGBA{0xff, 0x00, 0x00, 0xff},
+				color.NRGBA{0xff, 0xff, 0xff, 0xff},
+			},
+		},
+		{
+			name: "NRGBA src",
+			dst:  func() draw.Image { return image.NewNRGBA(image.Rect(0, 0, 4, 1)) },
+			opts: &DrawOptions{Color: blue, Op: draw.Src},
+			expected: []color.Color{
+				color.NRGBA{0x00, 0x00, 0x00, 0x00},
+				color.NRGBA{0x00, 0x00, 0xff, 0x40},
+				color.NRGBA{0x00, 0x00, 0xff, 0x80},
+				color.NRGBA{0xff, 0xff, 0xff, 0xff},
+			},
+		},
+		{
+			name: "gray over",
+			dst:  func() draw.Image { return image.NewGray(image.Rect(0, 0, 4, 1)) },
+			opts: &DrawOptions{Color: red},
+			expected: []color.Color{
+				color.Gray{0xff},
+				color.Gray{0xa5},
+				color.Gray{0x4c},
+				color.Gray{0xff},
+			},
+		},
+		{
+			name: "default color",
+			dst:  func() draw.Image { return image.NewRGBA(image.Rect(0, 0, 4, 1)) },
+			opts: nil,
+			expected: []color.Color{
+				color.RGBA{0xff, 0xff, 0xff, 0xff},
+				color.RGBA{0x7f, 0x7f, 0x7f, 0xff},
+				color.RGBA{0x00, 0x00, 0x00, 0xff},
+				color.RGBA{0xff, 0xff, 0xff, 0xff},
+			},
+		},
+		{
+			name: "clip",
+			dst:  func() draw.Image { return image.NewRGBA(image.Rect(0, 0, 4, 1)) },
+			opts: &DrawOptions{Color: red, Op: draw.Src, Clip: &image.Rectangle{Min: image.Pt(1, 0), Max: image.Pt(2, 5)}},
+			expected: []color.Color{
+				color.RGBA{0xff, 0xff, 0xff, 0xff},
+				color.RGBA{0x80, 0x00, 0x00, 0x80},
+				color.RGBA{0xff, 0xff, 0xff, 0xff},
+				color.RGBA{0xff, 0xff, 0xff, 0xff},
+			},
+		},
+	}
+
+	// The mask covers the first three pixels of the image, with no coverage,
+	// half coverage and full coverage.
+	mask := &image.Alpha{Pix: []uint8{0x00, 0x80, 0xff}, Stride: 3, Rect: image.Rect(0, 0, 3, 1)}
+
+	for _, test := range tests {
+		dst := test.dst()
+		draw.Draw(dst, dst.Bounds(), image.NewUniform(white), image.Point{}, draw.Src)
+		CompositeMask(dst, mask, test.opts)
+
+		for x, c := range test.expected {
+			if p := dst.At(x, 0); p != c {
+				t.Errorf("%s: pixel %d: invalid color: %v != %v", test.name, x, p, c)
+			}
+		}
+	}
+}
+
+func TestCompositeMaskBounds(t *testing.T) {
+	dst := image.NewAlpha(image.Rect(10, 10, 14, 14))
+	mask := image.NewAlpha(image.Rect(12, 12, 20, 20))
+
+	for i := range mask.Pix {
+		mask.Pix[i] = 0xff
+	}
+
+	CompositeMask(dst, mask, nil)
+
+	for y := 10; y != 14; y++ {
+		for x := 10; x != 14; x++ {
+			expected := uint8(0)
+
+			if x >= 12 && y >= 12 {
+				expected = 0xff
+			}
+
+			if a := dst.AlphaAt(x, y).A; a != expected {
+				t.Errorf("(%d, %d): invalid alpha: %#x != %#x", x, y, a, expected)
+			}
+		}
+	}
+}
+
+func TestDrawGlyphsImage(t *testing.T) {
+	red := color.RGBA{R: 0xff, A: 0xff}
+	white := color.RGBA{R: 0xff, G: 0xff, B: 0xff, A: 0xff}
+	clip := image.Rect(0, 0, 13, 40)
+
+	tests := []struct {
+		name   string
+		opts   *DrawOptions
+		inside color.RGBA
+		around color.RGBA
+		clip   image.Rectangle
+	}{
+		{
+			name:   "over",
+			opts:   &DrawOptions{Color: red},
+			inside: red,
+			around: white,
+			clip:   image.Rect(10, 20, 20, 30),
+		},
+		{
+			name:   "src",
+			opts:   &DrawOptions{Color: red, Op: draw.Src},
+			inside: red,
+			around: color.RGBA{},
+			clip:   image.Rect(10, 20, 20, 30),
+		},
+		{
+			name:   "clip",
+			opts:   &DrawOptions{Color: red, Op: draw.Src, Clip: &clip},
+			inside: red,
+			around: color.RGBA{},
+			clip:   image.Rect(10, 20, 13, 30),
+		},
+	}
+
+	// The image doesn't start at the origin, the glyphs are positioned relative
+	// to its top-left corner: glyph 2 covers (11, 24)-(13, 26) and glyph 3
+	// covers (14, 23)-(17, 26).
+	f := fakeGlyphDrawer{}
+	glyphs := []GlyphID{2, 3}
+	positions := []CG.Point{{X: 1, Y: 6}, {X: 4, Y: 6}}
+	ink := func(x, y int) bool {
+		return (x >= 11 && x < 13 && y >= 24 && y < 26) || (x >= 14 && x < 17 && y >= 23 && y < 26)
+	}
+
+	for _, test := range tests {
+		dst := image.NewRGBA(image.Rect(10, 20, 20, 30))
+		draw.Draw(dst, dst.Bounds(), image.NewUniform(white), image.Point{}, draw.Src)
+
+		if !DrawGlyphsImage(f, glyphs, positions, dst, test.opts) {
+			t.Errorf("%s: failed to draw the glyphs", test.name)
+			continue
+		}
+
+		for y := 20; y != 30; y++ {
+			for x := 10; x != 20; x++ {
+				expected := white
+
+				switch p := image.Pt(x, y); {
+				case !p.In(test.clip):
+				case ink(x, y):
+					expected = test.inside
+				default:
+					expected = test.around
+				}
+
+				if c := dst.RGBAAt(x, y); c != expected {
+					t.Errorf("%s: (%d, %d): invalid color: %v != %v", test.name, x, y, c, expected)
+				}
+			}
+		}
+	}
+}
+
+func TestDrawGlyphsImageError(t *testing.T) {
+	dst := image.NewRGBA(image.Rect(0, 0, 10, 10))
+
+	if DrawGlyphsImage(fakeGlyphDrawer{}, []GlyphID{0}, []CG.Point{{X: 1, Y: 5}}, dst, &DrawOptions{Op: draw.Src}) {
+		t.Error("drawing a glyph that failed to draw succeeded")
+	}
+
+	if DrawGlyphsImage(fakeGlyphDrawer{}, []GlyphID{1}, []CG.Point{{X: 1, Y: 5}}, dst, &DrawOptions{Render: &RenderOptions{Smoothing: true}}) {
+		t.Error("drawing glyphs with invalid render options succeeded")
+	}
+
+	// Nothing is drawn when the glyphs are outside of the image.
+	if !DrawGlyphsImage(fakeGlyphDrawer{}, []GlyphID{0}, []CG.Point{{X: 20, Y: 5}}, dst, nil) {
+		t.Error("drawing glyphs outside of the image failed")
+	}
+
+	defer func() {
+		if recover() == nil {
+			t.Error("no panic for mismatching glyphs and positions")
+		}
+	}()
+
+	DrawGlyphsImage(fakeGlyphDrawer{}, []GlyphID{1, 2}, []CG.Point{{}}, dst, nil)
+}
+
+// fakeGlyphDrawer draws glyphs as squares sitting on the baseline, the size of
+// which is the glyph identifier. Drawing glyph 0 fails.
+type fakeGlyphDrawer struct{}
+
+func (fakeGlyphDrawer) BoundingRectsForGlyphs(glyphs []GlyphID) ([]CG.Rect, CG.Rect) {
+	rects := make([]CG.Rect, len(glyphs))
+	overall := CG.RectNull
+
+	for i, g := range glyphs {
+		rects[i] = CG.RectMake(0, 0, CG.Float(g), CG.Float(g))
+		overall = overall.Union(rects[i])
+	}
+
+	return rects, overall
+}
+
+func (fakeGlyphDrawer) DrawGlyphsWithOptions(glyphs []GlyphID, positions []CG.Point, alpha *image.Alpha, opts *RenderOptions) error {
+	if err := opts.Validate(); err != nil {
+		return err
+	}
+
+	positions = opts.AlignPositions(positions)
+
+	for i, g := range glyphs {
+		if g == 0 {
+			return ErrDrawFailed
+		}
+
+		p := positions[i]
+		r := image.Rect(int(p.X), int(p.Y)-int(g), int(p.X)+int(g), int(p.Y))
+		draw.Draw(alpha, r.Add(alpha.Rect.Min), image.Opaque, image.Point{}, draw.Src)
+	}
+
+	return nil
+}
+
+func TestGlyphMaskBounds(t *testing.T) {
+	tests := []struct {
+		r      CG.Rect
+		offset CG.Float
+		bounds image.Rectangle
+	}{
+		{CG.RectNull, 0, image.Rectangle{}},
+		{CG.RectMake(0, 0, 0, 0), 0.5, image.Rectangle{}},
+		{CG.RectMake(0, 0, 10, 10), 0, image.Rect(-1, -11, 11, 1)},
+		{CG.RectMake(0, 0, 10, 10), 0.25, image.Rect(-1, -11, 12, 1)},
+		{CG.RectMake(-0.5, -2.5, 3, 5), 0, image.Rect(-2, -4, 4, 4)},
+		{CG.RectMake(-0.5, -2.5, 3, 5), 0.75, image.Rect(-1, -4, 5, 4)},
+	}
+
+	for _, test := range tests {
+		if bounds := GlyphMaskBounds(test.r, test.offset); bounds != test.bounds {
+			t.Errorf("%v at %v: invalid mask bounds: %v != %v", test.r, test.offset, bounds, test.bounds)
+		}
+	}
+}
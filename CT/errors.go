@@ -0,0 +1,46 @@
+package CT
+
+import (
+	"errors"
+	"fmt"
+	"math"
+
+	"github.com/go-vu/cocoa/CG"
+)
+
+// ErrCreateFailed is returned when a Core Text object could not be created,
+// either because the arguments were invalid or because the Core Text function
+// used to create it returned NULL.
+var ErrCreateFailed = errors.New("CT: failed to create the object")
+
+// ErrDrawFailed is returned when glyphs could not be drawn into an image.
+var ErrDrawFailed = errors.New("CT: failed to draw the glyphs")
+
+// checkFontParameters validates the size and transformation passed to the
+// functions that create fonts, a size of zero selects the default size of 12
+// points.
+func checkFontParameters(size CG.Float, transform *CG.AffineTransform) error {
+	if !isFinite(size) || size < 0 {
+		return fmt.Errorf("%w: invalid font size: %v", ErrCreateFailed, size)
+	}
+
+	if transform != nil {
+		t := *transform
+
+		for _, v := range [...]CG.Float{t.A, t.B, t.C, t.D, t.Tx, t.Ty} {
+			if !isFinite(v) {
+				return fmt.Errorf("%w: invalid font transformation: %v", ErrCreateFailed, t)
+			}
+		}
+
+		if _, err := t.Invert(); err != nil {
+			return fmt.Errorf("%w: invalid font transformation: %s", ErrCreateFailed, err)
+		}
+	}
+
+	return nil
+}
+
+func isFinite(f CG.Float) bool {
+	return !math.IsNaN(float64(f)) && !math.IsInf(float64(f), 0)
+}
@@ -0,0 +1,42 @@
+package CT
+
+import (
+	"errors"
+	"math"
+	"testing"
+
+	"github.com/go-vu/cocoa/CG"
+)
+
+func TestCheckFontParameters(t *testing.T) {
+	singular := CG.AffineTransformMake(1, 2, 2, 4, 0, 0)
+	rotation := CG.AffineTransformMakeRotation(1)
+	infinite := CG.AffineTransformMakeTranslation(CG.Float(math.Inf(1)), 0)
+
+	tests := []struct {
+		size      CG.Float
+		transform *CG.AffineTransform
+		valid     bool
+	}{
+		{0, nil, true},
+		{12, nil, true},
+		{12, &rotation, true},
+		{-1, nil, false},
+		{CG.Float(math.NaN()), nil, false},
+		{CG.Float(math.Inf(1)), nil, false},
+		{12, &singular, false},
+		{12, &infinite, false},
+	}
+
+	for _, test := range tests {
+		err := checkFontParameters(test.size, test.transform)
+
+		if test.valid && err != nil {
+			t.Errorf("%v %v: %s", test.size, test.transform, err)
+		}
+
+		if !test.valid && !errors.Is(err, ErrCreateFailed) {
+			t.Errorf("%v %v: invalid error returned for invalid font parameters: %v", test.size, test.transform, err)
+		}
+	}
+}
@@ -0,0 +1,79 @@
+package face
+
+import (
+	"container/list"
+	"image"
+)
+
+// glyphMask is a rendered glyph, the bounds are the position of the mask
+// relative to the glyph origin.
+type glyphMask struct {
+	alpha  *image.Alpha
+	bounds image.Rectangle
+}
+
+// maskKey identifies the mask of a rune drawn at a subpixel position.
+type maskKey struct {
+	r        rune
+	subpixel int
+}
+
+// maskCache is a cache of glyph masks which discards the least recently used
+// masks when it's full.
+type maskCache struct {
+	size    int
+	entries map[maskKey]*list.Element
+	lru     list.List
+}
+
+type maskEntry struct {
+	key  maskKey
+	mask *glyphMask
+}
+
+func newMaskCache(size int) *maskCache {
+	return &maskCache{
+		size:    size,
+		entries: make(map[maskKey]*list.Element),
+	}
+}
+
+func (c *maskCache) get(key maskKey) (*glyphMask, bool) {
+	e, ok := c.entries[key]
+
+	if !ok {
+		return nil, false
+	}
+
+	c.lru.MoveToFront(e)
+	return e.Value.(*maskEntry).mask, true
+}
+
+func (c *maskCache) put(key maskKey, mask *glyphMask) {
+	if c.size == 0 {
+		return
+	}
+
+	if e, ok := c.entries[key]; ok {
+		e.Value.(*maskEntry).mask = mask
+		c.lru.MoveToFront(e)
+		return
+	}
+
+	for c.lru.Len() >= c.size {
+		e := c.lru.Back()
+		c.lru.Remove(e)
+		delete(c.entries, e.Value.(*maskEntry).key)
+	}
+
+	c.entries[key] = c.lru.PushFront(&maskEntry{key: key, mask: mask})
+}
+
+func (c *maskCache) len() int {
+	return c.lru.Len()
+}
+
+func (c *maskCache) clear() {
+	c.entries = make(map[maskKey]*list.Element)
+	c.lru.Init()
+}
@@ -0,0 +1,52 @@
+package face
+
+import "testing"
+
+func TestMaskCache(t *testing.T) {
+	c := newMaskCache(2)
+	a := &glyphMask{}
+	b := &glyphMask{}
+	d := &glyphMask{}
+
+	c.put(maskKey{'a', 0}, a)
+	c.put(maskKey{'b', 0}, b)
+
+	if m, ok := c.get(maskKey{'a', 0}); !ok || m != a {
+		t.Error("mask not found in the cache")
+	}
+
+	if _, ok := c.get(maskKey{'a', 1}); ok {
+		t.Error("mask found for another subpixel position")
+	}
+
+	// 'b' is the least recently used mask, it is discarded to make room for
+	// 'd'.
+	c.put(maskKey{'d', 0}, d)
+
+	if _, ok := c.get(maskKey{'b', 0}); ok {
+		t.Error("the least recently used mask was not discarded")
+	}
+
+	if _, ok := c.get(maskKey{'a', 0}); !ok {
+		t.Error("a recently used mask was discarded")
+	}
+
+	c.put(maskKey{'d', 0}, b)
+
+	if m, _ := c.get(maskKey{'d', 0}); m != b || c.len() != 2 {
+		t.Error("the mask was not replaced")
+	}
+
+	c.clear()
+
+	if _, ok := c.get(maskKey{'a', 0}); ok || c.len() != 0 {
+		t.Error("masks found in a cleared cache")
+	}
+
+	c = newMaskCache(0)
+	c.put(maskKey{'a', 0}, a)
+
+	if _, ok := c.get(maskKey{'a', 0}); ok {
+		t.Error("mask found in a disabled cache")
+	}
+}
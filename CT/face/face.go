@@ -0,0 +1,228 @@
+// Package face implements the golang.org/x/image/font.Face interface on top
+// of Core Text fonts.
+//
+// The package is written against the CT.FontSource interface, which CT.FontRef
+// and the fonts of the fontfile package implement, so that the conversions to
+// fixed point values and the caching of glyph masks can be used and tested on
+// any platform.
+package face
+
+import (
+	"image"
+
+	"github.com/go-vu/cocoa/CG"
+	"github.com/go-vu/cocoa/CT"
+	"golang.org/x/image/font"
+	"golang.org/x/image/math/fixed"
+)
+
+// Font is the interface of the fonts that a Face is built on, it's an alias of
+// CT.FontSource. Values are in points, which the face maps to pixels one to
+// one, so the size of the font is its size in pixels.
+type Font = CT.FontSource
+
+// fontHeights is implemented by fonts that provide the x-height and cap-height
+// used in font.Metrics, like CT.FontRef.
+type fontHeights interface {
+	GetXHeight() CG.Float
+
+	GetCapHeight() CG.Float
+}
+
+// fontGlyphs is implemented by fonts that map runes to glyph identifiers, like
+// CT.FontRef, which the face uses to tell which runes the font has no glyph
+// for.
+type fontGlyphs interface {
+	GlyphsForRunes(runes []rune) []CT.GlyphID
+}
+
+// Options are the options of a Face, the zero value selects the defaults.
+type Options struct {
+	// SubpixelPositions is the number of horizontal positions within a pixel
+	// that glyphs are drawn at, glyphs are drawn at integer positions if it's
+	// 1. The default is 4.
+	SubpixelPositions int
+
+	// MaskCacheSize is the maximum number of glyph masks that the face keeps,
+	// the least recently used masks are discarded first. The default is 256,
+	// a negative value disables the cache.
+	MaskCacheSize int
+}
+
+const (
+	defaultSubpixelPositions = 4
+	defaultMaskCacheSize     = 256
+	maxSubpixelPositions     = 64
+)
+
+// Face is an implementation of the font.Face interface on top of a Font.
+//
+// Like all font.Face implementations a Face is not safe for concurrent use, the
+// masks returned by its Glyph method are reused by later calls.
+type Face struct {
+	font      Font
+	subpixels int
+	metrics   *font.Metrics
+	glyphs    map[rune]glyphMetrics
+	masks     *maskCache
+}
+
+// glyphMetrics are the metrics of the glyph of a rune, in points.
+type glyphMetrics struct {
+	advance CG.Float
+	bounds  CG.Rect
+	ok      bool
+}
+
+var _ font.Face = (*Face)(nil)
+
+// New returns a face that reads glyphs and metrics from f, opts may be nil to
+// use the default options.
+//
+// The face doesn't take ownership of the font, the program must keep it alive
+// while the face is used and release it after the face is closed.
+func New(f Font, opts *Options) *Face {
+	o := Options{}
+
+	if opts != nil {
+		o = *opts
+	}
+
+	switch {
+	case o.SubpixelPositions <= 0:
+		o.SubpixelPositions = defaultSubpixelPositions
+	case o.SubpixelPositions > maxSubpixelPositions:
+		o.SubpixelPositions = maxSubpixelPositions
+	}
+
+	switch {
+	case o.MaskCacheSize == 0:
+		o.MaskCacheSize = defaultMaskCacheSize
+	case o.MaskCacheSize < 0:
+		o.MaskCacheSize = 0
+	}
+
+	return &Face{
+		font:      f,
+		subpixels: o.SubpixelPositions,
+		glyphs:    make(map[rune]glyphMetrics),
+		masks:     newMaskCache(o.MaskCacheSize),
+	}
+}
+
+// Close satisfies the io.Closer interface, it discards the cached metrics and
+// masks but doesn't release the font.
+func (f *Face) Close() error {
+	f.metrics = nil
+	f.glyphs = make(map[rune]glyphMetrics)
+	f.masks.clear()
+	return nil
+}
+
+// Glyph satisfies the font.Face interface.
+func (f *Face) Glyph(dot fixed.Point26_6, r rune) (dr image.Rectangle, mask image.Image, maskp image.Point, advance fixed.Int26_6, ok bool) {
+	g := f.glyph(r)
+	x, subpixel := f.quantize(dot.X)
+	y := dot.Y.Round()
+
+	m, cached := f.masks.get(maskKey{r, subpixel})
+
+	if !cached {
+		m = f.draw(r, g, CG.Float(subpixel)/CG.Float(f.subpixels))
+		f.masks.put(maskKey{r, subpixel}, m)
+	}
+
+	dr = m.bounds.Add(image.Point{X: x, Y: y})
+	return dr, m.alpha, m.alpha.Rect.Min, toFixed(g.advance), g.ok
+}
+
+// GlyphBounds satisfies the font.Face interface.
+func (f *Face) GlyphBounds(r rune) (bounds fixed.Rectangle26_6, advance fixed.Int26_6, ok bool) {
+	g := f.glyph(r)
+	return toFixedRect(g.bounds), toFixed(g.advance), g.ok
+}
+
+// GlyphAdvance satisfies the font.Face interface.
+func (f *Face) GlyphAdvance(r rune) (advance fixed.Int26_6, ok bool) {
+	g := f.glyph(r)
+	return toFixed(g.advance), g.ok
+}
+
+// Kern satisfies the font.Face interface.
+func (f *Face) Kern(r0 rune, r1 rune) fixed.Int26_6 {
+	return toFixed(f.font.Kern(r0, r1))
+}
+
+// Metrics satisfies the font.Face interface.
+func (f *Face) Metrics() font.Metrics {
+	if f.metrics == nil {
+		ascent := f.font.GetAscent()
+		descent := f.font.GetDescent()
+		leading := f.font.GetLeading()
+
+		m := font.Metrics{
+			Height:     toFixed(ascent + descent + leading),
+			Ascent:     toFixed(ascent),
+			Descent:    toFixed(descent),
+			CaretSlope: image.Point{X: 0, Y: 1},
+		}
+
+		if h, ok := f.font.(fontHeights); ok {
+			m.XHeight = toFixed(h.GetXHeight())
+			m.CapHeight = toFixed(h.GetCapHeight())
+		}
+
+		f.metrics = &m
+	}
+	return *f.metrics
+}
+
+func (f *Face) glyph(r rune) glyphMetrics {
+	g, ok := f.glyphs[r]
+
+	if !ok {
+		g.advance, g.bounds = f.font.GlyphBounds(r)
+
+		// Fonts return a zero advance for glyphs that have no ink, like
+		// spaces, so it is read separately.
+		if g.advance == 0 {
+			g.advance = f.font.GlyphAdvance(r)
+		}
+
+		if m, ok := f.font.(fontGlyphs); ok {
+			g.ok = m.GlyphsForRunes([]rune{r})[0] != 0
+		} else {
+			g.ok = g.advance != 0 || !g.bounds.IsEmpty()
+		}
+
+		f.glyphs[r] = g
+	}
+
+	return g
+}
+
+// quantize splits the horizontal position x into the integer pixel position
+// and the index of the subpixel position that glyphs are drawn at.
+func (f *Face) quantize(x fixed.Int26_6) (pixel int, subpixel int) {
+	pixel = x.Floor()
+	subpixel = int(x-fixed.I(pixel)) * f.subpixels / 64
+	return
+}
+
+// draw renders the glyph of r, shifted horizontally by the fraction of pixel
+// offset, into a new mask.
+func (f *Face) draw(r rune, g glyphMetrics, offset CG.Float) *glyphMask {
+	bounds := CT.GlyphMaskBounds(g.bounds, offset)
+	alpha := image.NewAlpha(image.Rectangle{Max: bounds.Size()})
+
+	if !bounds.Empty() {
+		// The origin of the glyph is at the offset from the left edge of the
+		// mask, on the baseline which is at -bounds.Min.Y from the top.
+		f.font.GlyphDraw(r, CG.Point{
+			X: offset - CG.Float(bounds.Min.X),
+			Y: CG.Float(-bounds.Min.Y),
+		}, alpha)
+	}
+
+	return &glyphMask{alpha: alpha, bounds: bounds}
+}
@@ -0,0 +1,42 @@
+// +build darwin
+
+package face
+
+import (
+	"testing"
+
+	"github.com/go-vu/cocoa/CF"
+	"github.com/go-vu/cocoa/CT"
+	"golang.org/x/image/math/fixed"
+)
+
+var _ Font = CT.FontRef(0)
+
+func TestFaceFontRef(t *testing.T) {
+	s := CF.StringCreate("Monaco")
+	f := CT.FontCreateWithName(s, 12.0, nil)
+
+	defer s.Release()
+	defer f.Release()
+
+	face := New(f, nil)
+	defer face.Close()
+
+	if m := face.Metrics(); m.Ascent <= 0 || m.Descent <= 0 || m.Height < m.Ascent+m.Descent || m.XHeight <= 0 {
+		t.Errorf("invalid metrics: %+v", m)
+	}
+
+	if advance, ok := face.GlyphAdvance(' '); !ok || advance <= 0 {
+		t.Error("invalid advance of the space:", advance, ok)
+	}
+
+	dr, _, _, advance, ok := face.Glyph(fixed.P(10, 20), 'A')
+
+	if !ok || advance <= 0 || dr.Empty() {
+		t.Error("invalid glyph:", dr, advance, ok)
+	}
+
+	if _, ok := face.GlyphAdvance(0x10FFFF); ok {
+		t.Error("glyph found for a rune that the font doesn't have")
+	}
+}
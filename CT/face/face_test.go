@@ -0,0 +1,415 @@
+package face
+
+import (
+	"image"
+	"testing"
+
+	"github.com/go-vu/cocoa/CG"
+	"github.com/go-vu/cocoa/CT"
+	"github.com/go-vu/cocoa/CT/fontfile"
+	"golang.org/x/image/font"
+	"golang.org/x/image/math/fixed"
+)
+
+func TestFaceMetrics(t *testing.T) {
+	tests := []struct {
+		font    Font
+		metrics font.Metrics
+	}{
+		{
+			font: newFakeFont(),
+			metrics: font.Metrics{
+				Height:     1216,
+				Ascent:     909,
+				Descent:    243,
+				CaretSlope: image.Point{X: 0, Y: 1},
+			},
+		},
+		{
+			font: fakeMappedFont{newFakeFont()},
+			metrics: font.Metrics{
+				Height:     1216,
+				Ascent:     909,
+				Descent:    243,
+				XHeight:    480,
+				CapHeight:  768,
+				CaretSlope: image.Point{X: 0, Y: 1},
+			},
+		},
+	}
+
+	for _, test := range tests {
+		if m := New(test.font, nil).Metrics(); m != test.metrics {
+			t.Errorf("invalid metrics: %+v != %+v", m, test.metrics)
+		}
+	}
+}
+
+func TestFaceGlyphAdvance(t *testing.T) {
+	tests := []struct {
+		font    Font
+		r       rune
+		advance fixed.Int26_6
+		ok      bool
+	}{
+		{newFakeFont(), 'A', 640, true},
+		{newFakeFont(), '.', 211, true},
+		{newFakeFont(), ' ', 256, true},
+		{newFakeFont(), 'x', 0, false},
+		{fakeMappedFont{newFakeFont()}, 'A', 640, true},
+		{fakeMappedFont{newFakeFont()}, ' ', 256, false},
+		{fakeMappedFont{newFakeFont()}, 'x', 0, false},
+	}
+
+	for _, test := range tests {
+		if advance, ok := New(test.font, nil).GlyphAdvance(test.r); advance != test.advance || ok != test.ok {
+			t.Errorf("%q: invalid advance: %v, %t != %v, %t", test.r, advance, ok, test.advance, test.ok)
+		}
+	}
+}
+
+func TestFaceGlyphBounds(t *testing.T) {
+	tests := []struct {
+		r       rune
+		bounds  fixed.Rectangle26_6
+		advance fixed.Int26_6
+		ok      bool
+	}{
+		{'A', fixed.Rectangle26_6{Min: fixed.Point26_6{X: 32, Y: -768}, Max: fixed.Point26_6{X: 608, Y: 0}}, 640, true},
+		{'g', fixed.Rectangle26_6{Min: fixed.Point26_6{X: 64, Y: -448}, Max: fixed.Point26_6{X: 448, Y: 208}}, 512, true},
+		{'.', fixed.Rectangle26_6{Min: fixed.Point26_6{X: 64, Y: -96}, Max: fixed.Point26_6{X: 148, Y: 0}}, 211, true},
+		{' ', fixed.Rectangle26_6{}, 256, true},
+		{'x', fixed.Rectangle26_6{}, 0, false},
+	}
+
+	face := New(newFakeFont(), nil)
+
+	for _, test := range tests {
+		if bounds, advance, ok := face.GlyphBounds(test.r); bounds != test.bounds || advance != test.advance || ok != test.ok {
+			t.Errorf("%q: invalid bounds: %v, %v, %t != %v, %v, %t", test.r, bounds, advance, ok, test.bounds, test.advance, test.ok)
+		}
+	}
+}
+
+func TestFaceKern(t *testing.T) {
+	face := New(newFakeFont(), nil)
+
+	if kern := face.Kern('A', 'g'); kern != -80 {
+		t.Error("invalid kerning of the pair:", kern)
+	}
+
+	if kern := face.Kern('g', 'A'); kern != 0 {
+		t.Error("invalid kerning of the reversed pair:", kern)
+	}
+}
+
+func TestFaceGlyph(t *testing.T) {
+	f := newFakeFont()
+	face := New(f, nil)
+
+	dot := fixed.Point26_6{X: fixed.I(10) + 32, Y: fixed.I(20)}
+	dr, mask, maskp, advance, ok := face.Glyph(dot, 'A')
+
+	if !ok || advance != 640 {
+		t.Errorf("invalid advance: %v, %t", advance, ok)
+	}
+
+	// The glyph is drawn at the second of four subpixel positions, in a mask
+	// that has a margin of one pixel around its bounds.
+	if dr != image.Rect(10, 7, 21, 21) {
+		t.Error("invalid destination rectangle:", dr)
+	}
+
+	if f.origin != (CG.Point{X: 0.5, Y: 13}) {
+		t.Error("invalid origin of the glyph in the mask:", f.origin)
+	}
+
+	if mask.Bounds().Size() != dr.Size() {
+		t.Error("invalid size of the mask:", mask.Bounds())
+	}
+
+	// The ink of the glyph covers the pixels from 11 to 20 horizontally and
+	// from 8 to 20 vertically in the destination.
+	for _, test := range []struct {
+		p   image.Point
+		ink bool
+	}{
+		{image.Point{X: 10, Y: 7}, false},
+		{image.Point{X: 11, Y: 8}, true},
+		{image.Point{X: 19, Y: 19}, true},
+		{image.Point{X: 20, Y: 20}, false},
+	} {
+		_, _, _, a := mask.At(maskp.X+test.p.X-dr.Min.X, maskp.Y+test.p.Y-dr.Min.Y).RGBA()
+
+		if (a != 0) != test.ink {
+			t.Errorf("invalid coverage at %v: %#x", test.p, a)
+		}
+	}
+
+	// Glyphs without ink have an empty mask, but they still advance the dot.
+	dr, _, _, advance, ok = face.Glyph(dot, ' ')
+
+	if !dr.Empty() || advance != 256 || !ok {
+		t.Errorf("invalid glyph of the space: %v, %v, %t", dr, advance, ok)
+	}
+
+	if _, _, _, _, ok = face.Glyph(dot, 'x'); ok {
+		t.Error("glyph returned for a rune that the font has no glyph for")
+	}
+}
+
+func TestFaceGlyphCache(t *testing.T) {
+	f := newFakeFont()
+	face := New(f, nil)
+
+	glyph := func(x fixed.Int26_6, r rune) {
+		face.Glyph(fixed.Point26_6{X: x, Y: fixed.I(10)}, r)
+	}
+
+	tests := []struct {
+		x     fixed.Int26_6
+		r     rune
+		draws int
+	}{
+		{fixed.I(0), 'A', 1},
+		{fixed.I(0), 'A', 1},
+		{fixed.I(7), 'A', 1},     // same subpixel position at another pixel
+		{fixed.I(7) + 8, 'A', 1}, // rounded down to the same subpixel position
+		{fixed.I(7) + 16, 'A', 2},
+		{fixed.I(3) + 16, 'A', 2},
+		{fixed.I(0), 'g', 3},
+		{fixed.I(0), 'A', 3},
+	}
+
+	for i, test := range tests {
+		if glyph(test.x, test.r); f.draws != test.draws {
+			t.Errorf("#%d: invalid number of glyphs drawn: %d != %d", i, f.draws, test.draws)
+		}
+	}
+
+	if n := face.masks.len(); n != 3 {
+		t.Error("invalid number of cached masks:", n)
+	}
+
+	face.Close()
+
+	if n := face.masks.len(); n != 0 {
+		t.Error("masks are cached after the face was closed:", n)
+	}
+
+	if glyph(0, 'A'); f.draws != 4 {
+		t.Error("the glyph was not drawn again after the face was closed")
+	}
+}
+
+func TestFaceOptions(t *testing.T) {
+	tests := []struct {
+		opts      *Options
+		subpixels int
+		cache     int
+	}{
+		{nil, 4, 256},
+		{&Options{}, 4, 256},
+		{&Options{SubpixelPositions: 1, MaskCacheSize: 10}, 1, 10},
+		{&Options{SubpixelPositions: 1000}, 64, 256},
+		{&Options{SubpixelPositions: -1, MaskCacheSize: -1}, 4, 0},
+	}
+
+	for _, test := range tests {
+		face := New(newFakeFont(), test.opts)
+
+		if face.subpixels != test.subpixels || face.masks.size != test.cache {
+			t.Errorf("%+v: invalid options: %d subpixels, cache of %d masks", test.opts, face.subpixels, face.masks.size)
+		}
+	}
+
+	// Without subpixel positioning glyphs are drawn at integer positions, so
+	// a single mask is drawn for each rune.
+	f := newFakeFont()
+	face := New(f, &Options{SubpixelPositions: 1})
+
+	for x := fixed.Int26_6(0); x != 64; x += 8 {
+		face.Glyph(fixed.Point26_6{X: x}, 'A')
+	}
+
+	if f.draws != 1 {
+		t.Error("invalid number of glyphs drawn without subpixel positioning:", f.draws)
+	}
+
+	// With the cache disabled every call draws the glyph.
+	f = newFakeFont()
+	face = New(f, &Options{MaskCacheSize: -1})
+	face.Glyph(fixed.Point26_6{}, 'A')
+	face.Glyph(fixed.Point26_6{}, 'A')
+
+	if f.draws != 2 {
+		t.Error("invalid number of glyphs drawn without cache:", f.draws)
+	}
+}
+
+func TestFaceDrawer(t *testing.T) {
+	dst := image.NewAlpha(image.Rect(0, 0, 40, 20))
+	d := font.Drawer{
+		Dst:  dst,
+		Src:  image.Opaque,
+		Face: New(newFakeFont(), nil),
+		Dot:  fixed.P(2, 15),
+	}
+
+	if advance := d.MeasureString("Ag"); advance != 1072 {
+		t.Error("invalid advance of the string:", advance)
+	}
+
+	d.DrawString("Ag")
+
+	if d.Dot.X != fixed.I(2)+1072 {
+		t.Error("invalid position of the dot after drawing the string:", d.Dot.X)
+	}
+
+	if dst.AlphaAt(5, 10).A == 0 || dst.AlphaAt(14, 17).A == 0 {
+		t.Error("the string was not drawn")
+	}
+}
+
+func TestFaceFontFile(t *testing.T) {
+	f, err := fontfile.Open("../sfnt/testdata/CFFTest.otf", 0, 10)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	face := New(f, nil)
+
+	if m := face.Metrics(); m.Ascent != fixed.I(8) || m.Descent != 0 || m.Height != 570 || m.CapHeight != 508 {
+		t.Errorf("invalid metrics: %+v", m)
+	}
+
+	dst := image.NewAlpha(image.Rect(0, 0, 20, 12))
+	d := font.Drawer{
+		Dst:  dst,
+		Src:  image.Opaque,
+		Face: face,
+		Dot:  fixed.P(2, 10),
+	}
+
+	if advance := d.MeasureString("01"); advance != fixed.I(10) {
+		t.Error("invalid advance of the string:", advance)
+	}
+
+	if bounds, _ := d.BoundString("01"); bounds != fixed.R(3, 2, 11, 10) {
+		t.Error("invalid bounds of the string:", bounds)
+	}
+
+	d.DrawString("01")
+
+	// The stem of '1' is a rectangle from 9 to 11 on the x-axis and 2 to 10 on
+	// the y-axis.
+	if dst.AlphaAt(9, 5).A != 0xff || dst.AlphaAt(10, 9).A != 0xff || dst.AlphaAt(11, 5).A != 0 {
+		t.Error("the string was not drawn")
+	}
+}
+
+// fakeFont is a Font with fixed metrics, which draws glyphs as filled
+// rectangles matching their bounds.
+type fakeFont struct {
+	glyphs map[rune]fakeGlyph
+	kern   map[[2]rune]CG.Float
+	draws  int
+	origin CG.Point
+}
+
+type fakeGlyph struct {
+	advance CG.Float
+	bounds  CG.Rect
+}
+
+func newFakeFont() *fakeFont {
+	return &fakeFont{
+		glyphs: map[rune]fakeGlyph{
+			'A': {advance: 10, bounds: CG.RectMake(0.5, 0, 9, 12)},
+			'g': {advance: 8, bounds: CG.RectMake(1, -3.25, 6, 10.25)},
+			' ': {advance: 4, bounds: CG.RectNull},
+			'.': {advance: 3.3, bounds: CG.RectMake(1, 0, 1.3, 1.5)},
+		},
+		kern: map[[2]rune]CG.Float{
+			{'A', 'g'}: -1.25,
+		},
+	}
+}
+
+func (f *fakeFont) GetAscent() CG.Float  { return 14.2 }
+func (f *fakeFont) GetDescent() CG.Float { return 3.8 }
+func (f *fakeFont) GetLeading() CG.Float { return 1 }
+
+func (f *fakeFont) GlyphAdvance(char rune) CG.Float {
+	return f.glyphs[char].advance
+}
+
+// GlyphBounds mimics CT.FontRef, which returns a zero advance for glyphs that
+// have no ink.
+func (f *fakeFont) GlyphBounds(char rune) (CG.Float, CG.Rect) {
+	g, ok := f.glyphs[char]
+
+	if !ok || g.bounds.IsNull() {
+		return 0, CG.RectZero
+	}
+
+	return g.advance, g.bounds
+}
+
+func (f *fakeFont) Kern(char0 rune, char1 rune) CG.Float {
+	return f.kern[[2]rune{char0, char1}]
+}
+
+func (f *fakeFont) GlyphDraw(char rune, origin CG.Point, alpha *image.Alpha) bool {
+	g, ok := f.glyphs[char]
+
+	if !ok {
+		return false
+	}
+
+	f.draws++
+	f.origin = origin
+
+	if g.bounds.IsNull() {
+		return true
+	}
+
+	// The glyph rectangle is flipped from the Quartz space to the image space
+	// and rounded to the nearest pixels.
+	r := image.Rect(
+		int(origin.X+g.bounds.GetMinX()+0.5),
+		int(origin.Y-g.bounds.GetMaxY()+0.5),
+		int(origin.X+g.bounds.GetMaxX()+0.5),
+		int(origin.Y-g.bounds.GetMinY()+0.5),
+	).Intersect(alpha.Rect)
+
+	for y := r.Min.Y; y < r.Max.Y; y++ {
+		for x := r.Min.X; x < r.Max.X; x++ {
+			alpha.Pix[alpha.PixOffset(x, y)] = 0xFF
+		}
+	}
+
+	return true
+}
+
+// fakeMappedFont is a fakeFont which also maps runes to glyphs, the space is
+// mapped to the .notdef glyph.
+type fakeMappedFont struct {
+	*fakeFont
+}
+
+func (f fakeMappedFont) GlyphsForRunes(runes []rune) []CT.GlyphID {
+	glyphs := make([]CT.GlyphID, len(runes))
+
+	for i, r := range runes {
+		if _, ok := f.glyphs[r]; ok && r != ' ' {
+			glyphs[i] = CT.GlyphID(r)
+		}
+	}
+
+	return glyphs
+}
+
+func (f fakeMappedFont) GetXHeight() CG.Float   { return 7.5 }
+func (f fakeMappedFont) GetCapHeight() CG.Float { return 12 }
@@ -0,0 +1,37 @@
+package face
+
+import (
+	"math"
+
+	"github.com/go-vu/cocoa/CG"
+	"golang.org/x/image/math/fixed"
+)
+
+// toFixed converts a value in points to the nearest 26.6 fixed point value.
+func toFixed(x CG.Float) fixed.Int26_6 {
+	return fixed.Int26_6(math.Round(float64(x) * 64))
+}
+
+// toFixedRect converts a rectangle in the Quartz space, which has the y-axis
+// pointing up, to the smallest 26.6 fixed point rectangle that contains it in
+// the space of golang.org/x/image/font, which has the y-axis pointing down.
+//
+// Null and empty rectangles are converted to the zero rectangle.
+func toFixedRect(r CG.Rect) fixed.Rectangle26_6 {
+	if r.IsEmpty() {
+		return fixed.Rectangle26_6{}
+	}
+
+	return fixed.Rectangle26_6{
+		Min: fixed.Point26_6{X: floorFixed(r.GetMinX()), Y: floorFixed(-r.GetMaxY())},
+		Max: fixed.Point26_6{X: ceilFixed(r.GetMaxX()), Y: ceilFixed(-r.GetMinY())},
+	}
+}
+
+func floorFixed(x CG.Float) fixed.Int26_6 {
+	return fixed.Int26_6(math.Floor(float64(x) * 64))
+}
+
+func ceilFixed(x CG.Float) fixed.Int26_6 {
+	return fixed.Int26_6(math.Ceil(float64(x) * 64))
+}
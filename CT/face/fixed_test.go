@@ -0,0 +1,56 @@
+package face
+
+import (
+	"testing"
+
+	"github.com/go-vu/cocoa/CG"
+	"golang.org/x/image/math/fixed"
+)
+
+func TestToFixed(t *testing.T) {
+	tests := []struct {
+		x CG.Float
+		f fixed.Int26_6
+	}{
+		{0, 0},
+		{1, 64},
+		{-1, -64},
+		{0.5, 32},
+		{1.0 / 128, 1},
+		{1.0 / 256, 0},
+		{-1.0 / 128, -1},
+		{12.34, 790},
+	}
+
+	for _, test := range tests {
+		if f := toFixed(test.x); f != test.f {
+			t.Errorf("%v: invalid fixed point value: %v != %v", test.x, f, test.f)
+		}
+	}
+}
+
+func TestToFixedRect(t *testing.T) {
+	tests := []struct {
+		r CG.Rect
+		f fixed.Rectangle26_6
+	}{
+		{CG.RectNull, fixed.Rectangle26_6{}},
+		{CG.RectZero, fixed.Rectangle26_6{}},
+		{CG.RectMake(1, 2, 0, 5), fixed.Rectangle26_6{}},
+		{CG.RectMake(1, 2, 3, 4), fixed.R(1, -6, 4, -2)},
+		{CG.RectMake(1, -2, 3, 4), fixed.R(1, -2, 4, 2)},
+		{CG.RectMake(4, 2, -3, -4), fixed.R(1, -2, 4, 2)},
+
+		// The bounds are rounded outward to 1/64th of a point.
+		{CG.RectMake(0.001, -0.001, 0.998, 0.998), fixed.Rectangle26_6{
+			Min: fixed.Point26_6{X: 0, Y: -64},
+			Max: fixed.Point26_6{X: 64, Y: 1},
+		}},
+	}
+
+	for _, test := range tests {
+		if f := toFixedRect(test.r); f != test.f {
+			t.Errorf("%v: invalid fixed point rectangle: %v != %v", test.r, f, test.f)
+		}
+	}
+}
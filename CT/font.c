@@ -1,132 +1,154 @@
 // +build darwin
 
 #include "font.h"
-#include "kern.h"
-
-bool CTFontGlyphDraw__(CTFontRef font, UTF32Char character, CGPoint origin,
-                       UInt8 *buffer, size_t stride, size_t width,
-                       size_t height) {
-  bool ok = false;
-
-  CFStringRef string = CFStringCreateWithBytesNoCopy(
-      NULL, (const UInt8 *)&character, sizeof(character),
-      kCFStringEncodingUTF32LE, 0, kCFAllocatorNull);
-
-  UniChar unichars[4] = {0};
-  CGGlyph glyphs[4] = {0};
-  CFIndex length = CFStringGetLength(string);
-  CFStringGetCharacters(string, CFRangeMake(0, length), unichars);
-
-  if (CTFontGetGlyphsForCharacters(font, unichars, glyphs, length)) {
-    // The Quartz space has its origin in the bottom left corner, here we flip
-    // the coordinate system to draw make the origin the top-left corner.
-    origin.y = -(origin.y - height);
-
-    CGPoint positions[4] = {origin, origin, origin, origin};
-
-    for (CFIndex i = 1; i < length; ++i) {
-      CGFloat offset = 0.0;
-      CTFontGetLigatureCaretPositions(font, glyphs[i], &offset, 1);
-      positions[i].x = positions[i - 1].x + offset;
-    }
-
-    CGColorSpaceRef colors = CGColorSpaceCreateDeviceGray();
-    CGContextRef gc = CGBitmapContextCreateWithData(
-        buffer, width, height, 8, stride, colors, 0, NULL, NULL);
-
-    CGContextSetAllowsFontSubpixelPositioning(gc, true);
-    CGContextSetShouldSubpixelPositionFonts(gc, true);
-    CGContextSetGrayFillColor(gc, 1.0, 1.0);
-    CTFontDrawGlyphs(font, glyphs, positions, length, gc);
-
-    CGContextRelease(gc);
-    CGColorSpaceRelease(colors);
-    ok = true;
+
+// CGBitmapContextCreateGray__ creates a gray bitmap context drawing into the
+// buffer with the given render settings, and a white fill color. A negative
+// setting leaves the default of the context.
+static CGContextRef CGBitmapContextCreateGray__(
+    UInt8 *buffer, size_t stride, size_t width, size_t height, int antialias,
+    int smoothing, int subpixelPositioning, int subpixelQuantization) {
+  CGColorSpaceRef colors = CGColorSpaceCreateDeviceGray();
+  CGContextRef gc = CGBitmapContextCreateWithData(
+      buffer, width, height, 8, stride, colors, 0, NULL, NULL);
+  CGColorSpaceRelease(colors);
+
+  if (gc == NULL) {
+    return NULL;
   }
 
-  CFRelease(string);
-  return ok;
+  if (antialias >= 0) {
+    CGContextSetAllowsAntialiasing(gc, antialias);
+    CGContextSetShouldAntialias(gc, antialias);
+  }
+
+  if (smoothing >= 0) {
+    CGContextSetAllowsFontSmoothing(gc, smoothing);
+    CGContextSetShouldSmoothFonts(gc, smoothing);
+  }
+
+  if (subpixelPositioning >= 0) {
+    CGContextSetAllowsFontSubpixelPositioning(gc, subpixelPositioning);
+    CGContextSetShouldSubpixelPositionFonts(gc, subpixelPositioning);
+  }
+
+  if (subpixelQuantization >= 0) {
+    CGContextSetAllowsFontSubpixelQuantization(gc, subpixelQuantization);
+    CGContextSetShouldSubpixelQuantizeFonts(gc, subpixelQuantization);
+  }
+
+  CGContextSetGrayFillColor(gc, 1.0, 1.0);
+  return gc;
 }
 
-CGFloat CTFontGlyphAdvance__(CTFontRef font, UTF32Char character) {
-  CFStringRef string = CFStringCreateWithBytesNoCopy(
-      NULL, (const UInt8 *)&character, sizeof(character),
-      kCFStringEncodingUTF32LE, 0, kCFAllocatorNull);
+// CTLineCreateWithCharacters__ creates a line of text made of the characters
+// set in the font.
+static CTLineRef CTLineCreateWithCharacters__(CTFontRef font,
+                                              const UniChar *chars,
+                                              size_t count) {
+  CFStringRef string = CFStringCreateWithCharacters(NULL, chars, count);
 
-  UniChar unichars[4] = {0};
-  CGGlyph glyphs[4] = {0};
-  CGFloat advance = 0.0;
-  CFIndex length = CFStringGetLength(string);
-  CFStringGetCharacters(string, CFRangeMake(0, length), unichars);
+  if (string == NULL) {
+    return NULL;
+  }
 
-  if (CTFontGetGlyphsForCharacters(font, unichars, glyphs, length)) {
-    advance = CTFontGetAdvancesForGlyphs(font, 0, glyphs, NULL, length);
+  const void *keys[] = {kCTFontAttributeName};
+  const void *values[] = {font};
+  CFDictionaryRef attributes = CFDictionaryCreate(
+      NULL, keys, values, 1, &kCFTypeDictionaryKeyCallBacks,
+      &kCFTypeDictionaryValueCallBacks);
+  CFAttributedStringRef text =
+      CFAttributedStringCreate(NULL, string, attributes);
+  CTLineRef line = NULL;
+
+  if (text != NULL) {
+    line = CTLineCreateWithAttributedString(text);
+    CFRelease(text);
+  }
+
+  if (attributes != NULL) {
+    CFRelease(attributes);
   }
 
   CFRelease(string);
-  return advance;
+  return line;
 }
 
-CGFloat CTFontGlyphBounds__(CTFontRef font, UTF32Char character,
-                            CGRect *bounds) {
-  CFStringRef string = CFStringCreateWithBytesNoCopy(
-      NULL, (const UInt8 *)&character, sizeof(character),
-      kCFStringEncodingUTF32LE, 0, kCFAllocatorNull);
-
-  UniChar unichars[4] = {0};
-  CGGlyph glyphs[4] = {0};
-  CGFloat advance = 0.0;
-  CFIndex length = CFStringGetLength(string);
-  CFStringGetCharacters(string, CFRangeMake(0, length), unichars);
-
-  if (CTFontGetGlyphsForCharacters(font, unichars, glyphs, length)) {
-    CGRect rectangle =
-        CTFontGetBoundingRectsForGlyphs(font, 0, glyphs, NULL, length);
-
-    if (!CGRectIsNull(rectangle)) {
-      *bounds = rectangle;
-      advance = CTFontGetAdvancesForGlyphs(font, 0, glyphs, NULL, length);
-    }
+bool CTFontDrawGlyphs__(CTFontRef font, const CGGlyph *glyphs,
+                        const CGPoint *positions, size_t count,
+                        UInt8 *buffer, size_t stride, size_t width,
+                        size_t height, int antialias, int smoothing,
+                        int subpixelPositioning, int subpixelQuantization) {
+  CGContextRef gc = CGBitmapContextCreateGray__(
+      buffer, stride, width, height, antialias, smoothing, subpixelPositioning,
+      subpixelQuantization);
+
+  if (gc == NULL) {
+    return false;
   }
 
-  CFRelease(string);
-  return advance;
+  CTFontDrawGlyphs(font, glyphs, positions, count, gc);
+  CGContextRelease(gc);
+  return true;
 }
 
-CGFloat CTFontKern__(CTFontRef font, UTF32Char char0, UTF32Char char1) {
-  CFStringRef string0 = CFStringCreateWithBytesNoCopy(
-      NULL, (const UInt8 *)&char0, sizeof(char0), kCFStringEncodingUTF32LE, 0,
-      kCFAllocatorNull);
-
-  CFStringRef string1 = CFStringCreateWithBytesNoCopy(
-      NULL, (const UInt8 *)&char1, sizeof(char1), kCFStringEncodingUTF32LE, 0,
-      kCFAllocatorNull);
-
-  UniChar unichars0[4] = {0};
-  UniChar unichars1[4] = {0};
-  CFIndex length0 = CFStringGetLength(string0);
-  CFIndex length1 = CFStringGetLength(string1);
-  CFStringGetCharacters(string0, CFRangeMake(0, length0), unichars0);
-  CFStringGetCharacters(string1, CFRangeMake(0, length1), unichars1);
-
-  CFDataRef table = CTFontCopyTable(font, 'kern', kCTFontTableOptionNoOptions);
-  CGFloat kern = 0.0;
-
-  if (table != NULL) {
-    kern = CTFontKerningValueToPoints__(
-        font,
-        KernGet(CFDataGetBytePtr(table), unichars0[length0 - 1], unichars1[0]));
-    CFRelease(table);
+bool CTFontDrawCharacters__(CTFontRef font, const UniChar *chars, size_t count,
+                            CGPoint position, UInt8 *buffer, size_t stride,
+                            size_t width, size_t height, int antialias,
+                            int smoothing, int subpixelPositioning,
+                            int subpixelQuantization) {
+  CTLineRef line = CTLineCreateWithCharacters__(font, chars, count);
+
+  if (line == NULL) {
+    return false;
+  }
+
+  CGContextRef gc = CGBitmapContextCreateGray__(
+      buffer, stride, width, height, antialias, smoothing, subpixelPositioning,
+      subpixelQuantization);
+
+  if (gc == NULL) {
+    CFRelease(line);
+    return false;
   }
 
-  CFRelease(string1);
-  CFRelease(string0);
-  return kern;
+  CGContextSetTextPosition(gc, position.x, position.y);
+  CTLineDraw(line, gc);
+  CGContextRelease(gc);
+  CFRelease(line);
+  return true;
 }
 
-CGFloat CTFontKerningValueToPoints__(CTFontRef font, KernKerningValue kern) {
+CGFloat CTFontGetCharactersBounds__(CTFontRef font, const UniChar *chars,
+                                    size_t count, CGRect *bounds) {
+  CTLineRef line = CTLineCreateWithCharacters__(font, chars, count);
+
+  if (line == NULL) {
+    *bounds = CGRectNull;
+    return 0;
+  }
+
+  CGFloat advance = CTLineGetTypographicBounds(line, NULL, NULL, NULL);
+  *bounds = CTLineGetBoundsWithOptions(line, kCTLineBoundsUseGlyphPathBounds);
+  CFRelease(line);
+  return advance;
+}
+
+CGFloat CTFontUnitsToPoints__(CTFontRef font, CGFloat units) {
   const CGAffineTransform tm = CTFontGetMatrix(font);
   const CGFloat unit = CTFontGetUnitsPerEm(font);
   const CGFloat size = CTFontGetSize(font);
-  return (kern * size * tm.a) / unit;
+  return (units * size * tm.a) / unit;
+}
+
+void CTFontGetIdentity__(CTFontRef font, CFHashCode *hash,
+                         CFHashCode *nameHash, CGFloat *size) {
+  CFStringRef name = CTFontCopyPostScriptName(font);
+  *hash = CFHash(font);
+  *nameHash = name != NULL ? CFHash(name) : 0;
+  *size = CTFontGetSize(font);
+
+  if (name != NULL) {
+    CFRelease(name);
+  }
 }
@@ -9,29 +9,16 @@ package CT
 // #include "font.h"
 import "C"
 import (
+	"fmt"
 	"image"
+	"image/draw"
+	"unicode/utf16"
+	"unicode/utf8"
 	"unsafe"
 
 	"github.com/go-vu/cocoa/CF"
 	"github.com/go-vu/cocoa/CG"
-)
-
-// FontSymbolicTraits is an enumeration representing the style attributes of a font.
-//
-// https://developer.apple.com/library/mac/documentation/Carbon/Reference/CTFontDescriptorRef/#//apple_ref/c/tdef/CTFontSymbolicTraits
-type FontSymbolicTraits int
-
-// These constants are all the possible values of the FontSymbolicTraits
-// enumeration.
-const (
-	FontItalicTrait      FontSymbolicTraits = FontSymbolicTraits(C.kCTFontItalicTrait)
-	FontBoldTrait        FontSymbolicTraits = FontSymbolicTraits(C.kCTFontItalicTrait)
-	FontExpandedTrait    FontSymbolicTraits = FontSymbolicTraits(C.kCTFontItalicTrait)
-	FontCondensedTrait   FontSymbolicTraits = FontSymbolicTraits(C.kCTFontCondensedTrait)
-	FontMonoSpaceTrait   FontSymbolicTraits = FontSymbolicTraits(C.kCTFontCondensedTrait)
-	FontVerticalTrait    FontSymbolicTraits = FontSymbolicTraits(C.kCTFontVerticalTrait)
-	FontUIOptimizedTrait FontSymbolicTraits = FontSymbolicTraits(C.kCTFontUIOptimizedTrait)
-	FontClassMaskTrait   FontSymbolicTraits = FontSymbolicTraits(C.kCTFontClassMaskTrait)
+	"github.com/go-vu/cocoa/CT/sfnt"
 )
 
 // The FontRef type is an untyped reference to a Core Text font object.
@@ -39,9 +26,16 @@ const (
 // https://developer.apple.com/library/mac/documentation/Carbon/Reference/CTFontRef/#//apple_ref/c/tdef/CTFontRef
 type FontRef CF.TypeRef
 
+var _ FontSource = FontRef(0)
+
+var _ ClusterShaper = FontRef(0)
+
 // FontCreateWithName creates a new font object from a name, size and optional
 // affine transformation.
 //
+// See FontCreateWithNameE for a version that validates its arguments and
+// returns an error when the font cannot be created.
+//
 // https://developer.apple.com/library/mac/documentation/Carbon/Reference/CTFontRef/#//apple_ref/c/func/CTFontCreateWithName
 func FontCreateWithName(name CF.StringRef, size CG.Float, transform *CG.AffineTransform) FontRef {
 	return FontRef(unsafe.Pointer(C.CTFontCreateWithName(
@@ -51,6 +45,34 @@ func FontCreateWithName(name CF.StringRef, size CG.Float, transform *CG.AffineTr
 	)))
 }
 
+// FontCreateWithNameE is like FontCreateWithName but returns an error wrapping
+// ErrCreateFailed when the name is nil, the size is negative or not finite,
+// the transformation is not invertible, or when Core Text returns NULL.
+//
+// Note that Core Text falls back to a default font when no font matches the
+// name, so an unknown name isn't reported as an error.
+func FontCreateWithNameE(name CF.StringRef, size CG.Float, transform *CG.AffineTransform) (FontRef, error) {
+	if name == 0 {
+		return 0, fmt.Errorf("%w: nil font name", ErrCreateFailed)
+	}
+
+	if err := checkFontParameters(size, transform); err != nil {
+		return 0, err
+	}
+
+	f := FontRef(unsafe.Pointer(C.CTFontCreateWithName(
+		C.CFStringRef(unsafe.Pointer(name)),
+		C.CGFloat(size),
+		makeCGAffineTransform(transform),
+	)))
+
+	if f == 0 {
+		return 0, fmt.Errorf("%w: font %q of size %v", ErrCreateFailed, name.String(), size)
+	}
+
+	return f, nil
+}
+
 // FontCreateCopyWithSymbolicTraits makes a copy of an existing font object
 // but allows the program to change the affine transformation or the style
 // attributes of the font on the copy.
@@ -66,6 +88,14 @@ func FontCreateCopyWithSymbolicTraits(font FontRef, size CG.Float, transform *CG
 	)))
 }
 
+// GetSymbolicTraits returns the symbolic traits of the font, including its
+// stylistic class.
+//
+// https://developer.apple.com/library/mac/documentation/Carbon/Reference/CTFontRef/#//apple_ref/c/func/CTFontGetSymbolicTraits
+func (f FontRef) GetSymbolicTraits() FontSymbolicTraits {
+	return FontSymbolicTraits(C.CTFontGetSymbolicTraits(C.CTFontRef(unsafe.Pointer(f))))
+}
+
 // FontCopyPostScriptName returns a copy of the font's post-script name.
 //
 // https://developer.apple.com/library/mac/documentation/Carbon/Reference/CTFontRef/#//apple_ref/c/func/CTFontCopyPostScriptName
@@ -94,6 +124,13 @@ func (f FontRef) CopyFullName() CF.StringRef {
 	return CF.StringRef(unsafe.Pointer(C.CTFontCopyFullName(C.CTFontRef(unsafe.Pointer(f)))))
 }
 
+// GetSize returns the point size of the font.
+//
+// https://developer.apple.com/library/mac/documentation/Carbon/Reference/CTFontRef/#//apple_ref/c/func/CTFontGetSize
+func (f FontRef) GetSize() CG.Float {
+	return CG.Float(C.CTFontGetSize(C.CTFontRef(unsafe.Pointer(f))))
+}
+
 // FontGetAscent returns the ascent value of the font passed as argument.
 //
 // https://developer.apple.com/library/mac/documentation/Carbon/Reference/CTFontRef/#//apple_ref/c/func/CTFontGetAscent
@@ -115,20 +152,28 @@ func (f FontRef) GetLeading() CG.Float {
 	return CG.Float(C.CTFontGetLeading(C.CTFontRef(unsafe.Pointer(f))))
 }
 
+// FontGetXHeight returns the x-height value of the font passed as argument.
+//
+// https://developer.apple.com/library/mac/documentation/Carbon/Reference/CTFontRef/#//apple_ref/c/func/CTFontGetXHeight
+func (f FontRef) GetXHeight() CG.Float {
+	return CG.Float(C.CTFontGetXHeight(C.CTFontRef(unsafe.Pointer(f))))
+}
+
+// FontGetCapHeight returns the cap-height value of the font passed as
+// argument.
+//
+// https://developer.apple.com/library/mac/documentation/Carbon/Reference/CTFontRef/#//apple_ref/c/func/CTFontGetCapHeight
+func (f FontRef) GetCapHeight() CG.Float {
+	return CG.Float(C.CTFontGetCapHeight(C.CTFontRef(unsafe.Pointer(f))))
+}
+
 // FontGlyphDraw draws the font glyph representing the rune given as second
 // argument into the alpha image at the specified position.
 // The function returns true if the rune could be drawn, false otherwise, which
 // measn the font had no representation of the rune.
 func (f FontRef) GlyphDraw(char rune, origin CG.Point, alpha *image.Alpha) bool {
-	return bool(C.CTFontGlyphDraw__(
-		C.CTFontRef(unsafe.Pointer(f)),
-		C.UTF32Char(char),
-		makeCGPoint(origin),
-		(*C.UInt8)(unsafe.Pointer(&alpha.Pix[0])),
-		C.size_t(alpha.Stride),
-		C.size_t(alpha.Rect.Dx()),
-		C.size_t(alpha.Rect.Dy()),
-	))
+	glyph, ok := f.glyphForRune(char)
+	return ok && f.DrawGlyphs([]GlyphID{glyph}, []CG.Point{origin}, alpha)
 }
 
 // FontGlyphAdvance returns the 'advance' of the glyph representing the rune
@@ -136,7 +181,14 @@ func (f FontRef) GlyphDraw(char rune, origin CG.Point, alpha *image.Alpha) bool
 //
 // https://developer.apple.com/library/mac/documentation/TextFonts/Conceptual/CocoaTextArchitecture/TypoFeatures/TextSystemFeatures.html
 func (f FontRef) GlyphAdvance(char rune) CG.Float {
-	return CG.Float(C.CTFontGlyphAdvance__(C.CTFontRef(unsafe.Pointer(f)), C.UTF32Char(char)))
+	glyph, ok := f.glyphForRune(char)
+
+	if !ok {
+		return 0
+	}
+
+	_, advance := f.AdvancesForGlyphs([]GlyphID{glyph})
+	return advance
 }
 
 // FontGlyphBounds returns the 'advance' and 'bounds' of the glyph
@@ -144,17 +196,432 @@ func (f FontRef) GlyphAdvance(char rune) CG.Float {
 //
 // https://developer.apple.com/library/mac/documentation/TextFonts/Conceptual/CocoaTextArchitecture/TypoFeatures/TextSystemFeatures.html
 func (f FontRef) GlyphBounds(char rune) (advance CG.Float, bounds CG.Rect) {
-	b := C.CGRect{}
-	a := C.CTFontGlyphBounds__(C.CTFontRef(unsafe.Pointer(f)), C.UTF32Char(char), &b)
-	return CG.Float(a), makeRect(b)
+	glyph, ok := f.glyphForRune(char)
+
+	if !ok {
+		return
+	}
+
+	if _, bounds = f.BoundingRectsForGlyphs([]GlyphID{glyph}); bounds.IsNull() {
+		return 0, CG.RectZero
+	}
+
+	_, advance = f.AdvancesForGlyphs([]GlyphID{glyph})
+	return
+}
+
+// GlyphsForRunes returns the glyphs that the font maps the runes to, with one
+// glyph per rune. Runes that the font has no glyph for are mapped to glyph 0.
+//
+// https://developer.apple.com/library/mac/documentation/Carbon/Reference/CTFontRef/#//apple_ref/c/func/CTFontGetGlyphsForCharacters
+func (f FontRef) GlyphsForRunes(runes []rune) []GlyphID {
+	if cmap := f.tables().cmap(); cmap != nil {
+		glyphs := make([]GlyphID, len(runes))
+
+		for i, r := range runes {
+			glyphs[i], _ = cmap.Lookup(r)
+		}
+
+		return glyphs
+	}
+
+	if len(runes) == 0 {
+		return nil
+	}
+
+	chars := utf16.Encode(runes)
+	units := make([]GlyphID, len(chars))
+	C.CTFontGetGlyphsForCharacters(
+		C.CTFontRef(unsafe.Pointer(f)),
+		(*C.UniChar)(unsafe.Pointer(&chars[0])),
+		(*C.CGGlyph)(unsafe.Pointer(&units[0])),
+		C.CFIndex(len(chars)),
+	)
+	return glyphsForUTF16(runes, units)
+}
+
+// AdvancesForGlyphs returns the horizontal advances of the glyphs passed as
+// argument, and the sum of these advances.
+//
+// https://developer.apple.com/library/mac/documentation/Carbon/Reference/CTFontRef/#//apple_ref/c/func/CTFontGetAdvancesForGlyphs
+func (f FontRef) AdvancesForGlyphs(glyphs []GlyphID) (advances []CG.Size, total CG.Float) {
+	if len(glyphs) == 0 {
+		return nil, 0
+	}
+
+	advances = make([]CG.Size, len(glyphs))
+	total = CG.Float(C.CTFontGetAdvancesForGlyphs(
+		C.CTFontRef(unsafe.Pointer(f)),
+		C.kCTFontOrientationHorizontal,
+		(*C.CGGlyph)(unsafe.Pointer(&glyphs[0])),
+		(*C.CGSize)(unsafe.Pointer(&advances[0])),
+		C.CFIndex(len(glyphs)),
+	))
+	return
+}
+
+// BoundingRectsForGlyphs returns the bounding rectangles of the glyphs passed
+// as argument, and the union of these rectangles.
+//
+// The rectangles are in the Quartz space, relative to the origin of each
+// glyph. Glyphs that have no ink, like spaces, have a null bounding rectangle.
+//
+// https://developer.apple.com/library/mac/documentation/Carbon/Reference/CTFontRef/#//apple_ref/c/func/CTFontGetBoundingRectsForGlyphs
+func (f FontRef) BoundingRectsForGlyphs(glyphs []GlyphID) (rects []CG.Rect, overall CG.Rect) {
+	if len(glyphs) == 0 {
+		return nil, CG.RectNull
+	}
+
+	rects = make([]CG.Rect, len(glyphs))
+	overall = makeRect(C.CTFontGetBoundingRectsForGlyphs(
+		C.CTFontRef(unsafe.Pointer(f)),
+		C.kCTFontOrientationHorizontal,
+		(*C.CGGlyph)(unsafe.Pointer(&glyphs[0])),
+		(*C.CGRect)(unsafe.Pointer(&rects[0])),
+		C.CFIndex(len(glyphs)),
+	))
+	return
+}
+
+// DrawGlyphs draws the glyphs at the given positions into the alpha image, all
+// the glyphs are drawn with a single call to Core Text.
+//
+// The positions are in the coordinate space of the image, which has its origin
+// in the top-left corner, see GlyphPositions to compute them from the advances
+// of the glyphs. The method panics if there isn't one position per glyph, and
+// returns false if the image could not be drawn into.
+//
+// The glyphs are drawn with the default render options, see
+// DrawGlyphsWithOptions to configure them.
+//
+// https://developer.apple.com/library/mac/documentation/Carbon/Reference/CTFontRef/#//apple_ref/c/func/CTFontDrawGlyphs
+func (f FontRef) DrawGlyphs(glyphs []GlyphID, positions []CG.Point, alpha *image.Alpha) bool {
+	return f.DrawGlyphsWithOptions(glyphs, positions, alpha, nil) == nil
+}
+
+// DrawGlyphsWithOptions is like DrawGlyphs but rasterizes the glyphs with the
+// given render options, nil selecting the defaults. The positions are aligned
+// to the pixel grid with RenderOptions.AlignPositions before being passed to
+// Core Text.
+//
+// The method returns an error wrapping ErrInvalidRenderOptions if the options
+// are invalid, or ErrDrawFailed if the image could not be drawn into.
+func (f FontRef) DrawGlyphsWithOptions(glyphs []GlyphID, positions []CG.Point, alpha *image.Alpha, opts *RenderOptions) error {
+	checkGlyphSlices(len(glyphs), len(positions))
+	o, err := renderOptions(opts)
+
+	switch {
+	case err != nil:
+		return err
+	case len(glyphs) == 0:
+		return nil
+	case len(alpha.Pix) == 0:
+		return fmt.Errorf("%w: empty image", ErrDrawFailed)
+	}
+
+	// The Quartz space has its origin in the bottom left corner, here we flip
+	// the coordinate system to make the origin the top-left corner.
+	positions = flipPositions(o.AlignPositions(positions), alpha.Rect.Dy())
+	settings := opts.contextSettings()
+
+	ok := C.CTFontDrawGlyphs__(
+		C.CTFontRef(unsafe.Pointer(f)),
+		(*C.CGGlyph)(unsafe.Pointer(&glyphs[0])),
+		(*C.CGPoint)(unsafe.Pointer(&positions[0])),
+		C.size_t(len(glyphs)),
+		(*C.UInt8)(unsafe.Pointer(&alpha.Pix[0])),
+		C.size_t(alpha.Stride),
+		C.size_t(alpha.Rect.Dx()),
+		C.size_t(alpha.Rect.Dy()),
+		C.int(settings.antialias),
+		C.int(settings.smoothing),
+		C.int(settings.subpixelPositioning),
+		C.int(settings.subpixelQuantization),
+	)
+
+	if !ok {
+		return fmt.Errorf("%w: the bitmap context could not be created", ErrDrawFailed)
+	}
+
+	return nil
+}
+
+// DrawGlyphsImage draws the glyphs at the given positions into a color image,
+// with the color, compositing operator and clip rectangle of the options which
+// may be nil to use the defaults.
+//
+// The glyphs are rasterized by Core Text into a coverage mask which is
+// composited into the image in Go, see the DrawGlyphsImage function for
+// details.
+func (f FontRef) DrawGlyphsImage(glyphs []GlyphID, positions []CG.Point, dst draw.Image, opts *DrawOptions) bool {
+	return DrawGlyphsImage(f, glyphs, positions, dst, opts)
+}
+
+// GlyphDrawImage is like GlyphDraw but draws into a color image, see
+// DrawGlyphsImage for details.
+func (f FontRef) GlyphDrawImage(char rune, origin CG.Point, dst draw.Image, opts *DrawOptions) bool {
+	glyph, ok := f.glyphForRune(char)
+	return ok && f.DrawGlyphsImage([]GlyphID{glyph}, []CG.Point{origin}, dst, opts)
+}
+
+// DrawString draws the string into the alpha image, starting at the given
+// position, and returns the position where the next glyph would be drawn.
+//
+// The runes of the string are mapped to glyphs, positioned with their advances
+// and the kerning of the font, and drawn with a single call to Core Text.
+func (f FontRef) DrawString(s string, origin CG.Point, alpha *image.Alpha) CG.Point {
+	glyphs, advances := f.layoutString(s)
+	positions := GlyphPositions(origin, advances)
+	f.DrawGlyphs(glyphs, positions, alpha)
+	origin.X += totalAdvance(advances)
+	return origin
+}
+
+// ClusterDraw draws the grapheme cluster into the alpha image at the given
+// position, with the default render options. It returns false if the cluster
+// is empty or if the image could not be drawn into.
+//
+// Clusters of a single rune that the font has a glyph for are drawn with
+// DrawGlyphs, other clusters are shaped by Core Text as a line of text, which
+// substitutes the ligatures of the font, positions the combining marks and
+// falls back to other fonts for the characters that the font has no glyph
+// for, like emoji.
+//
+// https://developer.apple.com/documentation/coretext/ctlinedraw(_:_:)
+func (f FontRef) ClusterDraw(cluster string, origin CG.Point, alpha *image.Alpha) bool {
+	return len(cluster) != 0 && f.ClusterDrawWithOptions(cluster, origin, alpha, nil) == nil
+}
+
+// ClusterDrawWithOptions is like ClusterDraw but rasterizes the cluster with
+// the given render options, nil selecting the defaults. The origin of the
+// cluster is aligned to the pixel grid with RenderOptions.AlignPositions.
+//
+// The method returns an error wrapping ErrInvalidRenderOptions if the options
+// are invalid, or ErrDrawFailed if the cluster could not be drawn.
+func (f FontRef) ClusterDrawWithOptions(cluster string, origin CG.Point, alpha *image.Alpha, opts *RenderOptions) error {
+	chars := utf16.Encode([]rune(cluster))
+	o, err := renderOptions(opts)
+
+	switch {
+	case err != nil:
+		return err
+	case len(chars) == 0:
+		return nil
+	case len(alpha.Pix) == 0:
+		return fmt.Errorf("%w: empty image", ErrDrawFailed)
+	}
+
+	if glyph, ok := f.clusterGlyph(cluster); ok {
+		return f.DrawGlyphsWithOptions([]GlyphID{glyph}, []CG.Point{origin}, alpha, opts)
+	}
+
+	position := flipPositions(o.AlignPositions([]CG.Point{origin}), alpha.Rect.Dy())[0]
+	settings := opts.contextSettings()
+
+	ok := C.CTFontDrawCharacters__(
+		C.CTFontRef(unsafe.Pointer(f)),
+		(*C.UniChar)(unsafe.Pointer(&chars[0])),
+		C.size_t(len(chars)),
+		makeCGPoint(position),
+		(*C.UInt8)(unsafe.Pointer(&alpha.Pix[0])),
+		C.size_t(alpha.Stride),
+		C.size_t(alpha.Rect.Dx()),
+		C.size_t(alpha.Rect.Dy()),
+		C.int(settings.antialias),
+		C.int(settings.smoothing),
+		C.int(settings.subpixelPositioning),
+		C.int(settings.subpixelQuantization),
+	)
+
+	if !ok {
+		return fmt.Errorf("%w: the cluster %q could not be shaped or drawn", ErrDrawFailed, cluster)
+	}
+
+	return nil
+}
+
+// ClusterBounds returns the advance and bounds of the grapheme cluster as
+// ClusterDraw draws it, the clusters of a single rune that the font has a
+// glyph for are measured with the glyph methods of the font, and the other
+// ones are shaped by Core Text. The bounds are in the Quartz space, relative
+// to the origin of the cluster, and are CG.RectNull if the cluster has no ink.
+//
+// https://developer.apple.com/documentation/coretext/ctlinegetboundswithoptions(_:_:)
+func (f FontRef) ClusterBounds(cluster string) (advance CG.Float, bounds CG.Rect) {
+	if glyph, ok := f.clusterGlyph(cluster); ok {
+		glyphs := []GlyphID{glyph}
+		_, advance = f.AdvancesForGlyphs(glyphs)
+
+		if _, bounds = f.BoundingRectsForGlyphs(glyphs); bounds.IsEmpty() {
+			bounds = CG.RectNull
+		}
+
+		return
+	}
+
+	chars := utf16.Encode([]rune(cluster))
+
+	if len(chars) == 0 {
+		return 0, CG.RectNull
+	}
+
+	var rect C.CGRect
+	advance = CG.Float(C.CTFontGetCharactersBounds__(
+		C.CTFontRef(unsafe.Pointer(f)),
+		(*C.UniChar)(unsafe.Pointer(&chars[0])),
+		C.size_t(len(chars)),
+		&rect,
+	))
+
+	if bounds = makeRect(rect); bounds.IsEmpty() {
+		bounds = CG.RectNull
+	}
+
+	return
+}
+
+// clusterGlyph returns the glyph of the cluster if it's made of a single rune
+// that the font has a glyph for, which Core Text would lay out as the glyph
+// alone, the boolean is false otherwise.
+//
+// Clusters of several runes are left to Core Text, apart from "\r\n" all of
+// them combine their runes: marks are positioned over their base, and joiners,
+// variation selectors and regional indicators select ligatures.
+func (f FontRef) clusterGlyph(cluster string) (GlyphID, bool) {
+	r, n := utf8.DecodeRuneInString(cluster)
+
+	if n == 0 || n != len(cluster) {
+		return 0, false
+	}
+
+	return f.glyphForRune(r)
+}
+
+// StringAdvance returns the horizontal advance of the string, including the
+// kerning of the pairs of glyphs.
+func (f FontRef) StringAdvance(s string) CG.Float {
+	_, advances := f.layoutString(s)
+	return totalAdvance(advances)
+}
+
+// layoutString returns the glyphs of the string and their kerned advances.
+func (f FontRef) layoutString(s string) (glyphs []GlyphID, advances []CG.Size) {
+	runes := []rune(s)
+	glyphs = f.GlyphsForRunes(runes)
+	advances, _ = f.AdvancesForGlyphs(glyphs)
+
+	if len(glyphs) < 2 {
+		return
+	}
+
+	// The conversion from font units to points is linear, the scale is
+	// computed once instead of crossing cgo for each pair.
+	kerner := f.kerner(sfnt.ScriptForRunes(runes))
+	scale := f.unitsToPoints(1)
+	kerning := make([]CG.Float, len(glyphs)-1)
+
+	for i := range kerning {
+		kerning[i] = scale * CG.Float(kerner.Kern(glyphs[i], glyphs[i+1]))
+	}
+
+	return glyphs, kernAdvances(advances, kerning)
 }
 
 // FontKern returns the ideal spacing to leave between the two characters
 // passed as argument.
 //
+// The kerning value is read from the 'kern' feature of the font's 'GPOS' table
+// for the script of the characters, or from the legacy 'kern' table if the
+// font has no such feature, looking up the pair of glyphs that the characters
+// are mapped to.
+//
 // https://developer.apple.com/library/mac/documentation/TextFonts/Conceptual/CocoaTextArchitecture/TypoFeatures/TextSystemFeatures.html
 func (f FontRef) Kern(char0 rune, char1 rune) CG.Float {
-	return CG.Float(C.CTFontKern__(C.CTFontRef(unsafe.Pointer(f)), C.UTF32Char(char0), C.UTF32Char(char1)))
+	g0, ok0 := f.glyphForRune(char0)
+	g1, ok1 := f.glyphForRune(char1)
+
+	if !ok0 || !ok1 {
+		return 0
+	}
+
+	script := sfnt.ScriptForRunes([]rune{char0, char1})
+	return f.unitsToPoints(f.kerner(script).Kern(g0, g1))
+}
+
+// kerner returns the source of kerning values of the font for the given
+// script, the returned value is never nil.
+func (f FontRef) kerner(script string) sfnt.Kerner {
+	return f.tables().kerner(script)
+}
+
+// copyTable returns a copy of the font table with the given tag, or nil if the
+// font has no such table.
+//
+// https://developer.apple.com/library/mac/documentation/Carbon/Reference/CTFontRef/#//apple_ref/c/func/CTFontCopyTable
+func (f FontRef) copyTable(tag string) []byte {
+	table := C.CTFontCopyTable(
+		C.CTFontRef(unsafe.Pointer(f)),
+		C.CTFontTableTag(uint32(tag[0])<<24|uint32(tag[1])<<16|uint32(tag[2])<<8|uint32(tag[3])),
+		C.kCTFontTableOptionNoOptions,
+	)
+
+	if table == 0 {
+		return nil
+	}
+
+	defer C.CFRelease(C.CFTypeRef(table))
+	return C.GoBytes(unsafe.Pointer(C.CFDataGetBytePtr(table)), C.int(C.CFDataGetLength(table)))
+}
+
+// CopyCmap returns the parsed 'cmap' table of the font, which maps runes to
+// the glyphs that represent them.
+//
+// The table is copied and parsed on each call, the methods of the font that
+// map runes to glyphs use a table that is parsed once per font.
+//
+// https://developer.apple.com/library/mac/documentation/Carbon/Reference/CTFontRef/#//apple_ref/c/func/CTFontCopyTable
+func (f FontRef) CopyCmap() (*sfnt.Cmap, error) {
+	return sfnt.ParseCmap(f.copyTable("cmap"))
+}
+
+// glyphForRune returns the glyph that the font maps the rune to, the boolean
+// is false if the font has no glyph for the rune.
+//
+// The glyph is looked up in the 'cmap' table of the font, Core Text is only
+// queried for fonts that have no such table or a table that cannot be parsed.
+func (f FontRef) glyphForRune(char rune) (sfnt.GlyphID, bool) {
+	if cmap := f.tables().cmap(); cmap != nil {
+		return cmap.Lookup(char)
+	}
+
+	chars := utf16.Encode([]rune{char})
+	glyphs := [2]C.CGGlyph{}
+	ok := C.CTFontGetGlyphsForCharacters(
+		C.CTFontRef(unsafe.Pointer(f)),
+		(*C.UniChar)(unsafe.Pointer(&chars[0])),
+		&glyphs[0],
+		C.CFIndex(len(chars)),
+	)
+	return sfnt.GlyphID(glyphs[0]), bool(ok)
+}
+
+// unitsToPoints converts a value in font units to points, at the size and
+// with the transformation of the font.
+func (f FontRef) unitsToPoints(units int) CG.Float {
+	return CG.Float(C.CTFontUnitsToPoints__(C.CTFontRef(unsafe.Pointer(f)), C.CGFloat(units)))
+}
+
+// identity returns the values that tell the font apart from another font
+// created later at the same address.
+func (f FontRef) identity() fontIdentity {
+	var hash, nameHash C.CFHashCode
+	var size C.CGFloat
+	C.CTFontGetIdentity__(C.CTFontRef(unsafe.Pointer(f)), &hash, &nameHash, &size)
+	return fontIdentity{
+		hash:     uint64(hash),
+		nameHash: uint64(nameHash),
+		size:     CG.Float(size),
+	}
 }
 
 // Retain increases the refence counter of the Core Text font passed
@@ -168,8 +635,11 @@ func (f FontRef) Retain() {
 // Release decreases the reference counter of the Core Text font
 // passed as argument.
 //
+// The font tables that were parsed and cached for the font are dropped.
+//
 // https://developer.apple.com/library/mac/documentation/CoreFoundation/Reference/CFTypeRef/#//apple_ref/c/func/CFRelease
 func (f FontRef) Release() {
+	f.dropTables()
 	CF.TypeRef(f).Release()
 }
 
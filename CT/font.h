@@ -4,17 +4,24 @@
 #include <CoreGraphics/CoreGraphics.h>
 #include <CoreText/CoreText.h>
 
-bool CTFontGlyphDraw__(CTFontRef font, UTF32Char character, CGPoint origin,
-                       UInt8 *buffer, size_t stride, size_t width,
-                       size_t height);
+bool CTFontDrawGlyphs__(CTFontRef font, const CGGlyph *glyphs,
+                        const CGPoint *positions, size_t count,
+                        UInt8 *buffer, size_t stride, size_t width,
+                        size_t height, int antialias, int smoothing,
+                        int subpixelPositioning, int subpixelQuantization);
 
-CGFloat CTFontGlyphAdvance__(CTFontRef font, UTF32Char character);
+bool CTFontDrawCharacters__(CTFontRef font, const UniChar *chars, size_t count,
+                            CGPoint position, UInt8 *buffer, size_t stride,
+                            size_t width, size_t height, int antialias,
+                            int smoothing, int subpixelPositioning,
+                            int subpixelQuantization);
 
-CGFloat CTFontGlyphBounds__(CTFontRef font, UTF32Char character,
-                            CGRect *bounds);
+CGFloat CTFontGetCharactersBounds__(CTFontRef font, const UniChar *chars,
+                                    size_t count, CGRect *bounds);
 
-CGFloat CTFontKern__(CTFontRef font, UTF32Char from, UTF32Char to);
+CGFloat CTFontUnitsToPoints__(CTFontRef font, CGFloat units);
 
-CGFloat CTFontKerningValueToPoints__(CTFontRef font, KernKerningValue kern);
+void CTFontGetIdentity__(CTFontRef font, CFHashCode *hash,
+                         CFHashCode *nameHash, CGFloat *size);
 
 #endif /* GOVU_COCOA_FONT_H */
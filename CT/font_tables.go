@@ -0,0 +1,111 @@
+// +build darwin
+
+package CT
+
+import (
+	"sync"
+
+	"github.com/go-vu/cocoa/CG"
+	"github.com/go-vu/cocoa/CT/sfnt"
+)
+
+// fontTables holds the tables of a font that FontRef parses to map runes to
+// glyphs and to look up the kerning of pairs of glyphs. Copying and parsing
+// the tables is expensive, so they are parsed once per font and kept until the
+// font is released.
+type fontTables struct {
+	font     FontRef
+	identity fontIdentity
+
+	cmapOnce  sync.Once
+	cmapTable *sfnt.Cmap
+
+	kernOnce sync.Once
+	gpos     *sfnt.GPOSTable
+	kern     *sfnt.KernTable
+
+	mutex   sync.Mutex
+	kerners map[string]sfnt.Kerner
+}
+
+// fontIdentity holds the hashes of a font and of its PostScript name, and its
+// size, which tell apart the fonts that Core Text creates at the same address.
+type fontIdentity struct {
+	hash     uint64
+	nameHash uint64
+	size     CG.Float
+}
+
+// fontTablesCache maps the fonts to their *fontTables. Entries are removed by
+// FontRef.Release, but fonts may be released in other ways, so the entries
+// also record the identity of their font and are replaced when the address of
+// a released font is reused for another font.
+var fontTablesCache sync.Map
+
+// tables returns the cached tables of the font.
+func (f FontRef) tables() *fontTables {
+	identity := f.identity()
+
+	for {
+		v, ok := fontTablesCache.Load(f)
+
+		if ok && v.(*fontTables).identity == identity {
+			return v.(*fontTables)
+		}
+
+		t := &fontTables{font: f, identity: identity}
+
+		if !ok {
+			if _, loaded := fontTablesCache.LoadOrStore(f, t); !loaded {
+				return t
+			}
+		} else if fontTablesCache.CompareAndSwap(f, v, t) {
+			return t
+		}
+	}
+}
+
+// dropTables removes the cached tables of the font.
+func (f FontRef) dropTables() {
+	fontTablesCache.Delete(f)
+}
+
+// cmap returns the 'cmap' table of the font, or nil if the font has no such
+// table or if it cannot be parsed.
+func (t *fontTables) cmap() *sfnt.Cmap {
+	t.cmapOnce.Do(func() {
+		if cmap, err := t.font.CopyCmap(); err == nil {
+			t.cmapTable = cmap
+		}
+	})
+
+	return t.cmapTable
+}
+
+// kerner returns the source of kerning values of the font for the given
+// script, the kerners are cached since looking up the kerning feature of the
+// 'GPOS' table is expensive.
+func (t *fontTables) kerner(script string) sfnt.Kerner {
+	t.kernOnce.Do(func() {
+		// The parsers return nil tables for fonts that don't have them or
+		// when they cannot be parsed, which NewKerner accepts.
+		t.gpos, _ = sfnt.ParseGPOS(t.font.copyTable("GPOS"))
+		t.kern, _ = sfnt.ParseKern(t.font.copyTable("kern"))
+	})
+
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+
+	k, ok := t.kerners[script]
+
+	if !ok {
+		if t.kerners == nil {
+			t.kerners = make(map[string]sfnt.Kerner)
+		}
+
+		k = sfnt.NewKerner(t.gpos, t.kern, script)
+		t.kerners[script] = k
+	}
+
+	return k
+}
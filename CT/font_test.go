@@ -3,9 +3,13 @@
 package CT
 
 import (
+	"errors"
+	"image"
+	"image/color"
 	"testing"
 
 	"github.com/go-vu/cocoa/CF"
+	"github.com/go-vu/cocoa/CG"
 )
 
 func TestFontCreateWithName(t *testing.T) {
@@ -20,6 +24,27 @@ func TestFontCreateWithName(t *testing.T) {
 	}
 }
 
+func TestFontCreateWithNameE(t *testing.T) {
+	s := CF.StringCreate("Monaco")
+	defer s.Release()
+
+	f, err := FontCreateWithNameE(s, 12.0, nil)
+
+	if err != nil {
+		t.Error(err)
+	} else {
+		f.Release()
+	}
+
+	if _, err := FontCreateWithNameE(0, 12.0, nil); !errors.Is(err, ErrCreateFailed) {
+		t.Error("invalid error returned for a nil font name:", err)
+	}
+
+	if _, err := FontCreateWithNameE(s, -1.0, nil); !errors.Is(err, ErrCreateFailed) {
+		t.Error("invalid error returned for a negative font size:", err)
+	}
+}
+
 func TestFontCreateCopyWithSymbolicTraits(t *testing.T) {
 	s := CF.StringCreate("Monaco")
 	f := FontCreateWithName(s, 12.0, nil)
@@ -34,6 +59,25 @@ func TestFontCreateCopyWithSymbolicTraits(t *testing.T) {
 	}
 }
 
+func TestFontGetSymbolicTraits(t *testing.T) {
+	s := CF.StringCreate("Helvetica-Bold")
+	f := FontCreateWithName(s, 12.0, nil)
+
+	defer s.Release()
+	defer f.Release()
+
+	if traits := f.GetSymbolicTraits(); !traits.Has(FontBoldTrait) || traits.Has(FontItalicTrait) {
+		t.Error("invalid symbolic traits of Helvetica-Bold:", traits)
+	}
+
+	g := FontCreateCopyWithSymbolicTraits(f, 0.0, nil, FontItalicTrait, FontItalicTrait)
+	defer g.Release()
+
+	if traits := g.GetSymbolicTraits(); !traits.Has(FontBoldTrait | FontItalicTrait) {
+		t.Error("invalid symbolic traits of Helvetica-BoldOblique:", traits)
+	}
+}
+
 func TestFontCopyPostScriptName(t *testing.T) {
 	s := CF.StringCreate("Monaco")
 	f := FontCreateWithName(s, 12.0, nil)
@@ -45,3 +89,364 @@ func TestFontCopyPostScriptName(t *testing.T) {
 		t.Errorf("invalid post-script name:", name)
 	}
 }
+
+func TestFontKern(t *testing.T) {
+	s := CF.StringCreate("Monaco")
+	f := FontCreateWithName(s, 12.0, nil)
+
+	defer s.Release()
+	defer f.Release()
+
+	// Monaco is a monospaced font, it has no kerning.
+	if kern := f.Kern('A', 'V'); kern != 0 {
+		t.Error("invalid kerning of a monospaced font:", kern)
+	}
+}
+
+func TestFontKernPair(t *testing.T) {
+	s := CF.StringCreate("TimesNewRomanPSMT")
+	f := FontCreateWithName(s, 12.0, nil)
+
+	defer s.Release()
+	defer f.Release()
+
+	if kern := f.Kern('A', 'V'); kern >= 0 {
+		t.Error("invalid kerning of the AV pair:", kern)
+	}
+}
+
+func TestFontTablesCache(t *testing.T) {
+	s := CF.StringCreate("TimesNewRomanPSMT")
+	f := FontCreateWithName(s, 12.0, nil)
+	defer s.Release()
+
+	kern := f.Kern('A', 'V')
+	tables := f.tables()
+
+	if f.Kern('A', 'V') != kern || f.tables() != tables || len(tables.kerners) != 1 {
+		t.Error("the kerning tables of the font were not cached")
+	}
+
+	if cmap := tables.cmap(); cmap == nil || tables.cmap() != cmap {
+		t.Error("the 'cmap' table of the font was not cached")
+	}
+
+	f.Release()
+
+	if _, ok := fontTablesCache.Load(f); ok {
+		t.Error("the tables of a released font are still cached")
+	}
+}
+
+func TestFontTablesIdentity(t *testing.T) {
+	s1 := CF.StringCreate("TimesNewRomanPSMT")
+	s2 := CF.StringCreate("Monaco")
+	f1 := FontCreateWithName(s1, 12.0, nil)
+	f2 := FontCreateWithName(s2, 12.0, nil)
+
+	defer s1.Release()
+	defer s2.Release()
+	defer f1.Release()
+	defer f2.Release()
+
+	if f1.identity() == f2.identity() {
+		t.Fatal("different fonts have the same identity")
+	}
+
+	// The tables of a font released without FontRef.Release stay cached, and
+	// must not be used for the next font created at the same address.
+	stale := &fontTables{font: f1, identity: f2.identity()}
+	fontTablesCache.Store(f1, stale)
+
+	if tables := f1.tables(); tables == stale || tables.identity != f1.identity() {
+		t.Error("the cached tables of another font were used")
+	}
+}
+
+func TestFontCopyCmap(t *testing.T) {
+	s := CF.StringCreate("Monaco")
+	f := FontCreateWithName(s, 12.0, nil)
+
+	defer s.Release()
+	defer f.Release()
+
+	cmap, err := f.CopyCmap()
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, ok := cmap.Lookup('A'); !ok {
+		t.Error("no glyph for the rune 'A'")
+	}
+
+	if advance := f.GlyphAdvance('A'); advance <= 0 {
+		t.Error("invalid advance of the rune 'A':", advance)
+	}
+
+	if advance := f.GlyphAdvance(0x10FFFF); advance != 0 {
+		t.Error("invalid advance of a rune that has no glyph:", advance)
+	}
+}
+
+func TestFontGlyphsForRunes(t *testing.T) {
+	s := CF.StringCreate("Monaco")
+	f := FontCreateWithName(s, 12.0, nil)
+
+	defer s.Release()
+	defer f.Release()
+
+	runes := []rune("AV A\U0010FFFF")
+	glyphs := f.GlyphsForRunes(runes)
+
+	if len(glyphs) != len(runes) {
+		t.Fatal("invalid number of glyphs:", len(glyphs))
+	}
+
+	if glyphs[0] == 0 || glyphs[0] != glyphs[3] || glyphs[0] == glyphs[1] || glyphs[4] != 0 {
+		t.Error("invalid glyphs:", glyphs)
+	}
+
+	advances, total := f.AdvancesForGlyphs(glyphs[:4])
+
+	if len(advances) != 4 || totalAdvance(advances) != total {
+		t.Error("invalid advances:", advances, total)
+	}
+
+	// Monaco is a monospaced font, all glyphs have the same advance, which
+	// is also the advance of the rune.
+	for _, a := range advances {
+		if a.Width != advances[0].Width || a.Width != f.GlyphAdvance('A') {
+			t.Error("invalid advances of a monospaced font:", advances)
+			break
+		}
+	}
+
+	if advance := f.StringAdvance("AV A"); advance != total {
+		t.Error("invalid advance of the string:", advance, "!=", total)
+	}
+
+	rects, overall := f.BoundingRectsForGlyphs(glyphs[:4])
+
+	if len(rects) != 4 || !rects[2].IsNull() && !rects[2].IsEmpty() || overall.IsEmpty() {
+		t.Error("invalid bounding rectangles:", rects, overall)
+	}
+}
+
+func TestFontDrawString(t *testing.T) {
+	s := CF.StringCreate("Monaco")
+	f := FontCreateWithName(s, 12.0, nil)
+
+	defer s.Release()
+	defer f.Release()
+
+	alpha := image.NewAlpha(image.Rect(0, 0, 64, 16))
+	end := f.DrawString("AV", CG.Point{X: 2, Y: 12}, alpha)
+
+	if end.X != 2+f.StringAdvance("AV") || end.Y != 12 {
+		t.Error("invalid end position:", end)
+	}
+
+	ink := 0
+
+	for _, a := range alpha.Pix {
+		if a != 0 {
+			ink++
+		}
+	}
+
+	if ink == 0 {
+		t.Error("nothing was drawn")
+	}
+}
+
+func TestFontClusterBounds(t *testing.T) {
+	s := CF.StringCreate("Monaco")
+	f := FontCreateWithName(s, 12.0, nil)
+
+	defer s.Release()
+	defer f.Release()
+
+	// The accent is drawn over the letter, which makes the cluster as wide
+	// and taller.
+	advance, bounds := f.ClusterBounds("e\u0301")
+	_, letter := f.GlyphBounds('e')
+
+	if advance != f.GlyphAdvance('e') {
+		t.Error("invalid advance of a cluster with a combining mark:", advance)
+	}
+
+	if bounds.Size.Height <= letter.Size.Height {
+		t.Error("invalid bounds of a cluster with a combining mark:", bounds, letter)
+	}
+
+	// Clusters of a single rune are measured as the glyph of the rune.
+	_, glyph := f.BoundingRectsForGlyphs(f.GlyphsForRunes([]rune{'e'}))
+
+	if advance, bounds := f.ClusterBounds("e"); advance != f.GlyphAdvance('e') || bounds != glyph {
+		t.Error("invalid bounds of a cluster of a single rune:", advance, bounds, glyph)
+	}
+
+	if _, bounds := f.ClusterBounds(" "); !bounds.IsNull() {
+		t.Error("invalid bounds of a cluster without ink:", bounds)
+	}
+
+	if advance, bounds := f.ClusterBounds(""); advance != 0 || !bounds.IsNull() {
+		t.Error("invalid bounds of an empty cluster:", advance, bounds)
+	}
+}
+
+func TestFontClusterDraw(t *testing.T) {
+	s := CF.StringCreate("Monaco")
+	f := FontCreateWithName(s, 12.0, nil)
+
+	defer s.Release()
+	defer f.Release()
+
+	for _, cluster := range []string{"e\u0301", "\U0001F1EB\U0001F1F7", "\U0001F469\u200D\U0001F467"} {
+		alpha := image.NewAlpha(image.Rect(0, 0, 32, 16))
+
+		if !ClusterDraw(f, cluster, CG.Point{X: 2, Y: 12}, alpha) {
+			t.Errorf("%q: the cluster was not drawn", cluster)
+			continue
+		}
+
+		ink := 0
+
+		for _, a := range alpha.Pix {
+			if a != 0 {
+				ink++
+			}
+		}
+
+		if ink == 0 {
+			t.Errorf("%q: nothing was drawn", cluster)
+		}
+	}
+}
+
+func TestFontClusterFallback(t *testing.T) {
+	s := CF.StringCreate("Helvetica")
+	f := FontCreateWithName(s, 12.0, nil)
+
+	defer s.Release()
+	defer f.Release()
+
+	// Helvetica has no emoji, single runes are shaped by Core Text which
+	// falls back to a font that has them, like clusters of several runes.
+	for _, cluster := range []string{"\U0001F600", "\U0001F600\uFE0F"} {
+		if advance, bounds := ClusterBounds(f, cluster); advance <= 0 || bounds.IsNull() {
+			t.Errorf("%q: invalid advance and bounds: %v, %v", cluster, advance, bounds)
+		}
+
+		alpha := image.NewAlpha(image.Rect(0, 0, 32, 16))
+
+		if !ClusterDraw(f, cluster, CG.Point{X: 2, Y: 12}, alpha) {
+			t.Errorf("%q: the cluster was not drawn", cluster)
+		}
+	}
+}
+
+func TestFontClusterDrawWithOptions(t *testing.T) {
+	s := CF.StringCreate("Monaco")
+	f := FontCreateWithName(s, 12.0, nil)
+
+	defer s.Release()
+	defer f.Release()
+
+	alpha := image.NewAlpha(image.Rect(0, 0, 16, 16))
+
+	if err := f.ClusterDrawWithOptions("e\u0301", CG.Point{X: 2.3, Y: 12}, alpha, &RenderOptions{Smoothing: true}); !errors.Is(err, ErrInvalidRenderOptions) {
+		t.Error("invalid error returned for invalid render options:", err)
+	}
+
+	if err := f.ClusterDrawWithOptions("e\u0301", CG.Point{X: 2.3, Y: 12}, alpha, &RenderOptions{}); err != nil {
+		t.Fatal(err)
+	}
+
+	ink := 0
+
+	for _, a := range alpha.Pix {
+		switch a {
+		case 0:
+		case 0xff:
+			ink++
+		default:
+			t.Fatal("the cluster was antialiased:", a)
+		}
+	}
+
+	if ink == 0 {
+		t.Error("nothing was drawn")
+	}
+}
+
+func TestFontGlyphDrawImage(t *testing.T) {
+	s := CF.StringCreate("Monaco")
+	f := FontCreateWithName(s, 12.0, nil)
+
+	defer s.Release()
+	defer f.Release()
+
+	red := color.RGBA{R: 0xff, A: 0xff}
+	dst := image.NewRGBA(image.Rect(0, 0, 16, 16))
+
+	if !f.GlyphDrawImage('A', CG.Point{X: 2, Y: 12}, dst, &DrawOptions{Color: red}) {
+		t.Fatal("failed to draw the glyph")
+	}
+
+	ink := 0
+
+	for i := 0; i < len(dst.Pix); i += 4 {
+		if a := dst.Pix[i+3]; a != 0 {
+			if dst.Pix[i] != a || dst.Pix[i+1] != 0 || dst.Pix[i+2] != 0 {
+				t.Fatal("invalid color:", dst.Pix[i:i+4])
+			}
+			ink++
+		}
+	}
+
+	if ink == 0 {
+		t.Error("nothing was drawn")
+	}
+}
+
+func TestFontDrawGlyphsWithOptions(t *testing.T) {
+	s := CF.StringCreate("Monaco")
+	f := FontCreateWithName(s, 12.0, nil)
+
+	defer s.Release()
+	defer f.Release()
+
+	glyphs := f.GlyphsForRunes([]rune("AV"))
+	positions := []CG.Point{{X: 2.3, Y: 12}, {X: 9.6, Y: 12}}
+	alpha := image.NewAlpha(image.Rect(0, 0, 24, 16))
+
+	if err := f.DrawGlyphsWithOptions(glyphs, positions, alpha, &RenderOptions{}); err != nil {
+		t.Fatal(err)
+	}
+
+	ink := 0
+
+	for _, a := range alpha.Pix {
+		switch a {
+		case 0:
+		case 0xff:
+			ink++
+		default:
+			t.Fatalf("antialiased pixel drawn without antialiasing: %#x", a)
+		}
+	}
+
+	if ink == 0 {
+		t.Error("nothing was drawn")
+	}
+
+	if err := f.DrawGlyphsWithOptions(glyphs, positions, alpha, &RenderOptions{Smoothing: true}); !errors.Is(err, ErrInvalidRenderOptions) {
+		t.Error("invalid error for invalid render options:", err)
+	}
+
+	if err := f.DrawGlyphsWithOptions(glyphs, positions, &image.Alpha{}, nil); !errors.Is(err, ErrDrawFailed) {
+		t.Error("invalid error for an empty image:", err)
+	}
+}
@@ -0,0 +1,491 @@
+// Package fontfile implements CT.FontSource on top of TrueType and OpenType
+// font files, in pure Go.
+//
+// The fonts read their metrics from the 'head', 'hhea', 'hmtx' and 'OS/2'
+// tables, map runes to glyphs with the 'cmap' table, kern with the 'GPOS' or
+// 'kern' tables and rasterize the outlines of the 'glyf' or 'CFF ' tables with
+// golang.org/x/image/vector. They don't depend on Core Text so code written
+// against CT.FontSource can be run and tested with deterministic results on
+// any platform.
+//
+// Hinting instructions are ignored, the outlines are scaled and rasterized as
+// they are stored in the font file.
+package fontfile
+
+import (
+	"fmt"
+	"image"
+	"image/draw"
+	"math"
+	"os"
+	"sync"
+
+	"github.com/go-vu/cocoa/CG"
+	"github.com/go-vu/cocoa/CT"
+	"github.com/go-vu/cocoa/CT/sfnt"
+	"golang.org/x/image/vector"
+)
+
+// Font is a font read from a font file, at a given size.
+//
+// Fonts are immutable and safe for concurrent use.
+type Font struct {
+	size    CG.Float
+	scale   CG.Float
+	hhea    sfnt.Hhea
+	os2     sfnt.OS2
+	hmtx    *sfnt.Hmtx
+	cmap    *sfnt.Cmap
+	glyf    *sfnt.GlyfTable
+	cff     *sfnt.CFFTable
+	gpos    *sfnt.GPOSTable
+	kern    *sfnt.KernTable
+	nglyphs int
+
+	mutex   sync.Mutex
+	kerners map[string]sfnt.Kerner
+}
+
+var _ CT.FontSource = (*Font)(nil)
+
+// The size of the fonts created with a size of zero, which is the default size
+// of Core Text fonts.
+const defaultSize = 12
+
+// Open reads the font file at path and parses it, see Parse for details.
+func Open(path string, index int, size CG.Float) (*Font, error) {
+	b, err := os.ReadFile(path)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return Parse(b, index, size)
+}
+
+// Parse parses the content of a font file and returns a font of the given size
+// in points, index is the index of the font in the file if it's a font
+// collection. Like for Core Text fonts, a size of zero selects the default
+// size of 12 points.
+//
+// The font keeps references to b, which must not be modified while the font
+// is in use.
+//
+// The function returns an error wrapping CT.ErrCreateFailed if the size is
+// invalid, and an error wrapping sfnt.ErrInvalidFont or sfnt.ErrInvalidTable
+// if the font file is malformed or is missing one of the tables that are
+// needed to lay out and draw text.
+func Parse(b []byte, index int, size CG.Float) (*Font, error) {
+	if math.IsNaN(float64(size)) || math.IsInf(float64(size), 0) || size < 0 {
+		return nil, fmt.Errorf("%w: invalid font size: %v", CT.ErrCreateFailed, size)
+	}
+
+	if size == 0 {
+		size = defaultSize
+	}
+
+	file, err := sfnt.ParseFont(b, index)
+
+	if err != nil {
+		return nil, err
+	}
+
+	f := &Font{size: size}
+	tables := make(map[string][]byte)
+
+	for _, tag := range [...]string{"head", "hhea", "hmtx", "maxp", "cmap"} {
+		if tables[tag], err = requireTable(file, tag); err != nil {
+			return nil, err
+		}
+	}
+
+	head, err := sfnt.ParseHead(tables["head"])
+
+	if err != nil {
+		return nil, err
+	}
+
+	if f.hhea, err = sfnt.ParseHhea(tables["hhea"]); err != nil {
+		return nil, err
+	}
+
+	if f.nglyphs, err = sfnt.ParseMaxp(tables["maxp"]); err != nil {
+		return nil, err
+	}
+
+	if f.hmtx, err = sfnt.ParseHmtx(tables["hmtx"], f.hhea.NumberOfHMetrics, f.nglyphs); err != nil {
+		return nil, err
+	}
+
+	if f.cmap, err = sfnt.ParseCmap(tables["cmap"]); err != nil {
+		return nil, err
+	}
+
+	if err := f.parseOutlines(file, head); err != nil {
+		return nil, err
+	}
+
+	// The 'OS/2', 'GPOS' and 'kern' tables are optional, fonts without them
+	// have no x-height and cap-height, or no kerning.
+	f.os2, _ = sfnt.ParseOS2(file.Table("OS/2"))
+	f.gpos, _ = sfnt.ParseGPOS(file.Table("GPOS"))
+	f.kern, _ = sfnt.ParseKern(file.Table("kern"))
+
+	f.scale = size / CG.Float(head.UnitsPerEm)
+	return f, nil
+}
+
+// parseOutlines parses the table that holds the outlines of the glyphs, which
+// is the 'CFF ' table for OpenType fonts and the 'glyf' table otherwise.
+func (f *Font) parseOutlines(file *sfnt.Font, head sfnt.Head) (err error) {
+	if cff := file.Table("CFF "); cff != nil {
+		f.cff, err = sfnt.ParseCFF(cff)
+		return
+	}
+
+	glyf, err := requireTable(file, "glyf")
+
+	if err != nil {
+		return
+	}
+
+	loca, err := requireTable(file, "loca")
+
+	if err != nil {
+		return
+	}
+
+	f.glyf, err = sfnt.ParseGlyf(glyf, loca, head.IndexToLocFormat, f.nglyphs)
+	return
+}
+
+func requireTable(file *sfnt.Font, tag string) ([]byte, error) {
+	if t := file.Table(tag); t != nil {
+		return t, nil
+	}
+	return nil, fmt.Errorf("%w: missing %q table", sfnt.ErrInvalidFont, tag)
+}
+
+// GetSize returns the size of the font in points.
+func (f *Font) GetSize() CG.Float {
+	return f.size
+}
+
+// NumGlyphs returns the number of glyphs in the font.
+func (f *Font) NumGlyphs() int {
+	return f.nglyphs
+}
+
+// GetAscent returns the ascender of the 'hhea' table, in points.
+func (f *Font) GetAscent() CG.Float {
+	return f.points(f.hhea.Ascender)
+}
+
+// GetDescent returns the descender of the 'hhea' table as a positive value, in
+// points.
+func (f *Font) GetDescent() CG.Float {
+	return -f.points(f.hhea.Descender)
+}
+
+// GetLeading returns the line gap of the 'hhea' table, in points.
+func (f *Font) GetLeading() CG.Float {
+	return f.points(f.hhea.LineGap)
+}
+
+// GetXHeight returns the x-height of the 'OS/2' table, in points.
+func (f *Font) GetXHeight() CG.Float {
+	return f.points(f.os2.XHeight)
+}
+
+// GetCapHeight returns the cap-height of the 'OS/2' table, in points.
+func (f *Font) GetCapHeight() CG.Float {
+	return f.points(f.os2.CapHeight)
+}
+
+// GlyphDraw satisfies the CT.FontSource interface.
+func (f *Font) GlyphDraw(char rune, origin CG.Point, alpha *image.Alpha) bool {
+	glyph, ok := f.cmap.Lookup(char)
+	return ok && f.DrawGlyphs([]CT.GlyphID{glyph}, []CG.Point{origin}, alpha)
+}
+
+// GlyphAdvance satisfies the CT.FontSource interface.
+func (f *Font) GlyphAdvance(char rune) CG.Float {
+	glyph, ok := f.cmap.Lookup(char)
+
+	if !ok {
+		return 0
+	}
+
+	_, advance := f.AdvancesForGlyphs([]CT.GlyphID{glyph})
+	return advance
+}
+
+// GlyphBounds satisfies the CT.FontSource interface.
+func (f *Font) GlyphBounds(char rune) (advance CG.Float, bounds CG.Rect) {
+	glyph, ok := f.cmap.Lookup(char)
+
+	if !ok {
+		return
+	}
+
+	if _, bounds = f.BoundingRectsForGlyphs([]CT.GlyphID{glyph}); bounds.IsNull() {
+		return 0, CG.RectZero
+	}
+
+	_, advance = f.AdvancesForGlyphs([]CT.GlyphID{glyph})
+	return
+}
+
+// Kern satisfies the CT.FontSource interface.
+func (f *Font) Kern(char0 rune, char1 rune) CG.Float {
+	g0, ok0 := f.cmap.Lookup(char0)
+	g1, ok1 := f.cmap.Lookup(char1)
+
+	if !ok0 || !ok1 {
+		return 0
+	}
+
+	script := sfnt.ScriptForRunes([]rune{char0, char1})
+	return f.points(f.kerner(script).Kern(g0, g1))
+}
+
+// kerner returns the source of kerning values of the font for the given
+// script, the kerners are cached since looking up the kerning feature of the
+// 'GPOS' table is expensive.
+func (f *Font) kerner(script string) sfnt.Kerner {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+
+	k, ok := f.kerners[script]
+
+	if !ok {
+		if f.kerners == nil {
+			f.kerners = make(map[string]sfnt.Kerner)
+		}
+
+		k = sfnt.NewKerner(f.gpos, f.kern, script)
+		f.kerners[script] = k
+	}
+
+	return k
+}
+
+// GlyphsForRunes returns the glyphs that the font maps the runes to, with one
+// glyph per rune. Runes that the font has no glyph for are mapped to glyph 0.
+func (f *Font) GlyphsForRunes(runes []rune) []CT.GlyphID {
+	glyphs := make([]CT.GlyphID, len(runes))
+
+	for i, r := range runes {
+		glyphs[i], _ = f.cmap.Lookup(r)
+	}
+
+	return glyphs
+}
+
+// AdvancesForGlyphs returns the horizontal advances of the glyphs passed as
+// argument, and the sum of these advances. Glyphs that are not in the font
+// have no advance.
+func (f *Font) AdvancesForGlyphs(glyphs []CT.GlyphID) (advances []CG.Size, total CG.Float) {
+	if len(glyphs) == 0 {
+		return nil, 0
+	}
+
+	advances = make([]CG.Size, len(glyphs))
+
+	for i, g := range glyphs {
+		if int(g) < f.nglyphs {
+			advances[i].Width = f.points(f.hmtx.Advance(g))
+			total += advances[i].Width
+		}
+	}
+
+	return
+}
+
+// BoundingRectsForGlyphs returns the bounding rectangles of the glyphs passed
+// as argument, and the union of these rectangles.
+//
+// The rectangles are in the Quartz space, relative to the origin of each
+// glyph. Glyphs that have no ink, like spaces, and glyphs that are not in the
+// font have a null bounding rectangle.
+func (f *Font) BoundingRectsForGlyphs(glyphs []CT.GlyphID) (rects []CG.Rect, overall CG.Rect) {
+	if len(glyphs) == 0 {
+		return nil, CG.RectNull
+	}
+
+	rects = make([]CG.Rect, len(glyphs))
+	overall = CG.RectNull
+
+	for i, g := range glyphs {
+		min, max, ok := f.glyphBounds(g)
+
+		if !ok {
+			rects[i] = CG.RectNull
+			continue
+		}
+
+		rects[i] = CG.RectMake(
+			f.scale*CG.Float(min.X),
+			f.scale*CG.Float(min.Y),
+			f.scale*CG.Float(max.X-min.X),
+			f.scale*CG.Float(max.Y-min.Y),
+		)
+		overall = overall.Union(rects[i])
+	}
+
+	return
+}
+
+// glyphBounds returns the bounds of the glyph in font units, which are read
+// from the 'glyf' table for TrueType fonts and computed from the outline of
+// the glyph for CFF fonts.
+func (f *Font) glyphBounds(glyph CT.GlyphID) (min sfnt.Point, max sfnt.Point, ok bool) {
+	if f.glyf != nil {
+		return f.glyf.Bounds(glyph)
+	}
+
+	outline, err := f.cff.Outline(glyph)
+
+	if err != nil {
+		return
+	}
+
+	return outline.Bounds()
+}
+
+// outline returns the outline of the glyph in font units.
+func (f *Font) outline(glyph CT.GlyphID) (sfnt.Outline, error) {
+	if f.glyf != nil {
+		return f.glyf.Outline(glyph)
+	}
+	return f.cff.Outline(glyph)
+}
+
+// DrawGlyphs draws the glyphs at the given positions into the alpha image,
+// over its current content.
+//
+// The positions are in the coordinate space of the image, which has its origin
+// in the top-left corner, see CT.GlyphPositions to compute them from the
+// advances of the glyphs. The method panics if there isn't one position per
+// glyph, and returns false if the image could not be drawn into or if one of
+// the glyphs could not be loaded.
+//
+// The glyphs are drawn with the default render options, see
+// DrawGlyphsWithOptions to configure them.
+func (f *Font) DrawGlyphs(glyphs []CT.GlyphID, positions []CG.Point, alpha *image.Alpha) bool {
+	return f.DrawGlyphsWithOptions(glyphs, positions, alpha, nil) == nil
+}
+
+// DrawGlyphsWithOptions is like DrawGlyphs but rasterizes the glyphs with the
+// given render options, nil selecting the defaults. The positions are aligned
+// to the pixel grid with CT.RenderOptions.AlignPositions, and the coverage is
+// rounded to fully opaque or transparent pixels when antialiasing is off.
+// Font smoothing is ignored.
+//
+// The method returns an error wrapping CT.ErrInvalidRenderOptions if the
+// options are invalid, CT.ErrDrawFailed if the image could not be drawn into,
+// or the error of the first glyph that could not be loaded, in which case the
+// other glyphs are still drawn.
+func (f *Font) DrawGlyphsWithOptions(glyphs []CT.GlyphID, positions []CG.Point, alpha *image.Alpha, opts *CT.RenderOptions) error {
+	if len(glyphs) != len(positions) {
+		panic(fmt.Sprintf("fontfile: mismatching number of glyphs and positions: %d != %d", len(glyphs), len(positions)))
+	}
+
+	if err := opts.Validate(); err != nil {
+		return err
+	}
+
+	if len(glyphs) == 0 {
+		return nil
+	}
+
+	if len(alpha.Pix) == 0 {
+		return fmt.Errorf("%w: empty image", CT.ErrDrawFailed)
+	}
+
+	positions = opts.AlignPositions(positions)
+	size := alpha.Rect.Size()
+	z := vector.NewRasterizer(size.X, size.Y)
+	z.DrawOp = draw.Over
+	var loadErr error
+
+	for i, g := range glyphs {
+		outline, err := f.outline(g)
+
+		if err != nil {
+			if loadErr == nil {
+				loadErr = err
+			}
+			continue
+		}
+
+		// The outlines have the y-axis pointing up, they are flipped to the
+		// coordinate space of the image which has it pointing down.
+		p := positions[i]
+		point := func(q sfnt.Point) (float32, float32) {
+			return float32(p.X + f.scale*CG.Float(q.X)), float32(p.Y - f.scale*CG.Float(q.Y))
+		}
+
+		for _, s := range outline {
+			x0, y0 := point(s.Args[0])
+
+			switch s.Op {
+			case sfnt.SegmentMoveTo:
+				z.ClosePath()
+				z.MoveTo(x0, y0)
+			case sfnt.SegmentLineTo:
+				z.LineTo(x0, y0)
+			case sfnt.SegmentQuadTo:
+				x1, y1 := point(s.Args[1])
+				z.QuadTo(x0, y0, x1, y1)
+			case sfnt.SegmentCubeTo:
+				x1, y1 := point(s.Args[1])
+				x2, y2 := point(s.Args[2])
+				z.CubeTo(x0, y0, x1, y1, x2, y2)
+			}
+		}
+
+		z.ClosePath()
+	}
+
+	if opts == nil || opts.Antialias {
+		z.Draw(alpha, alpha.Rect, image.Opaque, image.Point{})
+		return loadErr
+	}
+
+	// Without antialiasing the coverage is rasterized into a separate mask so
+	// it can be rounded before being drawn over the content of the image.
+	mask := image.NewAlpha(alpha.Rect)
+	z.DrawOp = draw.Src
+	z.Draw(mask, mask.Rect, image.Opaque, image.Point{})
+
+	for i, a := range mask.Pix {
+		if a >= 0x80 {
+			mask.Pix[i] = 0xff
+		} else {
+			mask.Pix[i] = 0
+		}
+	}
+
+	draw.Draw(alpha, alpha.Rect, mask, mask.Rect.Min, draw.Over)
+	return loadErr
+}
+
+// DrawGlyphsImage draws the glyphs at the given positions into a color image,
+// with the color, compositing operator and clip rectangle of the options which
+// may be nil to use the defaults, see CT.DrawGlyphsImage for details.
+func (f *Font) DrawGlyphsImage(glyphs []CT.GlyphID, positions []CG.Point, dst draw.Image, opts *CT.DrawOptions) bool {
+	return CT.DrawGlyphsImage(f, glyphs, positions, dst, opts)
+}
+
+// GlyphDrawImage is like GlyphDraw but draws into a color image, see
+// DrawGlyphsImage for details.
+func (f *Font) GlyphDrawImage(char rune, origin CG.Point, dst draw.Image, opts *CT.DrawOptions) bool {
+	glyph, ok := f.cmap.Lookup(char)
+	return ok && f.DrawGlyphsImage([]CT.GlyphID{glyph}, []CG.Point{origin}, dst, opts)
+}
+
+// points converts a value in font units to points.
+func (f *Font) points(units int) CG.Float {
+	return f.scale * CG.Float(units)
+}
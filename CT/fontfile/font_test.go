@@ -0,0 +1,439 @@
+package fontfile
+
+import (
+	"encoding/binary"
+	"errors"
+	"image"
+	"image/color"
+	"image/draw"
+	"os"
+	"sort"
+	"testing"
+
+	"github.com/go-vu/cocoa/CG"
+	"github.com/go-vu/cocoa/CT"
+	"github.com/go-vu/cocoa/CT/sfnt"
+)
+
+// The test fonts are shared with the sfnt package.
+const (
+	glyfTest = "../sfnt/testdata/glyfTest.ttf"
+	cffTest  = "../sfnt/testdata/CFFTest.otf"
+)
+
+func openFont(t *testing.T, path string, size CG.Float) *Font {
+	f, err := Open(path, 0, size)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return f
+}
+
+func TestFontMetrics(t *testing.T) {
+	tests := []struct {
+		path      string
+		size      CG.Float
+		ascent    CG.Float
+		descent   CG.Float
+		leading   CG.Float
+		capHeight CG.Float
+		glyphs    int
+	}{
+		{path: glyfTest, size: 2048, ascent: 1984, descent: 0, leading: 184, glyphs: 10},
+		{path: glyfTest, size: 1024, ascent: 992, descent: 0, leading: 92, glyphs: 10},
+		{path: cffTest, size: 1000, ascent: 800, descent: 0, leading: 90, capHeight: 793, glyphs: 5},
+		{path: cffTest, size: 0, ascent: 9.6, descent: 0, leading: 1.08, capHeight: 9.516, glyphs: 5},
+	}
+
+	for _, test := range tests {
+		f := openFont(t, test.path, test.size)
+
+		if test.size == 0 && f.GetSize() != 12 {
+			t.Errorf("%s: invalid default size: %v", test.path, f.GetSize())
+		}
+
+		if a, d, l := f.GetAscent(), f.GetDescent(), f.GetLeading(); !near(a, test.ascent) || !near(d, test.descent) || !near(l, test.leading) {
+			t.Errorf("%s at %v: invalid metrics: %v, %v, %v", test.path, test.size, a, d, l)
+		}
+
+		if x, c := f.GetXHeight(), f.GetCapHeight(); x != 0 || !near(c, test.capHeight) {
+			t.Errorf("%s at %v: invalid heights: %v, %v", test.path, test.size, x, c)
+		}
+
+		if n := f.NumGlyphs(); n != test.glyphs {
+			t.Errorf("%s: invalid number of glyphs: %d", test.path, n)
+		}
+	}
+}
+
+func TestFontGlyphs(t *testing.T) {
+	tests := []struct {
+		path    string
+		size    CG.Float
+		char    rune
+		advance CG.Float
+		bounds  CG.Rect
+	}{
+		{glyfTest, 2048, '0', 1228, CG.RectMake(205, 0, 819, 1638)},
+		{glyfTest, 2048, '1', 819, CG.RectMake(205, 0, 409, 1638)},
+		{glyfTest, 20.48, '1', 8.19, CG.RectMake(2.05, 0, 4.09, 16.38)},
+		{glyfTest, 2048, 'Q', 0, CG.RectZero},
+		{cffTest, 1000, '0', 600, CG.RectMake(100, 0, 400, 800)},
+		{cffTest, 1000, 'Q', 1000, CG.RectMake(71, -39, 855, 879)},
+		{cffTest, 1000, '中', 600, CG.RectMake(137, 26, 326, 732)},
+		{cffTest, 1000, 'A', 0, CG.RectZero},
+	}
+
+	for _, test := range tests {
+		f := openFont(t, test.path, test.size)
+
+		if advance := f.GlyphAdvance(test.char); !near(advance, test.advance) {
+			t.Errorf("%s: %q: invalid advance: %v != %v", test.path, test.char, advance, test.advance)
+		}
+
+		advance, bounds := f.GlyphBounds(test.char)
+
+		if !near(advance, test.advance) || !nearRect(bounds, test.bounds) {
+			t.Errorf("%s: %q: invalid bounds: %v, %v != %v, %v", test.path, test.char, advance, bounds, test.advance, test.bounds)
+		}
+	}
+}
+
+func TestFontGlyphsForRunes(t *testing.T) {
+	f := openFont(t, cffTest, 1000)
+	glyphs := f.GlyphsForRunes([]rune("01Q中A"))
+	expected := []CT.GlyphID{1, 2, 3, 4, 0}
+
+	if len(glyphs) != len(expected) {
+		t.Fatal("invalid glyphs:", glyphs)
+	}
+
+	for i := range glyphs {
+		if glyphs[i] != expected[i] {
+			t.Errorf("invalid glyphs: %v != %v", glyphs, expected)
+			break
+		}
+	}
+
+	advances, total := f.AdvancesForGlyphs(append(glyphs, 5))
+
+	if total != 3100 || advances[1].Width != 400 || advances[5].Width != 0 {
+		t.Error("invalid advances:", advances, total)
+	}
+
+	rects, overall := f.BoundingRectsForGlyphs([]CT.GlyphID{1, 2, 5})
+
+	if !rects[2].IsNull() || !nearRect(overall, CG.RectMake(100, 0, 400, 800)) {
+		t.Error("invalid bounding rectangles:", rects, overall)
+	}
+
+	if _, overall := f.BoundingRectsForGlyphs(nil); !overall.IsNull() {
+		t.Error("invalid bounding rectangle of no glyphs:", overall)
+	}
+}
+
+func TestFontGlyphDraw(t *testing.T) {
+	// At this size the glyph of '1' is a rectangle from 2.05 to 6.14 on the
+	// x-axis and from 0 to 16.38 on the y-axis.
+	f := openFont(t, glyfTest, 20.48)
+	alpha := image.NewAlpha(image.Rect(10, 10, 20, 34))
+
+	if !f.GlyphDraw('1', CG.Point{X: 0, Y: 20}, alpha) {
+		t.Fatal("failed to draw the glyph")
+	}
+
+	tests := []struct {
+		x, y  int
+		alpha uint8
+	}{
+		{13, 14, 0xff},
+		{15, 29, 0xff},
+		{13, 30, 0},
+		{13, 12, 0},
+		{13, 13, 0x61},
+		{11, 20, 0},
+		{17, 20, 0},
+		{12, 20, 0xf3},
+		{16, 20, 0x23},
+	}
+
+	for _, test := range tests {
+		if a := alpha.AlphaAt(test.x, test.y).A; a != test.alpha {
+			t.Errorf("(%d, %d): invalid alpha: %#x != %#x", test.x, test.y, a, test.alpha)
+		}
+	}
+
+	if f.GlyphDraw('Q', CG.Point{X: 0, Y: 20}, alpha) {
+		t.Error("drawing a rune that the font has no glyph for succeeded")
+	}
+
+	if f.GlyphDraw('1', CG.Point{}, &image.Alpha{}) {
+		t.Error("drawing into an empty image succeeded")
+	}
+}
+
+func TestFontDrawGlyphs(t *testing.T) {
+	f := openFont(t, cffTest, 10)
+	glyphs := f.GlyphsForRunes([]rune("01"))
+	advances, _ := f.AdvancesForGlyphs(glyphs)
+	positions := CT.GlyphPositions(CG.Point{X: 1, Y: 10}, advances)
+	alpha := image.NewAlpha(image.Rect(0, 0, 12, 12))
+
+	if !f.DrawGlyphs(glyphs, positions, alpha) {
+		t.Fatal("failed to draw the glyphs")
+	}
+
+	rects, _ := f.BoundingRectsForGlyphs(glyphs)
+	bounds := CT.GlyphsBounds(positions, rects).Integral()
+	ink := image.Rectangle{}
+
+	for y := 0; y != 12; y++ {
+		for x := 0; x != 12; x++ {
+			if alpha.AlphaAt(x, y).A != 0 {
+				ink = ink.Union(image.Rect(x, y, x+1, y+1))
+			}
+		}
+	}
+
+	if expected := image.Rect(int(bounds.GetMinX()), int(bounds.GetMinY()), int(bounds.GetMaxX()), int(bounds.GetMaxY())); ink != expected {
+		t.Errorf("invalid bounds of the ink: %v != %v", ink, expected)
+	}
+
+	defer func() {
+		if recover() == nil {
+			t.Error("no panic for mismatching glyphs and positions")
+		}
+	}()
+
+	f.DrawGlyphs(glyphs, positions[:1], alpha)
+}
+
+func TestFontDrawGlyphsWithOptions(t *testing.T) {
+	f := openFont(t, glyfTest, 20.48)
+	glyphs := f.GlyphsForRunes([]rune("1"))
+	positions := []CG.Point{{X: 0.3, Y: 20.4}}
+
+	tests := []struct {
+		name string
+		opts *CT.RenderOptions
+		// The expected alpha of the pixels of a row crossing the glyph.
+		row []uint8
+	}{
+		{
+			name: "default",
+			opts: nil,
+			row:  []uint8{0, 0, 0xa6, 0xff, 0xff, 0xff, 0x70, 0},
+		},
+		{
+			name: "aliased",
+			opts: &CT.RenderOptions{},
+			row:  []uint8{0, 0, 0xff, 0xff, 0xff, 0xff, 0, 0},
+		},
+		{
+			name: "quantized",
+			opts: &CT.RenderOptions{Antialias: true, SubpixelPositioning: true, SubpixelQuantization: true},
+			row:  []uint8{0, 0, 0xb3, 0xff, 0xff, 0xff, 0x63, 0},
+		},
+	}
+
+	for _, test := range tests {
+		alpha := image.NewAlpha(image.Rect(0, 0, 8, 24))
+
+		if err := f.DrawGlyphsWithOptions(glyphs, positions, alpha, test.opts); err != nil {
+			t.Errorf("%s: %v", test.name, err)
+			continue
+		}
+
+		for x, a := range test.row {
+			if v := alpha.AlphaAt(x, 10).A; v != a {
+				t.Errorf("%s: pixel %d: invalid alpha: %#x != %#x", test.name, x, v, a)
+			}
+		}
+	}
+
+	alpha := image.NewAlpha(image.Rect(0, 0, 8, 24))
+
+	if err := f.DrawGlyphsWithOptions(glyphs, positions, alpha, &CT.RenderOptions{Smoothing: true}); !errors.Is(err, CT.ErrInvalidRenderOptions) {
+		t.Error("invalid error for invalid render options:", err)
+	}
+
+	if err := f.DrawGlyphsWithOptions(glyphs, positions, &image.Alpha{}, nil); !errors.Is(err, CT.ErrDrawFailed) {
+		t.Error("invalid error for an empty image:", err)
+	}
+
+	if err := f.DrawGlyphsWithOptions([]CT.GlyphID{4, 10}, []CG.Point{{}, {}}, alpha, nil); err == nil {
+		t.Error("no error for a glyph out of range")
+	}
+}
+
+func TestFontGlyphDrawImage(t *testing.T) {
+	// Same glyph as in TestFontGlyphDraw, drawn in translucent blue over an
+	// opaque white image.
+	f := openFont(t, glyfTest, 20.48)
+	white := color.NRGBA{R: 0xff, G: 0xff, B: 0xff, A: 0xff}
+	dst := image.NewNRGBA(image.Rect(10, 10, 20, 34))
+	draw.Draw(dst, dst.Bounds(), image.NewUniform(white), image.Point{}, draw.Src)
+
+	opts := &CT.DrawOptions{Color: color.NRGBA{B: 0xff, A: 0x80}}
+
+	if !f.GlyphDrawImage('1', CG.Point{X: 0, Y: 20}, dst, opts) {
+		t.Fatal("failed to draw the glyph")
+	}
+
+	tests := []struct {
+		x, y  int
+		color color.NRGBA
+	}{
+		{13, 14, color.NRGBA{0x7f, 0x7f, 0xff, 0xff}},
+		{13, 12, white},
+		{11, 20, white},
+		{12, 20, color.NRGBA{0x85, 0x85, 0xff, 0xff}},
+	}
+
+	for _, test := range tests {
+		if c := dst.NRGBAAt(test.x, test.y); c != test.color {
+			t.Errorf("(%d, %d): invalid color: %v != %v", test.x, test.y, c, test.color)
+		}
+	}
+
+	if f.GlyphDrawImage('Q', CG.Point{X: 0, Y: 20}, dst, opts) {
+		t.Error("drawing a rune that the font has no glyph for succeeded")
+	}
+}
+
+func TestFontKern(t *testing.T) {
+	b, err := os.ReadFile(glyfTest)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// A 'kern' table with a single format 0 subtable which moves the glyphs of
+	// '0' and '1' closer.
+	kern := appendUint16(nil, 0, 1, 0, 20, 0x0001, 1, 6, 0, 0, 3, 4)
+	kern = appendUint16(kern, 0xff9c)
+
+	f, err := Parse(withTable(t, b, "kern", kern), 0, 1024)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		char0, char1 rune
+		kern         CG.Float
+	}{
+		{'0', '1', -50},
+		{'1', '0', 0},
+		{'0', 'Q', 0},
+	}
+
+	for _, test := range tests {
+		if kern := f.Kern(test.char0, test.char1); kern != test.kern {
+			t.Errorf("%q%q: invalid kerning: %v != %v", test.char0, test.char1, kern, test.kern)
+		}
+	}
+
+	if kern := openFont(t, glyfTest, 1024).Kern('0', '1'); kern != 0 {
+		t.Error("kerning found in a font without kerning tables:", kern)
+	}
+}
+
+func TestParseError(t *testing.T) {
+	b, err := os.ReadFile(cffTest)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		font []byte
+		size CG.Float
+		err  error
+	}{
+		{b, -1, CT.ErrCreateFailed},
+		{nil, 12, sfnt.ErrInvalidFont},
+		{withTable(t, b, "cmap", nil), 12, sfnt.ErrInvalidFont},
+		{withTable(t, b, "CFF ", nil), 12, sfnt.ErrInvalidFont},
+		{withTable(t, b, "hhea", make([]byte, 10)), 12, sfnt.ErrInvalidTable},
+	}
+
+	for i, test := range tests {
+		if _, err := Parse(test.font, 0, test.size); !errors.Is(err, test.err) {
+			t.Errorf("#%d: invalid error: %v", i, err)
+		}
+	}
+
+	if _, err := Open("testdata/missing.ttf", 0, 12); !errors.Is(err, os.ErrNotExist) {
+		t.Error("invalid error for a missing file:", err)
+	}
+}
+
+func near(a CG.Float, b CG.Float) bool {
+	d := a - b
+	return d > -1e-6 && d < 1e-6
+}
+
+func nearRect(a CG.Rect, b CG.Rect) bool {
+	return near(a.Origin.X, b.Origin.X) && near(a.Origin.Y, b.Origin.Y) && near(a.Size.Width, b.Size.Width) && near(a.Size.Height, b.Size.Height)
+}
+
+func appendUint16(b []byte, values ...uint16) []byte {
+	for _, v := range values {
+		b = binary.BigEndian.AppendUint16(b, v)
+	}
+	return b
+}
+
+// withTable returns a copy of the font file where the table with the given tag
+// is replaced, added if the font has no such table, or removed if it's nil.
+func withTable(t *testing.T, b []byte, tag string, table []byte) []byte {
+	f, err := sfnt.ParseFont(b, 0)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tables := make(map[string][]byte)
+
+	for _, tag := range f.Tags() {
+		tables[tag] = f.Table(tag)
+	}
+
+	if table == nil {
+		delete(tables, tag)
+	} else {
+		tables[tag] = table
+	}
+
+	tags := make([]string, 0, len(tables))
+
+	for tag := range tables {
+		tags = append(tags, tag)
+	}
+
+	sort.Strings(tags)
+
+	// The tables are stored after the directory, aligned on 4 bytes. The
+	// fields that are not read by the parsers are left to zero.
+	off := 12 + 16*len(tags)
+	font := binary.BigEndian.AppendUint32(nil, binary.BigEndian.Uint32(b))
+	font = appendUint16(font, uint16(len(tags)), 0, 0, 0)
+	data := []byte{}
+
+	for _, tag := range tags {
+		font = append(font, tag...)
+		font = binary.BigEndian.AppendUint32(font, 0)
+		font = binary.BigEndian.AppendUint32(font, uint32(off+len(data)))
+		font = binary.BigEndian.AppendUint32(font, uint32(len(tables[tag])))
+		data = append(data, tables[tag]...)
+
+		for len(data)%4 != 0 {
+			data = append(data, 0)
+		}
+	}
+
+	return append(font, data...)
+}
@@ -0,0 +1,137 @@
+package CT
+
+import (
+	"github.com/go-vu/cocoa/CG"
+	"github.com/go-vu/cocoa/CT/sfnt"
+)
+
+// GlyphID is the index of a glyph in a font, it has the same representation
+// as CGGlyph so slices of glyphs are passed to Core Text without copies.
+//
+// https://developer.apple.com/library/mac/documentation/GraphicsImaging/Reference/CGFont/#//apple_ref/c/tdef/CGGlyph
+type GlyphID = sfnt.GlyphID
+
+// GlyphPositions returns the positions of glyphs laid out one after the other
+// from origin, each glyph being moved from the previous one by its advance.
+//
+// The positions are in the coordinate space of the image that the glyphs are
+// drawn into, which has its origin in the top-left corner, while the advances
+// are in the Quartz space which has the y-axis pointing up.
+func GlyphPositions(origin CG.Point, advances []CG.Size) []CG.Point {
+	positions := make([]CG.Point, len(advances))
+
+	for i, a := range advances {
+		positions[i] = origin
+		origin.X += a.Width
+		origin.Y -= a.Height
+	}
+
+	return positions
+}
+
+// GlyphsBounds returns the bounds of glyphs drawn at the given positions,
+// rects being the bounding rectangles of the glyphs as returned by
+// BoundingRectsForGlyphs.
+//
+// Like the positions, the returned rectangle is in the coordinate space of the
+// image, null rectangles of glyphs that have no ink are ignored. The function
+// returns CG.RectNull if none of the glyphs have ink.
+func GlyphsBounds(positions []CG.Point, rects []CG.Rect) CG.Rect {
+	checkGlyphSlices(len(positions), len(rects))
+	bounds := CG.RectNull
+
+	for i, r := range rects {
+		if r.IsNull() {
+			continue
+		}
+
+		// The rectangles have their origin at the bottom-left corner of the
+		// glyphs in Quartz space, they are flipped to have it at the top-left
+		// corner in the image space.
+		r = r.Standardize()
+		p := positions[i]
+		bounds = bounds.Union(CG.RectMake(
+			p.X+r.Origin.X,
+			p.Y-r.Origin.Y-r.Size.Height,
+			r.Size.Width,
+			r.Size.Height,
+		))
+	}
+
+	return bounds
+}
+
+// glyphsForUTF16 converts the glyphs returned by Core Text for the UTF-16
+// encoding of runes, which has one glyph per UTF-16 code unit, to a slice of
+// one glyph per rune.
+func glyphsForUTF16(runes []rune, units []GlyphID) []GlyphID {
+	glyphs := make([]GlyphID, len(runes))
+	i := 0
+
+	for j, r := range runes {
+		if i >= len(units) {
+			break
+		}
+
+		glyphs[j] = units[i]
+		i += utf16Len(r)
+	}
+
+	return glyphs
+}
+
+// utf16Len returns the number of UTF-16 code units that utf16.Encode produces
+// for r, invalid runes are replaced by U+FFFD which takes a single unit.
+func utf16Len(r rune) int {
+	if r >= 0x10000 && r <= 0x10FFFF {
+		return 2
+	}
+	return 1
+}
+
+// flipPositions converts positions from the coordinate space of an image of
+// the given height, which has its origin in the top-left corner, to the Quartz
+// space which has its origin in the bottom-left corner.
+func flipPositions(positions []CG.Point, height int) []CG.Point {
+	flipped := make([]CG.Point, len(positions))
+
+	for i, p := range positions {
+		flipped[i] = CG.Point{X: p.X, Y: CG.Float(height) - p.Y}
+	}
+
+	return flipped
+}
+
+// kernAdvances returns a copy of advances where the kerning value of each pair
+// of consecutive glyphs is added to the advance of the first glyph of the pair,
+// kerning[i] being the value of the pair made of glyphs i and i+1.
+func kernAdvances(advances []CG.Size, kerning []CG.Float) []CG.Size {
+	kerned := append([]CG.Size(nil), advances...)
+
+	for i, k := range kerning {
+		if i < len(kerned) {
+			kerned[i].Width += k
+		}
+	}
+
+	return kerned
+}
+
+// totalAdvance returns the sum of the horizontal advances.
+func totalAdvance(advances []CG.Size) CG.Float {
+	total := CG.Float(0)
+
+	for _, a := range advances {
+		total += a.Width
+	}
+
+	return total
+}
+
+// checkGlyphSlices panics if a slice of glyph attributes doesn't have one
+// element per glyph.
+func checkGlyphSlices(glyphs int, n int) {
+	if glyphs != n {
+		panic("CT: the number of glyphs and their attributes mismatch")
+	}
+}
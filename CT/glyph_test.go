@@ -0,0 +1,146 @@
+package CT
+
+import (
+	"reflect"
+	"testing"
+	"unsafe"
+
+	"github.com/go-vu/cocoa/CG"
+)
+
+func TestGlyphIDSize(t *testing.T) {
+	// Slices of glyphs are passed to Core Text as arrays of CGGlyph, which is
+	// a 16 bits unsigned integer.
+	if size := unsafe.Sizeof(GlyphID(0)); size != 2 {
+		t.Error("invalid size of glyph identifiers:", size)
+	}
+}
+
+func TestGlyphPositions(t *testing.T) {
+	origin := CG.Point{X: 10, Y: 20}
+	advances := []CG.Size{{Width: 5}, {Width: 7.5}, {Width: 0}, {Width: 2, Height: 1}}
+
+	positions := GlyphPositions(origin, advances)
+	expected := []CG.Point{{X: 10, Y: 20}, {X: 15, Y: 20}, {X: 22.5, Y: 20}, {X: 22.5, Y: 20}}
+
+	if !reflect.DeepEqual(positions, expected) {
+		t.Errorf("invalid glyph positions: %v != %v", positions, expected)
+	}
+
+	if positions := GlyphPositions(origin, advances[3:]); positions[0] != origin {
+		t.Error("the first glyph is not at the origin:", positions[0])
+	}
+
+	// The y-axis of the advances points up, which moves the glyphs toward the
+	// top of the image.
+	if positions := GlyphPositions(CG.PointZero, []CG.Size{{Height: 3}, {}}); positions[1].Y != -3 {
+		t.Error("invalid vertical position:", positions[1])
+	}
+
+	if positions := GlyphPositions(origin, nil); len(positions) != 0 {
+		t.Error("positions returned for no glyphs:", positions)
+	}
+}
+
+func TestGlyphsBounds(t *testing.T) {
+	tests := []struct {
+		positions []CG.Point
+		rects     []CG.Rect
+		bounds    CG.Rect
+	}{
+		{
+			positions: nil,
+			rects:     nil,
+			bounds:    CG.RectNull,
+		},
+		{
+			positions: []CG.Point{{X: 0, Y: 10}},
+			rects:     []CG.Rect{CG.RectNull},
+			bounds:    CG.RectNull,
+		},
+		{
+			// A glyph that goes 2 points below and 6 points above the
+			// baseline.
+			positions: []CG.Point{{X: 0, Y: 10}},
+			rects:     []CG.Rect{CG.RectMake(1, -2, 4, 8)},
+			bounds:    CG.RectMake(1, 4, 4, 8),
+		},
+		{
+			positions: []CG.Point{{X: 0, Y: 10}, {X: 5, Y: 10}, {X: 8, Y: 10}},
+			rects:     []CG.Rect{CG.RectMake(1, 0, 3, 6), CG.RectNull, CG.RectMake(0, -3, 4, 5)},
+			bounds:    CG.RectMake(1, 4, 11, 9),
+		},
+	}
+
+	for _, test := range tests {
+		if bounds := GlyphsBounds(test.positions, test.rects); bounds != test.bounds {
+			t.Errorf("invalid bounds of %v at %v: %v != %v", test.rects, test.positions, bounds, test.bounds)
+		}
+	}
+}
+
+func TestGlyphsBoundsPanic(t *testing.T) {
+	defer func() {
+		if recover() == nil {
+			t.Error("no panic when the number of positions and rectangles mismatch")
+		}
+	}()
+	GlyphsBounds(make([]CG.Point, 2), make([]CG.Rect, 1))
+}
+
+func TestGlyphsForUTF16(t *testing.T) {
+	tests := []struct {
+		runes  []rune
+		units  []GlyphID
+		glyphs []GlyphID
+	}{
+		{nil, nil, []GlyphID{}},
+		{[]rune("AB"), []GlyphID{1, 2}, []GlyphID{1, 2}},
+		{[]rune("A😀B"), []GlyphID{1, 3, 0, 2}, []GlyphID{1, 3, 2}},
+		{[]rune("😀😀"), []GlyphID{3, 0, 3, 0}, []GlyphID{3, 3}},
+		{[]rune{-1, 0xD800, 0x110000, 'A'}, []GlyphID{4, 4, 4, 1}, []GlyphID{4, 4, 4, 1}},
+		{[]rune("AB"), []GlyphID{1}, []GlyphID{1, 0}},
+	}
+
+	for _, test := range tests {
+		if glyphs := glyphsForUTF16(test.runes, test.units); !reflect.DeepEqual(glyphs, test.glyphs) {
+			t.Errorf("%q: invalid glyphs: %v != %v", string(test.runes), glyphs, test.glyphs)
+		}
+	}
+}
+
+func TestFlipPositions(t *testing.T) {
+	positions := []CG.Point{{X: 1, Y: 0}, {X: 2, Y: 10}, {X: 3, Y: 25}}
+	flipped := flipPositions(positions, 20)
+	expected := []CG.Point{{X: 1, Y: 20}, {X: 2, Y: 10}, {X: 3, Y: -5}}
+
+	if !reflect.DeepEqual(flipped, expected) {
+		t.Errorf("invalid flipped positions: %v != %v", flipped, expected)
+	}
+
+	if positions[0].Y != 0 {
+		t.Error("the positions were modified")
+	}
+}
+
+func TestKernAdvances(t *testing.T) {
+	advances := []CG.Size{{Width: 10}, {Width: 8}, {Width: 6}}
+	kerned := kernAdvances(advances, []CG.Float{-1, 0.5})
+	expected := []CG.Size{{Width: 9}, {Width: 8.5}, {Width: 6}}
+
+	if !reflect.DeepEqual(kerned, expected) {
+		t.Errorf("invalid kerned advances: %v != %v", kerned, expected)
+	}
+
+	if advances[0].Width != 10 {
+		t.Error("the advances were modified")
+	}
+
+	if total := totalAdvance(kerned); total != 23.5 {
+		t.Error("invalid total advance:", total)
+	}
+
+	if total := totalAdvance(nil); total != 0 {
+		t.Error("invalid total advance of no glyphs:", total)
+	}
+}
@@ -0,0 +1,183 @@
+// Package grapheme implements the segmentation of text into grapheme clusters,
+// which are the units of text that users perceive as characters, like a
+// letter followed by combining accents, a pair of regional indicators that
+// make a flag or a sequence of emoji joined by zero width joiners.
+//
+// Clusters are segmented with the extended grapheme cluster rules of the
+// Unicode text segmentation algorithm and the character properties of Unicode
+// 17.0.0.
+//
+// https://www.unicode.org/reports/tr29/
+package grapheme
+
+// property is an enumeration of the values of the Grapheme_Cluster_Break
+// property of the characters.
+type property uint8
+
+const (
+	gbOther property = iota
+	gbCR
+	gbLF
+	gbControl
+	gbExtend
+	gbZWJ
+	gbRegionalIndicator
+	gbPrepend
+	gbSpacingMark
+	gbL
+	gbV
+	gbT
+	gbLV
+	gbLVT
+)
+
+// conjunctBreak is an enumeration of the values of the Indic_Conjunct_Break
+// property of the characters, which the rule GB9c keeps the conjuncts of the
+// Indic scripts together with.
+type conjunctBreak uint8
+
+const (
+	conjunctNone conjunctBreak = iota
+	conjunctConsonant
+	conjunctExtend
+	conjunctLinker
+)
+
+// ClusterLen returns the number of runes of the grapheme cluster at the start
+// of runes, which is zero only if runes is empty.
+func ClusterLen(runes []rune) int {
+	if len(runes) == 0 {
+		return 0
+	}
+
+	s := segmenter{}
+	s.push(runes[0])
+
+	for i := 1; i < len(runes); i++ {
+		if s.breakBefore(runes[i]) {
+			return i
+		}
+
+		s.push(runes[i])
+	}
+
+	return len(runes)
+}
+
+// Clusters splits s into its grapheme clusters.
+func Clusters(s string) []string {
+	clusters := []string{}
+	runes := []rune(s)
+
+	for len(runes) != 0 {
+		n := ClusterLen(runes)
+		clusters = append(clusters, string(runes[:n]))
+		runes = runes[n:]
+	}
+
+	return clusters
+}
+
+// segmenter holds the state of the segmentation of a grapheme cluster, which
+// tells whether it can be extended with the next rune.
+type segmenter struct {
+	prev property
+
+	// emoji is true when the cluster ends with an extended pictographic
+	// character followed by extending characters, and zwjAfterEmoji is true
+	// when such a sequence is followed by a zero width joiner.
+	emoji         bool
+	zwjAfterEmoji bool
+
+	// regionalIndicators is the number of regional indicators that the
+	// cluster ends with.
+	regionalIndicators int
+
+	// consonant is true when the cluster ends with a consonant followed by
+	// extending characters and linkers, and linker is true when there is a
+	// linker among them.
+	consonant bool
+	linker    bool
+}
+
+// push adds the rune r to the cluster.
+func (s *segmenter) push(r rune) {
+	p := propertyOf(r)
+
+	switch {
+	case isExtendedPictographic(r):
+		s.emoji, s.zwjAfterEmoji = true, false
+	case p == gbExtend:
+		s.zwjAfterEmoji = false
+	case p == gbZWJ:
+		s.emoji, s.zwjAfterEmoji = false, s.emoji
+	default:
+		s.emoji, s.zwjAfterEmoji = false, false
+	}
+
+	if p == gbRegionalIndicator {
+		s.regionalIndicators++
+	} else {
+		s.regionalIndicators = 0
+	}
+
+	switch findConjunct(r) {
+	case conjunctConsonant:
+		s.consonant, s.linker = true, false
+	case conjunctLinker:
+		s.linker = s.consonant
+	case conjunctExtend:
+	default:
+		s.consonant, s.linker = false, false
+	}
+
+	s.prev = p
+}
+
+// breakBefore returns true if the cluster ends before the rune r.
+func (s *segmenter) breakBefore(r rune) bool {
+	prev, next := s.prev, propertyOf(r)
+
+	switch {
+	case prev == gbCR && next == gbLF: // GB3
+		return false
+	case prev == gbCR || prev == gbLF || prev == gbControl: // GB4
+		return true
+	case next == gbCR || next == gbLF || next == gbControl: // GB5
+		return true
+	case prev == gbL && (next == gbL || next == gbV || next == gbLV || next == gbLVT): // GB6
+		return false
+	case (prev == gbLV || prev == gbV) && (next == gbV || next == gbT): // GB7
+		return false
+	case (prev == gbLVT || prev == gbT) && next == gbT: // GB8
+		return false
+	case next == gbExtend || next == gbZWJ: // GB9
+		return false
+	case next == gbSpacingMark: // GB9a
+		return false
+	case prev == gbPrepend: // GB9b
+		return false
+	case s.linker && findConjunct(r) == conjunctConsonant: // GB9c
+		return false
+	case s.zwjAfterEmoji && isExtendedPictographic(r): // GB11
+		return false
+	case next == gbRegionalIndicator && s.regionalIndicators%2 == 1: // GB12, GB13
+		return false
+	default: // GB999
+		return true
+	}
+}
+
+// propertyOf returns the Grapheme_Cluster_Break property of r.
+//
+// https://www.unicode.org/reports/tr29/#Grapheme_Cluster_Break_Property_Values
+func propertyOf(r rune) property {
+	if r >= hangulFirst && r <= hangulLast {
+		if (r-hangulFirst)%hangulTrailing == 0 {
+			return gbLV
+		}
+		return gbLVT
+	}
+
+	return findProperty(r)
+}
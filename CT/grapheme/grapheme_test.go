@@ -0,0 +1,198 @@
+package grapheme
+
+import (
+	"bufio"
+	"compress/gzip"
+	"os"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+func TestClusters(t *testing.T) {
+	tests := []struct {
+		name     string
+		s        string
+		clusters []string
+	}{
+		{
+			name:     "empty",
+			clusters: []string{},
+		},
+		{
+			name:     "letters",
+			s:        "ab",
+			clusters: []string{"a", "b"},
+		},
+		{
+			name:     "combining accents",
+			s:        "ẹ́x",
+			clusters: []string{"ẹ́", "x"},
+		},
+		{
+			name:     "newlines",
+			s:        "a\r\n\n",
+			clusters: []string{"a", "\r\n", "\n"},
+		},
+		{
+			name:     "flags",
+			s:        "🇫🇷🇯🇵🇺",
+			clusters: []string{"🇫🇷", "🇯🇵", "🇺"},
+		},
+		{
+			name:     "zero width joiner sequence",
+			s:        "👩‍👩‍👧!",
+			clusters: []string{"👩‍👩‍👧", "!"},
+		},
+		{
+			name:     "zero width joiner without emoji",
+			s:        "a‍👧",
+			clusters: []string{"a‍", "👧"},
+		},
+		{
+			name:     "skin tone modifier",
+			s:        "👍🏽👍",
+			clusters: []string{"👍🏽", "👍"},
+		},
+		{
+			name:     "variation selector",
+			s:        "❤️",
+			clusters: []string{"❤️"},
+		},
+		{
+			name:     "hangul jamo",
+			s:        "각가",
+			clusters: []string{"각", "가"},
+		},
+		{
+			name:     "spacing mark",
+			s:        "कि",
+			clusters: []string{"कि"},
+		},
+		{
+			name:     "indic conjunct",
+			s:        "क्षिन",
+			clusters: []string{"क्षि", "न"},
+		},
+		{
+			name:     "indic conjunct with zero width joiner",
+			s:        "क्‍ष",
+			clusters: []string{"क्‍ष"},
+		},
+		{
+			name:     "prepended concatenation mark",
+			s:        "۝١",
+			clusters: []string{"۝١"},
+		},
+	}
+
+	for _, test := range tests {
+		clusters := Clusters(test.s)
+
+		if strings.Join(clusters, "|") != strings.Join(test.clusters, "|") || len(clusters) != len(test.clusters) {
+			t.Errorf("%s: invalid clusters: %q != %q", test.name, clusters, test.clusters)
+		}
+	}
+}
+
+func TestClusterLen(t *testing.T) {
+	tests := []struct {
+		runes []rune
+		n     int
+	}{
+		{nil, 0},
+		{[]rune("a"), 1},
+		{[]rune("ab"), 1},
+		{[]rune("áb"), 2},
+		{[]rune("́b"), 1},
+	}
+
+	for _, test := range tests {
+		if n := ClusterLen(test.runes); n != test.n {
+			t.Errorf("invalid cluster length of %q: %d != %d", string(test.runes), n, test.n)
+		}
+	}
+}
+
+func TestTables(t *testing.T) {
+	for i, r := range propertyRanges {
+		if r.lo > r.hi || (i != 0 && r.lo <= propertyRanges[i-1].hi) {
+			t.Errorf("property range %d is not sorted: %U-%U", i, r.lo, r.hi)
+		}
+	}
+
+	for i, r := range conjunctRanges {
+		if r.lo > r.hi || (i != 0 && r.lo <= conjunctRanges[i-1].hi) {
+			t.Errorf("conjunct range %d is not sorted: %U-%U", i, r.lo, r.hi)
+		}
+	}
+
+	for i, r := range extendedPictographic {
+		if r.lo > r.hi || (i != 0 && r.lo <= extendedPictographic[i-1].hi) {
+			t.Errorf("pictographic range %d is not sorted: %U-%U", i, r.lo, r.hi)
+		}
+	}
+}
+
+func TestClustersConformance(t *testing.T) {
+	f, err := os.Open("testdata/GraphemeBreakTest.txt.gz")
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	defer f.Close()
+	z, err := gzip.NewReader(f)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	scanner := bufio.NewScanner(z)
+
+	for scanner.Scan() {
+		line := scanner.Text()
+
+		if i := strings.IndexByte(line, '#'); i >= 0 {
+			line = line[:i]
+		}
+
+		if line = strings.TrimSpace(line); len(line) == 0 {
+			continue
+		}
+
+		// The expected clusters are the runes between the break marks, the
+		// first and last marks are the start and end of the text.
+		expected := []string{}
+		runes := []rune{}
+		cluster := []rune{}
+
+		for _, field := range strings.Fields(line) {
+			switch field {
+			case "÷":
+				if len(cluster) != 0 {
+					expected = append(expected, string(cluster))
+					cluster = nil
+				}
+			case "×":
+			default:
+				r, err := strconv.ParseUint(field, 16, 32)
+
+				if err != nil {
+					t.Fatal(err)
+				}
+
+				runes = append(runes, rune(r))
+				cluster = append(cluster, rune(r))
+			}
+		}
+
+		if clusters := Clusters(string(runes)); strings.Join(clusters, "÷") != strings.Join(expected, "÷") {
+			t.Errorf("%s: invalid clusters: %q != %q", line, clusters, expected)
+		}
+	}
+
+	if err := scanner.Err(); err != nil {
+		t.Fatal(err)
+	}
+}
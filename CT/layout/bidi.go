@@ -0,0 +1,887 @@
+package layout
+
+import (
+	"fmt"
+	"sort"
+)
+
+// WritingDirection is an enumeration of the base directions of paragraphs.
+//
+// https://developer.apple.com/documentation/coretext/ctwritingdirection
+type WritingDirection int
+
+// These constants are all the possible values of the WritingDirection
+// enumeration.
+const (
+	// The direction of paragraphs is the direction of their first strong
+	// character, or left-to-right if they have none.
+	WritingDirectionNatural WritingDirection = -1
+
+	// Paragraphs are left-to-right.
+	WritingDirectionLeftToRight WritingDirection = 0
+
+	// Paragraphs are right-to-left.
+	WritingDirectionRightToLeft WritingDirection = 1
+)
+
+// String satisfies the fmt.Stringer interface.
+func (d WritingDirection) String() string {
+	switch d {
+	case WritingDirectionNatural:
+		return "WritingDirectionNatural"
+	case WritingDirectionLeftToRight:
+		return "WritingDirectionLeftToRight"
+	case WritingDirectionRightToLeft:
+		return "WritingDirectionRightToLeft"
+	default:
+		return fmt.Sprintf("WritingDirection(%d)", int(d))
+	}
+}
+
+// BidiLevels returns the embedding levels of the runes of a paragraph, resolved
+// with the Unicode bidirectional algorithm, and the level of the paragraph.
+// Runes at even levels are left-to-right and runes at odd levels are
+// right-to-left.
+//
+// The explicit formatting characters and boundary neutrals that the algorithm
+// removes get the level of the rune before them. The levels don't account for
+// line breaks, BidiLineLevels adjusts them for each line before they are
+// reordered with VisualOrder.
+//
+// https://www.unicode.org/reports/tr9/
+func BidiLevels(runes []rune, dir WritingDirection) (levels []uint8, paragraphLevel uint8) {
+	classes := make([]bidiClass, len(runes))
+
+	for i, r := range runes {
+		classes[i] = bidiClassOf(r)
+	}
+
+	p := newBidiParagraph(classes, runes, dir)
+	return p.levels, p.level
+}
+
+// BidiLineLevels returns a copy of the levels of the runes of a line, which
+// were resolved by BidiLevels, where the trailing whitespace and the
+// separators have the level of the paragraph.
+//
+// https://www.unicode.org/reports/tr9/#L1
+func BidiLineLevels(runes []rune, levels []uint8, paragraphLevel uint8) []uint8 {
+	classes := make([]bidiClass, len(runes))
+
+	for i, r := range runes {
+		classes[i] = bidiClassOf(r)
+	}
+
+	return lineLevels(classes, levels, paragraphLevel)
+}
+
+// VisualOrder returns the indexes of the runes of a line in the order in which
+// they are displayed from left to right, given their levels.
+//
+// https://www.unicode.org/reports/tr9/#L2
+func VisualOrder(levels []uint8) []int {
+	order := make([]int, len(levels))
+	highest, lowestOdd := uint8(0), uint8(255)
+
+	for i, l := range levels {
+		order[i] = i
+
+		if l > highest {
+			highest = l
+		}
+
+		if l&1 != 0 && l < lowestOdd {
+			lowestOdd = l
+		}
+	}
+
+	for level := highest; level >= lowestOdd && level != 0; level-- {
+		for i := 0; i < len(levels); {
+			if levels[order[i]] < level {
+				i++
+				continue
+			}
+
+			j := i + 1
+
+			for j < len(levels) && levels[order[j]] >= level {
+				j++
+			}
+
+			for k, l := i, j-1; k < l; k, l = k+1, l-1 {
+				order[k], order[l] = order[l], order[k]
+			}
+
+			i = j
+		}
+	}
+
+	return order
+}
+
+// MirroredRune returns the rune that has the mirrored glyph of r, which is
+// displayed in its place when r is at a right-to-left level, and false if r
+// has no mirrored glyph.
+//
+// https://www.unicode.org/reports/tr9/#L4
+func MirroredRune(r rune) (rune, bool) {
+	i := sort.Search(len(bidiMirrors), func(i int) bool { return bidiMirrors[i][0] >= r })
+
+	if i == len(bidiMirrors) || bidiMirrors[i][0] != r {
+		return r, false
+	}
+
+	return bidiMirrors[i][1], true
+}
+
+// bidiClass is an enumeration of the values of the Bidi_Class property.
+type bidiClass uint8
+
+const (
+	bidiL bidiClass = iota
+	bidiR
+	bidiEN
+	bidiES
+	bidiET
+	bidiAN
+	bidiCS
+	bidiB
+	bidiS
+	bidiWS
+	bidiON
+	bidiBN
+	bidiNSM
+	bidiAL
+	bidiLRO
+	bidiRLO
+	bidiLRE
+	bidiRLE
+	bidiPDF
+	bidiLRI
+	bidiRLI
+	bidiFSI
+	bidiPDI
+)
+
+// The maximum explicit embedding level.
+const maxBidiDepth = 125
+
+// The maximum number of nested brackets of rule BD16.
+const maxBracketDepth = 63
+
+func bidiClassOf(r rune) bidiClass {
+	i := sort.Search(len(bidiRanges), func(i int) bool { return bidiRanges[i].hi >= r })
+
+	if i == len(bidiRanges) || bidiRanges[i].lo > r {
+		return bidiL
+	}
+
+	return bidiRanges[i].class
+}
+
+// bracketOf returns the closing bracket paired with r, and whether r opens or
+// closes the pair, or false if r isn't a paired bracket. Brackets that are
+// canonically equivalent are mapped to the same pair.
+func bracketOf(r rune) (closing rune, open bool, ok bool) {
+	// The angle brackets of the Miscellaneous Technical block decompose to
+	// the ones of the CJK Symbols and Punctuation block.
+	switch r {
+	case 0x2329:
+		r = 0x3008
+	case 0x232A:
+		r = 0x3009
+	}
+
+	i := sort.Search(len(bidiBrackets), func(i int) bool { return bidiBrackets[i][0] >= r })
+
+	if i != len(bidiBrackets) && bidiBrackets[i][0] == r {
+		return bidiBrackets[i][1], true, true
+	}
+
+	for _, pair := range bidiBrackets {
+		if pair[1] == r {
+			return r, false, true
+		}
+	}
+
+	return 0, false, false
+}
+
+func isRemovedByX9(c bidiClass) bool {
+	switch c {
+	case bidiLRE, bidiRLE, bidiLRO, bidiRLO, bidiPDF, bidiBN:
+		return true
+	}
+	return false
+}
+
+func isIsolateInitiator(c bidiClass) bool {
+	return c == bidiLRI || c == bidiRLI || c == bidiFSI
+}
+
+func isNeutralOrIsolate(c bidiClass) bool {
+	switch c {
+	case bidiB, bidiS, bidiWS, bidiON, bidiLRI, bidiRLI, bidiFSI, bidiPDI:
+		return true
+	}
+	return false
+}
+
+// strongDirection returns the direction that a resolved type counts as for
+// the rules N0 to N2, where numbers are right-to-left, or bidiON for the
+// neutrals.
+func strongDirection(c bidiClass) bidiClass {
+	switch c {
+	case bidiL:
+		return bidiL
+	case bidiR, bidiAL, bidiEN, bidiAN:
+		return bidiR
+	}
+	return bidiON
+}
+
+func directionOfLevel(level uint8) bidiClass {
+	if level&1 == 0 {
+		return bidiL
+	}
+	return bidiR
+}
+
+// bidiParagraph holds the state of the resolution of the levels of a
+// paragraph.
+type bidiParagraph struct {
+	classes []bidiClass
+	runes   []rune
+
+	// The types of the characters as they are resolved by the rules.
+	types  []bidiClass
+	levels []uint8
+	level  uint8
+
+	// The index of the PDI matching each isolate initiator, or the length of
+	// the paragraph if there is none, and whether each PDI has a matching
+	// isolate initiator.
+	matchingPDI []int
+	matched     []bool
+}
+
+// newBidiParagraph resolves the levels of a paragraph made of characters of
+// the given classes. The runes are used to pair brackets, they may be nil.
+func newBidiParagraph(classes []bidiClass, runes []rune, dir WritingDirection) *bidiParagraph {
+	p := &bidiParagraph{
+		classes:     classes,
+		runes:       runes,
+		types:       append([]bidiClass(nil), classes...),
+		levels:      make([]uint8, len(classes)),
+		matchingPDI: make([]int, len(classes)),
+		matched:     make([]bool, len(classes)),
+	}
+
+	p.matchIsolates()
+
+	switch dir {
+	case WritingDirectionLeftToRight:
+		p.level = 0
+	case WritingDirectionRightToLeft:
+		p.level = 1
+	default:
+		p.level = p.firstStrongLevel(0, len(classes))
+	}
+
+	p.resolveExplicitLevels()
+	sequences := p.isolatingRunSequences()
+
+	for _, s := range sequences {
+		s.resolveWeakTypes()
+		s.resolvePairedBrackets()
+		s.resolveNeutralTypes()
+		s.resolveImplicitLevels()
+	}
+
+	for i, c := range classes {
+		if isRemovedByX9(c) {
+			if i == 0 {
+				p.levels[i] = p.level
+			} else {
+				p.levels[i] = p.levels[i-1]
+			}
+		}
+	}
+
+	return p
+}
+
+// matchIsolates pairs the isolate initiators with their PDI, following the
+// definition BD9.
+func (p *bidiParagraph) matchIsolates() {
+	for i, c := range p.classes {
+		if !isIsolateInitiator(c) {
+			continue
+		}
+
+		p.matchingPDI[i] = len(p.classes)
+		depth := 1
+
+		for j := i + 1; j < len(p.classes); j++ {
+			if isIsolateInitiator(p.classes[j]) {
+				depth++
+			} else if p.classes[j] == bidiPDI {
+				if depth--; depth == 0 {
+					p.matchingPDI[i] = j
+					p.matched[j] = true
+					break
+				}
+			}
+		}
+	}
+}
+
+// firstStrongLevel applies the rules P2 and P3 to the characters from start to
+// end, and returns the level of the first strong character, skipping the
+// isolates.
+func (p *bidiParagraph) firstStrongLevel(start int, end int) uint8 {
+	for i := start; i < end; i++ {
+		switch c := p.classes[i]; {
+		case c == bidiL:
+			return 0
+		case c == bidiR || c == bidiAL:
+			return 1
+		case isIsolateInitiator(c):
+			i = p.matchingPDI[i]
+		}
+	}
+	return 0
+}
+
+// bidiStatus is an entry of the directional status stack of the rules X1 to
+// X8.
+type bidiStatus struct {
+	level    uint8
+	override bidiClass
+	isolate  bool
+}
+
+// resolveExplicitLevels applies the rules X1 to X8.
+func (p *bidiParagraph) resolveExplicitLevels() {
+	stack := []bidiStatus{{level: p.level, override: bidiON}}
+	overflowIsolates, overflowEmbeddings, validIsolates := 0, 0, 0
+
+	for i, c := range p.classes {
+		top := stack[len(stack)-1]
+		p.levels[i] = top.level
+
+		switch c {
+		case bidiRLE, bidiLRE, bidiRLO, bidiLRO:
+			level := nextLevel(top.level, c == bidiRLE || c == bidiRLO)
+
+			if level <= maxBidiDepth && overflowIsolates == 0 && overflowEmbeddings == 0 {
+				override := bidiON
+
+				switch c {
+				case bidiRLO:
+					override = bidiR
+				case bidiLRO:
+					override = bidiL
+				}
+
+				stack = append(stack, bidiStatus{level: level, override: override})
+			} else if overflowIsolates == 0 {
+				overflowEmbeddings++
+			}
+
+		case bidiRLI, bidiLRI, bidiFSI:
+			if top.override != bidiON {
+				p.types[i] = top.override
+			}
+
+			rtl := c == bidiRLI
+
+			if c == bidiFSI {
+				rtl = p.firstStrongLevel(i+1, p.matchingPDI[i]) == 1
+			}
+
+			level := nextLevel(top.level, rtl)
+
+			if level <= maxBidiDepth && overflowIsolates == 0 && overflowEmbeddings == 0 {
+				validIsolates++
+				stack = append(stack, bidiStatus{level: level, override: bidiON, isolate: true})
+			} else {
+				overflowIsolates++
+			}
+
+		case bidiPDI:
+			if overflowIsolates > 0 {
+				overflowIsolates--
+			} else if validIsolates > 0 {
+				overflowEmbeddings = 0
+
+				for !stack[len(stack)-1].isolate {
+					stack = stack[:len(stack)-1]
+				}
+
+				stack = stack[:len(stack)-1]
+				validIsolates--
+			}
+
+			top = stack[len(stack)-1]
+			p.levels[i] = top.level
+
+			if top.override != bidiON {
+				p.types[i] = top.override
+			}
+
+		case bidiPDF:
+			if overflowIsolates > 0 {
+			} else if overflowEmbeddings > 0 {
+				overflowEmbeddings--
+			} else if !top.isolate && len(stack) >= 2 {
+				stack = stack[:len(stack)-1]
+			}
+
+		case bidiB:
+			// X8: paragraph separators terminate the embeddings, overrides
+			// and isolates.
+			p.levels[i] = p.level
+			stack = stack[:1]
+			overflowIsolates, overflowEmbeddings, validIsolates = 0, 0, 0
+
+		case bidiBN:
+
+		default:
+			if top.override != bidiON {
+				p.types[i] = top.override
+			}
+		}
+	}
+}
+
+// nextLevel returns the least odd level greater than level if rtl is true, or
+// the least even level greater than level otherwise.
+func nextLevel(level uint8, rtl bool) uint8 {
+	if rtl {
+		return (level + 1) | 1
+	}
+	return (level + 2) &^ 1
+}
+
+// isolatingRunSequences applies the rules X9 and X10, and returns the
+// isolating run sequences of the paragraph.
+func (p *bidiParagraph) isolatingRunSequences() []*isolatingRunSequence {
+	// The level runs are made of the characters that are not removed by X9.
+	runs := [][]int{}
+	run := []int{}
+
+	for i, c := range p.classes {
+		if isRemovedByX9(c) {
+			continue
+		}
+
+		if len(run) != 0 && p.levels[i] != p.levels[run[0]] {
+			runs = append(runs, run)
+			run = []int{}
+		}
+
+		run = append(run, i)
+	}
+
+	if len(run) != 0 {
+		runs = append(runs, run)
+	}
+
+	// The runs are chained from the isolate initiators that end them to the
+	// runs that start with the matching PDI.
+	runStartingAt := make(map[int]int, len(runs))
+
+	for i, r := range runs {
+		runStartingAt[r[0]] = i
+	}
+
+	sequences := []*isolatingRunSequence{}
+
+	for _, r := range runs {
+		if p.classes[r[0]] == bidiPDI && p.matched[r[0]] {
+			continue
+		}
+
+		indexes := append([]int(nil), r...)
+
+		for {
+			last := indexes[len(indexes)-1]
+
+			if !isIsolateInitiator(p.classes[last]) || p.matchingPDI[last] == len(p.classes) {
+				break
+			}
+
+			next, ok := runStartingAt[p.matchingPDI[last]]
+
+			if !ok {
+				break
+			}
+
+			indexes = append(indexes, runs[next]...)
+		}
+
+		sequences = append(sequences, p.newIsolatingRunSequence(indexes))
+	}
+
+	return sequences
+}
+
+// isolatingRunSequence is a sequence of characters that the rules W1 to I2 are
+// applied to.
+type isolatingRunSequence struct {
+	p       *bidiParagraph
+	indexes []int
+	types   []bidiClass
+	level   uint8
+	sos     bidiClass
+	eos     bidiClass
+}
+
+func (p *bidiParagraph) newIsolatingRunSequence(indexes []int) *isolatingRunSequence {
+	s := &isolatingRunSequence{
+		p:       p,
+		indexes: indexes,
+		types:   make([]bidiClass, len(indexes)),
+		level:   p.levels[indexes[0]],
+	}
+
+	for i, j := range indexes {
+		s.types[i] = p.types[j]
+	}
+
+	prev := indexes[0] - 1
+
+	for prev >= 0 && isRemovedByX9(p.classes[prev]) {
+		prev--
+	}
+
+	prevLevel := p.level
+
+	if prev >= 0 {
+		prevLevel = p.levels[prev]
+	}
+
+	last := indexes[len(indexes)-1]
+	next := last + 1
+
+	for next < len(p.classes) && isRemovedByX9(p.classes[next]) {
+		next++
+	}
+
+	nextLevel := p.level
+
+	if next < len(p.classes) && !isIsolateInitiator(p.classes[last]) {
+		nextLevel = p.levels[next]
+	}
+
+	s.sos = directionOfLevel(maxLevel(prevLevel, s.level))
+	s.eos = directionOfLevel(maxLevel(nextLevel, s.level))
+	return s
+}
+
+func maxLevel(l1 uint8, l2 uint8) uint8 {
+	if l1 > l2 {
+		return l1
+	}
+	return l2
+}
+
+// resolveWeakTypes applies the rules W1 to W7.
+func (s *isolatingRunSequence) resolveWeakTypes() {
+	types := s.types
+
+	// W1: non-spacing marks take the type of the previous character, or ON
+	// after isolate initiators and PDIs.
+	for i, t := range types {
+		if t != bidiNSM {
+			continue
+		}
+
+		switch {
+		case i == 0:
+			types[i] = s.sos
+		case isIsolateInitiator(types[i-1]) || types[i-1] == bidiPDI:
+			types[i] = bidiON
+		default:
+			types[i] = types[i-1]
+		}
+	}
+
+	// W2 and W3: European numbers following Arabic letters are Arabic
+	// numbers, Arabic letters are right-to-left.
+	strong := s.sos
+
+	for i, t := range types {
+		switch t {
+		case bidiL, bidiR, bidiAL:
+			strong = t
+		case bidiEN:
+			if strong == bidiAL {
+				types[i] = bidiAN
+			}
+		}
+	}
+
+	for i, t := range types {
+		if t == bidiAL {
+			types[i] = bidiR
+		}
+	}
+
+	// W4: single separators between numbers of the same type take their type.
+	for i := 1; i < len(types)-1; i++ {
+		before, after := types[i-1], types[i+1]
+
+		switch {
+		case types[i] == bidiES && before == bidiEN && after == bidiEN:
+			types[i] = bidiEN
+		case types[i] == bidiCS && before == bidiEN && after == bidiEN:
+			types[i] = bidiEN
+		case types[i] == bidiCS && before == bidiAN && after == bidiAN:
+			types[i] = bidiAN
+		}
+	}
+
+	// W5: sequences of terminators adjacent to European numbers are European
+	// numbers.
+	for i := 0; i < len(types); {
+		if types[i] != bidiET {
+			i++
+			continue
+		}
+
+		j := i + 1
+
+		for j < len(types) && types[j] == bidiET {
+			j++
+		}
+
+		if (i > 0 && types[i-1] == bidiEN) || (j < len(types) && types[j] == bidiEN) {
+			for k := i; k < j; k++ {
+				types[k] = bidiEN
+			}
+		}
+
+		i = j
+	}
+
+	// W6: the remaining separators and terminators are neutrals.
+	for i, t := range types {
+		switch t {
+		case bidiES, bidiET, bidiCS:
+			types[i] = bidiON
+		}
+	}
+
+	// W7: European numbers following left-to-right characters are
+	// left-to-right.
+	strong = s.sos
+
+	for i, t := range types {
+		switch t {
+		case bidiL, bidiR:
+			strong = t
+		case bidiEN:
+			if strong == bidiL {
+				types[i] = bidiL
+			}
+		}
+	}
+}
+
+// bracketPair is a pair of brackets of an isolating run sequence, identified by
+// their positions in the sequence.
+type bracketPair struct {
+	open, close int
+}
+
+// bracketPairs returns the pairs of brackets of the sequence, sorted by
+// opening bracket, following the definition BD16.
+func (s *isolatingRunSequence) bracketPairs() []bracketPair {
+	if s.p.runes == nil {
+		return nil
+	}
+
+	type opening struct {
+		closing rune
+		pos     int
+	}
+
+	stack := []opening{}
+	pairs := []bracketPair{}
+
+	for i, j := range s.indexes {
+		if s.types[i] != bidiON {
+			continue
+		}
+
+		closing, open, ok := bracketOf(s.p.runes[j])
+
+		switch {
+		case !ok:
+		case open:
+			if len(stack) == maxBracketDepth {
+				sort.Slice(pairs, func(i, j int) bool { return pairs[i].open < pairs[j].open })
+				return pairs
+			}
+
+			stack = append(stack, opening{closing, i})
+
+		default:
+			for k := len(stack) - 1; k >= 0; k-- {
+				if stack[k].closing == closing {
+					pairs = append(pairs, bracketPair{stack[k].pos, i})
+					stack = stack[:k]
+					break
+				}
+			}
+		}
+	}
+
+	sort.Slice(pairs, func(i, j int) bool { return pairs[i].open < pairs[j].open })
+	return pairs
+}
+
+// resolvePairedBrackets applies the rule N0.
+func (s *isolatingRunSequence) resolvePairedBrackets() {
+	embedding := directionOfLevel(s.level)
+
+	for _, pair := range s.bracketPairs() {
+		inside := bidiON
+
+		for i := pair.open + 1; i < pair.close; i++ {
+			if d := strongDirection(s.types[i]); d == embedding {
+				inside = d
+				break
+			} else if d != bidiON {
+				inside = d
+			}
+		}
+
+		switch inside {
+		case bidiON:
+			continue
+
+		case embedding:
+
+		default:
+			// The brackets take the opposite direction when the context
+			// before them also has it.
+			before := s.sos
+
+			for i := pair.open - 1; i >= 0; i-- {
+				if d := strongDirection(s.types[i]); d != bidiON {
+					before = d
+					break
+				}
+			}
+
+			if before != inside {
+				inside = embedding
+			}
+		}
+
+		s.setBracketType(pair.open, inside)
+		s.setBracketType(pair.close, inside)
+	}
+}
+
+// setBracketType sets the type of the bracket at position i, and the type of
+// the non-spacing marks that follow it.
+func (s *isolatingRunSequence) setBracketType(i int, t bidiClass) {
+	s.types[i] = t
+
+	for i++; i < len(s.types) && s.p.classes[s.indexes[i]] == bidiNSM; i++ {
+		s.types[i] = t
+	}
+}
+
+// resolveNeutralTypes applies the rules N1 and N2.
+func (s *isolatingRunSequence) resolveNeutralTypes() {
+	types := s.types
+	embedding := directionOfLevel(s.level)
+
+	for i := 0; i < len(types); {
+		if !isNeutralOrIsolate(types[i]) {
+			i++
+			continue
+		}
+
+		j := i + 1
+
+		for j < len(types) && isNeutralOrIsolate(types[j]) {
+			j++
+		}
+
+		before, after := s.sos, s.eos
+
+		if i > 0 {
+			before = strongDirection(types[i-1])
+		}
+
+		if j < len(types) {
+			after = strongDirection(types[j])
+		}
+
+		t := embedding
+
+		if before == after {
+			t = before
+		}
+
+		for k := i; k < j; k++ {
+			types[k] = t
+		}
+
+		i = j
+	}
+}
+
+// resolveImplicitLevels applies the rules I1 and I2, and sets the levels and
+// types of the characters of the paragraph.
+func (s *isolatingRunSequence) resolveImplicitLevels() {
+	for i, j := range s.indexes {
+		level := s.p.levels[j]
+		t := s.types[i]
+
+		if level&1 == 0 {
+			switch t {
+			case bidiR:
+				level++
+			case bidiAN, bidiEN:
+				level += 2
+			}
+		} else if t == bidiL || t == bidiEN || t == bidiAN {
+			level++
+		}
+
+		s.p.levels[j] = level
+		s.p.types[j] = t
+	}
+}
+
+// lineLevels applies the rule L1 to the levels of the characters of a line.
+func lineLevels(classes []bidiClass, levels []uint8, paragraphLevel uint8) []uint8 {
+	line := append([]uint8(nil), levels...)
+
+	// The whitespace is reset when it precedes a separator or the end of the
+	// line, the loop goes backwards to know which whitespace does.
+	trailing := true
+
+	for i := len(classes) - 1; i >= 0; i-- {
+		switch c := classes[i]; {
+		case c == bidiS || c == bidiB:
+			line[i] = paragraphLevel
+			trailing = true
+		case c == bidiWS || isIsolateInitiator(c) || c == bidiPDI || isRemovedByX9(c):
+			if trailing {
+				line[i] = paragraphLevel
+			}
+		default:
+			trailing = false
+		}
+	}
+
+	return line
+}
@@ -0,0 +1,375 @@
+package layout
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+func TestBidiLevels(t *testing.T) {
+	tests := []struct {
+		name   string
+		s      string
+		dir    WritingDirection
+		levels []uint8
+		level  uint8
+	}{
+		{
+			name: "empty",
+		},
+		{
+			name:   "left-to-right",
+			s:      "ab",
+			dir:    WritingDirectionNatural,
+			levels: []uint8{0, 0},
+		},
+		{
+			name:   "right-to-left",
+			s:      "אב",
+			dir:    WritingDirectionNatural,
+			levels: []uint8{1, 1},
+			level:  1,
+		},
+		{
+			name:   "first strong character",
+			s:      "1 אa",
+			dir:    WritingDirectionNatural,
+			levels: []uint8{2, 1, 1, 2},
+			level:  1,
+		},
+		{
+			name:   "right-to-left run",
+			s:      "a אב c",
+			levels: []uint8{0, 0, 1, 1, 0, 0},
+		},
+		{
+			name:   "numbers after a right-to-left run",
+			s:      "א 12",
+			dir:    WritingDirectionRightToLeft,
+			levels: []uint8{1, 1, 2, 2},
+			level:  1,
+		},
+		{
+			name:   "arabic numbers",
+			s:      "ب ١٢",
+			levels: []uint8{1, 1, 2, 2},
+		},
+		{
+			name:   "brackets",
+			s:      "a (א) b",
+			levels: []uint8{0, 0, 0, 1, 0, 0, 0},
+		},
+		{
+			name:   "brackets in right-to-left context",
+			s:      "אב (a) ג",
+			dir:    WritingDirectionRightToLeft,
+			levels: []uint8{1, 1, 1, 1, 2, 1, 1, 1},
+			level:  1,
+		},
+		{
+			name:   "embedding",
+			s:      "a‫b‬c",
+			levels: []uint8{0, 0, 2, 2, 0},
+		},
+		{
+			name:   "override",
+			s:      "‮ab‬",
+			levels: []uint8{0, 1, 1, 1},
+		},
+		{
+			name:   "isolate",
+			s:      "א⁦a⁩ב",
+			dir:    WritingDirectionNatural,
+			levels: []uint8{1, 1, 2, 1, 1},
+			level:  1,
+		},
+		{
+			name:   "first strong isolate",
+			s:      "a⁨אb⁩c",
+			levels: []uint8{0, 0, 1, 2, 0, 0},
+		},
+	}
+
+	for _, test := range tests {
+		levels, level := BidiLevels([]rune(test.s), test.dir)
+
+		if !equalLevels(levels, test.levels) || level != test.level {
+			t.Errorf("%s: invalid levels: %v, %d != %v, %d", test.name, levels, level, test.levels, test.level)
+		}
+	}
+}
+
+func TestBidiLineLevels(t *testing.T) {
+	runes := []rune("אב \tג  ")
+	levels, level := BidiLevels(runes, WritingDirectionLeftToRight)
+	expected := []uint8{1, 1, 0, 0, 1, 0, 0}
+
+	if line := BidiLineLevels(runes, levels, level); !equalLevels(line, expected) {
+		t.Errorf("invalid line levels: %v != %v", line, expected)
+	}
+}
+
+func TestVisualOrder(t *testing.T) {
+	tests := []struct {
+		levels []uint8
+		order  []int
+	}{
+		{
+			levels: []uint8{},
+			order:  []int{},
+		},
+		{
+			levels: []uint8{0, 0, 0},
+			order:  []int{0, 1, 2},
+		},
+		{
+			levels: []uint8{1, 1, 1},
+			order:  []int{2, 1, 0},
+		},
+		{
+			levels: []uint8{0, 1, 1, 0},
+			order:  []int{0, 2, 1, 3},
+		},
+		{
+			levels: []uint8{1, 2, 2, 1, 1},
+			order:  []int{4, 3, 1, 2, 0},
+		},
+		{
+			levels: []uint8{0, 1, 2, 3, 2, 1},
+			order:  []int{0, 5, 2, 3, 4, 1},
+		},
+	}
+
+	for _, test := range tests {
+		if order := VisualOrder(test.levels); fmt.Sprint(order) != fmt.Sprint(test.order) {
+			t.Errorf("invalid visual order of %v: %v != %v", test.levels, order, test.order)
+		}
+	}
+}
+
+func TestMirroredRune(t *testing.T) {
+	tests := []struct {
+		r        rune
+		mirrored rune
+		ok       bool
+	}{
+		{'(', ')', true},
+		{')', '(', true},
+		{'<', '>', true},
+		{'[', ']', true},
+		{'«', '»', true},
+		{'≤', '≥', true},
+		{'a', 'a', false},
+		{'-', '-', false},
+	}
+
+	for _, test := range tests {
+		if mirrored, ok := MirroredRune(test.r); mirrored != test.mirrored || ok != test.ok {
+			t.Errorf("invalid mirrored rune of %q: %q, %t != %q, %t", test.r, mirrored, ok, test.mirrored, test.ok)
+		}
+	}
+}
+
+func TestWritingDirectionString(t *testing.T) {
+	tests := []struct {
+		d WritingDirection
+		s string
+	}{
+		{WritingDirectionNatural, "WritingDirectionNatural"},
+		{WritingDirectionLeftToRight, "WritingDirectionLeftToRight"},
+		{WritingDirectionRightToLeft, "WritingDirectionRightToLeft"},
+		{WritingDirection(42), "WritingDirection(42)"},
+	}
+
+	for _, test := range tests {
+		if s := test.d.String(); s != test.s {
+			t.Errorf("invalid string: %s != %s", s, test.s)
+		}
+	}
+}
+
+func TestBidiTables(t *testing.T) {
+	for i, r := range bidiRanges {
+		if r.lo > r.hi || (i != 0 && r.lo <= bidiRanges[i-1].hi) {
+			t.Errorf("range %d is not sorted: %U-%U", i, r.lo, r.hi)
+		}
+	}
+
+	for i, pair := range bidiBrackets {
+		if i != 0 && pair[0] <= bidiBrackets[i-1][0] {
+			t.Errorf("bracket %d is not sorted: %U", i, pair[0])
+		}
+
+		if m, _ := MirroredRune(pair[0]); m != pair[1] {
+			t.Errorf("bracket %U is not mirrored: %U != %U", pair[0], m, pair[1])
+		}
+	}
+
+	for i, pair := range bidiMirrors {
+		if i != 0 && pair[0] <= bidiMirrors[i-1][0] {
+			t.Errorf("mirror %d is not sorted: %U", i, pair[0])
+		}
+
+		if m, _ := MirroredRune(pair[1]); m != pair[0] {
+			t.Errorf("mirror %U is not symmetric: %U != %U", pair[1], m, pair[0])
+		}
+	}
+}
+
+// TestBidiConformance runs the conformance tests of the Unicode Character
+// Database that are made of bidirectional classes, see testdata/README.md.
+func TestBidiConformance(t *testing.T) {
+	path := "testdata/BidiTest.txt.gz"
+
+	classes := map[string]bidiClass{
+		"L": bidiL, "R": bidiR, "EN": bidiEN, "ES": bidiES, "ET": bidiET,
+		"AN": bidiAN, "CS": bidiCS, "B": bidiB, "S": bidiS, "WS": bidiWS,
+		"ON": bidiON, "BN": bidiBN, "NSM": bidiNSM, "AL": bidiAL,
+		"LRO": bidiLRO, "RLO": bidiRLO, "LRE": bidiLRE, "RLE": bidiRLE,
+		"PDF": bidiPDF, "LRI": bidiLRI, "RLI": bidiRLI, "FSI": bidiFSI,
+		"PDI": bidiPDI,
+	}
+	directions := []WritingDirection{
+		WritingDirectionNatural,
+		WritingDirectionLeftToRight,
+		WritingDirectionRightToLeft,
+	}
+	levels, order := "", ""
+
+	for _, line := range readTestLines(t, path) {
+		if strings.HasPrefix(line, "@Levels:") {
+			levels = strings.TrimSpace(strings.TrimPrefix(line, "@Levels:"))
+			continue
+		}
+
+		if strings.HasPrefix(line, "@Reorder:") {
+			order = strings.TrimSpace(strings.TrimPrefix(line, "@Reorder:"))
+			continue
+		}
+
+		fields := strings.Split(line, ";")
+
+		if len(fields) != 2 {
+			t.Fatal("invalid test:", line)
+		}
+
+		input := []bidiClass{}
+
+		for _, name := range strings.Fields(fields[0]) {
+			input = append(input, classes[name])
+		}
+
+		set, err := strconv.ParseUint(strings.TrimSpace(fields[1]), 10, 8)
+
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		for i, dir := range directions {
+			if set&(1<<uint(i)) == 0 {
+				continue
+			}
+
+			p := newBidiParagraph(input, nil, dir)
+			gotLevels, gotOrder := resolvedLine(input, p.levels, p.level)
+
+			if gotLevels != levels || gotOrder != order {
+				t.Errorf("%s (%s): invalid levels and order: %s; %s != %s; %s", line, dir, gotLevels, gotOrder, levels, order)
+			}
+		}
+	}
+}
+
+// TestBidiCharacterConformance runs the conformance tests of the Unicode
+// Character Database that are made of characters, which include paired
+// brackets.
+func TestBidiCharacterConformance(t *testing.T) {
+	path := "testdata/BidiCharacterTest.txt.gz"
+
+	directions := []WritingDirection{
+		WritingDirectionLeftToRight,
+		WritingDirectionRightToLeft,
+		WritingDirectionNatural,
+	}
+
+	for _, line := range readTestLines(t, path) {
+		fields := strings.Split(line, ";")
+
+		if len(fields) != 5 {
+			t.Fatal("invalid test:", line)
+		}
+
+		runes := []rune{}
+
+		for _, field := range strings.Fields(fields[0]) {
+			r, err := strconv.ParseUint(field, 16, 32)
+
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			runes = append(runes, rune(r))
+		}
+
+		dir, err := strconv.Atoi(fields[1])
+
+		if err != nil || dir < 0 || dir >= len(directions) {
+			t.Fatal("invalid direction:", line)
+		}
+
+		classes := make([]bidiClass, len(runes))
+
+		for i, r := range runes {
+			classes[i] = bidiClassOf(r)
+		}
+
+		levels, level := BidiLevels(runes, directions[dir])
+		gotLevels, gotOrder := resolvedLine(classes, levels, level)
+
+		if strconv.Itoa(int(level)) != fields[2] || gotLevels != strings.TrimSpace(fields[3]) || gotOrder != strings.TrimSpace(fields[4]) {
+			t.Errorf("%s: invalid level, levels and order: %d; %s; %s", line, level, gotLevels, gotOrder)
+		}
+	}
+}
+
+// resolvedLine formats the levels of a line and its visual order like the
+// conformance tests do, where the characters removed by the rule X9 have no
+// level and are not reordered.
+func resolvedLine(classes []bidiClass, levels []uint8, paragraphLevel uint8) (string, string) {
+	line := lineLevels(classes, levels, paragraphLevel)
+	formatted := make([]string, len(line))
+	kept, indexes := []uint8{}, []int{}
+
+	for i, l := range line {
+		if isRemovedByX9(classes[i]) {
+			formatted[i] = "x"
+			continue
+		}
+
+		formatted[i] = strconv.Itoa(int(l))
+		kept, indexes = append(kept, l), append(indexes, i)
+	}
+
+	order := []string{}
+
+	for _, i := range VisualOrder(kept) {
+		order = append(order, strconv.Itoa(indexes[i]))
+	}
+
+	return strings.Join(formatted, " "), strings.Join(order, " ")
+}
+
+func equalLevels(l1 []uint8, l2 []uint8) bool {
+	if len(l1) != len(l2) {
+		return false
+	}
+
+	for i := range l1 {
+		if l1[i] != l2[i] {
+			return false
+		}
+	}
+
+	return true
+}
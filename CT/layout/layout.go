@@ -0,0 +1,729 @@
+// Package layout implements the layout of paragraphs of text into lines, with
+// Unicode line breaking, alignment, line spacing and truncation. Lines that mix
+// left-to-right and right-to-left scripts are reordered with the Unicode
+// bidirectional algorithm.
+//
+// The package is written against the CT.FontSource interface, which CT.FontRef
+// and the fonts of the fontfile package implement, so that text can be laid
+// out and tested on any platform. The names of the types and constants follow
+// the paragraph styles of Core Text.
+//
+// https://developer.apple.com/documentation/coretext/ctparagraphstyle
+package layout
+
+import (
+	"fmt"
+	"image"
+
+	"github.com/go-vu/cocoa/CG"
+	"github.com/go-vu/cocoa/CT"
+	"github.com/go-vu/cocoa/CT/grapheme"
+)
+
+// TextAlignment is an enumeration of the ways lines are aligned horizontally.
+//
+// https://developer.apple.com/documentation/coretext/cttextalignment
+type TextAlignment int
+
+// These constants are all the possible values of the TextAlignment enumeration.
+const (
+	// Lines are aligned to the left edge of the frame.
+	TextAlignmentLeft TextAlignment = iota
+
+	// Lines are aligned to the right edge of the frame.
+	TextAlignmentRight
+
+	// Lines are centered in the frame.
+	TextAlignmentCenter
+
+	// Lines are aligned to the left edge of the frame and the spaces between
+	// their words are stretched to reach the right edge, except for the last
+	// lines of paragraphs.
+	TextAlignmentJustified
+
+	// Lines are aligned to the left edge of the frame in left-to-right
+	// paragraphs, and to the right edge in right-to-left paragraphs.
+	TextAlignmentNatural
+)
+
+// String satisfies the fmt.Stringer interface.
+func (a TextAlignment) String() string {
+	switch a {
+	case TextAlignmentLeft:
+		return "TextAlignmentLeft"
+	case TextAlignmentRight:
+		return "TextAlignmentRight"
+	case TextAlignmentCenter:
+		return "TextAlignmentCenter"
+	case TextAlignmentJustified:
+		return "TextAlignmentJustified"
+	case TextAlignmentNatural:
+		return "TextAlignmentNatural"
+	default:
+		return fmt.Sprintf("TextAlignment(%d)", int(a))
+	}
+}
+
+// LineBreakMode is an enumeration of the ways lines that are too long to fit
+// the width of a frame are wrapped.
+//
+// https://developer.apple.com/documentation/coretext/ctlinebreakmode
+type LineBreakMode int
+
+// These constants are all the possible values of the LineBreakMode
+// enumeration.
+const (
+	// Lines are wrapped at the line break opportunities of the Unicode line
+	// breaking algorithm, which are mostly between words. Words that don't
+	// fit on a line by themselves are wrapped between characters.
+	LineBreakByWordWrapping LineBreakMode = iota
+
+	// Lines are wrapped between any grapheme clusters.
+	LineBreakByCharWrapping
+)
+
+// String satisfies the fmt.Stringer interface.
+func (m LineBreakMode) String() string {
+	switch m {
+	case LineBreakByWordWrapping:
+		return "LineBreakByWordWrapping"
+	case LineBreakByCharWrapping:
+		return "LineBreakByCharWrapping"
+	default:
+		return fmt.Sprintf("LineBreakMode(%d)", int(m))
+	}
+}
+
+// The string appended to truncated lines when the options don't set one, a
+// horizontal ellipsis.
+const defaultEllipsis = "…"
+
+// Options are the options of Layout, the zero value lays out the text in a
+// single column of unlimited width, one line per paragraph, aligned to the
+// left.
+type Options struct {
+	// Width is the width of the frame that lines are wrapped to, zero
+	// disables wrapping.
+	Width CG.Float
+
+	// Alignment is the horizontal alignment of the lines. Without wrapping,
+	// lines are aligned in a frame as wide as the longest line.
+	Alignment TextAlignment
+
+	// WritingDirection is the base direction of the paragraphs, which
+	// orders the runs of text of different directions on each line. The zero
+	// value is left-to-right, WritingDirectionNatural takes the direction of
+	// the first strong character of each paragraph.
+	//
+	// https://developer.apple.com/documentation/coretext/ctparagraphstylespecifier/kctparagraphstylespecifierbasewritingdirection
+	WritingDirection WritingDirection
+
+	// LineBreakMode selects where lines that are too long are wrapped.
+	LineBreakMode LineBreakMode
+
+	// LineHeightMultiple scales the height of the lines, which is the sum of
+	// the ascent, descent and leading of the font. Zero selects 1.
+	//
+	// https://developer.apple.com/documentation/coretext/ctparagraphstylespecifier/kctparagraphstylespecifierlineheightmultiple
+	LineHeightMultiple CG.Float
+
+	// LineSpacing is added between lines, in points.
+	//
+	// https://developer.apple.com/documentation/coretext/ctparagraphstylespecifier/kctparagraphstylespecifierlinespacingadjustment
+	LineSpacing CG.Float
+
+	// MaxLines is the maximum number of lines, zero means no limit. When the
+	// text needs more lines the last one is truncated and ends with the
+	// ellipsis.
+	MaxLines int
+
+	// Ellipsis is the string appended to truncated lines, the default is a
+	// horizontal ellipsis.
+	Ellipsis string
+
+	// Measure are the options that lines are measured with, which set tab
+	// stops, letter spacing and kerning, nil selects the defaults.
+	Measure *CT.MeasureOptions
+}
+
+// Line is a line of a frame.
+type Line struct {
+	// Start and End are the indexes of the runes of the text that the line
+	// holds, the line terminator and the trailing spaces included.
+	Start, End int
+
+	// Runes are the runes that the line draws, in visual order from left to
+	// right. They are the runes of the text without the line terminator,
+	// followed by the ellipsis if the line was truncated, reordered by the
+	// bidirectional algorithm. Runes at right-to-left levels are replaced by
+	// their mirrored rune, if they have one.
+	Runes []rune
+
+	// Indexes are the indexes in the text of the runes of the line, the runes
+	// of the ellipsis have the index -1.
+	Indexes []int
+
+	// Positions are the origins of the runes, on the baseline of the line,
+	// in the coordinate space of the frame which has its origin in the
+	// top-left corner.
+	Positions []CG.Point
+
+	// Origin is the position of the start of the baseline of the line in the
+	// coordinate space of the frame.
+	Origin CG.Point
+
+	// Width is the advance of the line, without its trailing spaces.
+	Width CG.Float
+
+	// Truncated is true if the line ends with the ellipsis.
+	Truncated bool
+}
+
+// Frame is the result of laying out text, it's made of lines stacked from the
+// top of the frame.
+//
+// https://developer.apple.com/documentation/coretext/ctframe
+type Frame struct {
+	Lines []Line
+
+	// Size is the size of the frame, which is as wide as the width of the
+	// options or as the longest line if there was no wrapping, and as high
+	// as the lines from the ascent of the first to the descent of the last.
+	Size CG.Size
+
+	// Truncated is true if the text didn't fit in the maximum number of
+	// lines.
+	Truncated bool
+
+	font CT.FontSource
+}
+
+// Layout lays out the text s with the metrics of the font f, breaking lines at
+// newlines and where they would be wider than the frame.
+func Layout(f CT.FontSource, s string, opts *Options) *Frame {
+	o := Options{}
+
+	if opts != nil {
+		o = *opts
+	}
+
+	if o.LineHeightMultiple <= 0 {
+		o.LineHeightMultiple = 1
+	}
+
+	if len(o.Ellipsis) == 0 {
+		o.Ellipsis = defaultEllipsis
+	}
+
+	runes := []rune(s)
+	breaks, state := lineBreaks(runes)
+	p := paragraph{
+		font:     f,
+		opts:     o,
+		runes:    runes,
+		breaks:   breaks,
+		state:    state,
+		clusters: clusterBoundaries(runes),
+	}
+	p.resolveLevels()
+	frame := &Frame{font: f}
+
+	for start := 0; start < len(runes); {
+		if o.MaxLines > 0 && len(frame.Lines) == o.MaxLines-1 {
+			if line, ok := p.truncatedLine(start); ok {
+				frame.Lines = append(frame.Lines, line)
+				frame.Truncated = true
+				break
+			}
+		}
+
+		line := p.line(start, p.lineEnd(start))
+		frame.Lines = append(frame.Lines, line)
+		start = line.End
+	}
+
+	p.align(frame)
+	return frame
+}
+
+// Draw draws the lines of the frame into the alpha image, origin being the
+// position of the top-left corner of the frame in the coordinate space of the
+// image. The runes are drawn by grapheme clusters with CT.ClusterDraw.
+//
+// https://developer.apple.com/documentation/coretext/1399020-ctframedraw
+func (frame *Frame) Draw(origin CG.Point, alpha *image.Alpha) {
+	for _, line := range frame.Lines {
+		for i := 0; i < len(line.Runes); {
+			n := grapheme.ClusterLen(line.Runes[i:])
+			p := line.Positions[i]
+			CT.ClusterDraw(frame.font, string(line.Runes[i:i+n]), CG.Point{X: origin.X + p.X, Y: origin.Y + p.Y}, alpha)
+			i += n
+		}
+	}
+}
+
+// paragraph holds the state of the layout of a text.
+type paragraph struct {
+	font   CT.FontSource
+	opts   Options
+	runes  []rune
+	breaks []LineBreak
+	state  *lineBreakState
+
+	// Whether each index of the runes, and their end, is the boundary of a
+	// grapheme cluster.
+	clusters []bool
+
+	// The bidi levels of the runes, and the levels of the paragraphs that
+	// they belong to.
+	levels          []uint8
+	paragraphLevels []uint8
+}
+
+// resolveLevels resolves the bidi levels of the runes, paragraphs being
+// separated by mandatory breaks.
+func (p *paragraph) resolveLevels() {
+	p.levels = make([]uint8, len(p.runes))
+	p.paragraphLevels = make([]uint8, len(p.runes))
+
+	for start := 0; start < len(p.runes); {
+		end := p.paragraphEnd(start)
+		levels, level := BidiLevels(p.runes[start:end], p.opts.WritingDirection)
+		copy(p.levels[start:], levels)
+
+		for i := start; i < end; i++ {
+			p.paragraphLevels[i] = level
+		}
+
+		start = end
+	}
+}
+
+// lineEnd returns the index where the line starting at index start ends.
+//
+// The clusters of the line are measured once, from its start, until the line
+// stops fitting the width of the frame at a break.
+func (p *paragraph) lineEnd(start int) int {
+	end := p.paragraphEnd(start)
+
+	if p.opts.Width <= 0 {
+		return end
+	}
+
+	widths := p.lineWidths(start)
+	wordWrap, charWrap := 0, 0
+
+	for i := start + 1; i <= end; i++ {
+		isWordBreak, isCharBoundary := p.breaks[i] != NoBreak, p.isCharBoundary(i)
+
+		if !isWordBreak && !isCharBoundary {
+			continue
+		}
+
+		if !widths.fits(i) {
+			break
+		}
+
+		if i == end {
+			return end
+		}
+
+		if isWordBreak {
+			wordWrap = i
+		}
+
+		if isCharBoundary {
+			charWrap = i
+		}
+	}
+
+	if p.opts.LineBreakMode == LineBreakByWordWrapping && wordWrap > 0 {
+		return wordWrap
+	}
+
+	// The line is wrapped between characters, the spaces that follow the
+	// break stay at the end of the line.
+	wrap := charWrap
+
+	if wrap <= 0 {
+		wrap = p.nextCharBoundary(start, end)
+	}
+
+	for wrap < end && p.state.raw[wrap] == lbSP {
+		wrap++
+	}
+
+	return wrap
+}
+
+// paragraphEnd returns the index of the first mandatory break after start.
+func (p *paragraph) paragraphEnd(start int) int {
+	end := start + 1
+
+	for p.breaks[end] != MandatoryBreak {
+		end++
+	}
+
+	return end
+}
+
+// isCharBoundary returns true if index i is between two grapheme clusters.
+func (p *paragraph) isCharBoundary(i int) bool {
+	return p.clusters[i]
+}
+
+// nextCharBoundary returns the index of the first character boundary after
+// start, or end if there is none before.
+func (p *paragraph) nextCharBoundary(start int, end int) int {
+	i := start + 1
+
+	for i < end && !p.isCharBoundary(i) {
+		i++
+	}
+
+	return i
+}
+
+// lineWidths returns the widths of the prefixes of the line that starts at
+// index start.
+func (p *paragraph) lineWidths(start int) *lineWidths {
+	return &lineWidths{
+		p:        p,
+		start:    start,
+		measured: start,
+		measurer: CT.NewLineMeasurer(p.font, p.opts.Measure),
+		advances: []CG.Float{0},
+	}
+}
+
+// lineWidths measures a line one grapheme cluster at a time, as far as it's
+// needed, and keeps the advances of its prefixes so that they are measured
+// once.
+type lineWidths struct {
+	p        *paragraph
+	start    int
+	measured int
+	measurer CT.LineMeasurer
+
+	// advances[i-start] is the advance of the runes from start to i, for the
+	// cluster boundaries i up to measured.
+	advances []CG.Float
+}
+
+// fits returns true if the runes from the start of the line to end, without
+// the trailing spaces, fit the width of the frame.
+func (w *lineWidths) fits(end int) bool {
+	return w.advance(w.p.trimEnd(w.start, end)) <= w.p.opts.Width
+}
+
+// advance returns the advance of the runes from the start of the line to end.
+func (w *lineWidths) advance(end int) CG.Float {
+	p := w.p
+
+	for w.measured < end {
+		next := p.nextCharBoundary(w.measured, len(p.runes))
+		w.measurer.Add(p.runes[w.measured:next])
+
+		for w.measured++; w.measured < next; w.measured++ {
+			w.advances = append(w.advances, 0)
+		}
+
+		w.advances = append(w.advances, w.measurer.Advance())
+	}
+
+	// The spaces that follow a prepended character are part of its cluster,
+	// the line is then trimmed within a cluster.
+	if !p.isCharBoundary(end) {
+		return p.measure(p.runes[w.start:end]).Advance
+	}
+
+	return w.advances[end-w.start]
+}
+
+// trimEnd returns the index of the end of the runes from start to end without
+// the trailing spaces and line terminator.
+func (p *paragraph) trimEnd(start int, end int) int {
+	for end > start {
+		switch p.state.raw[end-1] {
+		case lbSP, lbBK, lbCR, lbLF, lbNL:
+			end--
+			continue
+		}
+		break
+	}
+	return end
+}
+
+func (p *paragraph) measure(runes []rune) CT.Measurement {
+	return CT.MeasureString(p.font, string(runes), p.opts.Measure)
+}
+
+// line returns the line holding the runes from start to end, which isn't
+// aligned yet.
+func (p *paragraph) line(start int, end int) Line {
+	visible := end
+
+	for visible > start && isLineTerminator(p.state.raw[visible-1]) {
+		visible--
+	}
+
+	trimmed := p.trimEnd(start, end)
+	runes, indexes := p.reorder(start, visible, nil)
+	positions := p.measure(runes).Positions
+
+	// The trailing spaces of right-to-left lines are on their left, they
+	// hang outside of the line like the ones of left-to-right lines hang on
+	// their right.
+	for i, index := range indexes {
+		if index < trimmed {
+			shift := positions[i].X
+
+			for j := range positions {
+				positions[j].X -= shift
+			}
+
+			break
+		}
+	}
+
+	trimmedRunes, _ := p.reorder(start, trimmed, nil)
+	return Line{
+		Start:     start,
+		End:       end,
+		Runes:     runes,
+		Indexes:   indexes,
+		Positions: positions,
+		Width:     p.measure(trimmedRunes).Advance,
+	}
+}
+
+// reorder returns the runes from start to end followed by the extra runes in
+// visual order, and their indexes in the text, which are -1 for the extra
+// runes. The extra runes are at the level of the paragraph.
+//
+// Grapheme clusters are reordered as a whole, their runes stay in logical
+// order so that they are measured and drawn together.
+func (p *paragraph) reorder(start int, end int, extra []rune) ([]rune, []int) {
+	if start == end && len(extra) == 0 {
+		return nil, nil
+	}
+
+	level := p.paragraphLevels[start]
+	levels := BidiLineLevels(p.runes[start:end], p.levels[start:end], level)
+
+	for range extra {
+		levels = append(levels, level)
+	}
+
+	// The clusters are the ranges of levels that start at clusters[i], and
+	// take the level of their first rune.
+	clusters := []int{}
+	clusterLevels := []uint8{}
+
+	for i := range levels {
+		if i == 0 || i >= end-start || p.clusters[start+i] {
+			clusters = append(clusters, i)
+			clusterLevels = append(clusterLevels, levels[i])
+		}
+	}
+
+	clusters = append(clusters, len(levels))
+	runes := make([]rune, 0, len(levels))
+	indexes := make([]int, 0, len(levels))
+
+	for _, c := range VisualOrder(clusterLevels) {
+		for j := clusters[c]; j < clusters[c+1]; j++ {
+			r, index := rune(0), -1
+
+			if j < end-start {
+				r, index = p.runes[start+j], start+j
+			} else {
+				r = extra[j-(end-start)]
+			}
+
+			if levels[j]&1 != 0 {
+				r, _ = MirroredRune(r)
+			}
+
+			runes, indexes = append(runes, r), append(indexes, index)
+		}
+	}
+
+	return runes, indexes
+}
+
+// truncatedLine returns the last line of a frame that reached its maximum
+// number of lines, starting at index start, and false if the remaining text
+// fits on that line.
+func (p *paragraph) truncatedLine(start int) (Line, bool) {
+	end := p.lineEnd(start)
+
+	if end == len(p.runes) {
+		return Line{}, false
+	}
+
+	// The line is made of the text of the paragraph that fits the frame with
+	// the ellipsis, which is measured after each cluster until it doesn't.
+	ellipsis := []rune(p.opts.Ellipsis)
+	cut := p.trimEnd(start, end)
+
+	if p.opts.Width > 0 {
+		limit := p.trimEnd(start, p.paragraphEnd(start))
+		line := CT.NewLineMeasurer(p.font, p.opts.Measure)
+		cut = start
+
+		for i := start; i < limit; {
+			next := p.nextCharBoundary(i, limit)
+			line.Add(p.runes[i:next])
+			i = next
+
+			if p.trimEnd(start, i) != i {
+				continue
+			}
+
+			if advanceWithEllipsis(line, ellipsis) > p.opts.Width {
+				break
+			}
+
+			cut = i
+		}
+	}
+
+	runes, indexes := p.reorder(start, cut, ellipsis)
+	m := p.measure(runes)
+	return Line{
+		Start:     start,
+		End:       cut,
+		Runes:     runes,
+		Indexes:   indexes,
+		Positions: m.Positions,
+		Width:     m.Advance,
+		Truncated: true,
+	}, true
+}
+
+// advanceWithEllipsis returns the advance of the line measured by m followed by
+// the ellipsis, m is a copy so the ellipsis isn't added to the line.
+func advanceWithEllipsis(m CT.LineMeasurer, ellipsis []rune) CG.Float {
+	for i := 0; i < len(ellipsis); {
+		n := grapheme.ClusterLen(ellipsis[i:])
+		m.Add(ellipsis[i : i+n])
+		i += n
+	}
+
+	return m.Advance()
+}
+
+// align positions the lines of the frame vertically and horizontally, and
+// sets the size of the frame.
+func (p *paragraph) align(frame *Frame) {
+	f := p.font
+	ascent := f.GetAscent()
+	lineHeight := (ascent+f.GetDescent()+f.GetLeading())*p.opts.LineHeightMultiple + p.opts.LineSpacing
+	width := p.opts.Width
+
+	if width <= 0 {
+		for _, line := range frame.Lines {
+			if line.Width > width {
+				width = line.Width
+			}
+		}
+	}
+
+	for i := range frame.Lines {
+		line := &frame.Lines[i]
+		line.Origin.Y = ascent + CG.Float(i)*lineHeight
+
+		if p.opts.Alignment == TextAlignmentJustified && p.breaks[line.End] != MandatoryBreak && !line.Truncated {
+			p.justify(line, width)
+		}
+
+		switch p.alignment(line) {
+		case TextAlignmentRight:
+			line.Origin.X = width - line.Width
+		case TextAlignmentCenter:
+			line.Origin.X = (width - line.Width) / 2
+		}
+
+		positions := make([]CG.Point, len(line.Positions))
+
+		for j, pos := range line.Positions {
+			positions[j] = CG.Point{X: line.Origin.X + pos.X, Y: line.Origin.Y + pos.Y}
+		}
+
+		line.Positions = positions
+	}
+
+	frame.Size.Width = width
+
+	if n := len(frame.Lines); n != 0 {
+		frame.Size.Height = ascent + f.GetDescent() + CG.Float(n-1)*lineHeight
+	}
+}
+
+// alignment returns the alignment of the line, which is left or right for the
+// natural alignment and for justified lines, following the direction of their
+// paragraph. Stretched justified lines are as wide as the frame, so either
+// alignment places them at its left edge.
+func (p *paragraph) alignment(line *Line) TextAlignment {
+	switch p.opts.Alignment {
+	case TextAlignmentNatural, TextAlignmentJustified:
+		if p.paragraphLevels[line.Start]&1 != 0 {
+			return TextAlignmentRight
+		}
+		return TextAlignmentLeft
+	default:
+		return p.opts.Alignment
+	}
+}
+
+// justify moves the runes of the line to stretch the spaces between its words
+// so that it reaches the width of the frame.
+func (p *paragraph) justify(line *Line, width CG.Float) {
+	end := p.trimEnd(line.Start, line.End)
+	spaces := 0
+
+	for _, c := range p.state.raw[line.Start:end] {
+		if c == lbSP {
+			spaces++
+		}
+	}
+
+	if spaces == 0 || line.Width >= width {
+		return
+	}
+
+	extra := (width - line.Width) / CG.Float(spaces)
+	shift := CG.Float(0)
+
+	for i, index := range line.Indexes {
+		line.Positions[i].X += shift
+
+		if index >= 0 && index < end && p.state.raw[index] == lbSP {
+			shift += extra
+		}
+	}
+
+	line.Width = width
+}
+
+// clusterBoundaries returns whether each index of the runes, and their end, is
+// the boundary of a grapheme cluster.
+func clusterBoundaries(runes []rune) []bool {
+	boundaries := make([]bool, len(runes)+1)
+
+	for i := 0; i < len(runes); i += grapheme.ClusterLen(runes[i:]) {
+		boundaries[i] = true
+	}
+
+	boundaries[len(runes)] = true
+	return boundaries
+}
+
+func isLineTerminator(c lineBreakClass) bool {
+	switch c {
+	case lbBK, lbCR, lbLF, lbNL:
+		return true
+	}
+	return false
+}
@@ -0,0 +1,519 @@
+package layout
+
+import (
+	"fmt"
+	"image"
+	"testing"
+	"unicode"
+
+	"github.com/go-vu/cocoa/CG"
+	"github.com/go-vu/cocoa/CT"
+)
+
+func TestLayout(t *testing.T) {
+	type line struct {
+		start, end int
+		origin     CG.Point
+		width      CG.Float
+		runes      string
+	}
+
+	tests := []struct {
+		name      string
+		s         string
+		opts      *Options
+		lines     []line
+		size      CG.Size
+		truncated bool
+	}{
+		{
+			name: "empty",
+		},
+		{
+			name:  "single line",
+			s:     "ab cd",
+			lines: []line{{0, 5, CG.Point{X: 0, Y: 8}, 45, "ab cd"}},
+			size:  CG.Size{Width: 45, Height: 10},
+		},
+		{
+			name: "newlines",
+			s:    "a\r\nb\n",
+			lines: []line{
+				{0, 3, CG.Point{X: 0, Y: 8}, 10, "a"},
+				{3, 5, CG.Point{X: 0, Y: 20}, 10, "b"},
+			},
+			size: CG.Size{Width: 10, Height: 22},
+		},
+		{
+			name: "word wrapping",
+			s:    "ab cd ef",
+			opts: &Options{Width: 50},
+			lines: []line{
+				{0, 6, CG.Point{X: 0, Y: 8}, 45, "ab cd "},
+				{6, 8, CG.Point{X: 0, Y: 20}, 20, "ef"},
+			},
+			size: CG.Size{Width: 50, Height: 22},
+		},
+		{
+			name: "right alignment",
+			s:    "ab cd ef",
+			opts: &Options{Width: 50, Alignment: TextAlignmentRight},
+			lines: []line{
+				{0, 6, CG.Point{X: 5, Y: 8}, 45, "ab cd "},
+				{6, 8, CG.Point{X: 30, Y: 20}, 20, "ef"},
+			},
+			size: CG.Size{Width: 50, Height: 22},
+		},
+		{
+			name: "center alignment",
+			s:    "ab cd ef",
+			opts: &Options{Width: 50, Alignment: TextAlignmentCenter},
+			lines: []line{
+				{0, 6, CG.Point{X: 2.5, Y: 8}, 45, "ab cd "},
+				{6, 8, CG.Point{X: 15, Y: 20}, 20, "ef"},
+			},
+			size: CG.Size{Width: 50, Height: 22},
+		},
+		{
+			name: "center alignment without wrapping",
+			s:    "ab\nabcd",
+			opts: &Options{Alignment: TextAlignmentCenter},
+			lines: []line{
+				{0, 3, CG.Point{X: 10, Y: 8}, 20, "ab"},
+				{3, 7, CG.Point{X: 0, Y: 20}, 40, "abcd"},
+			},
+			size: CG.Size{Width: 40, Height: 22},
+		},
+		{
+			name: "justified alignment",
+			s:    "ab cd ef",
+			opts: &Options{Width: 50, Alignment: TextAlignmentJustified},
+			lines: []line{
+				{0, 6, CG.Point{X: 0, Y: 8}, 50, "ab cd "},
+				{6, 8, CG.Point{X: 0, Y: 20}, 20, "ef"},
+			},
+			size: CG.Size{Width: 50, Height: 22},
+		},
+		{
+			name: "long word",
+			s:    "abcdefgh",
+			opts: &Options{Width: 35},
+			lines: []line{
+				{0, 3, CG.Point{X: 0, Y: 8}, 30, "abc"},
+				{3, 6, CG.Point{X: 0, Y: 20}, 30, "def"},
+				{6, 8, CG.Point{X: 0, Y: 32}, 20, "gh"},
+			},
+			size: CG.Size{Width: 35, Height: 34},
+		},
+		{
+			name: "character wrapping",
+			s:    "ab cdef",
+			opts: &Options{Width: 40, LineBreakMode: LineBreakByCharWrapping},
+			lines: []line{
+				{0, 4, CG.Point{X: 0, Y: 8}, 35, "ab c"},
+				{4, 7, CG.Point{X: 0, Y: 20}, 30, "def"},
+			},
+			size: CG.Size{Width: 40, Height: 22},
+		},
+		{
+			name: "character wrapping with spaces",
+			s:    "abc  de",
+			opts: &Options{Width: 30, LineBreakMode: LineBreakByCharWrapping},
+			lines: []line{
+				{0, 5, CG.Point{X: 0, Y: 8}, 30, "abc  "},
+				{5, 7, CG.Point{X: 0, Y: 20}, 20, "de"},
+			},
+			size: CG.Size{Width: 30, Height: 22},
+		},
+		{
+			name: "character wrapping with combining marks",
+			s:    "aéb",
+			opts: &Options{Width: 15, LineBreakMode: LineBreakByCharWrapping},
+			lines: []line{
+				{0, 1, CG.Point{X: 0, Y: 8}, 10, "a"},
+				{1, 3, CG.Point{X: 0, Y: 20}, 10, "é"},
+				{3, 4, CG.Point{X: 0, Y: 32}, 10, "b"},
+			},
+			size: CG.Size{Width: 15, Height: 34},
+		},
+		{
+			name: "character wrapping with flags",
+			s:    "\U0001F1EB\U0001F1F7\U0001F1EF\U0001F1F5",
+			opts: &Options{Width: 15, LineBreakMode: LineBreakByCharWrapping},
+			lines: []line{
+				{0, 2, CG.Point{X: 0, Y: 8}, 20, "\U0001F1EB\U0001F1F7"},
+				{2, 4, CG.Point{X: 0, Y: 20}, 20, "\U0001F1EF\U0001F1F5"},
+			},
+			size: CG.Size{Width: 15, Height: 22},
+		},
+		{
+			// The mark stays after its base character when the run is
+			// reversed.
+			name:  "combining mark in right-to-left run",
+			s:     "\u05D0\u05B8\u05D1",
+			opts:  &Options{WritingDirection: WritingDirectionNatural},
+			lines: []line{{0, 3, CG.Point{X: 0, Y: 8}, 20, "\u05D1\u05D0\u05B8"}},
+			size:  CG.Size{Width: 20, Height: 10},
+		},
+		{
+			name: "line spacing",
+			s:    "a\nb",
+			opts: &Options{LineHeightMultiple: 2, LineSpacing: 1},
+			lines: []line{
+				{0, 2, CG.Point{X: 0, Y: 8}, 10, "a"},
+				{2, 3, CG.Point{X: 0, Y: 33}, 10, "b"},
+			},
+			size: CG.Size{Width: 10, Height: 35},
+		},
+		{
+			name: "truncation",
+			s:    "ab cd ef gh ij",
+			opts: &Options{Width: 50, MaxLines: 2},
+			lines: []line{
+				{0, 6, CG.Point{X: 0, Y: 8}, 45, "ab cd "},
+				{6, 10, CG.Point{X: 0, Y: 20}, 45, "ef g…"},
+			},
+			size:      CG.Size{Width: 50, Height: 22},
+			truncated: true,
+		},
+		{
+			name: "truncation before a space",
+			s:    "ab cd ef gh",
+			opts: &Options{Width: 55, MaxLines: 1, Ellipsis: "..."},
+			lines: []line{
+				{0, 2, CG.Point{X: 0, Y: 8}, 50, "ab..."},
+			},
+			size:      CG.Size{Width: 55, Height: 10},
+			truncated: true,
+		},
+		{
+			name: "truncation without wrapping",
+			s:    "ab\ncd",
+			opts: &Options{MaxLines: 1},
+			lines: []line{
+				{0, 2, CG.Point{X: 0, Y: 8}, 30, "ab…"},
+			},
+			size:      CG.Size{Width: 30, Height: 10},
+			truncated: true,
+		},
+		{
+			name:  "right-to-left run",
+			s:     "ab אבג",
+			lines: []line{{0, 6, CG.Point{X: 0, Y: 8}, 55, "ab גבא"}},
+			size:  CG.Size{Width: 55, Height: 10},
+		},
+		{
+			name:  "mirrored brackets",
+			s:     "אב (ג)",
+			opts:  &Options{WritingDirection: WritingDirectionNatural},
+			lines: []line{{0, 6, CG.Point{X: 0, Y: 8}, 55, "(ג) בא"}},
+			size:  CG.Size{Width: 55, Height: 10},
+		},
+		{
+			name:  "numbers in right-to-left paragraph",
+			s:     "א 12",
+			opts:  &Options{Width: 50, WritingDirection: WritingDirectionRightToLeft, Alignment: TextAlignmentNatural},
+			lines: []line{{0, 4, CG.Point{X: 15, Y: 8}, 35, "12 א"}},
+			size:  CG.Size{Width: 50, Height: 10},
+		},
+		{
+			name: "paragraphs of different directions",
+			s:    "ab\nאב",
+			opts: &Options{Width: 30, WritingDirection: WritingDirectionNatural, Alignment: TextAlignmentNatural},
+			lines: []line{
+				{0, 3, CG.Point{X: 0, Y: 8}, 20, "ab"},
+				{3, 5, CG.Point{X: 10, Y: 20}, 20, "בא"},
+			},
+			size: CG.Size{Width: 30, Height: 22},
+		},
+		{
+			name: "right-to-left truncation",
+			s:    "אב גד הו",
+			opts: &Options{Width: 35, MaxLines: 1, WritingDirection: WritingDirectionRightToLeft, Alignment: TextAlignmentNatural},
+			lines: []line{
+				{0, 2, CG.Point{X: 5, Y: 8}, 30, "…בא"},
+			},
+			size:      CG.Size{Width: 35, Height: 10},
+			truncated: true,
+		},
+		{
+			name: "no truncation",
+			s:    "ab\ncd",
+			opts: &Options{MaxLines: 2},
+			lines: []line{
+				{0, 3, CG.Point{X: 0, Y: 8}, 20, "ab"},
+				{3, 5, CG.Point{X: 0, Y: 20}, 20, "cd"},
+			},
+			size: CG.Size{Width: 20, Height: 22},
+		},
+	}
+
+	for _, test := range tests {
+		frame := Layout(&fakeFont{}, test.s, test.opts)
+
+		if frame.Size != test.size || frame.Truncated != test.truncated {
+			t.Errorf("%s: invalid frame: %v, %t != %v, %t", test.name, frame.Size, frame.Truncated, test.size, test.truncated)
+		}
+
+		if len(frame.Lines) != len(test.lines) {
+			t.Errorf("%s: invalid number of lines: %d != %d", test.name, len(frame.Lines), len(test.lines))
+			continue
+		}
+
+		for i, l := range frame.Lines {
+			expected := test.lines[i]
+
+			if l.Start != expected.start || l.End != expected.end || l.Origin != expected.origin || l.Width != expected.width || string(l.Runes) != expected.runes {
+				t.Errorf("%s: invalid line %d: %d, %d, %v, %v, %q", test.name, i, l.Start, l.End, l.Origin, l.Width, string(l.Runes))
+			}
+
+			if len(l.Positions) != len(l.Runes) || l.Positions[0] != l.Origin {
+				t.Errorf("%s: invalid positions of line %d: %v", test.name, i, l.Positions)
+			}
+
+			if l.Truncated != (test.truncated && i == len(test.lines)-1) {
+				t.Errorf("%s: line %d is not truncated as expected", test.name, i)
+			}
+		}
+	}
+}
+
+func TestLayoutMeasuresLinesOnce(t *testing.T) {
+	f := &countingFont{}
+	s := ""
+
+	for len(s) < 20000 {
+		s += "lorem ipsum dolor sit amet, "
+	}
+
+	frame := Layout(f, s, &Options{Width: 400, MaxLines: 1000})
+
+	// Each line is measured from its start up to where it's wrapped, then
+	// once more to position its runes, the text isn't measured again for
+	// every break opportunity.
+	if len(frame.Lines) < 100 || f.kerned > 4*len(s) {
+		t.Errorf("%d pairs were kerned to lay out %d runes on %d lines", f.kerned, len(s), len(frame.Lines))
+	}
+}
+
+func TestLayoutJustified(t *testing.T) {
+	frame := Layout(&fakeFont{}, "a b c dddd", &Options{Width: 50, Alignment: TextAlignmentJustified})
+	expected := []CG.Point{
+		{X: 0, Y: 8},
+		{X: 10, Y: 8},
+		{X: 20, Y: 8},
+		{X: 30, Y: 8},
+		{X: 40, Y: 8},
+		{X: 50, Y: 8},
+	}
+
+	if len(frame.Lines) != 2 {
+		t.Fatal("invalid number of lines:", len(frame.Lines))
+	}
+
+	// The 10 points left on the first line are shared by its two spaces, its
+	// trailing space is not stretched.
+	for i, p := range frame.Lines[0].Positions {
+		if p != expected[i] {
+			t.Errorf("invalid position of rune %d: %v != %v", i, p, expected[i])
+		}
+	}
+}
+
+func TestLayoutRightToLeft(t *testing.T) {
+	tests := []struct {
+		name      string
+		s         string
+		width     CG.Float
+		alignment TextAlignment
+		runes     []string
+		indexes   [][]int
+		positions [][]CG.Point
+	}{
+		{
+			name:      "trailing spaces",
+			s:         "אב גד",
+			width:     30,
+			alignment: TextAlignmentNatural,
+			runes:     []string{" בא", "דג"},
+			indexes:   [][]int{{2, 1, 0}, {4, 3}},
+			positions: [][]CG.Point{
+				{{X: 5, Y: 8}, {X: 10, Y: 8}, {X: 20, Y: 8}},
+				{{X: 10, Y: 20}, {X: 20, Y: 20}},
+			},
+		},
+		{
+			name:      "justified",
+			s:         "א ב גגגג",
+			width:     50,
+			alignment: TextAlignmentJustified,
+			runes:     []string{" ב א", "גגגג"},
+			indexes:   [][]int{{3, 2, 1, 0}, {7, 6, 5, 4}},
+			positions: [][]CG.Point{
+				{{X: -5, Y: 8}, {X: 0, Y: 8}, {X: 10, Y: 8}, {X: 40, Y: 8}},
+				{{X: 10, Y: 20}, {X: 20, Y: 20}, {X: 30, Y: 20}, {X: 40, Y: 20}},
+			},
+		},
+	}
+
+	for _, test := range tests {
+		frame := Layout(&fakeFont{}, test.s, &Options{
+			Width:            test.width,
+			Alignment:        test.alignment,
+			WritingDirection: WritingDirectionRightToLeft,
+		})
+
+		if len(frame.Lines) != len(test.runes) {
+			t.Errorf("%s: invalid number of lines: %d != %d", test.name, len(frame.Lines), len(test.runes))
+			continue
+		}
+
+		for i, l := range frame.Lines {
+			if string(l.Runes) != test.runes[i] || fmt.Sprint(l.Indexes) != fmt.Sprint(test.indexes[i]) || fmt.Sprint(l.Positions) != fmt.Sprint(test.positions[i]) {
+				t.Errorf("%s: invalid line %d: %q, %v, %v", test.name, i, string(l.Runes), l.Indexes, l.Positions)
+			}
+		}
+	}
+}
+
+func TestFrameDraw(t *testing.T) {
+	f := &fakeFont{}
+	frame := Layout(f, "ab\ncd", nil)
+	frame.Draw(CG.Point{X: 1, Y: 2}, image.NewAlpha(image.Rect(0, 0, 10, 10)))
+
+	expected := []drawnGlyph{
+		{'a', CG.Point{X: 1, Y: 10}},
+		{'b', CG.Point{X: 11, Y: 10}},
+		{'c', CG.Point{X: 1, Y: 22}},
+		{'d', CG.Point{X: 11, Y: 22}},
+	}
+
+	if len(f.drawn) != len(expected) {
+		t.Fatal("invalid glyphs drawn:", f.drawn)
+	}
+
+	for i, g := range f.drawn {
+		if g != expected[i] {
+			t.Errorf("invalid glyph drawn: %v != %v", g, expected[i])
+		}
+	}
+}
+
+func TestFrameDrawClusters(t *testing.T) {
+	f := &fakeFont{}
+	frame := Layout(f, "e\u0301\u05D0\u05B8", &Options{WritingDirection: WritingDirectionLeftToRight})
+	frame.Draw(CG.Point{}, image.NewAlpha(image.Rect(0, 0, 10, 10)))
+
+	// The clusters are drawn from left to right, each mark after its base.
+	expected := []drawnGlyph{
+		{'e', CG.Point{X: 0, Y: 8}},
+		{'\u0301', CG.Point{X: 10, Y: 8}},
+		{'\u05D0', CG.Point{X: 10, Y: 8}},
+		{'\u05B8', CG.Point{X: 20, Y: 8}},
+	}
+
+	if len(f.drawn) != len(expected) {
+		t.Fatal("invalid glyphs drawn:", f.drawn)
+	}
+
+	for i, g := range f.drawn {
+		if g != expected[i] {
+			t.Errorf("invalid glyph drawn: %v != %v", g, expected[i])
+		}
+	}
+}
+
+func TestTextAlignmentString(t *testing.T) {
+	tests := []struct {
+		a TextAlignment
+		s string
+	}{
+		{TextAlignmentLeft, "TextAlignmentLeft"},
+		{TextAlignmentRight, "TextAlignmentRight"},
+		{TextAlignmentCenter, "TextAlignmentCenter"},
+		{TextAlignmentJustified, "TextAlignmentJustified"},
+		{TextAlignmentNatural, "TextAlignmentNatural"},
+		{TextAlignment(42), "TextAlignment(42)"},
+	}
+
+	for _, test := range tests {
+		if s := test.a.String(); s != test.s {
+			t.Errorf("invalid string: %s != %s", s, test.s)
+		}
+	}
+}
+
+func TestLineBreakModeString(t *testing.T) {
+	tests := []struct {
+		m LineBreakMode
+		s string
+	}{
+		{LineBreakByWordWrapping, "LineBreakByWordWrapping"},
+		{LineBreakByCharWrapping, "LineBreakByCharWrapping"},
+		{LineBreakMode(42), "LineBreakMode(42)"},
+	}
+
+	for _, test := range tests {
+		if s := test.m.String(); s != test.s {
+			t.Errorf("invalid string: %s != %s", s, test.s)
+		}
+	}
+}
+
+type drawnGlyph struct {
+	char   rune
+	origin CG.Point
+}
+
+// fakeFont is a font of 10 points wide glyphs, with 5 points wide spaces and
+// combining marks that have no advance. It records the glyphs it draws.
+type fakeFont struct {
+	drawn []drawnGlyph
+}
+
+var _ CT.FontSource = (*fakeFont)(nil)
+
+func (fakeFont) GetAscent() CG.Float  { return 8 }
+func (fakeFont) GetDescent() CG.Float { return 2 }
+func (fakeFont) GetLeading() CG.Float { return 2 }
+
+func (f *fakeFont) GlyphDraw(char rune, origin CG.Point, alpha *image.Alpha) bool {
+	f.drawn = append(f.drawn, drawnGlyph{char, origin})
+	return true
+}
+
+func (fakeFont) GlyphAdvance(char rune) CG.Float {
+	switch {
+	case char == ' ':
+		return 5
+	case unicode.Is(unicode.Mn, char):
+		return 0
+	default:
+		return 10
+	}
+}
+
+func (f fakeFont) GlyphBounds(char rune) (CG.Float, CG.Rect) {
+	advance := f.GlyphAdvance(char)
+
+	if char == ' ' {
+		return 0, CG.RectZero
+	}
+
+	return advance, CG.RectMake(0, 0, advance, 8)
+}
+
+func (fakeFont) Kern(char0 rune, char1 rune) CG.Float {
+	return 0
+}
+
+// countingFont is a fakeFont that counts the pairs it kerns.
+type countingFont struct {
+	fakeFont
+	kerned int
+}
+
+func (f *countingFont) Kern(char0 rune, char1 rune) CG.Float {
+	f.kerned++
+	return 0
+}
@@ -0,0 +1,517 @@
+package layout
+
+// LineBreak is an enumeration of the kinds of line breaks allowed between two
+// runes.
+type LineBreak uint8
+
+// These constants are all the possible values of the LineBreak enumeration.
+const (
+	// The line must not be broken.
+	NoBreak LineBreak = iota
+
+	// The line may be broken, if it doesn't fit.
+	AllowBreak
+
+	// The line must be broken, after a newline or at the end of the text.
+	MandatoryBreak
+)
+
+// String satisfies the fmt.Stringer interface.
+func (b LineBreak) String() string {
+	switch b {
+	case NoBreak:
+		return "NoBreak"
+	case AllowBreak:
+		return "AllowBreak"
+	case MandatoryBreak:
+		return "MandatoryBreak"
+	default:
+		return "LineBreak(?)"
+	}
+}
+
+// LineBreaks returns the line breaks of the text, following the Unicode line
+// breaking algorithm. The returned slice has one more element than runes, the
+// element at index i being the kind of break allowed before runes[i], so the
+// first one is always NoBreak and the last one MandatoryBreak.
+//
+// The algorithm is implemented without tailoring, with the rules and the line
+// break classes of Unicode 17.0.0.
+//
+// https://www.unicode.org/reports/tr14/
+func LineBreaks(runes []rune) []LineBreak {
+	breaks, _ := lineBreaks(runes)
+	return breaks
+}
+
+// lineBreaks returns the line breaks of the runes and the state that they were
+// computed from.
+func lineBreaks(runes []rune) ([]LineBreak, *lineBreakState) {
+	breaks := make([]LineBreak, len(runes)+1)
+	s := newLineBreakState(runes)
+
+	if len(runes) == 0 {
+		return breaks, s
+	}
+
+	for i := 1; i != len(runes); i++ {
+		breaks[i] = s.breakBefore(i)
+	}
+
+	breaks[len(runes)] = MandatoryBreak
+	return breaks, s
+}
+
+// lineBreakClass is an enumeration of the values of the Line_Break property,
+// without the classes that the rule LB1 resolves to other classes. The unknown
+// class lbXX stands for the start and the end of the text.
+type lineBreakClass uint8
+
+const (
+	lbXX lineBreakClass = iota
+	lbAK
+	lbAL
+	lbAP
+	lbAS
+	lbB2
+	lbBA
+	lbBB
+	lbBK
+	lbCB
+	lbCL
+	lbCM
+	lbCP
+	lbCR
+	lbEB
+	lbEM
+	lbEX
+	lbGL
+	lbH2
+	lbH3
+	lbHH
+	lbHL
+	lbHY
+	lbID
+	lbIN
+	lbIS
+	lbJL
+	lbJT
+	lbJV
+	lbLF
+	lbNL
+	lbNS
+	lbNU
+	lbOP
+	lbPO
+	lbPR
+	lbQU
+	lbRI
+	lbSP
+	lbSY
+	lbVF
+	lbVI
+	lbWJ
+	lbZW
+	lbZWJ
+)
+
+// lineBreakClassOf returns the line break class of r, as listed in
+// LineBreak.txt, resolved by the rule LB1.
+func lineBreakClassOf(r rune) lineBreakClass {
+	if r >= 0xAC00 && r <= 0xD7A3 {
+		// Hangul syllables are LV syllables when they have no trailing
+		// consonant, which happens every 28 code points.
+		if (r-0xAC00)%28 == 0 {
+			return lbH2
+		}
+		return lbH3
+	}
+
+	if c, ok := findRange(lineBreakRanges, r); ok {
+		return c
+	}
+
+	return lbAL
+}
+
+// isEastAsian returns true if r has an East Asian Width of fullwidth, wide or
+// halfwidth.
+func isEastAsian(r rune) bool {
+	return inRanges(eastAsianRanges, r)
+}
+
+// lineBreakState holds the classes of the runes of a text after the rules LB1
+// to LB10 were applied, combining marks being attached to the runes that they
+// follow.
+type lineBreakState struct {
+	runes []rune
+
+	// The classes of the runes before and after the combining marks and
+	// zero-width joiners were attached.
+	raw     []lineBreakClass
+	classes []lineBreakClass
+
+	// The index of the first rune of the combining sequence that each rune
+	// belongs to, which is the rune itself if it starts a sequence.
+	base []int
+}
+
+func newLineBreakState(runes []rune) *lineBreakState {
+	s := &lineBreakState{
+		runes:   runes,
+		raw:     make([]lineBreakClass, len(runes)),
+		classes: make([]lineBreakClass, len(runes)),
+		base:    make([]int, len(runes)),
+	}
+
+	for i, r := range runes {
+		c := lineBreakClassOf(r)
+		s.raw[i] = c
+		s.base[i] = i
+
+		// LB9: combining marks and zero-width joiners take the class of the
+		// rune they follow, unless it's a break or a space. LB10: the ones
+		// that are not attached are treated as alphabetic.
+		if c == lbCM || c == lbZWJ {
+			if i != 0 && !isBreakOrSpace(s.classes[i-1]) {
+				s.classes[i] = s.classes[i-1]
+				s.base[i] = s.base[i-1]
+				continue
+			}
+			c = lbAL
+		}
+
+		s.classes[i] = c
+	}
+
+	return s
+}
+
+func isBreakOrSpace(c lineBreakClass) bool {
+	switch c {
+	case lbBK, lbCR, lbLF, lbNL, lbSP, lbZW:
+		return true
+	}
+	return false
+}
+
+// prev returns the class of the combining sequence preceding the one that
+// starts at i, and the index where it starts, or -1 at the start of the text.
+func (s *lineBreakState) prev(i int) (lineBreakClass, int) {
+	if i <= 0 {
+		return lbXX, -1
+	}
+	j := s.base[i-1]
+	return s.classes[j], j
+}
+
+// next returns the index where the combining sequence following the one that
+// starts at i starts, or the length of the text at the end of the text.
+func (s *lineBreakState) next(i int) int {
+	for j := i + 1; j < len(s.runes); j++ {
+		if s.base[j] == j {
+			return j
+		}
+	}
+	return len(s.runes)
+}
+
+// class returns the class of the combining sequence that starts at i, or lbXX
+// before the start or past the end of the text.
+func (s *lineBreakState) class(i int) lineBreakClass {
+	if i < 0 || i >= len(s.runes) {
+		return lbXX
+	}
+	return s.classes[i]
+}
+
+// skipSpaces returns the class of the combining sequence found before i when
+// skipping spaces backwards, and the index where it starts.
+func (s *lineBreakState) skipSpaces(i int) (lineBreakClass, int) {
+	c, j := s.prev(i)
+
+	for c == lbSP {
+		c, j = s.prev(j)
+	}
+
+	return c, j
+}
+
+// followsNumber returns true if the combining sequence before the one that
+// starts at i ends a number, which is a numeric followed by infix numeric
+// separators and symbols allowing breaks after.
+func (s *lineBreakState) followsNumber(i int) bool {
+	c, j := s.prev(i)
+
+	for c == lbSY || c == lbIS {
+		c, j = s.prev(j)
+	}
+
+	return c == lbNU
+}
+
+// breakBefore returns the kind of break allowed before the rune at index i,
+// which is neither the first nor past the last one.
+func (s *lineBreakState) breakBefore(i int) LineBreak {
+	before := s.raw[i-1]
+	after := s.raw[i]
+
+	switch {
+	case before == lbBK: // LB4
+		return MandatoryBreak
+	case before == lbCR && after == lbLF: // LB5
+		return NoBreak
+	case before == lbCR || before == lbLF || before == lbNL:
+		return MandatoryBreak
+	case after == lbBK || after == lbCR || after == lbLF || after == lbNL: // LB6
+		return NoBreak
+	case after == lbSP || after == lbZW: // LB7
+		return NoBreak
+	}
+
+	if c, _ := s.skipSpaces(i); c == lbZW { // LB8
+		return AllowBreak
+	}
+
+	switch {
+	case before == lbZWJ: // LB8a
+		return NoBreak
+	case s.base[i] != i: // LB9
+		return NoBreak
+	}
+
+	if s.allowBreak(i) {
+		return AllowBreak
+	}
+	return NoBreak
+}
+
+// allowBreak applies the rules from LB11 on to the combining sequences around
+// index i, and returns true if a line break is allowed there.
+func (s *lineBreakState) allowBreak(i int) bool {
+	a, j := s.prev(i)
+	b := s.classes[i]
+	k := s.next(i)
+	c := s.class(k)
+
+	switch {
+	case a == lbWJ || b == lbWJ: // LB11
+		return false
+	case a == lbGL: // LB12
+		return false
+	case b == lbGL && a != lbSP && a != lbBA && a != lbHY && a != lbHH: // LB12a
+		return false
+	case b == lbCL || b == lbCP || b == lbEX || b == lbSY: // LB13
+		return false
+	}
+
+	quote, q := s.skipSpaces(i)
+
+	switch {
+	case quote == lbOP: // LB14
+		return false
+	case quote == lbQU && s.isInitialQuote(q) && s.startsQuotation(q): // LB15a
+		return false
+	case b == lbQU && s.isFinalQuote(i) && s.endsQuotation(k): // LB15b
+		return false
+	case a == lbSP && b == lbIS && c == lbNU: // LB15c
+		return true
+	case b == lbIS: // LB15d
+		return false
+	case (quote == lbCL || quote == lbCP) && b == lbNS: // LB16
+		return false
+	case quote == lbB2 && b == lbB2: // LB17
+		return false
+	case a == lbSP: // LB18
+		return true
+	case b == lbQU && !s.isInitialQuote(i), a == lbQU && !s.isFinalQuote(j): // LB19
+		return false
+	}
+
+	// LB19a: quotation marks are only broken around when they are between
+	// East Asian characters.
+	if b == lbQU && (!s.isEastAsian(j) || !s.isEastAsian(k)) {
+		return false
+	}
+
+	if _, h := s.prev(j); a == lbQU && (!s.isEastAsian(i) || !s.isEastAsian(h)) {
+		return false
+	}
+
+	switch {
+	case a == lbCB || b == lbCB: // LB20
+		return true
+	case (a == lbHY || a == lbHH) && isAlphabetic(b) && s.startsWord(j): // LB20a
+		return false
+	case b == lbBA || b == lbHH || b == lbHY || b == lbNS || a == lbBB: // LB21
+		return false
+	}
+
+	if h, _ := s.prev(j); h == lbHL && (a == lbHY || a == lbHH) && b != lbHL { // LB21a
+		return false
+	}
+
+	switch {
+	case a == lbSY && b == lbHL: // LB21b
+		return false
+	case b == lbIN: // LB22
+		return false
+	case isAlphabetic(a) && b == lbNU, a == lbNU && isAlphabetic(b): // LB23
+		return false
+	case a == lbPR && isIdeographic(b), isIdeographic(a) && b == lbPO: // LB23a
+		return false
+	case isPrefixOrPostfix(a) && isAlphabetic(b), isAlphabetic(a) && isPrefixOrPostfix(b): // LB24
+		return false
+	}
+
+	// LB25: numbers are kept together with their prefixes and postfixes.
+	switch b {
+	case lbPO, lbPR:
+		if a == lbCL || a == lbCP {
+			if s.followsNumber(j) {
+				return false
+			}
+		} else if s.followsNumber(i) {
+			return false
+		}
+	case lbOP:
+		if isPrefixOrPostfix(a) && (c == lbNU || c == lbIS && s.class(s.next(k)) == lbNU) {
+			return false
+		}
+	case lbNU:
+		if isPrefixOrPostfix(a) || a == lbHY || a == lbIS || s.followsNumber(i) {
+			return false
+		}
+	}
+
+	switch {
+	case a == lbJL && (b == lbJL || b == lbJV || b == lbH2 || b == lbH3): // LB26
+		return false
+	case (a == lbJV || a == lbH2) && (b == lbJV || b == lbJT):
+		return false
+	case (a == lbJT || a == lbH3) && b == lbJT:
+		return false
+	case isKorean(a) && b == lbPO, a == lbPR && isKorean(b): // LB27
+		return false
+	case isAlphabetic(a) && isAlphabetic(b): // LB28
+		return false
+	}
+
+	// LB28a: the orthographic syllables of the Brahmic scripts are not broken.
+	if _, h := s.prev(j); a == lbAP && (b == lbAK || b == lbAS || s.isDottedCircle(i)) ||
+		s.isAksara(j) && (b == lbVF || b == lbVI) ||
+		s.isAksara(h) && a == lbVI && (b == lbAK || s.isDottedCircle(i)) ||
+		s.isAksara(j) && s.isAksara(i) && c == lbVF {
+		return false
+	}
+
+	switch {
+	case a == lbIS && isAlphabetic(b): // LB29
+		return false
+	case (isAlphabetic(a) || a == lbNU) && b == lbOP && !isEastAsian(s.runes[i]): // LB30
+		return false
+	case a == lbCP && !isEastAsian(s.runes[j]) && (isAlphabetic(b) || b == lbNU):
+		return false
+	case a == lbRI && b == lbRI: // LB30a
+		return !s.oddRegionalIndicators(j)
+	case b == lbEM && (a == lbEB || inRanges(unassignedPictographs, s.runes[j])): // LB30b
+		return false
+	}
+
+	return true // LB31
+}
+
+// isInitialQuote and isFinalQuote return true if the combining sequence that
+// starts at i is a quotation mark that is an initial or final punctuation.
+func (s *lineBreakState) isInitialQuote(i int) bool {
+	return inRanges(initialQuotes, s.runes[i])
+}
+
+func (s *lineBreakState) isFinalQuote(i int) bool {
+	return inRanges(finalQuotes, s.runes[i])
+}
+
+// startsQuotation returns true if the combining sequence that starts at i is
+// at the start of the text or follows a sequence that the rule LB15a allows
+// an initial quotation mark after.
+func (s *lineBreakState) startsQuotation(i int) bool {
+	switch c, _ := s.prev(i); c {
+	case lbXX, lbBK, lbCR, lbLF, lbNL, lbOP, lbQU, lbGL, lbSP, lbZW:
+		return true
+	}
+	return false
+}
+
+// endsQuotation returns true if the combining sequence that starts at i is
+// past the end of the text or one that the rule LB15b allows a final quotation
+// mark before.
+func (s *lineBreakState) endsQuotation(i int) bool {
+	switch s.class(i) {
+	case lbXX, lbSP, lbGL, lbWJ, lbCL, lbQU, lbCP, lbEX, lbIS, lbSY, lbBK, lbCR, lbLF, lbNL, lbZW:
+		return true
+	}
+	return false
+}
+
+// startsWord returns true if the combining sequence that starts at i is at
+// the start of the text or follows a sequence that the rule LB20a considers
+// to end the previous word.
+func (s *lineBreakState) startsWord(i int) bool {
+	switch c, _ := s.prev(i); c {
+	case lbXX, lbBK, lbCR, lbLF, lbNL, lbSP, lbZW, lbCB, lbGL:
+		return true
+	}
+	return false
+}
+
+// isEastAsian returns true if the combining sequence that starts at i is an
+// East Asian character, it's false before the start and past the end of the
+// text.
+func (s *lineBreakState) isEastAsian(i int) bool {
+	return i >= 0 && i < len(s.runes) && isEastAsian(s.runes[i])
+}
+
+// isDottedCircle returns true if the combining sequence that starts at i is
+// the dotted circle, which stands for a missing base of the Brahmic scripts.
+func (s *lineBreakState) isDottedCircle(i int) bool {
+	return i >= 0 && i < len(s.runes) && s.runes[i] == 0x25CC
+}
+
+// isAksara returns true if the combining sequence that starts at i can start
+// an orthographic syllable of the Brahmic scripts.
+func (s *lineBreakState) isAksara(i int) bool {
+	c := s.class(i)
+	return c == lbAK || c == lbAS || s.isDottedCircle(i)
+}
+
+// oddRegionalIndicators returns true if the sequence of regional indicators
+// ending with the combining sequence at index i has an odd length.
+func (s *lineBreakState) oddRegionalIndicators(i int) bool {
+	n := 0
+
+	for c, j := s.classes[i], i; c == lbRI; c, j = s.prev(j) {
+		n++
+	}
+
+	return n%2 == 1
+}
+
+func isAlphabetic(c lineBreakClass) bool {
+	return c == lbAL || c == lbHL
+}
+
+func isIdeographic(c lineBreakClass) bool {
+	return c == lbID || c == lbEB || c == lbEM
+}
+
+func isPrefixOrPostfix(c lineBreakClass) bool {
+	return c == lbPR || c == lbPO
+}
+
+func isKorean(c lineBreakClass) bool {
+	switch c {
+	case lbJL, lbJV, lbJT, lbH2, lbH3:
+		return true
+	}
+	return false
+}
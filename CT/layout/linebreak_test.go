@@ -0,0 +1,174 @@
+package layout
+
+import (
+	"bufio"
+	"compress/gzip"
+	"os"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+func TestLineBreaks(t *testing.T) {
+	tests := []struct {
+		s      string
+		breaks []LineBreak
+	}{
+		{"", []LineBreak{NoBreak}},
+		{"a", []LineBreak{NoBreak, MandatoryBreak}},
+		{"a b", []LineBreak{NoBreak, NoBreak, AllowBreak, MandatoryBreak}},
+		{"a\nb", []LineBreak{NoBreak, NoBreak, MandatoryBreak, MandatoryBreak}},
+		{"a\r\nb", []LineBreak{NoBreak, NoBreak, NoBreak, MandatoryBreak, MandatoryBreak}},
+		{"well-known", []LineBreak{NoBreak, NoBreak, NoBreak, NoBreak, NoBreak, AllowBreak, NoBreak, NoBreak, NoBreak, NoBreak, MandatoryBreak}},
+		{"(1.5)", []LineBreak{NoBreak, NoBreak, NoBreak, NoBreak, NoBreak, MandatoryBreak}},
+		{"日本語", []LineBreak{NoBreak, AllowBreak, AllowBreak, MandatoryBreak}},
+		{"a\u00a0b", []LineBreak{NoBreak, NoBreak, NoBreak, MandatoryBreak}},
+		{"e\u0301 f", []LineBreak{NoBreak, NoBreak, NoBreak, AllowBreak, MandatoryBreak}},
+		{"-x", []LineBreak{NoBreak, NoBreak, MandatoryBreak}},
+		{"a \u00abb\u00bb", []LineBreak{NoBreak, NoBreak, AllowBreak, NoBreak, NoBreak, MandatoryBreak}},
+		{"\u0915\u094d\u0937", []LineBreak{NoBreak, NoBreak, NoBreak, MandatoryBreak}},
+	}
+
+	for _, test := range tests {
+		breaks := LineBreaks([]rune(test.s))
+
+		if !equalBreaks(breaks, test.breaks) {
+			t.Errorf("%q: invalid line breaks: %v != %v", test.s, breaks, test.breaks)
+		}
+	}
+}
+
+func TestLineBreaksConformance(t *testing.T) {
+	tests := readBreakTests(t, "testdata/LineBreakTest.txt.gz")
+
+	for _, test := range tests {
+		breaks := LineBreaks(test.runes)
+
+		// The conformance tests only tell whether breaks are allowed, the
+		// mandatory breaks are allowed breaks.
+		for i, b := range breaks {
+			if b == MandatoryBreak {
+				breaks[i] = AllowBreak
+			}
+		}
+
+		if !equalBreaks(breaks, test.breaks) {
+			t.Errorf("%s: invalid line breaks: %v != %v", test.line, breaks, test.breaks)
+		}
+	}
+}
+
+func TestLineBreakRanges(t *testing.T) {
+	for i, r := range lineBreakRanges {
+		if r.lo > r.hi || (i != 0 && r.lo <= lineBreakRanges[i-1].hi) {
+			t.Errorf("range %d is not sorted: %U-%U", i, r.lo, r.hi)
+		}
+	}
+
+	tables := map[string][]runeRange{
+		"eastAsianRanges":       eastAsianRanges,
+		"initialQuotes":         initialQuotes,
+		"finalQuotes":           finalQuotes,
+		"unassignedPictographs": unassignedPictographs,
+	}
+
+	for name, table := range tables {
+		for i, r := range table {
+			if r.lo > r.hi || (i != 0 && r.lo <= table[i-1].hi) {
+				t.Errorf("%s: range %d is not sorted: %U-%U", name, i, r.lo, r.hi)
+			}
+		}
+	}
+}
+
+func equalBreaks(b1 []LineBreak, b2 []LineBreak) bool {
+	if len(b1) != len(b2) {
+		return false
+	}
+
+	for i := range b1 {
+		if b1[i] != b2[i] {
+			return false
+		}
+	}
+
+	return true
+}
+
+// breakTest is a test case of the conformance tests of Unicode, which lists
+// code points separated by "÷" where breaks are allowed and "×" where they
+// are not.
+type breakTest struct {
+	line   string
+	runes  []rune
+	breaks []LineBreak
+}
+
+// readBreakTests reads the test cases of a gzip-compressed conformance test
+// file of Unicode.
+func readBreakTests(t *testing.T, path string) []breakTest {
+	tests := []breakTest{}
+
+	for _, line := range readTestLines(t, path) {
+		test := breakTest{line: line}
+
+		for _, field := range strings.Fields(line) {
+			switch field {
+			case "÷":
+				test.breaks = append(test.breaks, AllowBreak)
+			case "×":
+				test.breaks = append(test.breaks, NoBreak)
+			default:
+				r, err := strconv.ParseUint(field, 16, 32)
+
+				if err != nil {
+					t.Fatal(err)
+				}
+
+				test.runes = append(test.runes, rune(r))
+			}
+		}
+
+		tests = append(tests, test)
+	}
+
+	return tests
+}
+
+// readTestLines returns the lines of a gzipped test file without comments and
+// blank lines.
+func readTestLines(t *testing.T, path string) []string {
+	f, err := os.Open(path)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	defer f.Close()
+	z, err := gzip.NewReader(f)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	lines := []string{}
+	scanner := bufio.NewScanner(z)
+
+	for scanner.Scan() {
+		line := scanner.Text()
+
+		if i := strings.IndexByte(line, '#'); i >= 0 {
+			line = line[:i]
+		}
+
+		if line = strings.TrimSpace(line); len(line) != 0 {
+			lines = append(lines, line)
+		}
+	}
+
+	if err := scanner.Err(); err != nil {
+		t.Fatal(err)
+	}
+
+	return lines
+}
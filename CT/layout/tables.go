@@ -0,0 +1,1469 @@
+package layout
+
+import "sort"
+
+// lineBreakRange assigns a line break class to a range of code points.
+type lineBreakRange struct {
+	lo, hi rune
+	class  lineBreakClass
+}
+
+// lineBreakRanges are the code points that don't have the alphabetic class,
+// sorted and non-overlapping, except for the Hangul syllables whose class is
+// computed by lineBreakClassOf. The table was generated from the Line_Break
+// property of Unicode 17.0.0, which includes the default classes of the
+// unassigned code points, with the classes resolved by the rule LB1: the
+// ambiguous (AI), surrogate (SG) and unknown (XX) classes are alphabetic, the
+// complex context (SA) class is resolved to combining marks for the marks and
+// to alphabetic for the other characters, and the conditional Japanese
+// starters (CJ) are non-starters.
+//
+// https://www.unicode.org/Public/17.0.0/ucd/LineBreak.txt
+var lineBreakRanges = []lineBreakRange{
+	{0x0000, 0x0008, lbCM},
+	{0x0009, 0x0009, lbBA},
+	{0x000A, 0x000A, lbLF},
+	{0x000B, 0x000C, lbBK},
+	{0x000D, 0x000D, lbCR},
+	{0x000E, 0x001F, lbCM},
+	{0x0020, 0x0020, lbSP},
+	{0x0021, 0x0021, lbEX},
+	{0x0022, 0x0022, lbQU},
+	{0x0024, 0x0024, lbPR},
+	{0x0025, 0x0025, lbPO},
+	{0x0027, 0x0027, lbQU},
+	{0x0028, 0x0028, lbOP},
+	{0x0029, 0x0029, lbCP},
+	{0x002B, 0x002B, lbPR},
+	{0x002C, 0x002C, lbIS},
+	{0x002D, 0x002D, lbHY},
+	{0x002E, 0x002E, lbIS},
+	{0x002F, 0x002F, lbSY},
+	{0x0030, 0x0039, lbNU},
+	{0x003A, 0x003B, lbIS},
+	{0x003F, 0x003F, lbEX},
+	{0x005B, 0x005B, lbOP},
+	{0x005C, 0x005C, lbPR},
+	{0x005D, 0x005D, lbCP},
+	{0x007B, 0x007B, lbOP},
+	{0x007C, 0x007C, lbBA},
+	{0x007D, 0x007D, lbCL},
+	{0x007F, 0x0084, lbCM},
+	{0x0085, 0x0085, lbNL},
+	{0x0086, 0x009F, lbCM},
+	{0x00A0, 0x00A0, lbGL},
+	{0x00A1, 0x00A1, lbOP},
+	{0x00A2, 0x00A2, lbPO},
+	{0x00A3, 0x00A5, lbPR},
+	{0x00AB, 0x00AB, lbQU},
+	{0x00AD, 0x00AD, lbBA},
+	{0x00B0, 0x00B0, lbPO},
+	{0x00B1, 0x00B1, lbPR},
+	{0x00B4, 0x00B4, lbBB},
+	{0x00BB, 0x00BB, lbQU},
+	{0x00BF, 0x00BF, lbOP},
+	{0x02C8, 0x02C8, lbBB},
+	{0x02CC, 0x02CC, lbBB},
+	{0x02DF, 0x02DF, lbBB},
+	{0x0300, 0x035B, lbCM},
+	{0x035C, 0x0362, lbGL},
+	{0x0363, 0x036F, lbCM},
+	{0x037E, 0x037E, lbIS},
+	{0x0483, 0x0489, lbCM},
+	{0x0589, 0x0589, lbIS},
+	{0x058A, 0x058A, lbHH},
+	{0x058F, 0x058F, lbPR},
+	{0x0591, 0x05BD, lbCM},
+	{0x05BE, 0x05BE, lbHH},
+	{0x05BF, 0x05BF, lbCM},
+	{0x05C1, 0x05C2, lbCM},
+	{0x05C4, 0x05C5, lbCM},
+	{0x05C6, 0x05C6, lbEX},
+	{0x05C7, 0x05C7, lbCM},
+	{0x05D0, 0x05EA, lbHL},
+	{0x05EF, 0x05F2, lbHL},
+	{0x0600, 0x0605, lbNU},
+	{0x0609, 0x060B, lbPO},
+	{0x060C, 0x060D, lbIS},
+	{0x0610, 0x061A, lbCM},
+	{0x061B, 0x061B, lbEX},
+	{0x061C, 0x061C, lbCM},
+	{0x061D, 0x061F, lbEX},
+	{0x064B, 0x065F, lbCM},
+	{0x0660, 0x0669, lbNU},
+	{0x066A, 0x066A, lbPO},
+	{0x066B, 0x066C, lbNU},
+	{0x0670, 0x0670, lbCM},
+	{0x06D4, 0x06D4, lbEX},
+	{0x06D6, 0x06DC, lbCM},
+	{0x06DD, 0x06DD, lbNU},
+	{0x06DF, 0x06E4, lbCM},
+	{0x06E7, 0x06E8, lbCM},
+	{0x06EA, 0x06ED, lbCM},
+	{0x06F0, 0x06F9, lbNU},
+	{0x0711, 0x0711, lbCM},
+	{0x0730, 0x074A, lbCM},
+	{0x07A6, 0x07B0, lbCM},
+	{0x07C0, 0x07C9, lbNU},
+	{0x07EB, 0x07F3, lbCM},
+	{0x07F8, 0x07F8, lbIS},
+	{0x07F9, 0x07F9, lbEX},
+	{0x07FD, 0x07FD, lbCM},
+	{0x07FE, 0x07FF, lbPR},
+	{0x0816, 0x0819, lbCM},
+	{0x081B, 0x0823, lbCM},
+	{0x0825, 0x0827, lbCM},
+	{0x0829, 0x082D, lbCM},
+	{0x0859, 0x085B, lbCM},
+	{0x0890, 0x0891, lbNU},
+	{0x0897, 0x089F, lbCM},
+	{0x08CA, 0x08E1, lbCM},
+	{0x08E2, 0x08E2, lbNU},
+	{0x08E3, 0x0903, lbCM},
+	{0x093A, 0x093C, lbCM},
+	{0x093E, 0x094F, lbCM},
+	{0x0951, 0x0957, lbCM},
+	{0x0962, 0x0963, lbCM},
+	{0x0964, 0x0965, lbBA},
+	{0x0966, 0x096F, lbNU},
+	{0x0981, 0x0983, lbCM},
+	{0x09BC, 0x09BC, lbCM},
+	{0x09BE, 0x09C4, lbCM},
+	{0x09C7, 0x09C8, lbCM},
+	{0x09CB, 0x09CD, lbCM},
+	{0x09D7, 0x09D7, lbCM},
+	{0x09E2, 0x09E3, lbCM},
+	{0x09E6, 0x09EF, lbNU},
+	{0x09F2, 0x09F3, lbPO},
+	{0x09F9, 0x09F9, lbPO},
+	{0x09FB, 0x09FB, lbPR},
+	{0x09FE, 0x09FE, lbCM},
+	{0x0A01, 0x0A03, lbCM},
+	{0x0A3C, 0x0A3C, lbCM},
+	{0x0A3E, 0x0A42, lbCM},
+	{0x0A47, 0x0A48, lbCM},
+	{0x0A4B, 0x0A4D, lbCM},
+	{0x0A51, 0x0A51, lbCM},
+	{0x0A66, 0x0A6F, lbNU},
+	{0x0A70, 0x0A71, lbCM},
+	{0x0A75, 0x0A75, lbCM},
+	{0x0A81, 0x0A83, lbCM},
+	{0x0ABC, 0x0ABC, lbCM},
+	{0x0ABE, 0x0AC5, lbCM},
+	{0x0AC7, 0x0AC9, lbCM},
+	{0x0ACB, 0x0ACD, lbCM},
+	{0x0AE2, 0x0AE3, lbCM},
+	{0x0AE6, 0x0AEF, lbNU},
+	{0x0AF1, 0x0AF1, lbPR},
+	{0x0AFA, 0x0AFF, lbCM},
+	{0x0B01, 0x0B03, lbCM},
+	{0x0B3C, 0x0B3C, lbCM},
+	{0x0B3E, 0x0B44, lbCM},
+	{0x0B47, 0x0B48, lbCM},
+	{0x0B4B, 0x0B4D, lbCM},
+	{0x0B55, 0x0B57, lbCM},
+	{0x0B62, 0x0B63, lbCM},
+	{0x0B66, 0x0B6F, lbNU},
+	{0x0B82, 0x0B82, lbCM},
+	{0x0BBE, 0x0BC2, lbCM},
+	{0x0BC6, 0x0BC8, lbCM},
+	{0x0BCA, 0x0BCD, lbCM},
+	{0x0BD7, 0x0BD7, lbCM},
+	{0x0BE6, 0x0BEF, lbNU},
+	{0x0BF9, 0x0BF9, lbPR},
+	{0x0C00, 0x0C04, lbCM},
+	{0x0C3C, 0x0C3C, lbCM},
+	{0x0C3E, 0x0C44, lbCM},
+	{0x0C46, 0x0C48, lbCM},
+	{0x0C4A, 0x0C4D, lbCM},
+	{0x0C55, 0x0C56, lbCM},
+	{0x0C62, 0x0C63, lbCM},
+	{0x0C66, 0x0C6F, lbNU},
+	{0x0C77, 0x0C77, lbBB},
+	{0x0C81, 0x0C83, lbCM},
+	{0x0C84, 0x0C84, lbBB},
+	{0x0CBC, 0x0CBC, lbCM},
+	{0x0CBE, 0x0CC4, lbCM},
+	{0x0CC6, 0x0CC8, lbCM},
+	{0x0CCA, 0x0CCD, lbCM},
+	{0x0CD5, 0x0CD6, lbCM},
+	{0x0CE2, 0x0CE3, lbCM},
+	{0x0CE6, 0x0CEF, lbNU},
+	{0x0CF3, 0x0CF3, lbCM},
+	{0x0D00, 0x0D03, lbCM},
+	{0x0D3B, 0x0D3C, lbCM},
+	{0x0D3E, 0x0D44, lbCM},
+	{0x0D46, 0x0D48, lbCM},
+	{0x0D4A, 0x0D4D, lbCM},
+	{0x0D57, 0x0D57, lbCM},
+	{0x0D62, 0x0D63, lbCM},
+	{0x0D66, 0x0D6F, lbNU},
+	{0x0D79, 0x0D79, lbPO},
+	{0x0D81, 0x0D83, lbCM},
+	{0x0DCA, 0x0DCA, lbCM},
+	{0x0DCF, 0x0DD4, lbCM},
+	{0x0DD6, 0x0DD6, lbCM},
+	{0x0DD8, 0x0DDF, lbCM},
+	{0x0DE6, 0x0DEF, lbNU},
+	{0x0DF2, 0x0DF3, lbCM},
+	{0x0E31, 0x0E31, lbCM},
+	{0x0E34, 0x0E3A, lbCM},
+	{0x0E3F, 0x0E3F, lbPR},
+	{0x0E47, 0x0E4E, lbCM},
+	{0x0E50, 0x0E59, lbNU},
+	{0x0E5A, 0x0E5B, lbBA},
+	{0x0EB1, 0x0EB1, lbCM},
+	{0x0EB4, 0x0EBC, lbCM},
+	{0x0EC8, 0x0ECE, lbCM},
+	{0x0ED0, 0x0ED9, lbNU},
+	{0x0F01, 0x0F04, lbBB},
+	{0x0F06, 0x0F07, lbBB},
+	{0x0F08, 0x0F08, lbGL},
+	{0x0F09, 0x0F0A, lbBB},
+	{0x0F0B, 0x0F0B, lbBA},
+	{0x0F0C, 0x0F0C, lbGL},
+	{0x0F0D, 0x0F11, lbEX},
+	{0x0F12, 0x0F12, lbGL},
+	{0x0F14, 0x0F14, lbEX},
+	{0x0F18, 0x0F19, lbCM},
+	{0x0F20, 0x0F29, lbNU},
+	{0x0F34, 0x0F34, lbBA},
+	{0x0F35, 0x0F35, lbCM},
+	{0x0F37, 0x0F37, lbCM},
+	{0x0F39, 0x0F39, lbCM},
+	{0x0F3A, 0x0F3A, lbOP},
+	{0x0F3B, 0x0F3B, lbCL},
+	{0x0F3C, 0x0F3C, lbOP},
+	{0x0F3D, 0x0F3D, lbCL},
+	{0x0F3E, 0x0F3F, lbCM},
+	{0x0F71, 0x0F7E, lbCM},
+	{0x0F7F, 0x0F7F, lbBA},
+	{0x0F80, 0x0F84, lbCM},
+	{0x0F85, 0x0F85, lbBA},
+	{0x0F86, 0x0F87, lbCM},
+	{0x0F8D, 0x0F97, lbCM},
+	{0x0F99, 0x0FBC, lbCM},
+	{0x0FBE, 0x0FBF, lbBA},
+	{0x0FC6, 0x0FC6, lbCM},
+	{0x0FD0, 0x0FD1, lbBB},
+	{0x0FD2, 0x0FD2, lbBA},
+	{0x0FD3, 0x0FD3, lbBB},
+	{0x0FD9, 0x0FDA, lbGL},
+	{0x102B, 0x103E, lbCM},
+	{0x1040, 0x1049, lbNU},
+	{0x104A, 0x104B, lbBA},
+	{0x1056, 0x1059, lbCM},
+	{0x105E, 0x1060, lbCM},
+	{0x1062, 0x1064, lbCM},
+	{0x1067, 0x106D, lbCM},
+	{0x1071, 0x1074, lbCM},
+	{0x1082, 0x108D, lbCM},
+	{0x108F, 0x108F, lbCM},
+	{0x1090, 0x1099, lbNU},
+	{0x109A, 0x109D, lbCM},
+	{0x1100, 0x115F, lbJL},
+	{0x1160, 0x11A7, lbJV},
+	{0x11A8, 0x11FF, lbJT},
+	{0x135D, 0x135F, lbCM},
+	{0x1361, 0x1361, lbBA},
+	{0x1400, 0x1400, lbHH},
+	{0x1680, 0x1680, lbBA},
+	{0x169B, 0x169B, lbOP},
+	{0x169C, 0x169C, lbCL},
+	{0x16EB, 0x16ED, lbBA},
+	{0x1712, 0x1715, lbCM},
+	{0x1732, 0x1734, lbCM},
+	{0x1735, 0x1736, lbBA},
+	{0x1752, 0x1753, lbCM},
+	{0x1772, 0x1773, lbCM},
+	{0x17B4, 0x17D3, lbCM},
+	{0x17D4, 0x17D5, lbBA},
+	{0x17D6, 0x17D6, lbNS},
+	{0x17D8, 0x17D8, lbBA},
+	{0x17DA, 0x17DA, lbBA},
+	{0x17DB, 0x17DB, lbPR},
+	{0x17DD, 0x17DD, lbCM},
+	{0x17E0, 0x17E9, lbNU},
+	{0x1802, 0x1803, lbEX},
+	{0x1804, 0x1805, lbBA},
+	{0x1806, 0x1806, lbBB},
+	{0x1808, 0x1809, lbEX},
+	{0x180B, 0x180D, lbCM},
+	{0x180E, 0x180E, lbGL},
+	{0x180F, 0x180F, lbCM},
+	{0x1810, 0x1819, lbNU},
+	{0x1885, 0x1886, lbCM},
+	{0x18A9, 0x18A9, lbCM},
+	{0x1920, 0x192B, lbCM},
+	{0x1930, 0x193B, lbCM},
+	{0x1944, 0x1945, lbEX},
+	{0x1946, 0x194F, lbNU},
+	{0x19D0, 0x19DA, lbNU},
+	{0x1A17, 0x1A1B, lbCM},
+	{0x1A55, 0x1A5E, lbCM},
+	{0x1A60, 0x1A7C, lbCM},
+	{0x1A7F, 0x1A7F, lbCM},
+	{0x1A80, 0x1A89, lbNU},
+	{0x1A90, 0x1A99, lbNU},
+	{0x1AB0, 0x1ADD, lbCM},
+	{0x1AE0, 0x1AEA, lbCM},
+	{0x1AEB, 0x1AEB, lbGL},
+	{0x1B00, 0x1B04, lbCM},
+	{0x1B05, 0x1B33, lbAK},
+	{0x1B34, 0x1B43, lbCM},
+	{0x1B44, 0x1B44, lbVI},
+	{0x1B45, 0x1B4C, lbAK},
+	{0x1B4E, 0x1B4F, lbBA},
+	{0x1B50, 0x1B59, lbAS},
+	{0x1B5A, 0x1B5B, lbBA},
+	{0x1B5C, 0x1B5C, lbID},
+	{0x1B5D, 0x1B60, lbBA},
+	{0x1B61, 0x1B6A, lbID},
+	{0x1B6B, 0x1B73, lbCM},
+	{0x1B74, 0x1B7C, lbID},
+	{0x1B7D, 0x1B7F, lbBA},
+	{0x1B80, 0x1B82, lbCM},
+	{0x1BA1, 0x1BAD, lbCM},
+	{0x1BB0, 0x1BB9, lbNU},
+	{0x1BC0, 0x1BE5, lbAS},
+	{0x1BE6, 0x1BF1, lbCM},
+	{0x1BF2, 0x1BF3, lbVF},
+	{0x1C24, 0x1C37, lbCM},
+	{0x1C3B, 0x1C3F, lbBA},
+	{0x1C40, 0x1C49, lbNU},
+	{0x1C50, 0x1C59, lbNU},
+	{0x1C7E, 0x1C7F, lbBA},
+	{0x1CD0, 0x1CD2, lbCM},
+	{0x1CD4, 0x1CE8, lbCM},
+	{0x1CED, 0x1CED, lbCM},
+	{0x1CF4, 0x1CF4, lbCM},
+	{0x1CF7, 0x1CF9, lbCM},
+	{0x1DC0, 0x1DCC, lbCM},
+	{0x1DCD, 0x1DCD, lbGL},
+	{0x1DCE, 0x1DFB, lbCM},
+	{0x1DFC, 0x1DFC, lbGL},
+	{0x1DFD, 0x1DFF, lbCM},
+	{0x1FFD, 0x1FFD, lbBB},
+	{0x2000, 0x2006, lbBA},
+	{0x2007, 0x2007, lbGL},
+	{0x2008, 0x200A, lbBA},
+	{0x200B, 0x200B, lbZW},
+	{0x200C, 0x200C, lbCM},
+	{0x200D, 0x200D, lbZWJ},
+	{0x200E, 0x200F, lbCM},
+	{0x2010, 0x2010, lbHH},
+	{0x2011, 0x2011, lbGL},
+	{0x2012, 0x2013, lbHH},
+	{0x2014, 0x2014, lbB2},
+	{0x2018, 0x2019, lbQU},
+	{0x201A, 0x201A, lbOP},
+	{0x201B, 0x201D, lbQU},
+	{0x201E, 0x201E, lbOP},
+	{0x201F, 0x201F, lbQU},
+	{0x2024, 0x2026, lbIN},
+	{0x2027, 0x2027, lbBA},
+	{0x2028, 0x2029, lbBK},
+	{0x202A, 0x202E, lbCM},
+	{0x202F, 0x202F, lbGL},
+	{0x2030, 0x2037, lbPO},
+	{0x2039, 0x203A, lbQU},
+	{0x203C, 0x203D, lbNS},
+	{0x2044, 0x2044, lbIS},
+	{0x2045, 0x2045, lbOP},
+	{0x2046, 0x2046, lbCL},
+	{0x2047, 0x2049, lbNS},
+	{0x2056, 0x2056, lbBA},
+	{0x2057, 0x2057, lbPO},
+	{0x2058, 0x205B, lbBA},
+	{0x205D, 0x205F, lbBA},
+	{0x2060, 0x2060, lbWJ},
+	{0x2066, 0x206F, lbCM},
+	{0x207D, 0x207D, lbOP},
+	{0x207E, 0x207E, lbCL},
+	{0x208D, 0x208D, lbOP},
+	{0x208E, 0x208E, lbCL},
+	{0x20A0, 0x20A6, lbPR},
+	{0x20A7, 0x20A7, lbPO},
+	{0x20A8, 0x20B5, lbPR},
+	{0x20B6, 0x20B6, lbPO},
+	{0x20B7, 0x20BA, lbPR},
+	{0x20BB, 0x20BB, lbPO},
+	{0x20BC, 0x20BD, lbPR},
+	{0x20BE, 0x20BE, lbPO},
+	{0x20BF, 0x20BF, lbPR},
+	{0x20C0, 0x20C0, lbPO},
+	{0x20C1, 0x20CF, lbPR},
+	{0x20D0, 0x20F0, lbCM},
+	{0x2103, 0x2103, lbPO},
+	{0x2109, 0x2109, lbPO},
+	{0x2116, 0x2116, lbPR},
+	{0x2212, 0x2213, lbPR},
+	{0x22EF, 0x22EF, lbIN},
+	{0x2308, 0x2308, lbOP},
+	{0x2309, 0x2309, lbCL},
+	{0x230A, 0x230A, lbOP},
+	{0x230B, 0x230B, lbCL},
+	{0x231A, 0x231B, lbID},
+	{0x2329, 0x2329, lbOP},
+	{0x232A, 0x232A, lbCL},
+	{0x23F0, 0x23F3, lbID},
+	{0x2600, 0x2603, lbID},
+	{0x2614, 0x2615, lbID},
+	{0x2618, 0x2618, lbID},
+	{0x261A, 0x261C, lbID},
+	{0x261D, 0x261D, lbEB},
+	{0x261E, 0x261F, lbID},
+	{0x2639, 0x263B, lbID},
+	{0x2668, 0x2668, lbID},
+	{0x267F, 0x267F, lbID},
+	{0x26BD, 0x26C8, lbID},
+	{0x26CD, 0x26CD, lbID},
+	{0x26CF, 0x26D1, lbID},
+	{0x26D3, 0x26D4, lbID},
+	{0x26D8, 0x26D9, lbID},
+	{0x26DC, 0x26DC, lbID},
+	{0x26DF, 0x26E1, lbID},
+	{0x26EA, 0x26EA, lbID},
+	{0x26F1, 0x26F5, lbID},
+	{0x26F7, 0x26F8, lbID},
+	{0x26F9, 0x26F9, lbEB},
+	{0x26FA, 0x26FA, lbID},
+	{0x26FD, 0x2704, lbID},
+	{0x2708, 0x2709, lbID},
+	{0x270A, 0x270D, lbEB},
+	{0x275B, 0x2760, lbQU},
+	{0x2762, 0x2763, lbEX},
+	{0x2764, 0x2764, lbID},
+	{0x2768, 0x2768, lbOP},
+	{0x2769, 0x2769, lbCL},
+	{0x276A, 0x276A, lbOP},
+	{0x276B, 0x276B, lbCL},
+	{0x276C, 0x276C, lbOP},
+	{0x276D, 0x276D, lbCL},
+	{0x276E, 0x276E, lbOP},
+	{0x276F, 0x276F, lbCL},
+	{0x2770, 0x2770, lbOP},
+	{0x2771, 0x2771, lbCL},
+	{0x2772, 0x2772, lbOP},
+	{0x2773, 0x2773, lbCL},
+	{0x2774, 0x2774, lbOP},
+	{0x2775, 0x2775, lbCL},
+	{0x27C5, 0x27C5, lbOP},
+	{0x27C6, 0x27C6, lbCL},
+	{0x27E6, 0x27E6, lbOP},
+	{0x27E7, 0x27E7, lbCL},
+	{0x27E8, 0x27E8, lbOP},
+	{0x27E9, 0x27E9, lbCL},
+	{0x27EA, 0x27EA, lbOP},
+	{0x27EB, 0x27EB, lbCL},
+	{0x27EC, 0x27EC, lbOP},
+	{0x27ED, 0x27ED, lbCL},
+	{0x27EE, 0x27EE, lbOP},
+	{0x27EF, 0x27EF, lbCL},
+	{0x2800, 0x2800, lbBA},
+	{0x2983, 0x2983, lbOP},
+	{0x2984, 0x2984, lbCL},
+	{0x2985, 0x2985, lbOP},
+	{0x2986, 0x2986, lbCL},
+	{0x2987, 0x2987, lbOP},
+	{0x2988, 0x2988, lbCL},
+	{0x2989, 0x2989, lbOP},
+	{0x298A, 0x298A, lbCL},
+	{0x298B, 0x298B, lbOP},
+	{0x298C, 0x298C, lbCL},
+	{0x298D, 0x298D, lbOP},
+	{0x298E, 0x298E, lbCL},
+	{0x298F, 0x298F, lbOP},
+	{0x2990, 0x2990, lbCL},
+	{0x2991, 0x2991, lbOP},
+	{0x2992, 0x2992, lbCL},
+	{0x2993, 0x2993, lbOP},
+	{0x2994, 0x2994, lbCL},
+	{0x2995, 0x2995, lbOP},
+	{0x2996, 0x2996, lbCL},
+	{0x2997, 0x2997, lbOP},
+	{0x2998, 0x2998, lbCL},
+	{0x29D8, 0x29D8, lbOP},
+	{0x29D9, 0x29D9, lbCL},
+	{0x29DA, 0x29DA, lbOP},
+	{0x29DB, 0x29DB, lbCL},
+	{0x29FC, 0x29FC, lbOP},
+	{0x29FD, 0x29FD, lbCL},
+	{0x2CEF, 0x2CF1, lbCM},
+	{0x2CF9, 0x2CF9, lbEX},
+	{0x2CFA, 0x2CFC, lbBA},
+	{0x2CFE, 0x2CFE, lbEX},
+	{0x2CFF, 0x2CFF, lbBA},
+	{0x2D70, 0x2D70, lbBA},
+	{0x2D7F, 0x2D7F, lbCM},
+	{0x2DE0, 0x2DFF, lbCM},
+	{0x2E00, 0x2E0D, lbQU},
+	{0x2E0E, 0x2E15, lbBA},
+	{0x2E17, 0x2E17, lbHH},
+	{0x2E18, 0x2E18, lbOP},
+	{0x2E19, 0x2E19, lbBA},
+	{0x2E1C, 0x2E1D, lbQU},
+	{0x2E20, 0x2E21, lbQU},
+	{0x2E22, 0x2E22, lbOP},
+	{0x2E23, 0x2E23, lbCL},
+	{0x2E24, 0x2E24, lbOP},
+	{0x2E25, 0x2E25, lbCL},
+	{0x2E26, 0x2E26, lbOP},
+	{0x2E27, 0x2E27, lbCL},
+	{0x2E28, 0x2E28, lbOP},
+	{0x2E29, 0x2E29, lbCL},
+	{0x2E2A, 0x2E2D, lbBA},
+	{0x2E2E, 0x2E2E, lbEX},
+	{0x2E30, 0x2E31, lbBA},
+	{0x2E33, 0x2E34, lbBA},
+	{0x2E3A, 0x2E3B, lbB2},
+	{0x2E3C, 0x2E3E, lbBA},
+	{0x2E40, 0x2E40, lbHH},
+	{0x2E41, 0x2E41, lbBA},
+	{0x2E42, 0x2E42, lbOP},
+	{0x2E43, 0x2E4A, lbBA},
+	{0x2E4C, 0x2E4C, lbBA},
+	{0x2E4E, 0x2E4F, lbBA},
+	{0x2E53, 0x2E54, lbEX},
+	{0x2E55, 0x2E55, lbOP},
+	{0x2E56, 0x2E56, lbCP},
+	{0x2E57, 0x2E57, lbOP},
+	{0x2E58, 0x2E58, lbCP},
+	{0x2E59, 0x2E59, lbOP},
+	{0x2E5A, 0x2E5A, lbCP},
+	{0x2E5B, 0x2E5B, lbOP},
+	{0x2E5C, 0x2E5C, lbCP},
+	{0x2E5D, 0x2E5D, lbHH},
+	{0x2E80, 0x2E99, lbID},
+	{0x2E9B, 0x2EF3, lbID},
+	{0x2F00, 0x2FD5, lbID},
+	{0x2FF0, 0x2FFF, lbID},
+	{0x3000, 0x3000, lbBA},
+	{0x3001, 0x3002, lbCL},
+	{0x3003, 0x3004, lbID},
+	{0x3005, 0x3005, lbNS},
+	{0x3006, 0x3007, lbID},
+	{0x3008, 0x3008, lbOP},
+	{0x3009, 0x3009, lbCL},
+	{0x300A, 0x300A, lbOP},
+	{0x300B, 0x300B, lbCL},
+	{0x300C, 0x300C, lbOP},
+	{0x300D, 0x300D, lbCL},
+	{0x300E, 0x300E, lbOP},
+	{0x300F, 0x300F, lbCL},
+	{0x3010, 0x3010, lbOP},
+	{0x3011, 0x3011, lbCL},
+	{0x3012, 0x3013, lbID},
+	{0x3014, 0x3014, lbOP},
+	{0x3015, 0x3015, lbCL},
+	{0x3016, 0x3016, lbOP},
+	{0x3017, 0x3017, lbCL},
+	{0x3018, 0x3018, lbOP},
+	{0x3019, 0x3019, lbCL},
+	{0x301A, 0x301A, lbOP},
+	{0x301B, 0x301B, lbCL},
+	{0x301C, 0x301C, lbNS},
+	{0x301D, 0x301D, lbOP},
+	{0x301E, 0x301F, lbCL},
+	{0x3020, 0x3029, lbID},
+	{0x302A, 0x302F, lbCM},
+	{0x3030, 0x3034, lbID},
+	{0x3035, 0x3035, lbCM},
+	{0x3036, 0x303A, lbID},
+	{0x303B, 0x303C, lbNS},
+	{0x303D, 0x303F, lbID},
+	{0x3041, 0x3041, lbNS},
+	{0x3042, 0x3042, lbID},
+	{0x3043, 0x3043, lbNS},
+	{0x3044, 0x3044, lbID},
+	{0x3045, 0x3045, lbNS},
+	{0x3046, 0x3046, lbID},
+	{0x3047, 0x3047, lbNS},
+	{0x3048, 0x3048, lbID},
+	{0x3049, 0x3049, lbNS},
+	{0x304A, 0x3062, lbID},
+	{0x3063, 0x3063, lbNS},
+	{0x3064, 0x3082, lbID},
+	{0x3083, 0x3083, lbNS},
+	{0x3084, 0x3084, lbID},
+	{0x3085, 0x3085, lbNS},
+	{0x3086, 0x3086, lbID},
+	{0x3087, 0x3087, lbNS},
+	{0x3088, 0x308D, lbID},
+	{0x308E, 0x308E, lbNS},
+	{0x308F, 0x3094, lbID},
+	{0x3095, 0x3096, lbNS},
+	{0x3099, 0x309A, lbCM},
+	{0x309B, 0x309E, lbNS},
+	{0x309F, 0x309F, lbID},
+	{0x30A0, 0x30A1, lbNS},
+	{0x30A2, 0x30A2, lbID},
+	{0x30A3, 0x30A3, lbNS},
+	{0x30A4, 0x30A4, lbID},
+	{0x30A5, 0x30A5, lbNS},
+	{0x30A6, 0x30A6, lbID},
+	{0x30A7, 0x30A7, lbNS},
+	{0x30A8, 0x30A8, lbID},
+	{0x30A9, 0x30A9, lbNS},
+	{0x30AA, 0x30C2, lbID},
+	{0x30C3, 0x30C3, lbNS},
+	{0x30C4, 0x30E2, lbID},
+	{0x30E3, 0x30E3, lbNS},
+	{0x30E4, 0x30E4, lbID},
+	{0x30E5, 0x30E5, lbNS},
+	{0x30E6, 0x30E6, lbID},
+	{0x30E7, 0x30E7, lbNS},
+	{0x30E8, 0x30ED, lbID},
+	{0x30EE, 0x30EE, lbNS},
+	{0x30EF, 0x30F4, lbID},
+	{0x30F5, 0x30F6, lbNS},
+	{0x30F7, 0x30FA, lbID},
+	{0x30FB, 0x30FE, lbNS},
+	{0x30FF, 0x30FF, lbID},
+	{0x3105, 0x312F, lbID},
+	{0x3131, 0x318E, lbID},
+	{0x3190, 0x31E5, lbID},
+	{0x31EF, 0x31EF, lbID},
+	{0x31F0, 0x31FF, lbNS},
+	{0x3200, 0x321E, lbID},
+	{0x3220, 0x3247, lbID},
+	{0x3250, 0x4DBF, lbID},
+	{0x4E00, 0xA014, lbID},
+	{0xA015, 0xA015, lbNS},
+	{0xA016, 0xA48C, lbID},
+	{0xA490, 0xA4C6, lbID},
+	{0xA4FE, 0xA4FF, lbBA},
+	{0xA60D, 0xA60D, lbBA},
+	{0xA60E, 0xA60E, lbEX},
+	{0xA60F, 0xA60F, lbBA},
+	{0xA620, 0xA629, lbNU},
+	{0xA66F, 0xA672, lbCM},
+	{0xA674, 0xA67D, lbCM},
+	{0xA69E, 0xA69F, lbCM},
+	{0xA6F0, 0xA6F1, lbCM},
+	{0xA6F3, 0xA6F7, lbBA},
+	{0xA802, 0xA802, lbCM},
+	{0xA806, 0xA806, lbCM},
+	{0xA80B, 0xA80B, lbCM},
+	{0xA823, 0xA827, lbCM},
+	{0xA82C, 0xA82C, lbCM},
+	{0xA838, 0xA838, lbPO},
+	{0xA874, 0xA875, lbBB},
+	{0xA876, 0xA877, lbEX},
+	{0xA880, 0xA881, lbCM},
+	{0xA8B4, 0xA8C5, lbCM},
+	{0xA8CE, 0xA8CF, lbBA},
+	{0xA8D0, 0xA8D9, lbNU},
+	{0xA8E0, 0xA8F1, lbCM},
+	{0xA8FC, 0xA8FC, lbBB},
+	{0xA8FF, 0xA8FF, lbCM},
+	{0xA900, 0xA909, lbNU},
+	{0xA926, 0xA92D, lbCM},
+	{0xA92E, 0xA92F, lbBA},
+	{0xA947, 0xA953, lbCM},
+	{0xA960, 0xA97C, lbJL},
+	{0xA980, 0xA983, lbCM},
+	{0xA984, 0xA9B2, lbAK},
+	{0xA9B3, 0xA9BF, lbCM},
+	{0xA9C0, 0xA9C0, lbVI},
+	{0xA9C1, 0xA9C6, lbID},
+	{0xA9C7, 0xA9C9, lbBA},
+	{0xA9CA, 0xA9CD, lbID},
+	{0xA9CF, 0xA9CF, lbBA},
+	{0xA9D0, 0xA9D9, lbAS},
+	{0xA9DE, 0xA9DF, lbID},
+	{0xA9E5, 0xA9E5, lbCM},
+	{0xA9F0, 0xA9F9, lbNU},
+	{0xAA00, 0xAA28, lbAS},
+	{0xAA29, 0xAA36, lbCM},
+	{0xAA40, 0xAA42, lbBA},
+	{0xAA43, 0xAA43, lbCM},
+	{0xAA44, 0xAA4B, lbBA},
+	{0xAA4C, 0xAA4D, lbCM},
+	{0xAA50, 0xAA59, lbAS},
+	{0xAA5C, 0xAA5C, lbID},
+	{0xAA5D, 0xAA5F, lbBA},
+	{0xAA7B, 0xAA7D, lbCM},
+	{0xAAB0, 0xAAB0, lbCM},
+	{0xAAB2, 0xAAB4, lbCM},
+	{0xAAB7, 0xAAB8, lbCM},
+	{0xAABE, 0xAABF, lbCM},
+	{0xAAC1, 0xAAC1, lbCM},
+	{0xAAEB, 0xAAEF, lbCM},
+	{0xAAF0, 0xAAF1, lbBA},
+	{0xAAF5, 0xAAF6, lbCM},
+	{0xABE3, 0xABEA, lbCM},
+	{0xABEB, 0xABEB, lbBA},
+	{0xABEC, 0xABED, lbCM},
+	{0xABF0, 0xABF9, lbNU},
+	{0xD7B0, 0xD7C6, lbJV},
+	{0xD7CB, 0xD7FB, lbJT},
+	{0xF900, 0xFAFF, lbID},
+	{0xFB1D, 0xFB1D, lbHL},
+	{0xFB1E, 0xFB1E, lbCM},
+	{0xFB1F, 0xFB28, lbHL},
+	{0xFB2A, 0xFB36, lbHL},
+	{0xFB38, 0xFB3C, lbHL},
+	{0xFB3E, 0xFB3E, lbHL},
+	{0xFB40, 0xFB41, lbHL},
+	{0xFB43, 0xFB44, lbHL},
+	{0xFB46, 0xFB4F, lbHL},
+	{0xFD3E, 0xFD3E, lbCL},
+	{0xFD3F, 0xFD3F, lbOP},
+	{0xFDFC, 0xFDFC, lbPO},
+	{0xFE00, 0xFE0F, lbCM},
+	{0xFE10, 0xFE12, lbCL},
+	{0xFE13, 0xFE14, lbNS},
+	{0xFE15, 0xFE16, lbEX},
+	{0xFE17, 0xFE17, lbOP},
+	{0xFE18, 0xFE18, lbCL},
+	{0xFE19, 0xFE19, lbIN},
+	{0xFE20, 0xFE20, lbGL},
+	{0xFE21, 0xFE21, lbCM},
+	{0xFE22, 0xFE22, lbGL},
+	{0xFE23, 0xFE23, lbCM},
+	{0xFE24, 0xFE24, lbGL},
+	{0xFE25, 0xFE25, lbCM},
+	{0xFE26, 0xFE27, lbGL},
+	{0xFE28, 0xFE28, lbCM},
+	{0xFE29, 0xFE29, lbGL},
+	{0xFE2A, 0xFE2A, lbCM},
+	{0xFE2B, 0xFE2B, lbGL},
+	{0xFE2C, 0xFE2C, lbCM},
+	{0xFE2D, 0xFE2E, lbGL},
+	{0xFE2F, 0xFE2F, lbCM},
+	{0xFE30, 0xFE34, lbID},
+	{0xFE35, 0xFE35, lbOP},
+	{0xFE36, 0xFE36, lbCL},
+	{0xFE37, 0xFE37, lbOP},
+	{0xFE38, 0xFE38, lbCL},
+	{0xFE39, 0xFE39, lbOP},
+	{0xFE3A, 0xFE3A, lbCL},
+	{0xFE3B, 0xFE3B, lbOP},
+	{0xFE3C, 0xFE3C, lbCL},
+	{0xFE3D, 0xFE3D, lbOP},
+	{0xFE3E, 0xFE3E, lbCL},
+	{0xFE3F, 0xFE3F, lbOP},
+	{0xFE40, 0xFE40, lbCL},
+	{0xFE41, 0xFE41, lbOP},
+	{0xFE42, 0xFE42, lbCL},
+	{0xFE43, 0xFE43, lbOP},
+	{0xFE44, 0xFE44, lbCL},
+	{0xFE45, 0xFE46, lbID},
+	{0xFE47, 0xFE47, lbOP},
+	{0xFE48, 0xFE48, lbCL},
+	{0xFE49, 0xFE4F, lbID},
+	{0xFE50, 0xFE50, lbCL},
+	{0xFE51, 0xFE51, lbID},
+	{0xFE52, 0xFE52, lbCL},
+	{0xFE54, 0xFE55, lbNS},
+	{0xFE56, 0xFE57, lbEX},
+	{0xFE58, 0xFE58, lbID},
+	{0xFE59, 0xFE59, lbOP},
+	{0xFE5A, 0xFE5A, lbCL},
+	{0xFE5B, 0xFE5B, lbOP},
+	{0xFE5C, 0xFE5C, lbCL},
+	{0xFE5D, 0xFE5D, lbOP},
+	{0xFE5E, 0xFE5E, lbCL},
+	{0xFE5F, 0xFE66, lbID},
+	{0xFE68, 0xFE68, lbID},
+	{0xFE69, 0xFE69, lbPR},
+	{0xFE6A, 0xFE6A, lbPO},
+	{0xFE6B, 0xFE6B, lbID},
+	{0xFEFF, 0xFEFF, lbWJ},
+	{0xFF01, 0xFF01, lbEX},
+	{0xFF02, 0xFF03, lbID},
+	{0xFF04, 0xFF04, lbPR},
+	{0xFF05, 0xFF05, lbPO},
+	{0xFF06, 0xFF07, lbID},
+	{0xFF08, 0xFF08, lbOP},
+	{0xFF09, 0xFF09, lbCL},
+	{0xFF0A, 0xFF0B, lbID},
+	{0xFF0C, 0xFF0C, lbCL},
+	{0xFF0D, 0xFF0D, lbID},
+	{0xFF0E, 0xFF0E, lbCL},
+	{0xFF0F, 0xFF19, lbID},
+	{0xFF1A, 0xFF1B, lbNS},
+	{0xFF1C, 0xFF1E, lbID},
+	{0xFF1F, 0xFF1F, lbEX},
+	{0xFF20, 0xFF3A, lbID},
+	{0xFF3B, 0xFF3B, lbOP},
+	{0xFF3C, 0xFF3C, lbID},
+	{0xFF3D, 0xFF3D, lbCL},
+	{0xFF3E, 0xFF5A, lbID},
+	{0xFF5B, 0xFF5B, lbOP},
+	{0xFF5C, 0xFF5C, lbID},
+	{0xFF5D, 0xFF5D, lbCL},
+	{0xFF5E, 0xFF5E, lbID},
+	{0xFF5F, 0xFF5F, lbOP},
+	{0xFF60, 0xFF61, lbCL},
+	{0xFF62, 0xFF62, lbOP},
+	{0xFF63, 0xFF64, lbCL},
+	{0xFF65, 0xFF65, lbNS},
+	{0xFF66, 0xFF66, lbID},
+	{0xFF67, 0xFF70, lbNS},
+	{0xFF71, 0xFF9D, lbID},
+	{0xFF9E, 0xFF9F, lbNS},
+	{0xFFA0, 0xFFBE, lbID},
+	{0xFFC2, 0xFFC7, lbID},
+	{0xFFCA, 0xFFCF, lbID},
+	{0xFFD2, 0xFFD7, lbID},
+	{0xFFDA, 0xFFDC, lbID},
+	{0xFFE0, 0xFFE0, lbPO},
+	{0xFFE1, 0xFFE1, lbPR},
+	{0xFFE2, 0xFFE4, lbID},
+	{0xFFE5, 0xFFE6, lbPR},
+	{0xFFF9, 0xFFFB, lbCM},
+	{0xFFFC, 0xFFFC, lbCB},
+	{0x10100, 0x10102, lbBA},
+	{0x101FD, 0x101FD, lbCM},
+	{0x102E0, 0x102E0, lbCM},
+	{0x10376, 0x1037A, lbCM},
+	{0x1039F, 0x1039F, lbBA},
+	{0x103D0, 0x103D0, lbBA},
+	{0x104A0, 0x104A9, lbNU},
+	{0x10857, 0x10857, lbBA},
+	{0x1091F, 0x1091F, lbBA},
+	{0x10A01, 0x10A03, lbCM},
+	{0x10A05, 0x10A06, lbCM},
+	{0x10A0C, 0x10A0F, lbCM},
+	{0x10A38, 0x10A3A, lbCM},
+	{0x10A3F, 0x10A3F, lbCM},
+	{0x10A50, 0x10A57, lbBA},
+	{0x10AE5, 0x10AE6, lbCM},
+	{0x10AF0, 0x10AF5, lbBA},
+	{0x10AF6, 0x10AF6, lbIN},
+	{0x10B39, 0x10B3F, lbBA},
+	{0x10D24, 0x10D27, lbCM},
+	{0x10D30, 0x10D39, lbNU},
+	{0x10D40, 0x10D49, lbNU},
+	{0x10D69, 0x10D6D, lbCM},
+	{0x10D6E, 0x10D6E, lbHH},
+	{0x10EAB, 0x10EAC, lbCM},
+	{0x10EAD, 0x10EAD, lbHH},
+	{0x10ED0, 0x10ED0, lbBA},
+	{0x10EFA, 0x10EFF, lbCM},
+	{0x10F46, 0x10F50, lbCM},
+	{0x10F82, 0x10F85, lbCM},
+	{0x11000, 0x11002, lbCM},
+	{0x11003, 0x11004, lbAP},
+	{0x11005, 0x11037, lbAK},
+	{0x11038, 0x11045, lbCM},
+	{0x11046, 0x11046, lbVI},
+	{0x11047, 0x11048, lbBA},
+	{0x11049, 0x1104D, lbID},
+	{0x11052, 0x11065, lbID},
+	{0x11066, 0x1106F, lbAS},
+	{0x11070, 0x11070, lbCM},
+	{0x11071, 0x11072, lbAK},
+	{0x11073, 0x11074, lbCM},
+	{0x11075, 0x11075, lbAK},
+	{0x1107F, 0x1107F, lbGL},
+	{0x11080, 0x11082, lbCM},
+	{0x110B0, 0x110BA, lbCM},
+	{0x110BD, 0x110BD, lbNU},
+	{0x110BE, 0x110C1, lbBA},
+	{0x110C2, 0x110C2, lbCM},
+	{0x110CD, 0x110CD, lbNU},
+	{0x110F0, 0x110F9, lbNU},
+	{0x11100, 0x11102, lbCM},
+	{0x11127, 0x11134, lbCM},
+	{0x11136, 0x1113F, lbNU},
+	{0x11140, 0x11143, lbBA},
+	{0x11145, 0x11146, lbCM},
+	{0x11173, 0x11173, lbCM},
+	{0x11175, 0x11175, lbBB},
+	{0x11180, 0x11182, lbCM},
+	{0x111B3, 0x111C0, lbCM},
+	{0x111C5, 0x111C6, lbBA},
+	{0x111C8, 0x111C8, lbBA},
+	{0x111C9, 0x111CC, lbCM},
+	{0x111CE, 0x111CF, lbCM},
+	{0x111D0, 0x111D9, lbNU},
+	{0x111DB, 0x111DB, lbBB},
+	{0x111DD, 0x111DF, lbBA},
+	{0x1122C, 0x11237, lbCM},
+	{0x11238, 0x11239, lbBA},
+	{0x1123B, 0x1123C, lbBA},
+	{0x1123E, 0x1123E, lbCM},
+	{0x11241, 0x11241, lbCM},
+	{0x112A9, 0x112A9, lbBA},
+	{0x112DF, 0x112EA, lbCM},
+	{0x112F0, 0x112F9, lbNU},
+	{0x11300, 0x11303, lbCM},
+	{0x11305, 0x1130C, lbAK},
+	{0x1130F, 0x11310, lbAK},
+	{0x11313, 0x11328, lbAK},
+	{0x1132A, 0x11330, lbAK},
+	{0x11332, 0x11333, lbAK},
+	{0x11335, 0x11339, lbAK},
+	{0x1133B, 0x1133C, lbCM},
+	{0x1133D, 0x1133D, lbBA},
+	{0x1133E, 0x11344, lbCM},
+	{0x11347, 0x11348, lbCM},
+	{0x1134B, 0x1134C, lbCM},
+	{0x1134D, 0x1134D, lbVI},
+	{0x11350, 0x11350, lbAS},
+	{0x11357, 0x11357, lbCM},
+	{0x1135D, 0x1135D, lbBA},
+	{0x1135E, 0x1135F, lbAS},
+	{0x11360, 0x11361, lbAK},
+	{0x11362, 0x11363, lbCM},
+	{0x11366, 0x1136C, lbCM},
+	{0x11370, 0x11374, lbCM},
+	{0x11380, 0x11389, lbAS},
+	{0x1138B, 0x1138B, lbAS},
+	{0x1138E, 0x1138E, lbAS},
+	{0x11390, 0x11391, lbAS},
+	{0x11392, 0x113B5, lbAK},
+	{0x113B7, 0x113B7, lbID},
+	{0x113B8, 0x113C0, lbCM},
+	{0x113C2, 0x113C2, lbCM},
+	{0x113C5, 0x113C5, lbCM},
+	{0x113C7, 0x113CA, lbCM},
+	{0x113CC, 0x113CF, lbCM},
+	{0x113D0, 0x113D0, lbVI},
+	{0x113D1, 0x113D1, lbAP},
+	{0x113D2, 0x113D2, lbCM},
+	{0x113D3, 0x113D5, lbID},
+	{0x113D7, 0x113D8, lbID},
+	{0x113E1, 0x113E2, lbCM},
+	{0x11435, 0x11446, lbCM},
+	{0x1144B, 0x1144E, lbBA},
+	{0x11450, 0x11459, lbNU},
+	{0x1145A, 0x1145B, lbBA},
+	{0x1145E, 0x1145E, lbCM},
+	{0x114B0, 0x114C3, lbCM},
+	{0x114D0, 0x114D9, lbNU},
+	{0x115AF, 0x115B5, lbCM},
+	{0x115B8, 0x115C0, lbCM},
+	{0x115C1, 0x115C1, lbBB},
+	{0x115C2, 0x115C3, lbBA},
+	{0x115C4, 0x115C5, lbEX},
+	{0x115C9, 0x115D7, lbBA},
+	{0x115DC, 0x115DD, lbCM},
+	{0x11630, 0x11640, lbCM},
+	{0x11641, 0x11642, lbBA},
+	{0x11650, 0x11659, lbNU},
+	{0x11660, 0x1166C, lbBB},
+	{0x116AB, 0x116B7, lbCM},
+	{0x116C0, 0x116C9, lbNU},
+	{0x116D0, 0x116E3, lbNU},
+	{0x1171D, 0x1172B, lbCM},
+	{0x11730, 0x11739, lbNU},
+	{0x1173C, 0x1173E, lbBA},
+	{0x1182C, 0x1183A, lbCM},
+	{0x118E0, 0x118E9, lbNU},
+	{0x11900, 0x11906, lbAK},
+	{0x11909, 0x11909, lbAK},
+	{0x1190C, 0x11913, lbAK},
+	{0x11915, 0x11916, lbAK},
+	{0x11918, 0x1192F, lbAK},
+	{0x11930, 0x11935, lbCM},
+	{0x11937, 0x11938, lbCM},
+	{0x1193B, 0x1193D, lbCM},
+	{0x1193E, 0x1193E, lbVI},
+	{0x1193F, 0x1193F, lbAP},
+	{0x11940, 0x11940, lbCM},
+	{0x11941, 0x11941, lbAP},
+	{0x11942, 0x11943, lbCM},
+	{0x11944, 0x11946, lbBA},
+	{0x11950, 0x11959, lbAS},
+	{0x119D1, 0x119D7, lbCM},
+	{0x119DA, 0x119E0, lbCM},
+	{0x119E2, 0x119E2, lbBB},
+	{0x119E4, 0x119E4, lbCM},
+	{0x11A01, 0x11A0A, lbCM},
+	{0x11A33, 0x11A39, lbCM},
+	{0x11A3B, 0x11A3E, lbCM},
+	{0x11A3F, 0x11A3F, lbBB},
+	{0x11A41, 0x11A44, lbBA},
+	{0x11A45, 0x11A45, lbBB},
+	{0x11A47, 0x11A47, lbCM},
+	{0x11A51, 0x11A5B, lbCM},
+	{0x11A8A, 0x11A99, lbCM},
+	{0x11A9A, 0x11A9C, lbBA},
+	{0x11A9E, 0x11AA0, lbBB},
+	{0x11AA1, 0x11AA2, lbBA},
+	{0x11B00, 0x11B09, lbBB},
+	{0x11B60, 0x11B67, lbCM},
+	{0x11BF0, 0x11BF9, lbNU},
+	{0x11C2F, 0x11C36, lbCM},
+	{0x11C38, 0x11C3F, lbCM},
+	{0x11C41, 0x11C45, lbBA},
+	{0x11C50, 0x11C59, lbNU},
+	{0x11C70, 0x11C70, lbBB},
+	{0x11C71, 0x11C71, lbEX},
+	{0x11C92, 0x11CA7, lbCM},
+	{0x11CA9, 0x11CB6, lbCM},
+	{0x11D31, 0x11D36, lbCM},
+	{0x11D3A, 0x11D3A, lbCM},
+	{0x11D3C, 0x11D3D, lbCM},
+	{0x11D3F, 0x11D45, lbCM},
+	{0x11D47, 0x11D47, lbCM},
+	{0x11D50, 0x11D59, lbNU},
+	{0x11D8A, 0x11D8E, lbCM},
+	{0x11D90, 0x11D91, lbCM},
+	{0x11D93, 0x11D97, lbCM},
+	{0x11DA0, 0x11DA9, lbNU},
+	{0x11DE0, 0x11DE9, lbNU},
+	{0x11EE0, 0x11EF1, lbAS},
+	{0x11EF2, 0x11EF2, lbBA},
+	{0x11EF3, 0x11EF6, lbCM},
+	{0x11EF7, 0x11EF8, lbBA},
+	{0x11F00, 0x11F01, lbCM},
+	{0x11F02, 0x11F02, lbAP},
+	{0x11F03, 0x11F03, lbCM},
+	{0x11F04, 0x11F10, lbAK},
+	{0x11F12, 0x11F33, lbAK},
+	{0x11F34, 0x11F3A, lbCM},
+	{0x11F3E, 0x11F41, lbCM},
+	{0x11F42, 0x11F42, lbVI},
+	{0x11F43, 0x11F44, lbBA},
+	{0x11F45, 0x11F4F, lbID},
+	{0x11F50, 0x11F59, lbAS},
+	{0x11F5A, 0x11F5A, lbCM},
+	{0x11FDD, 0x11FE0, lbPO},
+	{0x11FFF, 0x11FFF, lbBA},
+	{0x12470, 0x12474, lbBA},
+	{0x13258, 0x1325A, lbOP},
+	{0x1325B, 0x1325D, lbCL},
+	{0x13282, 0x13282, lbCL},
+	{0x13286, 0x13286, lbOP},
+	{0x13287, 0x13287, lbCL},
+	{0x13288, 0x13288, lbOP},
+	{0x13289, 0x13289, lbCL},
+	{0x13379, 0x13379, lbOP},
+	{0x1337A, 0x1337B, lbCL},
+	{0x1342F, 0x1342F, lbOP},
+	{0x13430, 0x13436, lbGL},
+	{0x13437, 0x13437, lbOP},
+	{0x13438, 0x13438, lbCL},
+	{0x13439, 0x1343B, lbGL},
+	{0x1343C, 0x1343C, lbOP},
+	{0x1343D, 0x1343D, lbCL},
+	{0x1343E, 0x1343E, lbOP},
+	{0x1343F, 0x1343F, lbCL},
+	{0x13440, 0x13440, lbCM},
+	{0x13447, 0x13455, lbCM},
+	{0x145CE, 0x145CE, lbOP},
+	{0x145CF, 0x145CF, lbCL},
+	{0x16100, 0x1611D, lbAS},
+	{0x1611E, 0x1612F, lbCM},
+	{0x16130, 0x16139, lbAS},
+	{0x16A60, 0x16A69, lbNU},
+	{0x16A6E, 0x16A6F, lbBA},
+	{0x16AC0, 0x16AC9, lbNU},
+	{0x16AF0, 0x16AF4, lbCM},
+	{0x16AF5, 0x16AF5, lbBA},
+	{0x16B30, 0x16B36, lbCM},
+	{0x16B37, 0x16B39, lbBA},
+	{0x16B44, 0x16B44, lbBA},
+	{0x16B50, 0x16B59, lbNU},
+	{0x16D6E, 0x16D6F, lbBA},
+	{0x16D70, 0x16D79, lbNU},
+	{0x16E97, 0x16E98, lbBA},
+	{0x16F4F, 0x16F4F, lbCM},
+	{0x16F51, 0x16F87, lbCM},
+	{0x16F8F, 0x16F92, lbCM},
+	{0x16FE0, 0x16FE3, lbNS},
+	{0x16FE4, 0x16FE4, lbGL},
+	{0x16FF0, 0x16FF1, lbCM},
+	{0x16FF2, 0x16FF3, lbNS},
+	{0x16FF4, 0x16FF6, lbID},
+	{0x17000, 0x18AFF, lbID},
+	{0x18D00, 0x18D1E, lbID},
+	{0x18D80, 0x18DF2, lbID},
+	{0x1B000, 0x1B122, lbID},
+	{0x1B132, 0x1B132, lbNS},
+	{0x1B150, 0x1B152, lbNS},
+	{0x1B155, 0x1B155, lbNS},
+	{0x1B164, 0x1B167, lbNS},
+	{0x1B170, 0x1B2FB, lbID},
+	{0x1BC9D, 0x1BC9E, lbCM},
+	{0x1BC9F, 0x1BC9F, lbBA},
+	{0x1BCA0, 0x1BCA3, lbCM},
+	{0x1CCF0, 0x1CCF9, lbNU},
+	{0x1CF00, 0x1CF2D, lbCM},
+	{0x1CF30, 0x1CF46, lbCM},
+	{0x1D165, 0x1D169, lbCM},
+	{0x1D16D, 0x1D182, lbCM},
+	{0x1D185, 0x1D18B, lbCM},
+	{0x1D1AA, 0x1D1AD, lbCM},
+	{0x1D242, 0x1D244, lbCM},
+	{0x1D7CE, 0x1D7FF, lbNU},
+	{0x1DA00, 0x1DA36, lbCM},
+	{0x1DA3B, 0x1DA6C, lbCM},
+	{0x1DA75, 0x1DA75, lbCM},
+	{0x1DA84, 0x1DA84, lbCM},
+	{0x1DA87, 0x1DA8A, lbBA},
+	{0x1DA9B, 0x1DA9F, lbCM},
+	{0x1DAA1, 0x1DAAF, lbCM},
+	{0x1E000, 0x1E006, lbCM},
+	{0x1E008, 0x1E018, lbCM},
+	{0x1E01B, 0x1E021, lbCM},
+	{0x1E023, 0x1E024, lbCM},
+	{0x1E026, 0x1E02A, lbCM},
+	{0x1E08F, 0x1E08F, lbCM},
+	{0x1E130, 0x1E136, lbCM},
+	{0x1E140, 0x1E149, lbNU},
+	{0x1E2AE, 0x1E2AE, lbCM},
+	{0x1E2EC, 0x1E2EF, lbCM},
+	{0x1E2F0, 0x1E2F9, lbNU},
+	{0x1E2FF, 0x1E2FF, lbPR},
+	{0x1E4EC, 0x1E4EF, lbCM},
+	{0x1E4F0, 0x1E4F9, lbNU},
+	{0x1E5EE, 0x1E5EF, lbCM},
+	{0x1E5F1, 0x1E5FA, lbNU},
+	{0x1E6E3, 0x1E6E3, lbCM},
+	{0x1E6E6, 0x1E6E6, lbCM},
+	{0x1E6EE, 0x1E6EF, lbCM},
+	{0x1E6F5, 0x1E6F5, lbCM},
+	{0x1E8D0, 0x1E8D6, lbCM},
+	{0x1E944, 0x1E94A, lbCM},
+	{0x1E950, 0x1E959, lbNU},
+	{0x1E95E, 0x1E95F, lbOP},
+	{0x1ECAC, 0x1ECAC, lbPO},
+	{0x1ECB0, 0x1ECB0, lbPO},
+	{0x1F000, 0x1F0FF, lbID},
+	{0x1F1AE, 0x1F1E5, lbID},
+	{0x1F1E6, 0x1F1FF, lbRI},
+	{0x1F200, 0x1F384, lbID},
+	{0x1F385, 0x1F385, lbEB},
+	{0x1F386, 0x1F39B, lbID},
+	{0x1F39E, 0x1F3B4, lbID},
+	{0x1F3B7, 0x1F3BB, lbID},
+	{0x1F3BD, 0x1F3C1, lbID},
+	{0x1F3C2, 0x1F3C4, lbEB},
+	{0x1F3C5, 0x1F3C6, lbID},
+	{0x1F3C7, 0x1F3C7, lbEB},
+	{0x1F3C8, 0x1F3C9, lbID},
+	{0x1F3CA, 0x1F3CC, lbEB},
+	{0x1F3CD, 0x1F3FA, lbID},
+	{0x1F3FB, 0x1F3FF, lbEM},
+	{0x1F400, 0x1F441, lbID},
+	{0x1F442, 0x1F443, lbEB},
+	{0x1F444, 0x1F445, lbID},
+	{0x1F446, 0x1F450, lbEB},
+	{0x1F451, 0x1F465, lbID},
+	{0x1F466, 0x1F478, lbEB},
+	{0x1F479, 0x1F47B, lbID},
+	{0x1F47C, 0x1F47C, lbEB},
+	{0x1F47D, 0x1F480, lbID},
+	{0x1F481, 0x1F483, lbEB},
+	{0x1F484, 0x1F484, lbID},
+	{0x1F485, 0x1F487, lbEB},
+	{0x1F488, 0x1F48E, lbID},
+	{0x1F48F, 0x1F48F, lbEB},
+	{0x1F490, 0x1F490, lbID},
+	{0x1F491, 0x1F491, lbEB},
+	{0x1F492, 0x1F49F, lbID},
+	{0x1F4A1, 0x1F4A1, lbID},
+	{0x1F4A3, 0x1F4A3, lbID},
+	{0x1F4A5, 0x1F4A9, lbID},
+	{0x1F4AA, 0x1F4AA, lbEB},
+	{0x1F4AB, 0x1F4AE, lbID},
+	{0x1F4B0, 0x1F4B0, lbID},
+	{0x1F4B3, 0x1F4FF, lbID},
+	{0x1F507, 0x1F516, lbID},
+	{0x1F525, 0x1F531, lbID},
+	{0x1F54A, 0x1F573, lbID},
+	{0x1F574, 0x1F575, lbEB},
+	{0x1F576, 0x1F579, lbID},
+	{0x1F57A, 0x1F57A, lbEB},
+	{0x1F57B, 0x1F58F, lbID},
+	{0x1F590, 0x1F590, lbEB},
+	{0x1F591, 0x1F594, lbID},
+	{0x1F595, 0x1F596, lbEB},
+	{0x1F597, 0x1F5D3, lbID},
+	{0x1F5DC, 0x1F5F3, lbID},
+	{0x1F5FA, 0x1F644, lbID},
+	{0x1F645, 0x1F647, lbEB},
+	{0x1F648, 0x1F64A, lbID},
+	{0x1F64B, 0x1F64F, lbEB},
+	{0x1F676, 0x1F678, lbQU},
+	{0x1F679, 0x1F67B, lbNS},
+	{0x1F680, 0x1F6A2, lbID},
+	{0x1F6A3, 0x1F6A3, lbEB},
+	{0x1F6A4, 0x1F6B3, lbID},
+	{0x1F6B4, 0x1F6B6, lbEB},
+	{0x1F6B7, 0x1F6BF, lbID},
+	{0x1F6C0, 0x1F6C0, lbEB},
+	{0x1F6C1, 0x1F6CB, lbID},
+	{0x1F6CC, 0x1F6CC, lbEB},
+	{0x1F6CD, 0x1F6FF, lbID},
+	{0x1F774, 0x1F776, lbID},
+	{0x1F77B, 0x1F77F, lbID},
+	{0x1F7D5, 0x1F7FF, lbID},
+	{0x1F90C, 0x1F90C, lbEB},
+	{0x1F90D, 0x1F90E, lbID},
+	{0x1F90F, 0x1F90F, lbEB},
+	{0x1F910, 0x1F917, lbID},
+	{0x1F918, 0x1F91F, lbEB},
+	{0x1F920, 0x1F925, lbID},
+	{0x1F926, 0x1F926, lbEB},
+	{0x1F927, 0x1F92F, lbID},
+	{0x1F930, 0x1F939, lbEB},
+	{0x1F93A, 0x1F93B, lbID},
+	{0x1F93C, 0x1F93E, lbEB},
+	{0x1F93F, 0x1F976, lbID},
+	{0x1F977, 0x1F977, lbEB},
+	{0x1F978, 0x1F9B4, lbID},
+	{0x1F9B5, 0x1F9B6, lbEB},
+	{0x1F9B7, 0x1F9B7, lbID},
+	{0x1F9B8, 0x1F9B9, lbEB},
+	{0x1F9BA, 0x1F9BA, lbID},
+	{0x1F9BB, 0x1F9BB, lbEB},
+	{0x1F9BC, 0x1F9CC, lbID},
+	{0x1F9CD, 0x1F9CF, lbEB},
+	{0x1F9D0, 0x1F9D0, lbID},
+	{0x1F9D1, 0x1F9DD, lbEB},
+	{0x1F9DE, 0x1F9FF, lbID},
+	{0x1FA58, 0x1FAC2, lbID},
+	{0x1FAC3, 0x1FAC5, lbEB},
+	{0x1FAC6, 0x1FAEF, lbID},
+	{0x1FAF0, 0x1FAF8, lbEB},
+	{0x1FAF9, 0x1FAFF, lbID},
+	{0x1FBF0, 0x1FBF9, lbNU},
+	{0x1FC00, 0x1FFFD, lbID},
+	{0x20000, 0x2FFFD, lbID},
+	{0x30000, 0x3FFFD, lbID},
+	{0xE0001, 0xE0001, lbCM},
+	{0xE0020, 0xE007F, lbCM},
+	{0xE0100, 0xE01EF, lbCM},
+}
+
+// runeRange is a range of code points.
+type runeRange struct {
+	lo, hi rune
+}
+
+// eastAsianRanges are the code points that have an East Asian Width of
+// fullwidth (F), wide (W) or halfwidth (H) in Unicode 17.0.0, which the rules
+// LB19a and LB30 refer to as East Asian characters.
+//
+// https://www.unicode.org/Public/17.0.0/ucd/EastAsianWidth.txt
+var eastAsianRanges = []runeRange{
+	{0x1100, 0x115F},
+	{0x20A9, 0x20A9},
+	{0x231A, 0x231B},
+	{0x2329, 0x232A},
+	{0x23E9, 0x23EC},
+	{0x23F0, 0x23F0},
+	{0x23F3, 0x23F3},
+	{0x25FD, 0x25FE},
+	{0x2614, 0x2615},
+	{0x2630, 0x2637},
+	{0x2648, 0x2653},
+	{0x267F, 0x267F},
+	{0x268A, 0x268F},
+	{0x2693, 0x2693},
+	{0x26A1, 0x26A1},
+	{0x26AA, 0x26AB},
+	{0x26BD, 0x26BE},
+	{0x26C4, 0x26C5},
+	{0x26CE, 0x26CE},
+	{0x26D4, 0x26D4},
+	{0x26EA, 0x26EA},
+	{0x26F2, 0x26F3},
+	{0x26F5, 0x26F5},
+	{0x26FA, 0x26FA},
+	{0x26FD, 0x26FD},
+	{0x2705, 0x2705},
+	{0x270A, 0x270B},
+	{0x2728, 0x2728},
+	{0x274C, 0x274C},
+	{0x274E, 0x274E},
+	{0x2753, 0x2755},
+	{0x2757, 0x2757},
+	{0x2795, 0x2797},
+	{0x27B0, 0x27B0},
+	{0x27BF, 0x27BF},
+	{0x2B1B, 0x2B1C},
+	{0x2B50, 0x2B50},
+	{0x2B55, 0x2B55},
+	{0x2E80, 0x2E99},
+	{0x2E9B, 0x2EF3},
+	{0x2F00, 0x2FD5},
+	{0x2FF0, 0x303E},
+	{0x3041, 0x3096},
+	{0x3099, 0x30FF},
+	{0x3105, 0x312F},
+	{0x3131, 0x318E},
+	{0x3190, 0x31E5},
+	{0x31EF, 0x321E},
+	{0x3220, 0x3247},
+	{0x3250, 0xA48C},
+	{0xA490, 0xA4C6},
+	{0xA960, 0xA97C},
+	{0xAC00, 0xD7A3},
+	{0xF900, 0xFAFF},
+	{0xFE10, 0xFE19},
+	{0xFE30, 0xFE52},
+	{0xFE54, 0xFE66},
+	{0xFE68, 0xFE6B},
+	{0xFF01, 0xFFBE},
+	{0xFFC2, 0xFFC7},
+	{0xFFCA, 0xFFCF},
+	{0xFFD2, 0xFFD7},
+	{0xFFDA, 0xFFDC},
+	{0xFFE0, 0xFFE6},
+	{0xFFE8, 0xFFEE},
+	{0x16FE0, 0x16FE4},
+	{0x16FF0, 0x16FF6},
+	{0x17000, 0x18CD5},
+	{0x18CFF, 0x18D1E},
+	{0x18D80, 0x18DF2},
+	{0x1AFF0, 0x1AFF3},
+	{0x1AFF5, 0x1AFFB},
+	{0x1AFFD, 0x1AFFE},
+	{0x1B000, 0x1B122},
+	{0x1B132, 0x1B132},
+	{0x1B150, 0x1B152},
+	{0x1B155, 0x1B155},
+	{0x1B164, 0x1B167},
+	{0x1B170, 0x1B2FB},
+	{0x1D300, 0x1D356},
+	{0x1D360, 0x1D376},
+	{0x1F004, 0x1F004},
+	{0x1F0CF, 0x1F0CF},
+	{0x1F18E, 0x1F18E},
+	{0x1F191, 0x1F19A},
+	{0x1F200, 0x1F202},
+	{0x1F210, 0x1F23B},
+	{0x1F240, 0x1F248},
+	{0x1F250, 0x1F251},
+	{0x1F260, 0x1F265},
+	{0x1F300, 0x1F320},
+	{0x1F32D, 0x1F335},
+	{0x1F337, 0x1F37C},
+	{0x1F37E, 0x1F393},
+	{0x1F3A0, 0x1F3CA},
+	{0x1F3CF, 0x1F3D3},
+	{0x1F3E0, 0x1F3F0},
+	{0x1F3F4, 0x1F3F4},
+	{0x1F3F8, 0x1F43E},
+	{0x1F440, 0x1F440},
+	{0x1F442, 0x1F4FC},
+	{0x1F4FF, 0x1F53D},
+	{0x1F54B, 0x1F54E},
+	{0x1F550, 0x1F567},
+	{0x1F57A, 0x1F57A},
+	{0x1F595, 0x1F596},
+	{0x1F5A4, 0x1F5A4},
+	{0x1F5FB, 0x1F64F},
+	{0x1F680, 0x1F6C5},
+	{0x1F6CC, 0x1F6CC},
+	{0x1F6D0, 0x1F6D2},
+	{0x1F6D5, 0x1F6D8},
+	{0x1F6DC, 0x1F6DF},
+	{0x1F6EB, 0x1F6EC},
+	{0x1F6F4, 0x1F6FC},
+	{0x1F7E0, 0x1F7EB},
+	{0x1F7F0, 0x1F7F0},
+	{0x1F90C, 0x1F93A},
+	{0x1F93C, 0x1F945},
+	{0x1F947, 0x1F9FF},
+	{0x1FA70, 0x1FA7C},
+	{0x1FA80, 0x1FA8A},
+	{0x1FA8E, 0x1FAC6},
+	{0x1FAC8, 0x1FAC8},
+	{0x1FACD, 0x1FADC},
+	{0x1FADF, 0x1FAEA},
+	{0x1FAEF, 0x1FAF8},
+	{0x20000, 0x2FFFD},
+	{0x30000, 0x3FFFD},
+}
+
+// initialQuotes and finalQuotes are the quotation marks (QU) that have the
+// general category of initial (Pi) and final (Pf) punctuation in Unicode
+// 17.0.0, which the rules LB15a, LB15b and LB19 tell apart.
+//
+// https://www.unicode.org/Public/17.0.0/ucd/extracted/DerivedGeneralCategory.txt
+var initialQuotes = []runeRange{
+	{0x00AB, 0x00AB},
+	{0x2018, 0x2018},
+	{0x201B, 0x201C},
+	{0x201F, 0x201F},
+	{0x2039, 0x2039},
+	{0x2E02, 0x2E02},
+	{0x2E04, 0x2E04},
+	{0x2E09, 0x2E09},
+	{0x2E0C, 0x2E0C},
+	{0x2E1C, 0x2E1C},
+	{0x2E20, 0x2E20},
+}
+
+var finalQuotes = []runeRange{
+	{0x00BB, 0x00BB},
+	{0x2019, 0x2019},
+	{0x201D, 0x201D},
+	{0x203A, 0x203A},
+	{0x2E03, 0x2E03},
+	{0x2E05, 0x2E05},
+	{0x2E0A, 0x2E0A},
+	{0x2E0D, 0x2E0D},
+	{0x2E1D, 0x2E1D},
+	{0x2E21, 0x2E21},
+}
+
+// unassignedPictographs are the unassigned code points of Unicode 17.0.0 that
+// have the Extended_Pictographic property, which are reserved for emoji.
+//
+// https://www.unicode.org/Public/17.0.0/ucd/emoji/emoji-data.txt
+var unassignedPictographs = []runeRange{
+	{0x1F02C, 0x1F02F},
+	{0x1F094, 0x1F09F},
+	{0x1F0AF, 0x1F0B0},
+	{0x1F0C0, 0x1F0C0},
+	{0x1F0D0, 0x1F0D0},
+	{0x1F0F6, 0x1F0FF},
+	{0x1F1AE, 0x1F1E5},
+	{0x1F203, 0x1F20F},
+	{0x1F23C, 0x1F23F},
+	{0x1F249, 0x1F24F},
+	{0x1F252, 0x1F25F},
+	{0x1F266, 0x1F2FF},
+	{0x1F6D9, 0x1F6DB},
+	{0x1F6ED, 0x1F6EF},
+	{0x1F6FD, 0x1F6FF},
+	{0x1F7DA, 0x1F7DF},
+	{0x1F7EC, 0x1F7EF},
+	{0x1F7F1, 0x1F7FF},
+	{0x1F80C, 0x1F80F},
+	{0x1F848, 0x1F84F},
+	{0x1F85A, 0x1F85F},
+	{0x1F888, 0x1F88F},
+	{0x1F8AE, 0x1F8AF},
+	{0x1F8BC, 0x1F8BF},
+	{0x1F8C2, 0x1F8CF},
+	{0x1F8D9, 0x1F8FF},
+	{0x1FA58, 0x1FA5F},
+	{0x1FA6E, 0x1FA6F},
+	{0x1FA7D, 0x1FA7F},
+	{0x1FA8B, 0x1FA8D},
+	{0x1FAC7, 0x1FAC7},
+	{0x1FAC9, 0x1FACC},
+	{0x1FADD, 0x1FADE},
+	{0x1FAEB, 0x1FAEE},
+	{0x1FAF9, 0x1FAFF},
+	{0x1FC00, 0x1FFFD},
+}
+
+// findRange returns the class of the range of the table that contains r, or
+// false if there is none.
+func findRange(table []lineBreakRange, r rune) (lineBreakClass, bool) {
+	i := sort.Search(len(table), func(i int) bool { return table[i].hi >= r })
+
+	if i == len(table) || table[i].lo > r {
+		return 0, false
+	}
+
+	return table[i].class, true
+}
+
+// inRanges returns true if one of the ranges of the table contains r.
+func inRanges(table []runeRange, r rune) bool {
+	i := sort.Search(len(table), func(i int) bool { return table[i].hi >= r })
+	return i != len(table) && table[i].lo <= r
+}
@@ -0,0 +1,204 @@
+package CT
+
+import (
+	"math"
+
+	"github.com/go-vu/cocoa/CG"
+	"github.com/go-vu/cocoa/CT/grapheme"
+)
+
+// The number of spaces between tab stops when the measure options don't set
+// the width of tabs.
+const defaultTabSpaces = 8
+
+// MeasureOptions are the options of MeasureString, the zero value measures
+// kerned text with tab stops every eight spaces and no letter spacing.
+type MeasureOptions struct {
+	// TabWidth is the distance between tab stops in points, zero selects
+	// eight times the advance of a space. Tab stops are measured from the
+	// start of the lines.
+	TabWidth CG.Float
+
+	// LetterSpacing is added between the runes of each line, like the
+	// tracking of Core Text. It isn't added around tabs, which move the next
+	// rune to a tab stop, nor after the last rune of the lines.
+	//
+	// https://developer.apple.com/documentation/coretext/kctkernattributename
+	LetterSpacing CG.Float
+
+	// DisableKerning turns off the kerning of the pairs of runes.
+	DisableKerning bool
+}
+
+// Measurement is the result of measuring a string with MeasureString.
+//
+// Positions and bounds are in the coordinate space of the image that the
+// string would be drawn into, relative to the origin of the first line, which
+// is on its baseline. Lines are stacked downwards, each line being moved from
+// the previous one by the sum of the ascent, descent and leading of the font.
+type Measurement struct {
+	// Advance is the advance of the longest line.
+	Advance CG.Float
+
+	// Bounds is the union of the bounds of the glyphs that have ink, it's
+	// CG.RectNull if none of them do.
+	Bounds CG.Rect
+
+	// Lines is the number of lines of the string, a string ending with a
+	// newline has an empty last line, and the empty string has no lines.
+	Lines int
+
+	// Positions are the origins of the runes of the string, one per rune.
+	// The runes of a grapheme cluster share its position, and the position of
+	// a newline is the end of the line that it terminates.
+	Positions []CG.Point
+}
+
+// MeasureString lays out the string with the metrics of the font f and
+// returns its measurement, which doesn't require drawing it.
+//
+// The string is measured by grapheme clusters, see ClusterBounds, so that
+// combining marks and emoji sequences are measured as a unit. Lines are broken
+// at "\n", "\r\n" and "\r", and kerning is applied between the clusters of
+// the same line that are not separated by a tab, each pair being kerned with
+// f.Kern(previous, next) where previous and next are the first runes of the
+// clusters. The lines are measured with a LineMeasurer.
+func MeasureString(f FontSource, s string, opts *MeasureOptions) Measurement {
+	runes := []rune(s)
+	lineHeight := f.GetAscent() + f.GetDescent() + f.GetLeading()
+	m := Measurement{
+		Bounds:    CG.RectNull,
+		Positions: make([]CG.Point, len(runes)),
+	}
+
+	if len(runes) != 0 {
+		m.Lines = 1
+	}
+
+	line := NewLineMeasurer(f, opts)
+	y := CG.Float(0)
+
+	for i := 0; i < len(runes); {
+		switch r := runes[i]; r {
+		case '\r', '\n':
+			m.Positions[i] = CG.Point{X: line.Advance(), Y: y}
+			m.Advance = CG.Float(math.Max(float64(m.Advance), float64(line.Advance())))
+			i++
+
+			// The carriage return of "\r\n" is at the end of the line, it's
+			// the newline that moves the pen.
+			if r == '\r' && i < len(runes) && runes[i] == '\n' {
+				continue
+			}
+
+			m.Lines++
+			line.reset()
+			y += lineHeight
+
+		default:
+			n := grapheme.ClusterLen(runes[i:])
+			x, bounds := line.Add(runes[i : i+n])
+
+			if !bounds.IsNull() {
+				m.Bounds = m.Bounds.Union(CG.RectMake(
+					x+bounds.Origin.X,
+					y-bounds.Origin.Y-bounds.Size.Height,
+					bounds.Size.Width,
+					bounds.Size.Height,
+				))
+			}
+
+			for end := i + n; i < end; i++ {
+				m.Positions[i] = CG.Point{X: x, Y: y}
+			}
+		}
+	}
+
+	m.Advance = CG.Float(math.Max(float64(m.Advance), float64(line.Advance())))
+	return m
+}
+
+// LineMeasurer measures a line of text one grapheme cluster at a time, with
+// the rules of MeasureString, for callers that need the advance of each prefix
+// of a line, like line wrapping, without measuring the line again for each.
+//
+// A LineMeasurer can be copied to measure different continuations of the
+// same prefix.
+type LineMeasurer struct {
+	font FontSource
+	opts MeasureOptions
+
+	// prev is the rune that the next cluster is kerned and spaced with, it's
+	// negative at the start of the line and after tabs.
+	prev    rune
+	advance CG.Float
+}
+
+// NewLineMeasurer returns a LineMeasurer of an empty line, measuring with the
+// font f and the options, nil selecting the defaults of MeasureString.
+func NewLineMeasurer(f FontSource, opts *MeasureOptions) LineMeasurer {
+	m := LineMeasurer{font: f, prev: -1}
+
+	if opts != nil {
+		m.opts = *opts
+	}
+
+	if m.opts.TabWidth <= 0 {
+		m.opts.TabWidth = defaultTabSpaces * f.GlyphAdvance(' ')
+	}
+
+	return m
+}
+
+// Add measures the grapheme cluster that follows the clusters already added
+// to the line, and returns its horizontal position from the start of the line
+// and its bounds. The bounds are in the Quartz space, relative to the origin
+// of the cluster, and are CG.RectNull if the cluster has no ink.
+//
+// A tab moves the line to the next tab stop. The cluster must not be a line
+// terminator, lines are measured separately.
+func (m *LineMeasurer) Add(cluster []rune) (x CG.Float, bounds CG.Rect) {
+	if len(cluster) == 0 {
+		return m.advance, CG.RectNull
+	}
+
+	if cluster[0] == '\t' {
+		x = m.advance
+		m.advance = nextTabStop(m.advance, m.opts.TabWidth)
+		m.prev = -1
+		return x, CG.RectNull
+	}
+
+	if m.prev >= 0 {
+		m.advance += m.opts.LetterSpacing
+
+		if !m.opts.DisableKerning {
+			m.advance += m.font.Kern(m.prev, cluster[0])
+		}
+	}
+
+	x = m.advance
+	advance, bounds := clusterBounds(m.font, cluster)
+	m.advance += advance
+	m.prev = cluster[0]
+	return x, bounds
+}
+
+// Advance returns the advance of the clusters added to the line.
+func (m *LineMeasurer) Advance() CG.Float {
+	return m.advance
+}
+
+// reset makes the line empty again.
+func (m *LineMeasurer) reset() {
+	m.prev, m.advance = -1, 0
+}
+
+// nextTabStop returns the position of the first tab stop after x, tab stops
+// being width apart, or x if the width is zero.
+func nextTabStop(x CG.Float, width CG.Float) CG.Float {
+	if width <= 0 {
+		return x
+	}
+	return CG.Float(math.Floor(float64(x/width))+1) * width
+}
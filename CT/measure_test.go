@@ -0,0 +1,248 @@
+package CT
+
+import (
+	"image"
+	"testing"
+
+	"github.com/go-vu/cocoa/CG"
+)
+
+func TestMeasureString(t *testing.T) {
+	tests := []struct {
+		s         string
+		opts      *MeasureOptions
+		advance   CG.Float
+		bounds    CG.Rect
+		lines     int
+		positions []CG.Point
+	}{
+		{
+			s:      "",
+			bounds: CG.RectNull,
+		},
+		{
+			s:         "AV",
+			advance:   18,
+			bounds:    CG.RectMake(1, -8, 16, 8),
+			lines:     1,
+			positions: []CG.Point{{X: 0, Y: 0}, {X: 8, Y: 0}},
+		},
+		{
+			// The pairs are kerned in order, the fake font only kerns "AV".
+			s:         "VA",
+			advance:   20,
+			bounds:    CG.RectMake(1, -8, 18, 8),
+			lines:     1,
+			positions: []CG.Point{{X: 0, Y: 0}, {X: 10, Y: 0}},
+		},
+		{
+			s:         "AV",
+			opts:      &MeasureOptions{DisableKerning: true},
+			advance:   20,
+			bounds:    CG.RectMake(1, -8, 18, 8),
+			lines:     1,
+			positions: []CG.Point{{X: 0, Y: 0}, {X: 10, Y: 0}},
+		},
+		{
+			s:         "AVi",
+			opts:      &MeasureOptions{LetterSpacing: 1},
+			advance:   24,
+			bounds:    CG.RectMake(1, -8, 22, 8),
+			lines:     1,
+			positions: []CG.Point{{X: 0, Y: 0}, {X: 9, Y: 0}, {X: 20, Y: 0}},
+		},
+		{
+			s:         "  ",
+			advance:   10,
+			bounds:    CG.RectNull,
+			lines:     1,
+			positions: []CG.Point{{X: 0, Y: 0}, {X: 5, Y: 0}},
+		},
+		{
+			s:         "A\nig",
+			advance:   14,
+			bounds:    CG.RectMake(1, -8, 12, 23),
+			lines:     2,
+			positions: []CG.Point{{X: 0, Y: 0}, {X: 10, Y: 0}, {X: 0, Y: 12}, {X: 4, Y: 12}},
+		},
+		{
+			s:         "a\r\nb\rc\n",
+			advance:   10,
+			bounds:    CG.RectMake(1, -8, 8, 32),
+			lines:     4,
+			positions: []CG.Point{{X: 0, Y: 0}, {X: 10, Y: 0}, {X: 10, Y: 0}, {X: 0, Y: 12}, {X: 10, Y: 12}, {X: 0, Y: 24}, {X: 10, Y: 24}},
+		},
+		{
+			// Letter spacing isn't added around tabs, which move the pen to
+			// the next tab stop, every 8 spaces by default.
+			s:         "A\t i",
+			opts:      &MeasureOptions{LetterSpacing: 1},
+			advance:   50,
+			bounds:    CG.RectMake(1, -8, 48, 8),
+			lines:     1,
+			positions: []CG.Point{{X: 0, Y: 0}, {X: 10, Y: 0}, {X: 40, Y: 0}, {X: 46, Y: 0}},
+		},
+		{
+			// A rune at a tab stop is moved to the next one.
+			s:         "i\t\tA\t",
+			opts:      &MeasureOptions{TabWidth: 4},
+			advance:   24,
+			bounds:    CG.RectMake(1, -8, 20, 8),
+			lines:     1,
+			positions: []CG.Point{{X: 0, Y: 0}, {X: 4, Y: 0}, {X: 8, Y: 0}, {X: 12, Y: 0}, {X: 22, Y: 0}},
+		},
+		{
+			// Combining marks are measured with the cluster of their base.
+			s:         "e\u0301i",
+			advance:   14,
+			bounds:    CG.RectMake(1, -11, 12, 11),
+			lines:     1,
+			positions: []CG.Point{{X: 0, Y: 0}, {X: 0, Y: 0}, {X: 10, Y: 0}},
+		},
+		{
+			// Clusters are kerned with their first runes.
+			s:         "A\u0301V",
+			advance:   18,
+			bounds:    CG.RectMake(1, -11, 16, 11),
+			lines:     1,
+			positions: []CG.Point{{X: 0, Y: 0}, {X: 0, Y: 0}, {X: 8, Y: 0}},
+		},
+	}
+
+	for _, test := range tests {
+		m := MeasureString(fakeFontSource{}, test.s, test.opts)
+
+		if m.Advance != test.advance {
+			t.Errorf("%q: invalid advance: %v != %v", test.s, m.Advance, test.advance)
+		}
+
+		if m.Bounds != test.bounds {
+			t.Errorf("%q: invalid bounds: %v != %v", test.s, m.Bounds, test.bounds)
+		}
+
+		if m.Lines != test.lines {
+			t.Errorf("%q: invalid number of lines: %d != %d", test.s, m.Lines, test.lines)
+		}
+
+		if len(m.Positions) != len(test.positions) {
+			t.Errorf("%q: invalid positions: %v != %v", test.s, m.Positions, test.positions)
+			continue
+		}
+
+		for i, p := range m.Positions {
+			if p != test.positions[i] {
+				t.Errorf("%q: invalid position of rune %d: %v != %v", test.s, i, p, test.positions[i])
+			}
+		}
+	}
+}
+
+func TestMeasureStringAdvance(t *testing.T) {
+	// The measurement of a single line matches laying out the runes one by
+	// one with the advances and kerning of the font.
+	f := fakeFontSource{}
+	s := "AVAiVgA"
+	advance := CG.Float(0)
+	runes := []rune(s)
+
+	for i, r := range runes {
+		advance += f.GlyphAdvance(r)
+
+		if i != 0 {
+			advance += f.Kern(runes[i-1], r)
+		}
+	}
+
+	if m := MeasureString(f, s, nil); m.Advance != advance {
+		t.Errorf("invalid advance: %v != %v", m.Advance, advance)
+	}
+}
+
+func TestLineMeasurer(t *testing.T) {
+	f := fakeFontSource{}
+	opts := &MeasureOptions{LetterSpacing: 1}
+	m := NewLineMeasurer(f, opts)
+	s := "AVe\u0301\tig"
+	runes := []rune(s)
+
+	// The clusters are measured like MeasureString measures the line.
+	expected := MeasureString(f, s, opts)
+	clusters := []int{0, 1, 2, 4, 5, 6}
+
+	for i, start := range clusters {
+		end := len(runes)
+
+		if i+1 < len(clusters) {
+			end = clusters[i+1]
+		}
+
+		if x, _ := m.Add(runes[start:end]); x != expected.Positions[start].X {
+			t.Errorf("invalid position of cluster %q: %v != %v", string(runes[start:end]), x, expected.Positions[start].X)
+		}
+	}
+
+	if m.Advance() != expected.Advance {
+		t.Errorf("invalid advance: %v != %v", m.Advance(), expected.Advance)
+	}
+
+	// Copies measure different continuations of the same prefix.
+	prefix := NewLineMeasurer(f, nil)
+	prefix.Add([]rune("A"))
+	v, i := prefix, prefix
+	v.Add([]rune("V"))
+	i.Add([]rune("i"))
+
+	if prefix.Advance() != 10 || v.Advance() != MeasureString(f, "AV", nil).Advance || i.Advance() != MeasureString(f, "Ai", nil).Advance {
+		t.Errorf("invalid advances of the copies: %v, %v, %v", prefix.Advance(), v.Advance(), i.Advance())
+	}
+}
+
+// fakeFontSource is a font of 10 points wide glyphs, with a narrow "i", a
+// space that has no ink and a combining acute accent that has no advance, and
+// which kerns the "AV" pair. Glyphs have 1 point side bearings and sit on the
+// baseline, except "g" which has a descender and the accent which is drawn
+// over the glyph before it.
+type fakeFontSource struct{}
+
+func (fakeFontSource) GetAscent() CG.Float  { return 8 }
+func (fakeFontSource) GetDescent() CG.Float { return 3 }
+func (fakeFontSource) GetLeading() CG.Float { return 1 }
+
+func (fakeFontSource) GlyphDraw(char rune, origin CG.Point, alpha *image.Alpha) bool {
+	return false
+}
+
+func (fakeFontSource) GlyphAdvance(char rune) CG.Float {
+	switch char {
+	case 'i':
+		return 4
+	case ' ':
+		return 5
+	case '\u0301':
+		return 0
+	default:
+		return 10
+	}
+}
+
+func (f fakeFontSource) GlyphBounds(char rune) (CG.Float, CG.Rect) {
+	advance := f.GlyphAdvance(char)
+
+	switch char {
+	case ' ':
+		return 0, CG.RectZero
+	case 'g':
+		return advance, CG.RectMake(1, -3, advance-2, 11)
+	case '\u0301':
+		return advance, CG.RectMake(-7, 9, 5, 2)
+	default:
+		return advance, CG.RectMake(1, 0, advance-2, 8)
+	}
+}
+
+func (fakeFontSource) Kern(char0 rune, char1 rune) CG.Float {
+	if char0 == 'A' && char1 == 'V' {
+		return -2
+	}
+	return 0
+}
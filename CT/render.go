@@ -0,0 +1,217 @@
+package CT
+
+import (
+	"errors"
+	"fmt"
+	"math"
+
+	"github.com/go-vu/cocoa/CG"
+)
+
+// ErrInvalidRenderOptions is returned when glyphs are drawn with render options
+// that are out of range or that contradict each other.
+var ErrInvalidRenderOptions = errors.New("CT: invalid render options")
+
+// Hinting is an enumeration of the ways glyphs are aligned to the pixel grid.
+//
+// Neither Core Text nor the fontfile package run the hinting instructions of
+// fonts, hinting is a preference that is applied by aligning the origins of
+// the glyphs to whole pixels.
+type Hinting int
+
+// These constants are all the possible values of the Hinting enumeration.
+const (
+	// The origins of the glyphs are left where they are.
+	HintingNone Hinting = iota
+
+	// The baselines of the glyphs are aligned to whole pixels, which keeps
+	// the horizontal edges of the glyphs sharp.
+	HintingVertical
+
+	// Both coordinates of the origins of the glyphs are aligned to whole
+	// pixels.
+	HintingFull
+)
+
+// String satisfies the fmt.Stringer interface.
+func (h Hinting) String() string {
+	switch h {
+	case HintingNone:
+		return "HintingNone"
+	case HintingVertical:
+		return "HintingVertical"
+	case HintingFull:
+		return "HintingFull"
+	default:
+		return fmt.Sprintf("Hinting(%d)", int(h))
+	}
+}
+
+// RenderOptions control how glyphs are rasterized, a nil pointer selects the
+// options returned by DefaultRenderOptions, except that Core Text then leaves
+// antialiasing, font smoothing and subpixel quantization to the defaults of
+// Core Graphics contexts.
+//
+// The zero value turns everything off, which draws crisp glyphs made of fully
+// opaque or transparent pixels, at integer positions.
+//
+// https://developer.apple.com/documentation/coregraphics/cgcontext/1456461-setshouldantialias
+type RenderOptions struct {
+	// Antialias enables the antialiasing of the edges of the glyphs, when it's
+	// false the pixels of the glyphs are either fully opaque or transparent.
+	Antialias bool
+
+	// Smoothing enables the font smoothing of Core Text, which slightly
+	// dilates the coverage of the glyphs. It requires antialiasing, and is
+	// ignored by the fontfile package.
+	//
+	// Glyphs are always rasterized into alpha coverage masks, including when
+	// they are drawn into color images, so smoothing never produces LCD-style
+	// glyphs with a coverage per color channel, which isn't supported.
+	//
+	// https://developer.apple.com/documentation/coregraphics/cgcontext/1456292-setshouldsmoothfonts
+	Smoothing bool
+
+	// SubpixelPositioning lets glyphs be drawn at fractional horizontal
+	// positions, otherwise the origins of the glyphs are rounded to whole
+	// pixels.
+	//
+	// https://developer.apple.com/documentation/coregraphics/cgcontext/1455580-setshouldsubpixelpositionfonts
+	SubpixelPositioning bool
+
+	// SubpixelQuantization rounds the fractional horizontal positions of the
+	// glyphs to a quarter of a pixel, it requires subpixel positioning.
+	//
+	// https://developer.apple.com/documentation/coregraphics/cgcontext/1456089-setshouldsubpixelquantizefonts
+	SubpixelQuantization bool
+
+	// Hinting selects how the origins of the glyphs are aligned to the pixel
+	// grid, full hinting is incompatible with subpixel positioning.
+	Hinting Hinting
+}
+
+// The number of horizontal positions within a pixel that glyphs are drawn at
+// when subpixel quantization is enabled.
+const subpixelQuantizationSteps = 4
+
+// DefaultRenderOptions returns the render options used when none are given,
+// which draw antialiased glyphs at fractional horizontal positions without
+// smoothing, quantization or hinting.
+//
+// Passing these options explicitly turns font smoothing and subpixel
+// quantization off in Core Text, which otherwise follows the defaults of Core
+// Graphics contexts when no options are given.
+func DefaultRenderOptions() RenderOptions {
+	return RenderOptions{
+		Antialias:           true,
+		SubpixelPositioning: true,
+	}
+}
+
+// contextSetting is the value of a setting of the Core Graphics contexts that
+// Core Text draws glyphs into.
+type contextSetting int
+
+// These constants are all the possible values of the contextSetting
+// enumeration.
+const (
+	contextDefault contextSetting = -1 // the setting is left to the context
+	contextOff     contextSetting = 0
+	contextOn      contextSetting = 1
+)
+
+// contextSettings are the settings of the Core Graphics contexts that Core
+// Text draws glyphs into.
+type contextSettings struct {
+	antialias            contextSetting
+	smoothing            contextSetting
+	subpixelPositioning  contextSetting
+	subpixelQuantization contextSetting
+}
+
+// contextSettings returns the settings of the contexts that glyphs are drawn
+// into with the options. A nil pointer only enables subpixel positioning and
+// leaves the other settings to the defaults of the context, which is how
+// glyphs were drawn before render options existed.
+func (o *RenderOptions) contextSettings() contextSettings {
+	if o == nil {
+		return contextSettings{
+			antialias:            contextDefault,
+			smoothing:            contextDefault,
+			subpixelPositioning:  contextOn,
+			subpixelQuantization: contextDefault,
+		}
+	}
+
+	return contextSettings{
+		antialias:            contextSettingOf(o.Antialias),
+		smoothing:            contextSettingOf(o.Smoothing),
+		subpixelPositioning:  contextSettingOf(o.SubpixelPositioning),
+		subpixelQuantization: contextSettingOf(o.SubpixelQuantization),
+	}
+}
+
+func contextSettingOf(on bool) contextSetting {
+	if on {
+		return contextOn
+	}
+	return contextOff
+}
+
+// renderOptions returns the options pointed to by opts, or the defaults if
+// opts is nil, and an error if they are invalid.
+func renderOptions(opts *RenderOptions) (RenderOptions, error) {
+	if opts == nil {
+		return DefaultRenderOptions(), nil
+	}
+	return *opts, opts.Validate()
+}
+
+// Validate returns an error wrapping ErrInvalidRenderOptions if the options are
+// invalid, a nil pointer is valid since it selects the default options.
+func (o *RenderOptions) Validate() error {
+	switch {
+	case o == nil:
+		return nil
+	case o.Hinting < HintingNone || o.Hinting > HintingFull:
+		return fmt.Errorf("%w: invalid hinting: %v", ErrInvalidRenderOptions, o.Hinting)
+	case o.Smoothing && !o.Antialias:
+		return fmt.Errorf("%w: smoothing requires antialiasing", ErrInvalidRenderOptions)
+	case o.SubpixelQuantization && !o.SubpixelPositioning:
+		return fmt.Errorf("%w: subpixel quantization requires subpixel positioning", ErrInvalidRenderOptions)
+	case o.Hinting == HintingFull && o.SubpixelPositioning:
+		return fmt.Errorf("%w: full hinting is incompatible with subpixel positioning", ErrInvalidRenderOptions)
+	}
+	return nil
+}
+
+// AlignPositions returns a copy of the glyph positions aligned to the pixel
+// grid as selected by the options, a nil pointer selects the default options.
+//
+// The positions are in the coordinate space of an image, whole pixels being at
+// integer coordinates.
+func (o *RenderOptions) AlignPositions(positions []CG.Point) []CG.Point {
+	r, _ := renderOptions(o)
+	aligned := make([]CG.Point, len(positions))
+
+	for i, p := range positions {
+		switch {
+		case r.Hinting == HintingFull || !r.SubpixelPositioning:
+			p.X = round(p.X)
+		case r.SubpixelQuantization:
+			p.X = round(p.X*subpixelQuantizationSteps) / subpixelQuantizationSteps
+		}
+
+		if r.Hinting != HintingNone {
+			p.Y = round(p.Y)
+		}
+
+		aligned[i] = p
+	}
+
+	return aligned
+}
+
+func round(f CG.Float) CG.Float {
+	return CG.Float(math.Floor(float64(f) + 0.5))
+}
@@ -0,0 +1,150 @@
+package CT
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/go-vu/cocoa/CG"
+)
+
+func TestDefaultRenderOptions(t *testing.T) {
+	o := DefaultRenderOptions()
+
+	if !o.Antialias || o.Smoothing || !o.SubpixelPositioning || o.SubpixelQuantization || o.Hinting != HintingNone {
+		t.Errorf("invalid default render options: %+v", o)
+	}
+
+	if err := o.Validate(); err != nil {
+		t.Error("invalid default render options:", err)
+	}
+
+	if o, err := renderOptions(nil); err != nil || o != DefaultRenderOptions() {
+		t.Errorf("nil render options don't select the defaults: %+v, %v", o, err)
+	}
+}
+
+func TestRenderOptionsContextSettings(t *testing.T) {
+	tests := []struct {
+		opts     *RenderOptions
+		settings contextSettings
+	}{
+		{
+			// Glyphs drawn without options keep the context defaults, as
+			// they always were, except for subpixel positioning.
+			opts:     nil,
+			settings: contextSettings{contextDefault, contextDefault, contextOn, contextDefault},
+		},
+		{
+			opts:     &RenderOptions{},
+			settings: contextSettings{contextOff, contextOff, contextOff, contextOff},
+		},
+		{
+			opts:     &RenderOptions{Antialias: true, Smoothing: true, SubpixelPositioning: true, SubpixelQuantization: true},
+			settings: contextSettings{contextOn, contextOn, contextOn, contextOn},
+		},
+	}
+
+	for _, test := range tests {
+		if settings := test.opts.contextSettings(); settings != test.settings {
+			t.Errorf("%+v: invalid context settings: %+v != %+v", test.opts, settings, test.settings)
+		}
+	}
+}
+
+func TestRenderOptionsValidate(t *testing.T) {
+	tests := []struct {
+		opts  *RenderOptions
+		valid bool
+	}{
+		{nil, true},
+		{&RenderOptions{}, true},
+		{&RenderOptions{Antialias: true, Smoothing: true}, true},
+		{&RenderOptions{SubpixelPositioning: true, SubpixelQuantization: true}, true},
+		{&RenderOptions{SubpixelPositioning: true, Hinting: HintingVertical}, true},
+		{&RenderOptions{Hinting: HintingFull}, true},
+		{&RenderOptions{Smoothing: true}, false},
+		{&RenderOptions{SubpixelQuantization: true}, false},
+		{&RenderOptions{SubpixelPositioning: true, Hinting: HintingFull}, false},
+		{&RenderOptions{Hinting: -1}, false},
+		{&RenderOptions{Hinting: 3}, false},
+	}
+
+	for _, test := range tests {
+		err := test.opts.Validate()
+
+		if test.valid && err != nil {
+			t.Errorf("%+v: %s", test.opts, err)
+		}
+
+		if !test.valid && !errors.Is(err, ErrInvalidRenderOptions) {
+			t.Errorf("%+v: invalid error returned for invalid render options: %v", test.opts, err)
+		}
+	}
+}
+
+func TestRenderOptionsAlignPositions(t *testing.T) {
+	positions := []CG.Point{{X: 1.3, Y: 10.6}, {X: 2.5, Y: 10.4}, {X: 3.9, Y: -0.5}}
+
+	tests := []struct {
+		name     string
+		opts     *RenderOptions
+		expected []CG.Point
+	}{
+		{
+			name:     "default",
+			opts:     nil,
+			expected: positions,
+		},
+		{
+			name:     "integer",
+			opts:     &RenderOptions{},
+			expected: []CG.Point{{X: 1, Y: 10.6}, {X: 3, Y: 10.4}, {X: 4, Y: -0.5}},
+		},
+		{
+			name:     "quantized",
+			opts:     &RenderOptions{SubpixelPositioning: true, SubpixelQuantization: true},
+			expected: []CG.Point{{X: 1.25, Y: 10.6}, {X: 2.5, Y: 10.4}, {X: 4, Y: -0.5}},
+		},
+		{
+			name:     "vertical hinting",
+			opts:     &RenderOptions{SubpixelPositioning: true, Hinting: HintingVertical},
+			expected: []CG.Point{{X: 1.3, Y: 11}, {X: 2.5, Y: 10}, {X: 3.9, Y: 0}},
+		},
+		{
+			name:     "full hinting",
+			opts:     &RenderOptions{Hinting: HintingFull},
+			expected: []CG.Point{{X: 1, Y: 11}, {X: 3, Y: 10}, {X: 4, Y: 0}},
+		},
+	}
+
+	for _, test := range tests {
+		aligned := test.opts.AlignPositions(positions)
+
+		if !reflect.DeepEqual(aligned, test.expected) {
+			t.Errorf("%s: invalid positions: %v != %v", test.name, aligned, test.expected)
+		}
+	}
+
+	if positions[0] != (CG.Point{X: 1.3, Y: 10.6}) {
+		t.Error("the positions were modified")
+	}
+}
+
+func TestHintingString(t *testing.T) {
+	tests := []struct {
+		hinting Hinting
+		s       string
+	}{
+		{HintingNone, "HintingNone"},
+		{HintingVertical, "HintingVertical"},
+		{HintingFull, "HintingFull"},
+		{42, "Hinting(42)"},
+	}
+
+	for _, test := range tests {
+		if s := test.hinting.String(); s != test.s {
+			t.Errorf("invalid string: %q != %q", s, test.s)
+		}
+	}
+}
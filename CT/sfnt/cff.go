@@ -0,0 +1,729 @@
+package sfnt
+
+import (
+	"errors"
+	"fmt"
+	"math"
+)
+
+// CFFTable is a parsed 'CFF ' table, which holds the cubic outlines of
+// OpenType fonts as Type 2 charstrings.
+//
+// Both name-keyed and CID-keyed fonts are supported. Hints are ignored, and
+// the arithmetic and storage operators, which are rarely used, are not
+// supported.
+//
+// https://learn.microsoft.com/en-us/typography/opentype/spec/cff
+//
+// https://adobe-type-tools.github.io/font-tech-notes/pdfs/5176.CFF.pdf
+//
+// https://adobe-type-tools.github.io/font-tech-notes/pdfs/5177.Type2.pdf
+type CFFTable struct {
+	data        data
+	charStrings cffIndex
+	globalSubrs cffIndex
+
+	// The local subroutines of name-keyed fonts.
+	localSubrs cffIndex
+
+	// The local subroutines of each font dict of CID-keyed fonts, and the
+	// selector of the font dict of each glyph.
+	fdSubrs  []cffIndex
+	fdSelect func(GlyphID) int
+}
+
+// cffIndex is an INDEX structure, which is an array of variable length
+// objects.
+type cffIndex struct {
+	count   int
+	offSize int
+	offsets data
+	objects data
+}
+
+// Operators of the DICT structures.
+const (
+	cffCharStrings    = 17
+	cffPrivate        = 18
+	cffSubrs          = 19
+	cffCharStringType = 12<<8 | 6
+	cffROS            = 12<<8 | 30
+	cffFDArray        = 12<<8 | 36
+	cffFDSelect       = 12<<8 | 37
+)
+
+// ParseCFF parses the content of a 'CFF ' table, only the first font of the
+// table is read.
+func ParseCFF(b []byte) (*CFFTable, error) {
+	d := data(b)
+
+	if !d.has(0, 4) {
+		return nil, invalidTable("CFF ", "truncated header")
+	}
+
+	if major := d.u8(0); major != 1 {
+		return nil, invalidTable("CFF ", "unsupported version %d", major)
+	}
+
+	t := &CFFTable{data: d}
+	off := int(d.u8(2))
+
+	names, off, err := parseCFFIndex(d, off)
+
+	if err != nil {
+		return nil, invalidTable("CFF ", "name index: %v", err)
+	}
+
+	topDicts, off, err := parseCFFIndex(d, off)
+
+	if err != nil {
+		return nil, invalidTable("CFF ", "top dict index: %v", err)
+	}
+
+	if _, off, err = parseCFFIndex(d, off); err != nil {
+		return nil, invalidTable("CFF ", "string index: %v", err)
+	}
+
+	if t.globalSubrs, _, err = parseCFFIndex(d, off); err != nil {
+		return nil, invalidTable("CFF ", "global subroutines index: %v", err)
+	}
+
+	if names.count < 1 || topDicts.count < 1 {
+		return nil, invalidTable("CFF ", "the table has no font")
+	}
+
+	top, err := parseCFFDict(topDicts.object(0))
+
+	if err != nil {
+		return nil, invalidTable("CFF ", "top dict: %v", err)
+	}
+
+	if v, ok := top[cffCharStringType]; ok && (len(v) != 1 || v[0] != 2) {
+		return nil, invalidTable("CFF ", "unsupported charstring type: %v", v)
+	}
+
+	if t.charStrings, _, err = parseCFFIndex(d, top.offset(cffCharStrings)); err != nil {
+		return nil, invalidTable("CFF ", "charstrings index: %v", err)
+	}
+
+	if _, cid := top[cffROS]; !cid {
+		if t.localSubrs, err = t.parsePrivate(top); err != nil {
+			return nil, invalidTable("CFF ", "private dict: %v", err)
+		}
+		return t, nil
+	}
+
+	if err = t.parseCID(top); err != nil {
+		return nil, invalidTable("CFF ", "%v", err)
+	}
+
+	return t, nil
+}
+
+// parsePrivate parses the private dict referenced by the dict passed as
+// argument, and returns its local subroutines.
+func (t *CFFTable) parsePrivate(dict cffDict) (cffIndex, error) {
+	p, ok := dict[cffPrivate]
+
+	if !ok {
+		return cffIndex{}, nil
+	}
+
+	if len(p) != 2 {
+		return cffIndex{}, fmt.Errorf("invalid operands: %v", p)
+	}
+
+	size, off := int(p[0]), int(p[1])
+	private, err := parseCFFDict(t.data.slice(off, size))
+
+	if err != nil {
+		return cffIndex{}, err
+	}
+
+	if _, ok := private[cffSubrs]; !ok {
+		return cffIndex{}, nil
+	}
+
+	subrs, _, err := parseCFFIndex(t.data, off+private.offset(cffSubrs))
+	return subrs, err
+}
+
+func (t *CFFTable) parseCID(top cffDict) error {
+	fdArray, _, err := parseCFFIndex(t.data, top.offset(cffFDArray))
+
+	if err != nil {
+		return fmt.Errorf("font dict index: %v", err)
+	}
+
+	t.fdSubrs = make([]cffIndex, fdArray.count)
+
+	for i := range t.fdSubrs {
+		fd, err := parseCFFDict(fdArray.object(i))
+
+		if err == nil {
+			t.fdSubrs[i], err = t.parsePrivate(fd)
+		}
+
+		if err != nil {
+			return fmt.Errorf("font dict %d: %v", i, err)
+		}
+	}
+
+	off := top.offset(cffFDSelect)
+	n := t.charStrings.count
+
+	switch format := t.data.u8(off); format {
+	case 0:
+		selector := t.data.slice(off+1, n)
+
+		if selector == nil {
+			return fmt.Errorf("truncated font dict selector")
+		}
+
+		t.fdSelect = func(g GlyphID) int { return int(selector.u8(int(g))) }
+
+	case 3:
+		count := int(t.data.u16(off + 1))
+		ranges := t.data.slice(off+3, 3*count+2)
+
+		if ranges == nil {
+			return fmt.Errorf("truncated font dict selector")
+		}
+
+		t.fdSelect = func(g GlyphID) int {
+			for i := 0; i != count; i++ {
+				if int(g) >= int(ranges.u16(3*i)) && int(g) < int(ranges.u16(3*i+3)) {
+					return int(ranges.u8(3*i + 2))
+				}
+			}
+			return -1
+		}
+
+	default:
+		return fmt.Errorf("unsupported font dict selector format: %d", format)
+	}
+
+	return nil
+}
+
+func parseCFFIndex(d data, off int) (cffIndex, int, error) {
+	if !d.has(off, 2) {
+		return cffIndex{}, off, fmt.Errorf("offset out of bounds: %d", off)
+	}
+
+	x := cffIndex{count: int(d.u16(off))}
+
+	if x.count == 0 {
+		return x, off + 2, nil
+	}
+
+	x.offSize = int(d.u8(off + 2))
+
+	if x.offSize < 1 || x.offSize > 4 {
+		return x, off, fmt.Errorf("invalid offset size: %d", x.offSize)
+	}
+
+	if x.offsets = d.slice(off+3, x.offSize*(x.count+1)); x.offsets == nil {
+		return x, off, fmt.Errorf("truncated list of %d offsets", x.count+1)
+	}
+
+	// Offsets are relative to the byte that precedes the objects.
+	start := off + 3 + len(x.offsets) - 1
+	size := x.offset(x.count)
+
+	if x.objects = d.slice(start, size); x.objects == nil || size < 1 {
+		return x, off, fmt.Errorf("truncated list of %d objects", x.count)
+	}
+
+	return x, start + size, nil
+}
+
+func (x *cffIndex) offset(i int) int {
+	v := 0
+
+	for j := 0; j != x.offSize; j++ {
+		v = v<<8 | int(x.offsets.u8(i*x.offSize+j))
+	}
+
+	return v
+}
+
+// object returns the object at index i, or nil if the index or the offsets of
+// the object are invalid.
+func (x *cffIndex) object(i int) data {
+	if i < 0 || i >= x.count {
+		return nil
+	}
+
+	start, end := x.offset(i), x.offset(i+1)
+
+	if start < 1 || end < start {
+		return nil
+	}
+
+	return x.objects.slice(start, end-start)
+}
+
+// cffDict is a parsed DICT structure, which maps operators to their operands.
+type cffDict map[int][]float64
+
+// offset returns the first operand of the operator, which is an offset for
+// the operators that it's used with, or -1 if the dict has no such operator.
+func (d cffDict) offset(op int) int {
+	if v := d[op]; len(v) != 0 {
+		return int(v[len(v)-1])
+	}
+	return -1
+}
+
+func parseCFFDict(d data) (cffDict, error) {
+	dict := cffDict{}
+	operands := []float64{}
+
+	for i := 0; i < len(d); {
+		b0 := int(d[i])
+
+		switch {
+		case b0 <= 21:
+			op := b0
+			i++
+
+			if b0 == 12 {
+				op = 12<<8 | int(d.u8(i))
+				i++
+			}
+
+			dict[op] = operands
+			operands = []float64{}
+			continue
+
+		case b0 == 28:
+			operands = append(operands, float64(d.i16(i+1)))
+			i += 3
+
+		case b0 == 29:
+			operands = append(operands, float64(int32(d.u32(i+1))))
+			i += 5
+
+		case b0 == 30:
+			v, n := parseCFFReal(d[i+1:])
+			operands = append(operands, v)
+			i += 1 + n
+
+		case b0 >= 32 && b0 <= 246:
+			operands = append(operands, float64(b0-139))
+			i++
+
+		case b0 >= 247 && b0 <= 250:
+			operands = append(operands, float64((b0-247)*256+int(d.u8(i+1))+108))
+			i += 2
+
+		case b0 >= 251 && b0 <= 254:
+			operands = append(operands, float64(-(b0-251)*256-int(d.u8(i+1))-108))
+			i += 2
+
+		default:
+			return nil, fmt.Errorf("invalid byte %d at offset %d", b0, i)
+		}
+
+		if i > len(d) {
+			return nil, fmt.Errorf("truncated operand")
+		}
+	}
+
+	return dict, nil
+}
+
+// parseCFFReal parses a real number encoded as a sequence of nibbles, and
+// returns it with the number of bytes that it spans.
+func parseCFFReal(d data) (float64, int) {
+	s := []byte{}
+
+	for i := 0; i < len(d); i++ {
+		for _, nibble := range [2]byte{d[i] >> 4, d[i] & 0xF} {
+			switch {
+			case nibble <= 9:
+				s = append(s, '0'+nibble)
+			case nibble == 0xA:
+				s = append(s, '.')
+			case nibble == 0xB:
+				s = append(s, 'E')
+			case nibble == 0xC:
+				s = append(s, 'E', '-')
+			case nibble == 0xE:
+				s = append(s, '-')
+			case nibble == 0xF:
+				v := 0.0
+				fmt.Sscan(string(s), &v)
+				return v, i + 1
+			}
+		}
+	}
+
+	return 0, len(d)
+}
+
+// NumGlyphs returns the number of glyphs in the table.
+func (t *CFFTable) NumGlyphs() int {
+	return t.charStrings.count
+}
+
+// Outline satisfies the Outliner interface.
+func (t *CFFTable) Outline(glyph GlyphID) (Outline, error) {
+	charString := t.charStrings.object(int(glyph))
+
+	if charString == nil {
+		return nil, fmt.Errorf("sfnt: glyph %d out of range in a font of %d glyphs", glyph, t.charStrings.count)
+	}
+
+	c := &cffCharString{globalSubrs: &t.globalSubrs, localSubrs: &t.localSubrs}
+
+	if t.fdSelect != nil {
+		fd := t.fdSelect(glyph)
+
+		if fd < 0 || fd >= len(t.fdSubrs) {
+			return nil, fmt.Errorf("sfnt: glyph %d: invalid font dict: %d", glyph, fd)
+		}
+
+		c.localSubrs = &t.fdSubrs[fd]
+	}
+
+	if err := c.run(charString, 0); err != nil && err != errCFFEndChar {
+		return nil, fmt.Errorf("sfnt: glyph %d: %v", glyph, err)
+	}
+
+	c.closePath()
+	return c.outline, nil
+}
+
+// cffCharString is an interpreter of Type 2 charstrings.
+type cffCharString struct {
+	outlineBuilder
+	globalSubrs *cffIndex
+	localSubrs  *cffIndex
+	stack       []float32
+	stems       int
+	width       bool
+	x, y        float32
+}
+
+const (
+	cffMaxStack     = 48
+	cffMaxCallDepth = 10
+)
+
+// errCFFEndChar is used to unwind the calls to subroutines when the endchar
+// operator is reached.
+var errCFFEndChar = errors.New("endchar")
+
+func cffSubrBias(x *cffIndex) int {
+	switch {
+	case x.count < 1240:
+		return 107
+	case x.count < 33900:
+		return 1131
+	default:
+		return 32768
+	}
+}
+
+func (c *cffCharString) run(d data, depth int) error {
+	if depth > cffMaxCallDepth {
+		return fmt.Errorf("too many nested subroutine calls")
+	}
+
+	for i := 0; i < len(d); {
+		b0 := int(d[i])
+		i++
+
+		if b0 >= 32 || b0 == 28 {
+			var v float32
+
+			switch {
+			case b0 == 28:
+				v = float32(d.i16(i))
+				i += 2
+			case b0 <= 246:
+				v = float32(b0 - 139)
+			case b0 <= 250:
+				v = float32((b0-247)*256 + int(d.u8(i)) + 108)
+				i++
+			case b0 <= 254:
+				v = float32(-(b0-251)*256 - int(d.u8(i)) - 108)
+				i++
+			default:
+				v = float32(int32(d.u32(i))) / 0x10000
+				i += 4
+			}
+
+			if i > len(d) {
+				return fmt.Errorf("truncated operand")
+			}
+
+			if len(c.stack) == cffMaxStack {
+				return fmt.Errorf("operand stack overflow")
+			}
+
+			c.stack = append(c.stack, v)
+			continue
+		}
+
+		op := b0
+
+		if b0 == 12 {
+			op = 12<<8 | int(d.u8(i))
+			i++
+		}
+
+		switch op {
+		case 10, 29: // callsubr, callgsubr
+			subrs := c.localSubrs
+
+			if op == 29 {
+				subrs = c.globalSubrs
+			}
+
+			if len(c.stack) == 0 {
+				return fmt.Errorf("missing subroutine index")
+			}
+
+			n := int(c.stack[len(c.stack)-1]) + cffSubrBias(subrs)
+			c.stack = c.stack[:len(c.stack)-1]
+			subr := subrs.object(n)
+
+			if subr == nil {
+				return fmt.Errorf("invalid subroutine index: %d", n)
+			}
+
+			if err := c.run(subr, depth+1); err != nil {
+				return err
+			}
+
+		case 11: // return
+			return nil
+
+		case 19, 20: // hintmask, cntrmask
+			c.stem()
+			i += (c.stems + 7) / 8
+
+		default:
+			if err := c.apply(op); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+// stem counts the stem hints of the operands, which may be preceded by the
+// width of the glyph.
+func (c *cffCharString) stem() {
+	c.popWidth(len(c.stack)%2 == 1)
+	c.stems += len(c.stack) / 2
+	c.stack = c.stack[:0]
+}
+
+// popWidth drops the width from the bottom of the stack if it's the first
+// stack clearing operator and the stack has an extra operand.
+func (c *cffCharString) popWidth(extra bool) {
+	if !c.width {
+		c.width = true
+
+		if extra && len(c.stack) != 0 {
+			c.stack = c.stack[1:]
+		}
+	}
+}
+
+func (c *cffCharString) point(dx float32, dy float32) Point {
+	c.x += dx
+	c.y += dy
+	return Point{X: c.x, Y: c.y}
+}
+
+func (c *cffCharString) curve(dx1, dy1, dx2, dy2, dx3, dy3 float32) {
+	p1 := c.point(dx1, dy1)
+	p2 := c.point(dx2, dy2)
+	p3 := c.point(dx3, dy3)
+	c.cubeTo(p1, p2, p3)
+}
+
+func (c *cffCharString) apply(op int) error {
+	s := c.stack
+	defer func() { c.stack = c.stack[:0] }()
+
+	switch op {
+	case 1, 3, 18, 23: // hstem, vstem, hstemhm, vstemhm
+		c.stem()
+
+	case 21: // rmoveto
+		c.popWidth(len(s) > 2)
+		s = c.stack
+
+		if len(s) < 2 {
+			return fmt.Errorf("missing operands of rmoveto")
+		}
+
+		c.moveTo(c.point(s[0], s[1]))
+
+	case 22, 4: // hmoveto, vmoveto
+		c.popWidth(len(s) > 1)
+		s = c.stack
+
+		if len(s) < 1 {
+			return fmt.Errorf("missing operand of moveto")
+		}
+
+		if op == 22 {
+			c.moveTo(c.point(s[0], 0))
+		} else {
+			c.moveTo(c.point(0, s[0]))
+		}
+
+	case 5: // rlineto
+		for ; len(s) >= 2; s = s[2:] {
+			c.lineTo(c.point(s[0], s[1]))
+		}
+
+	case 6, 7: // hlineto, vlineto
+		horizontal := op == 6
+
+		for ; len(s) >= 1; s = s[1:] {
+			if horizontal {
+				c.lineTo(c.point(s[0], 0))
+			} else {
+				c.lineTo(c.point(0, s[0]))
+			}
+			horizontal = !horizontal
+		}
+
+	case 8: // rrcurveto
+		for ; len(s) >= 6; s = s[6:] {
+			c.curve(s[0], s[1], s[2], s[3], s[4], s[5])
+		}
+
+	case 24: // rcurveline
+		for ; len(s) >= 8; s = s[6:] {
+			c.curve(s[0], s[1], s[2], s[3], s[4], s[5])
+		}
+
+		if len(s) >= 2 {
+			c.lineTo(c.point(s[0], s[1]))
+		}
+
+	case 25: // rlinecurve
+		for ; len(s) >= 8; s = s[2:] {
+			c.lineTo(c.point(s[0], s[1]))
+		}
+
+		if len(s) >= 6 {
+			c.curve(s[0], s[1], s[2], s[3], s[4], s[5])
+		}
+
+	case 26: // vvcurveto
+		dx1 := float32(0)
+
+		if len(s)%2 == 1 {
+			dx1, s = s[0], s[1:]
+		}
+
+		for ; len(s) >= 4; s = s[4:] {
+			c.curve(dx1, s[0], s[1], s[2], 0, s[3])
+			dx1 = 0
+		}
+
+	case 27: // hhcurveto
+		dy1 := float32(0)
+
+		if len(s)%2 == 1 {
+			dy1, s = s[0], s[1:]
+		}
+
+		for ; len(s) >= 4; s = s[4:] {
+			c.curve(s[0], dy1, s[1], s[2], s[3], 0)
+			dy1 = 0
+		}
+
+	case 30, 31: // vhcurveto, hvcurveto
+		horizontal := op == 31
+
+		for len(s) >= 4 {
+			last := float32(0)
+
+			if len(s) == 5 {
+				last = s[4]
+			}
+
+			if horizontal {
+				c.curve(s[0], 0, s[1], s[2], last, s[3])
+			} else {
+				c.curve(0, s[0], s[1], s[2], s[3], last)
+			}
+
+			s = s[4:]
+
+			if len(s) == 1 {
+				s = s[1:]
+			}
+
+			horizontal = !horizontal
+		}
+
+	case 14: // endchar
+		c.popWidth(len(s) == 1 || len(s) == 5)
+
+		if len(c.stack) == 4 {
+			return fmt.Errorf("accented characters (seac) are not supported")
+		}
+
+		return errCFFEndChar
+
+	case 12<<8 | 34: // hflex
+		if len(s) < 7 {
+			return fmt.Errorf("missing operands of hflex")
+		}
+
+		y := c.y
+		c.curve(s[0], 0, s[1], s[2], s[3], 0)
+		c.curve(s[4], 0, s[5], y-c.y, s[6], 0)
+
+	case 12<<8 | 35: // flex
+		if len(s) < 12 {
+			return fmt.Errorf("missing operands of flex")
+		}
+
+		c.curve(s[0], s[1], s[2], s[3], s[4], s[5])
+		c.curve(s[6], s[7], s[8], s[9], s[10], s[11])
+
+	case 12<<8 | 36: // hflex1
+		if len(s) < 9 {
+			return fmt.Errorf("missing operands of hflex1")
+		}
+
+		y := c.y
+		c.curve(s[0], s[1], s[2], s[3], s[4], 0)
+		c.curve(s[5], 0, s[6], s[7], s[8], y-(c.y+s[7]))
+
+	case 12<<8 | 37: // flex1
+		if len(s) < 11 {
+			return fmt.Errorf("missing operands of flex1")
+		}
+
+		x, y := c.x, c.y
+		dx := s[0] + s[2] + s[4] + s[6] + s[8]
+		dy := s[1] + s[3] + s[5] + s[7] + s[9]
+		c.curve(s[0], s[1], s[2], s[3], s[4], s[5])
+
+		if math.Abs(float64(dx)) > math.Abs(float64(dy)) {
+			c.curve(s[6], s[7], s[8], s[9], s[10], y-(c.y+s[7]+s[9]))
+		} else {
+			c.curve(s[6], s[7], s[8], s[9], x-(c.x+s[6]+s[8]), s[10])
+		}
+
+	default:
+		return fmt.Errorf("unsupported operator %d", op)
+	}
+
+	return nil
+}
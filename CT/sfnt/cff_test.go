@@ -0,0 +1,108 @@
+package sfnt
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestCFFOutline(t *testing.T) {
+	f := loadFont(t, "CFFTest.otf")
+	cff, err := ParseCFF(f.Table("CFF "))
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if n := cff.NumGlyphs(); n != 5 {
+		t.Errorf("invalid number of glyphs: %d", n)
+	}
+
+	tests := []struct {
+		glyph   GlyphID
+		outline Outline
+	}{
+		{
+			glyph: 0,
+			outline: Outline{
+				moveTo(50, 0), lineTo(450, 0), lineTo(450, 533), lineTo(50, 533), lineTo(50, 0),
+				moveTo(100, 50), lineTo(100, 483), lineTo(400, 483), lineTo(400, 50), lineTo(100, 50),
+			},
+		},
+		{
+			glyph: 1,
+			outline: Outline{
+				moveTo(300, 700),
+				cubeTo(380, 700, 420, 580, 420, 500),
+				cubeTo(420, 350, 390, 100, 300, 100),
+				cubeTo(220, 100, 180, 220, 180, 300),
+				cubeTo(180, 450, 210, 700, 300, 700),
+				moveTo(300, 800),
+				cubeTo(200, 800, 100, 580, 100, 400),
+				cubeTo(100, 220, 200, 0, 300, 0),
+				cubeTo(400, 0, 500, 220, 500, 400),
+				cubeTo(500, 580, 400, 800, 300, 800),
+			},
+		},
+		{
+			glyph:   2,
+			outline: Outline{moveTo(100, 0), lineTo(300, 0), lineTo(300, 800), lineTo(100, 800), lineTo(100, 0)},
+		},
+		{
+			glyph: 3,
+			outline: Outline{
+				moveTo(657, 237), lineTo(289, 387), lineTo(519, 615), lineTo(657, 237),
+				moveTo(792, 169),
+				cubeTo(867, 263, 926, 502, 791, 665),
+				cubeTo(645, 840, 380, 831, 228, 673),
+				cubeTo(71, 509, 110, 231, 242, 93),
+				cubeTo(369, -39, 641, 18, 722, 93),
+				lineTo(802, 3), lineTo(864, 83), lineTo(792, 169),
+			},
+		},
+	}
+
+	for _, test := range tests {
+		outline, err := cff.Outline(test.glyph)
+
+		if err != nil {
+			t.Errorf("glyph %d: %v", test.glyph, err)
+			continue
+		}
+
+		if !equalOutlines(outline, test.outline) {
+			t.Errorf("glyph %d: invalid outline:\n%v\n%v", test.glyph, outline, test.outline)
+		}
+	}
+
+	outline, err := cff.Outline(4)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if min, max, ok := outline.Bounds(); min != (Point{137, 26}) || max != (Point{463, 758}) || !ok {
+		t.Errorf("glyph 4: invalid bounds: %v, %v, %t", min, max, ok)
+	}
+
+	if _, err := cff.Outline(5); err == nil {
+		t.Error("no error for a glyph out of range")
+	}
+}
+
+func TestParseCFFError(t *testing.T) {
+	f := loadFont(t, "CFFTest.otf")
+	b := f.Table("CFF ")
+
+	tests := [][]byte{
+		nil,
+		b[:3],
+		append([]byte{2, 0}, b[2:]...),
+		b[:40],
+	}
+
+	for _, test := range tests {
+		if _, err := ParseCFF(test); !errors.Is(err, ErrInvalidTable) {
+			t.Errorf("invalid error for %d bytes: %v", len(test), err)
+		}
+	}
+}
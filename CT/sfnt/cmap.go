@@ -0,0 +1,494 @@
+package sfnt
+
+import (
+	"fmt"
+	"sort"
+	"unicode/utf8"
+)
+
+// Cmap is a parsed 'cmap' table, which maps runes to the glyphs of a font.
+//
+// The table is made of subtables for different platforms and encodings, the
+// parser selects the subtable that covers the most of Unicode among those in
+// formats 4 (BMP segments), 6 (trimmed table), 12 (segmented coverage) and 13
+// (many-to-one ranges). Unicode variation sequences are supported through the
+// format 14 subtable, if the font has one.
+//
+// Subtables that use other formats, or that are malformed, are reported in
+// Diagnostics.
+//
+// https://learn.microsoft.com/en-us/typography/opentype/spec/cmap
+type Cmap struct {
+	// Diagnostics lists the subtables that were skipped when the table was
+	// parsed.
+	Diagnostics []Diagnostic
+
+	subtable   cmapSubtable
+	variations *cmapVariations
+
+	// Symbol fonts map their glyphs to the private use area at U+F000, with
+	// the low byte being the code of the character in the symbol encoding.
+	symbol bool
+}
+
+// RuneRange is a range of runes, from First to Last inclusive.
+type RuneRange struct {
+	First rune
+	Last  rune
+}
+
+// String satisfies the fmt.Stringer interface.
+func (r RuneRange) String() string {
+	if r.First == r.Last {
+		return fmt.Sprintf("U+%04X", r.First)
+	}
+	return fmt.Sprintf("U+%04X-U+%04X", r.First, r.Last)
+}
+
+type cmapSubtable interface {
+	lookup(r rune) GlyphID
+
+	// ranges calls f for ranges of runes that may be mapped by the subtable,
+	// in increasing order. Runes of the ranges may still be mapped to glyph 0.
+	ranges(f func(first rune, last rune))
+}
+
+// ParseCmap parses the content of a 'cmap' table.
+//
+// The function returns an error wrapping ErrInvalidTable if the table header
+// cannot be read or if it has no subtable mapping Unicode characters in a
+// supported format.
+func ParseCmap(b []byte) (*Cmap, error) {
+	d := data(b)
+
+	if !d.has(0, 4) {
+		return nil, invalidTable("cmap", "truncated header")
+	}
+
+	n := int(d.u16(2))
+	records := d.slice(4, 8*n)
+
+	if records == nil {
+		return nil, invalidTable("cmap", "truncated list of %d encoding records", n)
+	}
+
+	c := &Cmap{}
+	best := 0
+
+	for i := 0; i != n; i++ {
+		platform := int(records.u16(8 * i))
+		encoding := int(records.u16(8*i + 2))
+		off := int(records.u32(8*i + 4))
+
+		if !d.has(off, 2) {
+			c.diagnose(i, fmt.Sprintf("subtable offset out of bounds: %d", off))
+			continue
+		}
+
+		format := int(d.u16(off))
+
+		if platform == 0 && encoding == 5 {
+			if v, err := parseCmapVariations(d, off); err != nil {
+				c.diagnose(i, err.Error())
+			} else {
+				c.variations = v
+			}
+			continue
+		}
+
+		score := cmapScore(platform, encoding)
+
+		if score == 0 {
+			continue
+		}
+
+		var s cmapSubtable
+		var err error
+
+		switch format {
+		case 4:
+			s, err = parseCmapFormat4(d, off)
+		case 6:
+			s, err = parseCmapFormat6(d, off)
+		case 12, 13:
+			s, err = parseCmapFormat12(d, off, format)
+		default:
+			err = fmt.Errorf("unsupported subtable format: %d", format)
+		}
+
+		if err != nil {
+			c.diagnose(i, err.Error())
+			continue
+		}
+
+		// Formats 12 and 13 cover all of Unicode, the others only the BMP.
+		if format >= 12 {
+			score++
+		}
+
+		if score > best {
+			best, c.subtable, c.symbol = score, s, (platform == 3 && encoding == 0)
+		}
+	}
+
+	if c.subtable == nil {
+		return nil, invalidTable("cmap", "no supported Unicode subtable")
+	}
+
+	return c, nil
+}
+
+// cmapScore ranks the platform and encoding of cmap subtables, zero means that
+// the subtable doesn't map Unicode characters.
+func cmapScore(platform int, encoding int) int {
+	switch platform {
+	case 0: // Unicode
+		switch encoding {
+		case 0, 1, 2:
+			return 2
+		case 3, 4, 6:
+			return 4
+		}
+	case 3: // Windows
+		switch encoding {
+		case 0: // Symbol
+			return 1
+		case 1: // Unicode BMP
+			return 3
+		case 10: // Unicode full repertoire
+			return 5
+		}
+	}
+	return 0
+}
+
+func (c *Cmap) diagnose(i int, msg string) {
+	c.Diagnostics = append(c.Diagnostics, Diagnostic{
+		Table:    "cmap",
+		Subtable: i,
+		Message:  msg,
+	})
+}
+
+// Lookup returns the glyph that r is mapped to, the boolean is false if the
+// font has no glyph for r.
+func (c *Cmap) Lookup(r rune) (GlyphID, bool) {
+	if r < 0 || r > utf8.MaxRune {
+		return 0, false
+	}
+
+	g := c.subtable.lookup(r)
+
+	if g == 0 && c.symbol && r <= 0xFF {
+		g = c.subtable.lookup(0xF000 + r)
+	}
+
+	return g, g != 0
+}
+
+// LookupVariant returns the glyph of the variation sequence made of r followed
+// by the variation selector, the boolean is false if the font doesn't support
+// the variation sequence, in which case the glyph of r alone should be used.
+//
+// https://learn.microsoft.com/en-us/typography/opentype/spec/cmap#format-14-unicode-variation-sequences
+func (c *Cmap) LookupVariant(r rune, selector rune) (GlyphID, bool) {
+	if c.variations == nil {
+		return 0, false
+	}
+
+	g, isDefault, ok := c.variations.lookup(r, selector)
+
+	if !ok {
+		return 0, false
+	}
+
+	if isDefault {
+		return c.Lookup(r)
+	}
+
+	return g, g != 0
+}
+
+// Selectors returns the variation selectors that the font has variation
+// sequences for, in increasing order.
+func (c *Cmap) Selectors() []rune {
+	if c.variations == nil {
+		return nil
+	}
+
+	selectors := make([]rune, c.variations.count)
+
+	for i := range selectors {
+		selectors[i] = c.variations.selector(i)
+	}
+
+	return selectors
+}
+
+// Ranges returns the ranges of runes that the font has glyphs for, in
+// increasing order. Adjacent ranges are merged together.
+func (c *Cmap) Ranges() []RuneRange {
+	ranges := []RuneRange{}
+
+	add := func(r rune) {
+		if n := len(ranges); n != 0 && ranges[n-1].Last == r-1 {
+			ranges[n-1].Last = r
+		} else {
+			ranges = append(ranges, RuneRange{First: r, Last: r})
+		}
+	}
+
+	c.subtable.ranges(func(first rune, last rune) {
+		for r := first; r <= last; r++ {
+			if c.subtable.lookup(r) != 0 {
+				add(r)
+			}
+		}
+	})
+
+	return ranges
+}
+
+// cmapFormat4 is a segment mapping to delta values subtable.
+type cmapFormat4 struct {
+	segments int
+	data     data
+	end      int
+	start    int
+	delta    int
+	rangeOff int
+}
+
+func parseCmapFormat4(d data, off int) (cmapSubtable, error) {
+	if !d.has(off, 14) {
+		return nil, fmt.Errorf("truncated format 4 header")
+	}
+
+	// The length field is ignored, some fonts set it to an invalid value
+	// because it's only 16 bits wide. The subtable is assumed to extend to the
+	// end of the table, so glyph arrays can be read past the segments.
+	segments := int(d.u16(off+6)) / 2
+	s := &cmapFormat4{
+		segments: segments,
+		data:     d[off:],
+		end:      14,
+		start:    14 + 2*segments + 2,
+		delta:    14 + 4*segments + 2,
+		rangeOff: 14 + 6*segments + 2,
+	}
+
+	if !s.data.has(s.rangeOff, 2*segments) {
+		return nil, fmt.Errorf("truncated list of %d segments", segments)
+	}
+
+	return s, nil
+}
+
+func (s *cmapFormat4) lookup(r rune) GlyphID {
+	if r > 0xFFFF {
+		return 0
+	}
+
+	c := uint16(r)
+	d := s.data
+	i := sort.Search(s.segments, func(i int) bool { return d.u16(s.end+2*i) >= c })
+
+	if i == s.segments {
+		return 0
+	}
+
+	start := d.u16(s.start + 2*i)
+
+	if c < start {
+		return 0
+	}
+
+	delta := d.u16(s.delta + 2*i)
+	rangeOff := int(d.u16(s.rangeOff + 2*i))
+
+	if rangeOff == 0 {
+		return GlyphID(c + delta)
+	}
+
+	g := d.u16(s.rangeOff + 2*i + rangeOff + 2*int(c-start))
+
+	if g == 0 {
+		return 0
+	}
+
+	return GlyphID(g + delta)
+}
+
+func (s *cmapFormat4) ranges(f func(rune, rune)) {
+	for i := 0; i != s.segments; i++ {
+		start, end := s.data.u16(s.start+2*i), s.data.u16(s.end+2*i)
+
+		if start <= end && !(start == 0xFFFF && end == 0xFFFF) {
+			f(rune(start), rune(end))
+		}
+	}
+}
+
+// cmapFormat6 is a trimmed table mapping subtable.
+type cmapFormat6 struct {
+	first  int
+	glyphs data
+}
+
+func parseCmapFormat6(d data, off int) (cmapSubtable, error) {
+	if !d.has(off, 10) {
+		return nil, fmt.Errorf("truncated format 6 header")
+	}
+
+	n := int(d.u16(off + 8))
+	s := &cmapFormat6{
+		first:  int(d.u16(off + 6)),
+		glyphs: d.slice(off+10, 2*n),
+	}
+
+	if s.glyphs == nil {
+		return nil, fmt.Errorf("truncated array of %d glyphs", n)
+	}
+
+	return s, nil
+}
+
+func (s *cmapFormat6) lookup(r rune) GlyphID {
+	if i := int(r) - s.first; i >= 0 && 2*i < len(s.glyphs) {
+		return GlyphID(s.glyphs.u16(2 * i))
+	}
+	return 0
+}
+
+func (s *cmapFormat6) ranges(f func(rune, rune)) {
+	if n := len(s.glyphs) / 2; n != 0 {
+		f(rune(s.first), rune(s.first+n-1))
+	}
+}
+
+// cmapFormat12 is a segmented coverage (format 12) or many-to-one range
+// mappings (format 13) subtable, they only differ by how glyphs are computed.
+type cmapFormat12 struct {
+	constant bool
+	count    int
+	groups   data
+}
+
+func parseCmapFormat12(d data, off int, format int) (cmapSubtable, error) {
+	if !d.has(off, 16) {
+		return nil, fmt.Errorf("truncated format %d header", format)
+	}
+
+	n := int(d.u32(off + 12))
+	s := &cmapFormat12{
+		constant: format == 13,
+		count:    n,
+		groups:   d.slice(off+16, 12*n),
+	}
+
+	if n < 0 || s.groups == nil {
+		return nil, fmt.Errorf("truncated list of %d groups", n)
+	}
+
+	return s, nil
+}
+
+func (s *cmapFormat12) lookup(r rune) GlyphID {
+	c := uint32(r)
+	i := sort.Search(s.count, func(i int) bool { return s.groups.u32(12*i+4) >= c })
+
+	if i == s.count || s.groups.u32(12*i) > c {
+		return 0
+	}
+
+	g := s.groups.u32(12*i + 8)
+
+	if !s.constant {
+		g += c - s.groups.u32(12*i)
+	}
+
+	if g > 0xFFFF {
+		return 0
+	}
+
+	return GlyphID(g)
+}
+
+func (s *cmapFormat12) ranges(f func(rune, rune)) {
+	for i := 0; i != s.count; i++ {
+		first, last := s.groups.u32(12*i), s.groups.u32(12*i+4)
+
+		if first <= last && last <= utf8.MaxRune {
+			f(rune(first), rune(last))
+		}
+	}
+}
+
+// cmapVariations is a Unicode variation sequences (format 14) subtable.
+type cmapVariations struct {
+	data    data
+	count   int
+	records data
+}
+
+func parseCmapVariations(d data, off int) (*cmapVariations, error) {
+	if !d.has(off, 10) || d.u16(off) != 14 {
+		return nil, fmt.Errorf("invalid variation sequences subtable")
+	}
+
+	n := int(d.u32(off + 6))
+	v := &cmapVariations{
+		data:    d[off:],
+		count:   n,
+		records: d.slice(off+10, 11*n),
+	}
+
+	if n < 0 || v.records == nil {
+		return nil, fmt.Errorf("truncated list of %d variation selectors", n)
+	}
+
+	return v, nil
+}
+
+func (d data) u24(off int) uint32 {
+	return uint32(d.u8(off))<<16 | uint32(d.u16(off+1))
+}
+
+func (v *cmapVariations) selector(i int) rune {
+	return rune(v.records.u24(11 * i))
+}
+
+// lookup returns the glyph of the variation sequence, isDefault is true if the
+// sequence uses the default glyph of r, and ok is false if the font doesn't
+// support the sequence.
+func (v *cmapVariations) lookup(r rune, selector rune) (g GlyphID, isDefault bool, ok bool) {
+	i := sort.Search(v.count, func(i int) bool { return v.selector(i) >= selector })
+
+	if i == v.count || v.selector(i) != selector {
+		return
+	}
+
+	c := uint32(r)
+
+	if off := int(v.records.u32(11*i + 3)); off != 0 {
+		n := int(v.data.u32(off))
+		ranges := v.data.slice(off+4, 4*n)
+		j := sort.Search(n, func(j int) bool { return ranges.u24(4*j)+uint32(ranges.u8(4*j+3)) >= c })
+
+		if n >= 0 && j < n && ranges.u24(4*j) <= c {
+			return 0, true, true
+		}
+	}
+
+	if off := int(v.records.u32(11*i + 7)); off != 0 {
+		n := int(v.data.u32(off))
+		mappings := v.data.slice(off+4, 5*n)
+		j := sort.Search(n, func(j int) bool { return mappings.u24(5*j) >= c })
+
+		if n >= 0 && j < n && mappings.u24(5*j) == c {
+			return GlyphID(mappings.u16(5*j + 3)), false, true
+		}
+	}
+
+	return
+}
@@ -0,0 +1,468 @@
+package sfnt
+
+import (
+	"errors"
+	"os"
+	"reflect"
+	"sort"
+	"testing"
+)
+
+func TestParseCmapFonts(t *testing.T) {
+	type glyph struct {
+		r rune
+		g GlyphID
+	}
+
+	tests := []struct {
+		font   string
+		runes  int
+		ranges int
+		glyphs []glyph
+	}{
+		{
+			font:   "Roboto-Regular",
+			runes:  896,
+			ranges: 78,
+			glyphs: []glyph{
+				{' ', 4}, {'A', 37}, {'z', 94}, {'é', 675}, {'Ω', 186}, {'Ж', 218}, {'€', 413},
+				{'ﬁ', 444}, {'�', 452}, {0x1D400, 0}, {0x1F600, 0}, {0x10FFFF, 0}, {0x7F, 0},
+				{0xE000, 0}, {0x0, 1},
+			},
+		},
+		{
+			font:   "DejaVuSansCondensed",
+			runes:  5918,
+			ranges: 281,
+			glyphs: []glyph{
+				{' ', 3}, {'A', 36}, {'z', 93}, {'é', 171}, {'Ω', 830}, {'Ж', 939}, {'€', 2948},
+				{'ﬁ', 5042}, {'�', 5372}, {0x1D400, 0}, {0x1F600, 5857}, {0x10FFFF, 0}, {0x7F, 0},
+				{0xE000, 0}, {0x0, 0},
+			},
+		},
+		{
+			font:   "cmapTest",
+			runes:  13,
+			ranges: 7,
+			glyphs: []glyph{
+				{'0', 3}, {'1', 4}, {'2', 5}, {'A', 6}, {'B', 7}, {'a', 8}, {0xFF, 9}, {0x100, 10},
+				{0x101, 11}, {0x4E2D, 12}, {0x1F0A1, 13}, {0x1F0B1, 14}, {0x1F0B2, 15}, {'b', 0},
+				{0x1F0B3, 0}, {-1, 0}, {0x110000, 0},
+			},
+		},
+	}
+
+	for _, test := range tests {
+		b, err := os.ReadFile("testdata/" + test.font + ".cmap")
+
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		cmap, err := ParseCmap(b)
+
+		if err != nil {
+			t.Errorf("%s: %v", test.font, err)
+			continue
+		}
+
+		if len(cmap.Diagnostics) != 0 {
+			t.Errorf("%s: unexpected diagnostics: %v", test.font, cmap.Diagnostics)
+		}
+
+		ranges := cmap.Ranges()
+		runes := 0
+
+		for _, r := range ranges {
+			runes += int(r.Last-r.First) + 1
+		}
+
+		if runes != test.runes || len(ranges) != test.ranges {
+			t.Errorf("%s: invalid coverage: %d runes in %d ranges != %d runes in %d ranges", test.font, runes, len(ranges), test.runes, test.ranges)
+		}
+
+		for _, test := range test.glyphs {
+			if g, ok := cmap.Lookup(test.r); g != test.g || ok != (test.g != 0) {
+				t.Errorf("%U: invalid glyph: %d, %t != %d", test.r, g, ok, test.g)
+			}
+		}
+	}
+}
+
+func TestCmapRanges(t *testing.T) {
+	b, err := os.ReadFile("testdata/cmapTest.cmap")
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	cmap, err := ParseCmap(b)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ranges := []RuneRange{
+		{0x30, 0x32}, {0x41, 0x42}, {0x61, 0x61}, {0xFF, 0x101}, {0x4E2D, 0x4E2D}, {0x1F0A1, 0x1F0A1}, {0x1F0B1, 0x1F0B2},
+	}
+
+	if r := cmap.Ranges(); !reflect.DeepEqual(r, ranges) {
+		t.Errorf("invalid ranges: %v", r)
+	}
+
+	if s := ranges[0].String(); s != "U+0030-U+0032" {
+		t.Error("invalid string representation of a range:", s)
+	}
+
+	if s := ranges[2].String(); s != "U+0061" {
+		t.Error("invalid string representation of a range:", s)
+	}
+}
+
+func TestCmapFormats(t *testing.T) {
+	format4 := cmapFormat4Body(
+		cmapSegment{start: 'A', end: 'C', first: 10},
+		cmapSegment{start: 'a', end: 'c', glyphs: []GlyphID{20, 0, 21}},
+	)
+	format6 := cmapFormat6Body(0x100, 5, 6, 0, 7)
+	format12 := cmapFormat12Body(12, cmapGroup{0x41, 0x43, 1}, cmapGroup{0x1F600, 0x1F64F, 100})
+	format13 := cmapFormat12Body(13, cmapGroup{0x41, 0x43, 1}, cmapGroup{0x1F600, 0x1F64F, 100})
+
+	tests := []struct {
+		name   string
+		table  []byte
+		glyphs map[rune]GlyphID
+		ranges []RuneRange
+	}{
+		{
+			name:   "format 4",
+			table:  cmapTable(cmapRecord{3, 1, format4}),
+			glyphs: map[rune]GlyphID{'A': 10, 'B': 11, 'C': 12, 'D': 0, 'a': 20, 'b': 0, 'c': 21, 0x10041: 0},
+			ranges: []RuneRange{{'A', 'C'}, {'a', 'a'}, {'c', 'c'}},
+		},
+		{
+			name:   "format 6",
+			table:  cmapTable(cmapRecord{0, 3, format6}),
+			glyphs: map[rune]GlyphID{0xFF: 0, 0x100: 5, 0x101: 6, 0x102: 0, 0x103: 7, 0x104: 0},
+			ranges: []RuneRange{{0x100, 0x101}, {0x103, 0x103}},
+		},
+		{
+			name:   "format 12",
+			table:  cmapTable(cmapRecord{3, 10, format12}),
+			glyphs: map[rune]GlyphID{'A': 1, 'C': 3, 'D': 0, 0x1F600: 100, 0x1F64F: 179, 0x1F650: 0},
+			ranges: []RuneRange{{'A', 'C'}, {0x1F600, 0x1F64F}},
+		},
+		{
+			name:   "format 13",
+			table:  cmapTable(cmapRecord{0, 6, format13}),
+			glyphs: map[rune]GlyphID{'A': 1, 'C': 1, 'D': 0, 0x1F600: 100, 0x1F64F: 100, 0x1F650: 0},
+			ranges: []RuneRange{{'A', 'C'}, {0x1F600, 0x1F64F}},
+		},
+		{
+			name:   "full repertoire is preferred",
+			table:  cmapTable(cmapRecord{3, 1, format4}, cmapRecord{3, 10, format12}),
+			glyphs: map[rune]GlyphID{'A': 1, 'a': 0, 0x1F600: 100},
+			ranges: []RuneRange{{'A', 'C'}, {0x1F600, 0x1F64F}},
+		},
+		{
+			name:   "mac roman is ignored",
+			table:  cmapTable(cmapRecord{1, 0, format6}, cmapRecord{3, 1, format4}),
+			glyphs: map[rune]GlyphID{'A': 10, 0x100: 0},
+			ranges: []RuneRange{{'A', 'C'}, {'a', 'a'}, {'c', 'c'}},
+		},
+		{
+			name:   "symbol",
+			table:  cmapTable(cmapRecord{3, 0, cmapFormat4Body(cmapSegment{start: 0xF041, end: 0xF042, first: 1})}),
+			glyphs: map[rune]GlyphID{'A': 1, 'B': 2, 'C': 0, 0xF041: 1},
+			ranges: []RuneRange{{0xF041, 0xF042}},
+		},
+	}
+
+	for _, test := range tests {
+		cmap, err := ParseCmap(test.table)
+
+		if err != nil {
+			t.Errorf("%s: %v", test.name, err)
+			continue
+		}
+
+		if len(cmap.Diagnostics) != 0 {
+			t.Errorf("%s: unexpected diagnostics: %v", test.name, cmap.Diagnostics)
+		}
+
+		for r, glyph := range test.glyphs {
+			if g, ok := cmap.Lookup(r); g != glyph || ok != (glyph != 0) {
+				t.Errorf("%s: %U: invalid glyph: %d, %t != %d", test.name, r, g, ok, glyph)
+			}
+		}
+
+		if ranges := cmap.Ranges(); !reflect.DeepEqual(ranges, test.ranges) {
+			t.Errorf("%s: invalid ranges: %v != %v", test.name, ranges, test.ranges)
+		}
+	}
+}
+
+func TestCmapVariations(t *testing.T) {
+	const (
+		vs1  = 0xFE00
+		vs16 = 0xFE0F
+	)
+
+	table := cmapTable(
+		cmapRecord{0, 3, cmapFormat4Body(cmapSegment{start: 0x2600, end: 0x2603, first: 50}, cmapSegment{start: '0', end: '9', first: 60})},
+		cmapRecord{0, 5, cmapFormat14Body(
+			cmapSelector{vs1, []cmapUVSRange{{'0', 9}}, nil},
+			cmapSelector{vs16, []cmapUVSRange{{0x2600, 1}}, []cmapUVSMapping{{0x2603, 99}}},
+		)},
+	)
+
+	cmap, err := ParseCmap(table)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(cmap.Diagnostics) != 0 {
+		t.Error("unexpected diagnostics:", cmap.Diagnostics)
+	}
+
+	if s := cmap.Selectors(); !reflect.DeepEqual(s, []rune{vs1, vs16}) {
+		t.Errorf("invalid variation selectors: %U", s)
+	}
+
+	tests := []struct {
+		r        rune
+		selector rune
+		glyph    GlyphID
+		ok       bool
+	}{
+		{'0', vs1, 60, true},
+		{'9', vs1, 69, true},
+		{'A', vs1, 0, false},
+		{0x2600, vs16, 50, true},
+		{0x2601, vs16, 51, true},
+		{0x2602, vs16, 0, false},
+		{0x2603, vs16, 99, true},
+		{0x2603, vs1, 0, false},
+		{0x2603, 0xFE01, 0, false},
+	}
+
+	for _, test := range tests {
+		if g, ok := cmap.LookupVariant(test.r, test.selector); g != test.glyph || ok != test.ok {
+			t.Errorf("%U %U: invalid glyph: %d, %t != %d, %t", test.r, test.selector, g, ok, test.glyph, test.ok)
+		}
+	}
+
+	// The variation sequences don't change the default mapping.
+	if g, _ := cmap.Lookup(0x2603); g != 53 {
+		t.Error("invalid glyph of a rune with a variation sequence:", g)
+	}
+
+	noVariations, err := ParseCmap(cmapTable(cmapRecord{0, 3, cmapFormat4Body(cmapSegment{start: '0', end: '9', first: 60})}))
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if g, ok := noVariations.LookupVariant('0', vs1); ok {
+		t.Error("variation sequence found in a table that has no format 14 subtable:", g)
+	}
+
+	if s := noVariations.Selectors(); s != nil {
+		t.Errorf("invalid variation selectors: %U", s)
+	}
+}
+
+func TestCmapDiagnostics(t *testing.T) {
+	format4 := cmapFormat4Body(cmapSegment{start: 'A', end: 'C', first: 10})
+
+	cmap, err := ParseCmap(cmapTable(
+		cmapRecord{0, 3, []byte{0, 2, 0, 6, 0, 0}},
+		cmapRecord{3, 1, format4},
+		cmapRecord{3, 10, cmapFormat12Body(12)[:12]},
+	))
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	diagnostics := []Diagnostic{
+		{"cmap", 0, "unsupported subtable format: 2"},
+		{"cmap", 2, "truncated format 12 header"},
+	}
+
+	if !reflect.DeepEqual(cmap.Diagnostics, diagnostics) {
+		t.Errorf("invalid diagnostics: %v", cmap.Diagnostics)
+	}
+
+	if g, _ := cmap.Lookup('B'); g != 11 {
+		t.Error("invalid glyph:", g)
+	}
+}
+
+func TestParseCmapError(t *testing.T) {
+	tests := [][]byte{
+		nil,
+		{0, 0, 0, 1},
+		cmapTable(),
+		cmapTable(cmapRecord{1, 0, cmapFormat6Body(0, 1)}),
+		cmapTable(cmapRecord{3, 1, []byte{0, 0, 1, 6, 0, 0}}),
+	}
+
+	for _, test := range tests {
+		if _, err := ParseCmap(test); !errors.Is(err, ErrInvalidTable) {
+			t.Errorf("%v: invalid error: %v", test, err)
+		}
+	}
+}
+
+type cmapRecord struct {
+	platform uint16
+	encoding uint16
+	body     []byte
+}
+
+func cmapTable(records ...cmapRecord) []byte {
+	b := appendUint16(nil, 0, uint16(len(records)))
+	off := 4 + 8*len(records)
+
+	for _, r := range records {
+		b = appendUint32(appendUint16(b, r.platform, r.encoding), uint32(off))
+		off += len(r.body)
+	}
+
+	for _, r := range records {
+		b = append(b, r.body...)
+	}
+
+	return b
+}
+
+// cmapSegment is a segment of a format 4 subtable, the glyphs of the runes are
+// computed from the first glyph if no glyphs are given.
+type cmapSegment struct {
+	start  rune
+	end    rune
+	first  GlyphID
+	glyphs []GlyphID
+}
+
+func cmapFormat4Body(segments ...cmapSegment) []byte {
+	segments = append(segments, cmapSegment{start: 0xFFFF, end: 0xFFFF, first: 1})
+	sort.Slice(segments, func(i, j int) bool { return segments[i].end < segments[j].end })
+	n := len(segments)
+	b := appendUint16(nil, 4, 0, 0, uint16(2*n), 0, 0, 0)
+
+	for _, s := range segments {
+		b = appendUint16(b, uint16(s.end))
+	}
+
+	b = appendUint16(b, 0)
+
+	for _, s := range segments {
+		b = appendUint16(b, uint16(s.start))
+	}
+
+	for _, s := range segments {
+		b = appendUint16(b, uint16(int(s.first)-int(s.start)))
+	}
+
+	// The glyph arrays are placed after the range offsets, in the order of
+	// the segments.
+	glyphs := []uint16{}
+
+	for i, s := range segments {
+		if s.glyphs == nil {
+			b = appendUint16(b, 0)
+			continue
+		}
+
+		b = appendUint16(b, uint16(2*(n-i)+2*len(glyphs)))
+
+		for _, g := range s.glyphs {
+			if g != 0 {
+				g = GlyphID(int(g) - (int(s.first) - int(s.start)))
+			}
+			glyphs = append(glyphs, uint16(g))
+		}
+	}
+
+	return appendUint16(b, glyphs...)
+}
+
+func cmapFormat6Body(first uint16, glyphs ...uint16) []byte {
+	return appendUint16(appendUint16(nil, 6, 0, 0, first, uint16(len(glyphs))), glyphs...)
+}
+
+type cmapGroup struct {
+	start uint32
+	end   uint32
+	glyph uint32
+}
+
+func cmapFormat12Body(format uint16, groups ...cmapGroup) []byte {
+	b := appendUint16(nil, format, 0)
+	b = appendUint32(b, uint32(16+12*len(groups)), 0, uint32(len(groups)))
+
+	for _, g := range groups {
+		b = appendUint32(b, g.start, g.end, g.glyph)
+	}
+
+	return b
+}
+
+type cmapUVSRange struct {
+	start rune
+	count uint8
+}
+
+type cmapUVSMapping struct {
+	r     rune
+	glyph GlyphID
+}
+
+type cmapSelector struct {
+	selector rune
+	defaults []cmapUVSRange
+	mappings []cmapUVSMapping
+}
+
+func cmapFormat14Body(selectors ...cmapSelector) []byte {
+	appendUint24 := func(b []byte, v rune) []byte {
+		return append(b, byte(v>>16), byte(v>>8), byte(v))
+	}
+
+	tables := []byte{}
+	records := []byte{}
+	off := 10 + 11*len(selectors)
+
+	for _, s := range selectors {
+		records = appendUint24(records, s.selector)
+
+		if s.defaults == nil {
+			records = appendUint32(records, 0)
+		} else {
+			records = appendUint32(records, uint32(off+len(tables)))
+			tables = appendUint32(tables, uint32(len(s.defaults)))
+
+			for _, r := range s.defaults {
+				tables = append(appendUint24(tables, r.start), r.count)
+			}
+		}
+
+		if s.mappings == nil {
+			records = appendUint32(records, 0)
+		} else {
+			records = appendUint32(records, uint32(off+len(tables)))
+			tables = appendUint32(tables, uint32(len(s.mappings)))
+
+			for _, m := range s.mappings {
+				tables = appendUint16(appendUint24(tables, m.r), uint16(m.glyph))
+			}
+		}
+	}
+
+	b := appendUint16(nil, 14)
+	b = appendUint32(b, uint32(off+len(tables)), uint32(len(selectors)))
+	return append(append(b, records...), tables...)
+}
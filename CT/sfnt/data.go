@@ -0,0 +1,44 @@
+package sfnt
+
+// data is a big-endian view of the bytes of a table, the methods return zero
+// when reading past the end so parsers only have to check lengths once, before
+// reading a structure.
+type data []byte
+
+func (d data) u8(off int) uint8 {
+	if off < 0 || off >= len(d) {
+		return 0
+	}
+	return d[off]
+}
+
+func (d data) u16(off int) uint16 {
+	if off < 0 || off+2 > len(d) {
+		return 0
+	}
+	return uint16(d[off])<<8 | uint16(d[off+1])
+}
+
+func (d data) i16(off int) int16 {
+	return int16(d.u16(off))
+}
+
+func (d data) u32(off int) uint32 {
+	if off < 0 || off+4 > len(d) {
+		return 0
+	}
+	return uint32(d[off])<<24 | uint32(d[off+1])<<16 | uint32(d[off+2])<<8 | uint32(d[off+3])
+}
+
+// has returns true if n bytes can be read at off.
+func (d data) has(off int, n int) bool {
+	return off >= 0 && n >= 0 && off <= len(d) && n <= len(d)-off
+}
+
+// slice returns the n bytes at off, or nil if they are out of bounds.
+func (d data) slice(off int, n int) data {
+	if !d.has(off, n) {
+		return nil
+	}
+	return d[off : off+n : off+n]
+}
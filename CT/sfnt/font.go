@@ -0,0 +1,110 @@
+package sfnt
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+)
+
+// ErrInvalidFont is returned when the header or the table directory of a font
+// file is malformed or truncated.
+var ErrInvalidFont = errors.New("sfnt: invalid font file")
+
+// Font is a parsed font file, it gives access to the raw bytes of the tables
+// of a TrueType or OpenType font.
+//
+// https://learn.microsoft.com/en-us/typography/opentype/spec/otff
+type Font struct {
+	tables map[string][]byte
+}
+
+// Versions of the font files and collections.
+const (
+	fontTrueType   = 0x00010000
+	fontOpenType   = 0x4F54544F // 'OTTO'
+	fontApple      = 0x74727565 // 'true'
+	fontCollection = 0x74746366 // 'ttcf'
+)
+
+// ParseFont parses the table directory of a font file, index is the index of
+// the font in the file if it's a font collection and is ignored otherwise.
+//
+// The tables are not parsed, the returned font keeps references to the bytes
+// of the file which must not be modified while it's in use.
+func ParseFont(b []byte, index int) (*Font, error) {
+	d := data(b)
+
+	if !d.has(0, 12) {
+		return nil, invalidFont("truncated header")
+	}
+
+	off := 0
+
+	if d.u32(0) == fontCollection {
+		n := int(d.u32(8))
+
+		if index < 0 || index >= n {
+			return nil, invalidFont("font index %d out of range in a collection of %d fonts", index, n)
+		}
+
+		if !d.has(12, 4*n) {
+			return nil, invalidFont("truncated collection header")
+		}
+
+		off = int(d.u32(12 + 4*index))
+	}
+
+	if !d.has(off, 12) {
+		return nil, invalidFont("truncated table directory")
+	}
+
+	switch v := d.u32(off); v {
+	case fontTrueType, fontOpenType, fontApple:
+	default:
+		return nil, invalidFont("unsupported version %#x", v)
+	}
+
+	n := int(d.u16(off + 4))
+	records := d.slice(off+12, 16*n)
+
+	if records == nil {
+		return nil, invalidFont("truncated directory of %d tables", n)
+	}
+
+	f := &Font{tables: make(map[string][]byte, n)}
+
+	for i := 0; i != n; i++ {
+		tag := string(records[16*i : 16*i+4])
+		table := d.slice(int(records.u32(16*i+8)), int(records.u32(16*i+12)))
+
+		if table == nil {
+			return nil, invalidFont("table %q is out of bounds", tag)
+		}
+
+		f.tables[tag] = table
+	}
+
+	return f, nil
+}
+
+// Table returns the bytes of the table with the given tag, or nil if the font
+// has no such table.
+func (f *Font) Table(tag string) []byte {
+	return f.tables[tag]
+}
+
+// Tags returns the sorted list of the tags of the tables in the font.
+func (f *Font) Tags() []string {
+	tags := make([]string, 0, len(f.tables))
+
+	for tag := range f.tables {
+		tags = append(tags, tag)
+	}
+
+	sort.Strings(tags)
+	return tags
+}
+
+func invalidFont(format string, args ...interface{}) error {
+	return fmt.Errorf("%w: %s", ErrInvalidFont, fmt.Sprintf(format, args...))
+}
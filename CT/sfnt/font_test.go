@@ -0,0 +1,120 @@
+package sfnt
+
+import (
+	"errors"
+	"os"
+	"reflect"
+	"testing"
+)
+
+func loadFont(t *testing.T, name string) *Font {
+	b, err := os.ReadFile("testdata/" + name)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	f, err := ParseFont(b, 0)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return f
+}
+
+func TestParseFont(t *testing.T) {
+	tests := []struct {
+		name string
+		tags []string
+	}{
+		{
+			name: "glyfTest.ttf",
+			tags: []string{"FFTM", "GDEF", "OS/2", "cmap", "cvt ", "gasp", "glyf", "head", "hhea", "hmtx", "loca", "maxp", "name", "post"},
+		},
+		{
+			name: "CFFTest.otf",
+			tags: []string{"CFF ", "FFTM", "GDEF", "OS/2", "cmap", "head", "hhea", "hmtx", "maxp", "name", "post"},
+		},
+	}
+
+	for _, test := range tests {
+		f := loadFont(t, test.name)
+
+		if tags := f.Tags(); !reflect.DeepEqual(tags, test.tags) {
+			t.Errorf("%s: invalid tables: %q", test.name, tags)
+		}
+
+		if head := f.Table("head"); len(head) != 54 {
+			t.Errorf("%s: invalid length of the 'head' table: %d", test.name, len(head))
+		}
+
+		if kern := f.Table("kern"); kern != nil {
+			t.Errorf("%s: unexpected 'kern' table", test.name)
+		}
+	}
+}
+
+func TestParseFontCollection(t *testing.T) {
+	b, err := os.ReadFile("testdata/CFFTest.otf")
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// A collection of two fonts which share the tables of the test font, the
+	// table offsets are relative to the beginning of the file so they are
+	// moved by the size of the collection header.
+	const header = 12 + 2*4
+	n := int(b[4])<<8 | int(b[5])
+	font := append([]byte(nil), b...)
+
+	for i := 0; i != n; i++ {
+		r := font[12+16*i+8:]
+		off := uint32(r[0])<<24 | uint32(r[1])<<16 | uint32(r[2])<<8 | uint32(r[3])
+		copy(r, appendUint32(nil, off+header))
+	}
+
+	ttc := appendUint32([]byte("ttcf"), 0x00010000, 2, header, header)
+	ttc = append(ttc, font...)
+
+	for _, index := range []int{0, 1} {
+		f, err := ParseFont(ttc, index)
+
+		if err != nil {
+			t.Error(index, err)
+			continue
+		}
+
+		if cff := f.Table("CFF "); len(cff) != 535 {
+			t.Errorf("font %d: invalid length of the 'CFF ' table: %d", index, len(cff))
+		}
+	}
+
+	if _, err := ParseFont(ttc, 2); !errors.Is(err, ErrInvalidFont) {
+		t.Error("invalid error for a font index out of range:", err)
+	}
+}
+
+func TestParseFontError(t *testing.T) {
+	b, err := os.ReadFile("testdata/glyfTest.ttf")
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := [][]byte{
+		nil,
+		b[:11],
+		append([]byte("wOFF"), b[4:]...),
+		b[:12+16*3],
+		b[:1000],
+		appendUint32([]byte("ttcf"), 0x00010000, 1),
+	}
+
+	for _, test := range tests {
+		if _, err := ParseFont(test, 0); !errors.Is(err, ErrInvalidFont) {
+			t.Errorf("invalid error for %d bytes: %v", len(test), err)
+		}
+	}
+}
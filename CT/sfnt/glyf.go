@@ -0,0 +1,380 @@
+package sfnt
+
+import "fmt"
+
+// GlyfTable is a parsed pair of 'glyf' and 'loca' tables, which hold the
+// quadratic outlines of TrueType fonts.
+//
+// Simple and composite glyphs are supported, composite glyphs which position
+// their components by matching points instead of offsets are not. Hinting
+// instructions are ignored.
+//
+// https://learn.microsoft.com/en-us/typography/opentype/spec/glyf
+//
+// https://learn.microsoft.com/en-us/typography/opentype/spec/loca
+type GlyfTable struct {
+	glyf    data
+	loca    data
+	format  int
+	nglyphs int
+}
+
+// Flags of the points of simple glyphs.
+const (
+	glyfOnCurve      = 0x01
+	glyfXShort       = 0x02
+	glyfYShort       = 0x04
+	glyfRepeat       = 0x08
+	glyfXSameOrPlus  = 0x10
+	glyfYSameOrPlus  = 0x20
+	glyfHeaderLength = 10
+)
+
+// Flags of the components of composite glyphs.
+const (
+	glyfArgsAreWords    = 0x0001
+	glyfArgsAreXY       = 0x0002
+	glyfScale           = 0x0008
+	glyfMoreComponents  = 0x0020
+	glyfXYScale         = 0x0040
+	glyfTwoByTwo        = 0x0080
+	glyfMaxCompoundLoad = 8
+)
+
+// ParseGlyf parses the content of the 'glyf' and 'loca' tables of a font,
+// format is the IndexToLocFormat of the 'head' table and glyphs the number of
+// glyphs given by the 'maxp' table.
+func ParseGlyf(glyf []byte, loca []byte, format int, glyphs int) (*GlyfTable, error) {
+	t := &GlyfTable{
+		glyf:    glyf,
+		format:  format,
+		nglyphs: glyphs,
+	}
+
+	size := 2
+
+	if format == 1 {
+		size = 4
+	}
+
+	if t.loca = data(loca).slice(0, size*(glyphs+1)); t.loca == nil {
+		return nil, invalidTable("loca", "truncated table of %d glyphs", glyphs)
+	}
+
+	return t, nil
+}
+
+// glyph returns the data of the glyph, which is empty for glyphs that have no
+// outline.
+func (t *GlyfTable) glyph(glyph GlyphID) (data, error) {
+	i := int(glyph)
+
+	if i >= t.nglyphs {
+		return nil, fmt.Errorf("sfnt: glyph %d out of range in a font of %d glyphs", i, t.nglyphs)
+	}
+
+	var start, end int
+
+	if t.format == 0 {
+		start, end = 2*int(t.loca.u16(2*i)), 2*int(t.loca.u16(2*i+2))
+	} else {
+		start, end = int(t.loca.u32(4*i)), int(t.loca.u32(4*i+4))
+	}
+
+	d := t.glyf.slice(start, end-start)
+
+	if d == nil {
+		return nil, fmt.Errorf("sfnt: glyph %d is out of bounds of the 'glyf' table", i)
+	}
+
+	if len(d) != 0 && len(d) < glyfHeaderLength {
+		return nil, fmt.Errorf("sfnt: truncated header of glyph %d", i)
+	}
+
+	return d, nil
+}
+
+// Bounds returns the bounding box of the glyph stored in the 'glyf' table, the
+// boolean is false if the glyph has no outline.
+func (t *GlyfTable) Bounds(glyph GlyphID) (min Point, max Point, ok bool) {
+	d, err := t.glyph(glyph)
+
+	if err != nil || len(d) == 0 {
+		return
+	}
+
+	min = Point{X: float32(d.i16(2)), Y: float32(d.i16(4))}
+	max = Point{X: float32(d.i16(6)), Y: float32(d.i16(8))}
+	return min, max, true
+}
+
+// Outline satisfies the Outliner interface.
+func (t *GlyfTable) Outline(glyph GlyphID) (Outline, error) {
+	b := &outlineBuilder{}
+
+	if err := t.load(b, glyph, 0); err != nil {
+		return nil, err
+	}
+
+	return b.outline, nil
+}
+
+func (t *GlyfTable) load(b *outlineBuilder, glyph GlyphID, depth int) error {
+	d, err := t.glyph(glyph)
+
+	switch {
+	case err != nil:
+		return err
+	case len(d) == 0:
+		return nil
+	case d.i16(0) >= 0:
+		return loadSimpleGlyph(b, d, glyph)
+	case depth == glyfMaxCompoundLoad:
+		return fmt.Errorf("sfnt: glyph %d: too many levels of composite glyphs", glyph)
+	default:
+		return t.loadCompositeGlyph(b, d, glyph, depth)
+	}
+}
+
+type glyfPoint struct {
+	Point
+	on bool
+}
+
+func loadSimpleGlyph(b *outlineBuilder, d data, glyph GlyphID) error {
+	contours := int(d.i16(0))
+	off := glyfHeaderLength
+	ends := d.u16Array(off, contours)
+
+	if ends == nil {
+		return fmt.Errorf("sfnt: glyph %d: truncated list of %d contours", glyph, contours)
+	}
+
+	off += 2 * contours
+	off += 2 + int(d.u16(off))
+
+	if contours == 0 {
+		return nil
+	}
+
+	n := ends[contours-1] + 1
+	points, err := readGlyfPoints(d, off, n)
+
+	if err != nil {
+		return fmt.Errorf("sfnt: glyph %d: %v", glyph, err)
+	}
+
+	start := 0
+
+	for _, end := range ends {
+		if end < start || end >= n {
+			return fmt.Errorf("sfnt: glyph %d: invalid end of contour: %d", glyph, end)
+		}
+
+		appendContour(b, points[start:end+1])
+		start = end + 1
+	}
+
+	return nil
+}
+
+// readGlyfPoints decodes the n points that start at off, which are stored as
+// a list of flags followed by the lists of x and y coordinates.
+func readGlyfPoints(d data, off int, n int) ([]glyfPoint, error) {
+	if !d.has(off, 0) {
+		return nil, fmt.Errorf("truncated instructions")
+	}
+
+	points := make([]glyfPoint, n)
+	flags := make([]uint8, n)
+
+	for i := 0; i < n; {
+		if !d.has(off, 1) {
+			return nil, fmt.Errorf("truncated list of flags")
+		}
+
+		f := d.u8(off)
+		off++
+		repeat := 1
+
+		if f&glyfRepeat != 0 {
+			repeat += int(d.u8(off))
+			off++
+		}
+
+		for ; repeat != 0 && i < n; repeat-- {
+			flags[i] = f
+			i++
+		}
+	}
+
+	readCoordinates := func(short uint8, same uint8, set func(int, float32)) error {
+		v := 0
+
+		for i, f := range flags {
+			switch {
+			case f&short != 0:
+				if f&same != 0 {
+					v += int(d.u8(off))
+				} else {
+					v -= int(d.u8(off))
+				}
+				off++
+			case f&same == 0:
+				v += int(d.i16(off))
+				off += 2
+			}
+
+			set(i, float32(v))
+		}
+
+		if !d.has(off, 0) {
+			return fmt.Errorf("truncated list of coordinates")
+		}
+
+		return nil
+	}
+
+	if err := readCoordinates(glyfXShort, glyfXSameOrPlus, func(i int, x float32) { points[i].X = x }); err != nil {
+		return nil, err
+	}
+
+	if err := readCoordinates(glyfYShort, glyfYSameOrPlus, func(i int, y float32) { points[i].Y = y }); err != nil {
+		return nil, err
+	}
+
+	for i, f := range flags {
+		points[i].on = f&glyfOnCurve != 0
+	}
+
+	return points, nil
+}
+
+// appendContour converts a contour made of on-curve and off-curve points to
+// segments, two consecutive off-curve points have an implied on-curve point
+// in their middle.
+func appendContour(b *outlineBuilder, points []glyfPoint) {
+	if len(points) == 0 {
+		return
+	}
+
+	// The contour starts at its first point if it's on-curve, at the second
+	// point if it's on-curve, or at the middle of the first two points.
+	var start Point
+	var control *Point
+
+	switch {
+	case points[0].on:
+		start = points[0].Point
+		points = points[1:]
+	case len(points) == 1:
+		start = points[0].Point
+		points = nil
+	case points[1].on:
+		start = points[1].Point
+		points = append(points[2:len(points):len(points)], points[0])
+	default:
+		start = midPoint(points[0].Point, points[1].Point)
+		points = append(points[1:len(points):len(points)], points[0])
+	}
+
+	b.moveTo(start)
+
+	for i := range points {
+		p := points[i]
+
+		switch {
+		case p.on && control == nil:
+			b.lineTo(p.Point)
+		case p.on:
+			b.quadTo(*control, p.Point)
+			control = nil
+		case control != nil:
+			b.quadTo(*control, midPoint(*control, p.Point))
+			control = &points[i].Point
+		default:
+			control = &points[i].Point
+		}
+	}
+
+	if control != nil {
+		b.quadTo(*control, start)
+	}
+
+	b.closePath()
+}
+
+func midPoint(p Point, q Point) Point {
+	return Point{X: (p.X + q.X) / 2, Y: (p.Y + q.Y) / 2}
+}
+
+func (t *GlyfTable) loadCompositeGlyph(b *outlineBuilder, d data, glyph GlyphID, depth int) error {
+	off := glyfHeaderLength
+
+	for {
+		if !d.has(off, 4) {
+			return fmt.Errorf("sfnt: glyph %d: truncated component", glyph)
+		}
+
+		flags := d.u16(off)
+		component := GlyphID(d.u16(off + 2))
+		off += 4
+
+		if flags&glyfArgsAreXY == 0 {
+			return fmt.Errorf("sfnt: glyph %d: components positioned by matching points are not supported", glyph)
+		}
+
+		var dx, dy float32
+
+		if flags&glyfArgsAreWords != 0 {
+			dx, dy = float32(d.i16(off)), float32(d.i16(off+2))
+			off += 4
+		} else {
+			dx, dy = float32(int8(d.u8(off))), float32(int8(d.u8(off+1)))
+			off += 2
+		}
+
+		// The transformation is made of 2.14 fixed point values.
+		xx, xy, yx, yy := float32(1), float32(0), float32(0), float32(1)
+		f2dot14 := func(off int) float32 { return float32(d.i16(off)) / 0x4000 }
+
+		switch {
+		case flags&glyfScale != 0:
+			xx = f2dot14(off)
+			yy = xx
+			off += 2
+		case flags&glyfXYScale != 0:
+			xx, yy = f2dot14(off), f2dot14(off+2)
+			off += 4
+		case flags&glyfTwoByTwo != 0:
+			xx, xy, yx, yy = f2dot14(off), f2dot14(off+2), f2dot14(off+4), f2dot14(off+6)
+			off += 8
+		}
+
+		if !d.has(off, 0) {
+			return fmt.Errorf("sfnt: glyph %d: truncated component", glyph)
+		}
+
+		n := len(b.outline)
+
+		if err := t.load(b, component, depth+1); err != nil {
+			return err
+		}
+
+		for i := n; i != len(b.outline); i++ {
+			s := &b.outline[i]
+
+			for j := range s.Args[:s.Op.points()] {
+				p := s.Args[j]
+				s.Args[j] = Point{
+					X: xx*p.X + yx*p.Y + dx,
+					Y: xy*p.X + yy*p.Y + dy,
+				}
+			}
+		}
+
+		if flags&glyfMoreComponents == 0 {
+			return nil
+		}
+	}
+}
@@ -0,0 +1,178 @@
+package sfnt
+
+import (
+	"errors"
+	"testing"
+)
+
+func loadGlyf(t *testing.T) (*GlyfTable, *Font) {
+	f := loadFont(t, "glyfTest.ttf")
+	head, err := ParseHead(f.Table("head"))
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	glyphs, err := ParseMaxp(f.Table("maxp"))
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	glyf, err := ParseGlyf(f.Table("glyf"), f.Table("loca"), head.IndexToLocFormat, glyphs)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return glyf, f
+}
+
+func TestGlyfOutline(t *testing.T) {
+	glyf, _ := loadGlyf(t)
+	square := rectangle(0, 0, 400, 100)
+
+	tests := []struct {
+		glyph   GlyphID
+		outline Outline
+	}{
+		{
+			glyph: 0,
+			outline: Outline{
+				moveTo(68, 0), lineTo(68, 1365), lineTo(612, 1365), lineTo(612, 0), lineTo(68, 0),
+				moveTo(136, 68), lineTo(544, 68), lineTo(544, 1297), lineTo(136, 1297), lineTo(136, 68),
+			},
+		},
+		{
+			glyph: 1,
+		},
+		{
+			glyph: 2,
+		},
+		{
+			glyph: 3,
+			outline: Outline{
+				moveTo(614, 1434),
+				quadTo(369, 1434, 369, 614),
+				quadTo(369, 471, 435, 338),
+				quadTo(502, 205, 614, 205),
+				quadTo(860, 205, 860, 1024),
+				quadTo(860, 1167, 793, 1300),
+				quadTo(727, 1434, 614, 1434),
+				moveTo(614, 1638),
+				quadTo(1024, 1638, 1024, 819),
+				quadTo(1024, 0, 614, 0),
+				quadTo(205, 0, 205, 819),
+				quadTo(205, 1638, 614, 1638),
+			},
+		},
+		{
+			glyph:   4,
+			outline: rectangle(205, 0, 614, 1638),
+		},
+		{
+			glyph:   5,
+			outline: square,
+		},
+		{
+			// Composite glyph with offset components.
+			glyph:   6,
+			outline: append(append(Outline{}, square...), rectangle(316, 234, 725, 1872)...),
+		},
+		{
+			// Composite glyph with a scaled component.
+			glyph: 7,
+			outline: append(append(Outline{}, square...),
+				moveTo(158.5, 117), lineTo(158.5, 936), lineTo(363, 936), lineTo(363, 117), lineTo(158.5, 117),
+			),
+		},
+	}
+
+	for _, test := range tests {
+		outline, err := glyf.Outline(test.glyph)
+
+		if err != nil {
+			t.Errorf("glyph %d: %v", test.glyph, err)
+			continue
+		}
+
+		if !equalOutlines(outline, test.outline) {
+			t.Errorf("glyph %d: invalid outline:\n%v\n%v", test.glyph, outline, test.outline)
+		}
+	}
+}
+
+func TestGlyfTransforms(t *testing.T) {
+	glyf, _ := loadGlyf(t)
+
+	// Glyphs 8 and 9 are scaled along each axis and by a 2x2 matrix, only their
+	// bounds are checked since their coordinates are fractional.
+	tests := []struct {
+		glyph GlyphID
+		min   Point
+		max   Point
+	}{
+		{glyph: 8, min: Point{0, 0}, max: Point{977, 1872}},
+		{glyph: 9, min: Point{0, 0}, max: Point{1676, 1984}},
+	}
+
+	for _, test := range tests {
+		outline, err := glyf.Outline(test.glyph)
+
+		if err != nil {
+			t.Errorf("glyph %d: %v", test.glyph, err)
+			continue
+		}
+
+		min, max, _ := outline.Bounds()
+
+		if min.X < test.min.X-1 || min.Y < test.min.Y-1 || max.X > test.max.X+1 || max.Y > test.max.Y+1 {
+			t.Errorf("glyph %d: outline out of bounds: %v, %v", test.glyph, min, max)
+		}
+	}
+}
+
+func TestGlyfBounds(t *testing.T) {
+	glyf, _ := loadGlyf(t)
+
+	tests := []struct {
+		glyph GlyphID
+		min   Point
+		max   Point
+		ok    bool
+	}{
+		{glyph: 0, min: Point{68, 0}, max: Point{612, 1365}, ok: true},
+		{glyph: 1},
+		{glyph: 4, min: Point{205, 0}, max: Point{614, 1638}, ok: true},
+		{glyph: 10},
+	}
+
+	for _, test := range tests {
+		if min, max, ok := glyf.Bounds(test.glyph); min != test.min || max != test.max || ok != test.ok {
+			t.Errorf("glyph %d: invalid bounds: %v, %v, %t", test.glyph, min, max, ok)
+		}
+	}
+}
+
+func TestParseGlyfError(t *testing.T) {
+	glyf, f := loadGlyf(t)
+
+	if _, err := ParseGlyf(f.Table("glyf"), f.Table("loca")[:21], 0, 10); !errors.Is(err, ErrInvalidTable) {
+		t.Error("invalid error for a truncated 'loca' table:", err)
+	}
+
+	if _, err := glyf.Outline(10); err == nil {
+		t.Error("no error for a glyph out of range")
+	}
+
+	// Truncating the 'glyf' table leaves the last glyphs out of bounds.
+	truncated, err := ParseGlyf(f.Table("glyf")[:100], f.Table("loca"), 0, 10)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := truncated.Outline(4); err == nil {
+		t.Error("no error for a glyph out of bounds")
+	}
+}
@@ -0,0 +1,518 @@
+package sfnt
+
+import (
+	"fmt"
+	"sort"
+)
+
+// GPOSTable is a parsed 'GPOS' table, which holds the glyph positioning rules
+// of OpenType fonts.
+//
+// Only the pair adjustment lookups (type 2, possibly wrapped in extension
+// lookups of type 9) referenced by the 'kern' feature are supported, which is
+// what's needed to kern pairs of glyphs. Lookups of the 'kern' feature that
+// have other types, or that are malformed, are skipped and reported in
+// Diagnostics.
+//
+// https://learn.microsoft.com/en-us/typography/opentype/spec/gpos
+type GPOSTable struct {
+	// Diagnostics lists the parts of the table that were skipped when it was
+	// parsed.
+	Diagnostics []Diagnostic
+
+	data     data
+	scripts  tagRecords
+	features tagRecords
+	lookups  map[int]*gposLookup
+}
+
+// ValueRecord holds the adjustments applied to the position of a glyph, in
+// font units.
+//
+// The device table adjustments of OpenType value records are not supported.
+type ValueRecord struct {
+	XPlacement int
+	YPlacement int
+	XAdvance   int
+	YAdvance   int
+}
+
+// Add returns the sum of v and w.
+func (v ValueRecord) Add(w ValueRecord) ValueRecord {
+	return ValueRecord{
+		XPlacement: v.XPlacement + w.XPlacement,
+		YPlacement: v.YPlacement + w.YPlacement,
+		XAdvance:   v.XAdvance + w.XAdvance,
+		YAdvance:   v.YAdvance + w.YAdvance,
+	}
+}
+
+type gposLookup struct {
+	subtables []pairPos
+}
+
+// pairPos is a pair adjustment positioning subtable.
+type pairPos struct {
+	format   int
+	data     data
+	coverage coverage
+	format1  int
+	format2  int
+
+	// Format 1
+	pairSets []int
+
+	// Format 2
+	classDef1 classDef
+	classDef2 classDef
+	class1    int
+	class2    int
+	records   data
+}
+
+// Bits of the value formats of pair positioning subtables.
+const (
+	valueXPlacement = 0x0001
+	valueYPlacement = 0x0002
+	valueXAdvance   = 0x0004
+	valueYAdvance   = 0x0008
+)
+
+// Lookup types of the GPOS table.
+const (
+	gposPairAdjustment = 2
+	gposExtension      = 9
+)
+
+// ParseGPOS parses the content of a 'GPOS' table.
+//
+// The function returns an error wrapping ErrInvalidTable if the table header
+// or the script, feature and lookup lists cannot be read.
+func ParseGPOS(b []byte) (*GPOSTable, error) {
+	d := data(b)
+
+	if !d.has(0, 10) {
+		return nil, invalidTable("GPOS", "truncated header")
+	}
+
+	if major := d.u16(0); major != 1 {
+		return nil, invalidTable("GPOS", "unsupported version %d.%d", major, d.u16(2))
+	}
+
+	t := &GPOSTable{
+		data:    d,
+		lookups: make(map[int]*gposLookup),
+	}
+
+	var err error
+
+	if t.scripts, err = parseTagRecords(d, int(d.u16(4))); err != nil {
+		return nil, invalidTable("GPOS", "script list: %v", err)
+	}
+
+	if t.features, err = parseTagRecords(d, int(d.u16(6))); err != nil {
+		return nil, invalidTable("GPOS", "feature list: %v", err)
+	}
+
+	lookupList := int(d.u16(8))
+
+	if !d.has(lookupList, 2) {
+		return nil, invalidTable("GPOS", "lookup list offset out of bounds: %d", lookupList)
+	}
+
+	lookupOffsets := d.u16Array(lookupList+2, int(d.u16(lookupList)))
+
+	if lookupOffsets == nil {
+		return nil, invalidTable("GPOS", "truncated lookup list")
+	}
+
+	for i := 0; i != t.features.count; i++ {
+		if t.features.tag(i) != "kern" {
+			continue
+		}
+
+		for _, index := range t.featureLookups(i) {
+			if _, done := t.lookups[index]; done {
+				continue
+			}
+
+			if index >= len(lookupOffsets) {
+				t.diagnose("feature %d references lookup %d which doesn't exist", i, index)
+				continue
+			}
+
+			lookup, err := t.parseLookup(lookupList + lookupOffsets[index])
+
+			if err != nil {
+				t.diagnose("lookup %d: %v", index, err)
+			}
+
+			t.lookups[index] = lookup
+		}
+	}
+
+	return t, nil
+}
+
+func (t *GPOSTable) diagnose(format string, args ...interface{}) {
+	t.Diagnostics = append(t.Diagnostics, Diagnostic{
+		Table:    "GPOS",
+		Subtable: -1,
+		Message:  fmt.Sprintf(format, args...),
+	})
+}
+
+// featureLookups returns the indexes of the lookups of the feature at index i
+// in the feature list.
+func (t *GPOSTable) featureLookups(i int) []int {
+	off := int(t.data.u16(6)) + t.features.offset(i)
+
+	if !t.data.has(off, 4) {
+		t.diagnose("feature %d: offset out of bounds: %d", i, off)
+		return nil
+	}
+
+	lookups := t.data.u16Array(off+4, int(t.data.u16(off+2)))
+
+	if lookups == nil {
+		t.diagnose("feature %d: truncated list of lookups", i)
+	}
+
+	return lookups
+}
+
+// parseLookup parses the lookup table at off, it returns the pair adjustment
+// subtables that it was able to parse even if an error occurred, so the valid
+// parts of the lookup can be used.
+func (t *GPOSTable) parseLookup(off int) (*gposLookup, error) {
+	d := t.data
+	lookup := &gposLookup{}
+
+	if !d.has(off, 6) {
+		return lookup, fmt.Errorf("offset out of bounds: %d", off)
+	}
+
+	lookupType := int(d.u16(off))
+	offsets := d.u16Array(off+6, int(d.u16(off+4)))
+
+	if offsets == nil {
+		return lookup, fmt.Errorf("truncated list of subtables")
+	}
+
+	if lookupType != gposPairAdjustment && lookupType != gposExtension {
+		return lookup, fmt.Errorf("unsupported lookup type: %d", lookupType)
+	}
+
+	for i, subOffset := range offsets {
+		sub := off + subOffset
+
+		if lookupType == gposExtension {
+			if !d.has(sub, 8) || d.u16(sub) != 1 {
+				return lookup, fmt.Errorf("subtable %d: invalid extension subtable", i)
+			}
+
+			if extType := int(d.u16(sub + 2)); extType != gposPairAdjustment {
+				return lookup, fmt.Errorf("subtable %d: unsupported extension lookup type: %d", i, extType)
+			}
+
+			sub += int(d.u32(sub + 4))
+		}
+
+		p, err := parsePairPos(d, sub)
+
+		if err != nil {
+			return lookup, fmt.Errorf("subtable %d: %v", i, err)
+		}
+
+		lookup.subtables = append(lookup.subtables, p)
+	}
+
+	return lookup, nil
+}
+
+func parsePairPos(d data, off int) (pairPos, error) {
+	if !d.has(off, 10) {
+		return pairPos{}, fmt.Errorf("offset out of bounds: %d", off)
+	}
+
+	// Offsets are relative to the beginning of the subtable.
+	d = d[off:]
+	p := pairPos{
+		format:  int(d.u16(0)),
+		data:    d,
+		format1: int(d.u16(4)),
+		format2: int(d.u16(6)),
+	}
+
+	var err error
+
+	if p.coverage, err = parseCoverage(d, int(d.u16(2))); err != nil {
+		return p, err
+	}
+
+	switch p.format {
+	case 1:
+		if p.pairSets = d.u16Array(10, int(d.u16(8))); p.pairSets == nil {
+			return p, fmt.Errorf("truncated list of pair sets")
+		}
+
+	case 2:
+		if !d.has(0, 16) {
+			return p, fmt.Errorf("truncated pair positioning header")
+		}
+
+		if p.classDef1, err = parseClassDef(d, int(d.u16(8))); err != nil {
+			return p, err
+		}
+
+		if p.classDef2, err = parseClassDef(d, int(d.u16(10))); err != nil {
+			return p, err
+		}
+
+		p.class1 = int(d.u16(12))
+		p.class2 = int(d.u16(14))
+
+		if p.records = d.slice(16, p.class1*p.class2*p.recordSize()); p.records == nil {
+			return p, fmt.Errorf("truncated array of %dx%d class records", p.class1, p.class2)
+		}
+
+	default:
+		return p, fmt.Errorf("unsupported pair positioning format: %d", p.format)
+	}
+
+	return p, nil
+}
+
+// valueRecordSize returns the size of the value records of the given format.
+func valueRecordSize(format int) int {
+	n := 0
+
+	for bits := format & 0xFF; bits != 0; bits &= bits - 1 {
+		n += 2
+	}
+
+	return n
+}
+
+func (p *pairPos) recordSize() int {
+	return valueRecordSize(p.format1) + valueRecordSize(p.format2)
+}
+
+func readValueRecord(d data, off int, format int) ValueRecord {
+	v := ValueRecord{}
+
+	for bit := 1; bit <= 0x80; bit <<= 1 {
+		if (format & bit) == 0 {
+			continue
+		}
+
+		switch bit {
+		case valueXPlacement:
+			v.XPlacement = int(d.i16(off))
+		case valueYPlacement:
+			v.YPlacement = int(d.i16(off))
+		case valueXAdvance:
+			v.XAdvance = int(d.i16(off))
+		case valueYAdvance:
+			v.YAdvance = int(d.i16(off))
+		}
+
+		off += 2
+	}
+
+	return v
+}
+
+// lookup returns the adjustments of the first and second glyph of the pair,
+// the boolean is false if the subtable doesn't apply to the pair.
+func (p *pairPos) lookup(first GlyphID, second GlyphID) (ValueRecord, ValueRecord, bool) {
+	index, ok := p.coverage.index(first)
+
+	if !ok {
+		return ValueRecord{}, ValueRecord{}, false
+	}
+
+	size1 := valueRecordSize(p.format1)
+	size := p.recordSize()
+
+	switch p.format {
+	case 1:
+		if index >= len(p.pairSets) {
+			break
+		}
+
+		off := p.pairSets[index]
+		n := int(p.data.u16(off))
+		set := p.data.slice(off+2, n*(2+size))
+
+		if set == nil {
+			break
+		}
+
+		i := sort.Search(n, func(i int) bool { return set.u16(i*(2+size)) >= uint16(second) })
+
+		if i < n && set.u16(i*(2+size)) == uint16(second) {
+			off := i*(2+size) + 2
+			return readValueRecord(set, off, p.format1), readValueRecord(set, off+size1, p.format2), true
+		}
+
+	case 2:
+		c1 := p.classDef1.class(first)
+		c2 := p.classDef2.class(second)
+
+		if c1 < p.class1 && c2 < p.class2 {
+			off := (c1*p.class2 + c2) * size
+			return readValueRecord(p.records, off, p.format1), readValueRecord(p.records, off+size1, p.format2), true
+		}
+	}
+
+	return ValueRecord{}, ValueRecord{}, false
+}
+
+// Scripts returns the tags of the scripts that the table has positioning rules
+// for, for example "latn" or "DFLT".
+func (t *GPOSTable) Scripts() []string {
+	return t.scripts.tags()
+}
+
+// Languages returns the tags of the language systems that the table has
+// specific rules for, in the given script.
+func (t *GPOSTable) Languages(script string) []string {
+	off, ok := t.script(script)
+
+	if !ok {
+		return nil
+	}
+
+	langs, err := parseTagRecords(t.data, off+2)
+
+	if err != nil {
+		return nil
+	}
+
+	return langs.tags()
+}
+
+func (t *GPOSTable) script(tag string) (int, bool) {
+	off, ok := t.scripts.find(tag)
+	return int(t.data.u16(4)) + off, ok
+}
+
+// Kerning returns the pair kerning rules of the 'kern' feature for the given
+// script and language tags, or nil if the table has no kerning for them.
+//
+// When the table has no rules for the script, the "DFLT" and "latn" scripts
+// are tried in that order, which is what text shaping engines do. The default
+// language system of the script is used when language is empty or when the
+// script has no rules specific to the language, and the next script is tried
+// when the script has no default language system either.
+func (t *GPOSTable) Kerning(script string, language string) *GPOSKerning {
+	if t == nil {
+		return nil
+	}
+
+	for _, tag := range []string{script, "DFLT", "latn"} {
+		off, ok := t.script(tag)
+
+		if !ok {
+			continue
+		}
+
+		if langSys, ok := t.langSys(off, language); ok {
+			return t.kerning(langSys)
+		}
+	}
+
+	return nil
+}
+
+// langSys returns the offset of the language system table of the script at off.
+func (t *GPOSTable) langSys(script int, language string) (int, bool) {
+	if language != "" {
+		if langs, err := parseTagRecords(t.data, script+2); err == nil {
+			if off, ok := langs.find(language); ok {
+				return script + off, true
+			}
+		}
+	}
+
+	if off := int(t.data.u16(script)); off != 0 {
+		return script + off, true
+	}
+
+	return 0, false
+}
+
+func (t *GPOSTable) kerning(langSys int) *GPOSKerning {
+	d := t.data
+
+	if !d.has(langSys, 6) {
+		return nil
+	}
+
+	features := d.u16Array(langSys+6, int(d.u16(langSys+4)))
+
+	if required := int(d.u16(langSys + 2)); required != 0xFFFF {
+		features = append(features, required)
+	}
+
+	indexes := []int{}
+
+	for _, i := range features {
+		if i < t.features.count && t.features.tag(i) == "kern" {
+			indexes = append(indexes, t.featureLookups(i)...)
+		}
+	}
+
+	// Lookups are applied in the order of the lookup list, each only once.
+	sort.Ints(indexes)
+	k := &GPOSKerning{}
+
+	for i, index := range indexes {
+		if lookup := t.lookups[index]; lookup != nil && (i == 0 || index != indexes[i-1]) {
+			k.lookups = append(k.lookups, lookup)
+		}
+	}
+
+	if len(k.lookups) == 0 {
+		return nil
+	}
+
+	return k
+}
+
+// GPOSKerning holds the pair adjustment lookups of the 'kern' feature of a
+// GPOS table, for a specific script and language.
+type GPOSKerning struct {
+	lookups []*gposLookup
+}
+
+// Pair returns the adjustments of the positions of the two glyphs of the pair
+// passed as arguments, the boolean is false if no rules apply to the pair.
+//
+// Each lookup is applied by its first subtable that covers the pair, the
+// adjustments of all lookups are added together.
+func (k *GPOSKerning) Pair(left GlyphID, right GlyphID) (first ValueRecord, second ValueRecord, ok bool) {
+	if k == nil {
+		return
+	}
+
+	for _, lookup := range k.lookups {
+		for i := range lookup.subtables {
+			if v1, v2, found := lookup.subtables[i].lookup(left, right); found {
+				first, second, ok = first.Add(v1), second.Add(v2), true
+				break
+			}
+		}
+	}
+
+	return
+}
+
+// Kern returns the adjustment of the space between the pair of glyphs passed
+// as arguments in font units, which is the horizontal advance adjustment of
+// the first glyph.
+func (k *GPOSKerning) Kern(left GlyphID, right GlyphID) int {
+	first, _, _ := k.Pair(left, right)
+	return first.XAdvance
+}
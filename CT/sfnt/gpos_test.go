@@ -0,0 +1,594 @@
+package sfnt
+
+import (
+	"errors"
+	"os"
+	"reflect"
+	"sort"
+	"testing"
+)
+
+func TestParseGPOSFonts(t *testing.T) {
+	type pair struct {
+		name  string
+		left  GlyphID
+		right GlyphID
+		kern  int
+	}
+
+	tests := []struct {
+		font    string
+		scripts []string
+		pairs   []pair
+	}{
+		{
+			font:    "Roboto-Regular",
+			scripts: []string{"DFLT", "cyrl", "grek", "latn"},
+			pairs: []pair{
+				{"AV", 37, 58, -87},
+				{"VA", 58, 37, -75},
+				{"AT", 37, 56, -129},
+				{"To", 56, 83, -99},
+				{"Yo", 61, 83, -65},
+				{"LT", 48, 56, -275},
+				{"Av", 37, 90, -50},
+				{"F.", 42, 18, -234},
+				{"Ty", 56, 93, -72},
+				{"AA", 37, 37, 0},
+				{"ab", 69, 70, 0},
+				{"P,", 52, 16, -324},
+				{"r.", 86, 18, -123},
+				{"Wa", 59, 69, -33},
+				{"ΑΥ", 910, 922, -94},
+				{"“A", 392, 37, -120},
+				{"y.", 93, 18, -107},
+			},
+		},
+		{
+			font: "DejaVuSansCondensed",
+			scripts: []string{
+				"DFLT", "arab", "armn", "brai", "cans", "cher", "cyrl", "geor", "grek", "hani",
+				"hebr", "kana", "lao ", "latn", "math", "nko ", "ogam", "runr", "tfng", "thai",
+			},
+			pairs: []pair{
+				{"AV", 36, 57, -131},
+				{"VA", 57, 36, -131},
+				{"AT", 36, 55, -159},
+				{"To", 55, 82, -348},
+				{"Yo", 60, 82, -272},
+				{"LT", 47, 55, -282},
+				{"Av", 36, 89, -120},
+				{"F.", 41, 17, -329},
+				{"Ty", 55, 92, -319},
+				{"AA", 36, 36, 57},
+				{"ab", 68, 69, 0},
+				{"P,", 51, 15, 0},
+				{"r.", 85, 17, -188},
+				{"Wa", 58, 68, -131},
+				{"ΑΥ", 807, 826, 0},
+				{"“A", 2815, 36, -264},
+				{"y.", 92, 17, -292},
+			},
+		},
+	}
+
+	for _, test := range tests {
+		b, err := os.ReadFile("testdata/" + test.font + ".GPOS")
+
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		gpos, err := ParseGPOS(b)
+
+		if err != nil {
+			t.Errorf("%s: %v", test.font, err)
+			continue
+		}
+
+		if len(gpos.Diagnostics) != 0 {
+			t.Errorf("%s: unexpected diagnostics: %v", test.font, gpos.Diagnostics)
+		}
+
+		if scripts := gpos.Scripts(); !reflect.DeepEqual(scripts, test.scripts) {
+			t.Errorf("%s: invalid scripts: %q", test.font, scripts)
+		}
+
+		k := gpos.Kerning("latn", "")
+
+		if k == nil {
+			t.Errorf("%s: no kerning found for the latin script", test.font)
+			continue
+		}
+
+		for _, p := range test.pairs {
+			if kern := k.Kern(p.left, p.right); kern != p.kern {
+				t.Errorf("%s: %s (%d, %d): invalid kerning: %d != %d", test.font, p.name, p.left, p.right, kern, p.kern)
+			}
+		}
+	}
+}
+
+func TestGPOSLanguages(t *testing.T) {
+	b, err := os.ReadFile("testdata/DejaVuSansCondensed.GPOS")
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	gpos, err := ParseGPOS(b)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if langs := gpos.Languages("cyrl"); !reflect.DeepEqual(langs, []string{"MKD ", "SRB "}) {
+		t.Errorf("invalid languages of the cyrillic script: %q", langs)
+	}
+
+	if langs := gpos.Languages("zzzz"); langs != nil {
+		t.Errorf("invalid languages of an unknown script: %q", langs)
+	}
+
+	// The Romanian language system has the same kerning as the default one,
+	// unknown languages fall back to the default language system.
+	for _, lang := range []string{"ROM ", "XXX "} {
+		if kern := gpos.Kerning("latn", lang).Kern(36, 57); kern != -131 {
+			t.Errorf("invalid kerning for the %q language: %d", lang, kern)
+		}
+	}
+
+	// Unknown scripts fall back to the default script, which doesn't kern
+	// latin glyphs in this font.
+	if k := gpos.Kerning("zzzz", ""); k == nil || !reflect.DeepEqual(k, gpos.Kerning("DFLT", "")) {
+		t.Error("an unknown script did not fall back to the default script")
+	} else if kern := k.Kern(36, 57); kern != 0 {
+		t.Error("invalid kerning for the default script:", kern)
+	}
+}
+
+func TestGPOSScriptFallback(t *testing.T) {
+	gpos, err := ParseGPOS(gposTableWithLanguage(
+		gposLookupTable(2, gposPairPosFormat1(map[GlyphID]map[GlyphID]int16{1: {2: -10}})),
+		gposLookupTable(2, gposPairPosFormat1(map[GlyphID]map[GlyphID]int16{1: {2: -30}})),
+	))
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The latin script only has rules for the Turkish language, the other
+	// languages fall back to the default script.
+	tests := []struct {
+		script   string
+		language string
+		kern     int
+	}{
+		{"latn", "TRK ", -30},
+		{"latn", "", -10},
+		{"latn", "XXX ", -10},
+		{"cyrl", "TRK ", -10},
+		{"DFLT", "", -10},
+	}
+
+	for _, test := range tests {
+		if kern := gpos.Kerning(test.script, test.language).Kern(1, 2); kern != test.kern {
+			t.Errorf("%q, %q: invalid kerning: %d != %d", test.script, test.language, kern, test.kern)
+		}
+	}
+}
+
+func TestParseGPOSError(t *testing.T) {
+	tests := [][]byte{
+		nil,
+		{0, 1, 0, 0, 0, 10, 0, 12},
+		{0, 2, 0, 0, 0, 10, 0, 12, 0, 14, 0, 0, 0, 0, 0, 0},
+		{0, 1, 0, 0, 0, 10, 0, 12, 0, 14, 0, 0, 0, 0, 0, 1},
+		{0, 1, 0, 0, 0, 10, 0, 12, 0, 40, 0, 0, 0, 0, 0, 0},
+	}
+
+	for _, test := range tests {
+		if _, err := ParseGPOS(test); !errors.Is(err, ErrInvalidTable) {
+			t.Errorf("%v: invalid error: %v", test, err)
+		}
+	}
+}
+
+func TestGPOSPairPositioning(t *testing.T) {
+	format1 := gposPairPosFormat1(map[GlyphID]map[GlyphID]int16{
+		1: {2: -10, 3: -20},
+		4: {2: 30},
+	})
+
+	// Glyphs 10 to 19 are in class 1 and glyphs 20 to 29 in class 2 of both
+	// class definitions.
+	format2 := gposPairPosFormat2(10, 29, [][]int16{
+		{0, 0, 0},
+		{0, -5, -15},
+		{0, 25, 35},
+	})
+
+	tests := []struct {
+		name  string
+		table []byte
+		left  GlyphID
+		right GlyphID
+		kern  int
+		ok    bool
+	}{
+		{"format 1", gposTable(gposLookupTable(2, format1)), 1, 2, -10, true},
+		{"format 1", gposTable(gposLookupTable(2, format1)), 1, 3, -20, true},
+		{"format 1", gposTable(gposLookupTable(2, format1)), 4, 2, 30, true},
+		{"format 1", gposTable(gposLookupTable(2, format1)), 4, 3, 0, false},
+		{"format 1", gposTable(gposLookupTable(2, format1)), 2, 1, 0, false},
+		{"format 2", gposTable(gposLookupTable(2, format2)), 10, 12, -5, true},
+		{"format 2", gposTable(gposLookupTable(2, format2)), 19, 25, -15, true},
+		{"format 2", gposTable(gposLookupTable(2, format2)), 25, 15, 25, true},
+		{"format 2", gposTable(gposLookupTable(2, format2)), 20, 29, 35, true},
+		{"format 2", gposTable(gposLookupTable(2, format2)), 12, 30, 0, true},
+		{"format 2", gposTable(gposLookupTable(2, format2)), 30, 12, 0, false},
+		{"extension", gposTable(gposLookupTable(9, gposExtensionSubtable(format1))), 1, 3, -20, true},
+		{"first subtable", gposTable(gposLookupTable(2, format1, gposPairPosFormat1(map[GlyphID]map[GlyphID]int16{1: {2: 100}}))), 1, 2, -10, true},
+		{"second subtable", gposTable(gposLookupTable(2, format1, format2)), 10, 25, -15, true},
+		{"two lookups", gposTable(gposLookupTable(2, format1), gposLookupTable(2, gposPairPosFormat1(map[GlyphID]map[GlyphID]int16{1: {2: 100}}))), 1, 2, 90, true},
+	}
+
+	for _, test := range tests {
+		gpos, err := ParseGPOS(test.table)
+
+		if err != nil {
+			t.Errorf("%s: %v", test.name, err)
+			continue
+		}
+
+		if len(gpos.Diagnostics) != 0 {
+			t.Errorf("%s: unexpected diagnostics: %v", test.name, gpos.Diagnostics)
+		}
+
+		first, second, ok := gpos.Kerning("latn", "").Pair(test.left, test.right)
+
+		if first.XAdvance != test.kern || ok != test.ok {
+			t.Errorf("%s (%d, %d): invalid kerning: %d, %t != %d, %t", test.name, test.left, test.right, first.XAdvance, ok, test.kern, test.ok)
+		}
+
+		if second != (ValueRecord{}) {
+			t.Errorf("%s (%d, %d): invalid adjustment of the second glyph: %+v", test.name, test.left, test.right, second)
+		}
+	}
+}
+
+func TestGPOSDiagnostics(t *testing.T) {
+	format1 := gposPairPosFormat1(map[GlyphID]map[GlyphID]int16{1: {2: -10}})
+
+	tests := []struct {
+		name        string
+		table       []byte
+		diagnostics []string
+		kern        int
+	}{
+		{
+			name:        "unsupported lookup type",
+			table:       gposTable(gposLookupTable(8, format1), gposLookupTable(2, format1)),
+			diagnostics: []string{"lookup 0: unsupported lookup type: 8"},
+			kern:        -10,
+		},
+		{
+			name:        "unsupported extension lookup type",
+			table:       gposTable(gposLookupTable(9, gposExtensionSubtable(format1)), gposLookupTable(9, []byte{0, 1, 0, 4, 0, 0, 0, 8})),
+			diagnostics: []string{"lookup 1: subtable 0: unsupported extension lookup type: 4"},
+			kern:        -10,
+		},
+		{
+			name:        "unsupported pair positioning format",
+			table:       gposTable(gposLookupTable(2, format1, []byte{0, 3, 0, 10, 0, 4, 0, 0, 0, 0, 0, 1, 0, 0})),
+			diagnostics: []string{"lookup 0: subtable 1: unsupported pair positioning format: 3"},
+			kern:        -10,
+		},
+	}
+
+	for _, test := range tests {
+		gpos, err := ParseGPOS(test.table)
+
+		if err != nil {
+			t.Errorf("%s: %v", test.name, err)
+			continue
+		}
+
+		messages := []string{}
+
+		for _, d := range gpos.Diagnostics {
+			messages = append(messages, d.Message)
+		}
+
+		if !reflect.DeepEqual(messages, test.diagnostics) {
+			t.Errorf("%s: invalid diagnostics: %q", test.name, messages)
+		}
+
+		if kern := gpos.Kerning("latn", "").Kern(1, 2); kern != test.kern {
+			t.Errorf("%s: invalid kerning: %d != %d", test.name, kern, test.kern)
+		}
+	}
+}
+
+func TestGPOSNoKerning(t *testing.T) {
+	var gpos *GPOSTable
+
+	if k := gpos.Kerning("latn", ""); k != nil {
+		t.Error("kerning returned by a nil table")
+	}
+
+	var k *GPOSKerning
+
+	if kern := k.Kern(1, 2); kern != 0 {
+		t.Error("non-zero kerning returned by a nil kerning:", kern)
+	}
+
+	gpos, err := ParseGPOS(gposTableWithFeature("liga", gposLookupTable(2)))
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if k := gpos.Kerning("latn", ""); k != nil {
+		t.Error("kerning returned by a table that has no kern feature")
+	}
+}
+
+func TestScriptForRune(t *testing.T) {
+	tests := []struct {
+		r      rune
+		script string
+	}{
+		{'A', "latn"},
+		{'é', "latn"},
+		{'Ω', "grek"},
+		{'Ж', "cyrl"},
+		{'ש', "hebr"},
+		{'ب', "arab"},
+		{'क', "dev2"},
+		{'ก', "thai"},
+		{'가', "hang"},
+		{'あ', "kana"},
+		{'ア', "kana"},
+		{'中', "hani"},
+		{'1', "DFLT"},
+		{' ', "DFLT"},
+		{'😀', "DFLT"},
+	}
+
+	for _, test := range tests {
+		if script := ScriptForRune(test.r); script != test.script {
+			t.Errorf("%q: invalid script: %q != %q", test.r, script, test.script)
+		}
+	}
+}
+
+func TestScriptForRunes(t *testing.T) {
+	tests := []struct {
+		runes  string
+		script string
+	}{
+		{"", "DFLT"},
+		{"12 ", "DFLT"},
+		{"AV", "latn"},
+		{"1. Ωmega", "grek"},
+		{"(中A)", "hani"},
+	}
+
+	for _, test := range tests {
+		if script := ScriptForRunes([]rune(test.runes)); script != test.script {
+			t.Errorf("%q: invalid script: %q != %q", test.runes, script, test.script)
+		}
+	}
+}
+
+func TestNewKerner(t *testing.T) {
+	b, err := os.ReadFile("testdata/Roboto-Regular.GPOS")
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	gpos, err := ParseGPOS(b)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if b, err = os.ReadFile("testdata/DejaVuSansCondensed.kern"); err != nil {
+		t.Fatal(err)
+	}
+
+	kern, err := ParseKern(b)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	liga, err := ParseGPOS(gposTableWithFeature("liga", gposLookupTable(2)))
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name   string
+		gpos   *GPOSTable
+		kern   *KernTable
+		script string
+		left   GlyphID
+		right  GlyphID
+		value  int
+	}{
+		{"GPOS", gpos, kern, "latn", 37, 58, -87},
+		{"GPOS without kerning", liga, kern, "latn", 36, 57, -131},
+		{"kern", nil, kern, "latn", 36, 57, -131},
+		{"none", nil, nil, "latn", 36, 57, 0},
+	}
+
+	for _, test := range tests {
+		k := NewKerner(test.gpos, test.kern, test.script)
+
+		if k == nil {
+			t.Errorf("%s: nil kerner", test.name)
+			continue
+		}
+
+		if value := k.Kern(test.left, test.right); value != test.value {
+			t.Errorf("%s: invalid kerning: %d != %d", test.name, value, test.value)
+		}
+	}
+}
+
+// gposTable returns a GPOS table where the default language system of the
+// default script has a 'kern' feature made of the lookups passed as arguments.
+func gposTable(lookups ...[]byte) []byte {
+	return gposTableWithFeature("kern", lookups...)
+}
+
+func gposTableWithFeature(feature string, lookups ...[]byte) []byte {
+	const (
+		scriptList  = 10
+		featureList = scriptList + 20
+		lookupList  = featureList + 12
+	)
+
+	b := appendUint16(nil, 1, 0, scriptList, featureList, uint16(lookupList+2*len(lookups)))
+
+	// The script list, with the script table and its default language system.
+	b = appendUint16(append(appendUint16(b, 1), "DFLT"...), 8)
+	b = appendUint16(b, 4, 0)
+	b = appendUint16(b, 0, 0xFFFF, 1, 0)
+
+	// The feature list, with the feature table.
+	b = appendUint16(append(appendUint16(b, 1), feature...), 8)
+	b = appendUint16(b, 0, uint16(len(lookups)))
+
+	for i := range lookups {
+		b = appendUint16(b, uint16(i))
+	}
+
+	return append(b, gposOffsets(0, lookups)...)
+}
+
+// gposTableWithLanguage returns a GPOS table where the default language system
+// of the default script has a 'kern' feature made of the first lookup, and the
+// latin script has no default language system and a Turkish language system
+// with a 'kern' feature made of the second lookup.
+func gposTableWithLanguage(dflt []byte, turkish []byte) []byte {
+	const (
+		scriptList  = 10
+		featureList = scriptList + 44
+		lookupList  = featureList + 26
+	)
+
+	b := appendUint16(nil, 1, 0, scriptList, featureList, lookupList)
+
+	// The script list, with the default and latin script tables.
+	b = appendUint16(append(appendUint16(b, 2), "DFLT"...), 14)
+	b = appendUint16(append(b, "latn"...), 26)
+	b = appendUint16(b, 4, 0)
+	b = appendUint16(b, 0, 0xFFFF, 1, 0)
+	b = appendUint16(append(appendUint16(b, 0, 1), "TRK "...), 10)
+	b = appendUint16(b, 0, 0xFFFF, 1, 1)
+
+	// The feature list, with a feature table for each language system.
+	b = appendUint16(append(appendUint16(b, 2), "kern"...), 14)
+	b = appendUint16(append(b, "kern"...), 20)
+	b = appendUint16(b, 0, 1, 0)
+	b = appendUint16(b, 0, 1, 1)
+
+	return append(b, gposOffsets(0, [][]byte{dflt, turkish})...)
+}
+
+// gposLookupTable returns a lookup table of the given type made of the
+// subtables passed as arguments.
+func gposLookupTable(lookupType int, subtables ...[]byte) []byte {
+	return append(appendUint16(nil, uint16(lookupType), 0), gposOffsets(4, subtables)...)
+}
+
+// gposOffsets returns an array of the offsets of the tables passed as
+// arguments, preceded by the number of tables and followed by the tables.
+// Offsets are relative to the position base bytes before the array.
+func gposOffsets(base int, tables [][]byte) []byte {
+	b := appendUint16(nil, uint16(len(tables)))
+	off := base + 2 + 2*len(tables)
+
+	for _, t := range tables {
+		b = appendUint16(b, uint16(off))
+		off += len(t)
+	}
+
+	for _, t := range tables {
+		b = append(b, t...)
+	}
+
+	return b
+}
+
+// gposPairPosFormat1 returns a pair positioning subtable of format 1 with the
+// advance adjustments of the first glyphs of the pairs.
+func gposPairPosFormat1(pairs map[GlyphID]map[GlyphID]int16) []byte {
+	first := sortedGlyphs(pairs)
+	sets := [][]byte{}
+
+	for _, g := range first {
+		second := sortedGlyphs(pairs[g])
+		set := appendUint16(nil, uint16(len(second)))
+
+		for _, h := range second {
+			set = appendUint16(set, uint16(h), uint16(pairs[g][h]))
+		}
+
+		sets = append(sets, set)
+	}
+
+	// The coverage table is placed after the pair sets.
+	offsets := gposOffsets(8, sets)
+	b := appendUint16(nil, 1, uint16(8+len(offsets)), valueXAdvance, 0)
+	b = append(b, offsets...)
+
+	b = appendUint16(b, 1, uint16(len(first)))
+
+	for _, g := range first {
+		b = appendUint16(b, uint16(g))
+	}
+
+	return b
+}
+
+// gposPairPosFormat2 returns a pair positioning subtable of format 2 covering
+// glyphs first to last, where glyphs are in class 1 if their index is less
+// than 20 and in class 2 otherwise.
+func gposPairPosFormat2(first GlyphID, last GlyphID, values [][]int16) []byte {
+	const header = 16
+	records := header + 2*len(values)*len(values[0])
+	coverage := records
+	classDef := coverage + 10
+
+	b := appendUint16(nil, 2, uint16(coverage), valueXAdvance, 0, uint16(classDef), uint16(classDef), uint16(len(values)), uint16(len(values[0])))
+
+	for _, row := range values {
+		for _, v := range row {
+			b = appendUint16(b, uint16(v))
+		}
+	}
+
+	b = appendUint16(b, 2, 1, uint16(first), uint16(last), 0)
+	return appendUint16(b, 2, 2, 10, 19, 1, 20, 29, 2)
+}
+
+func gposExtensionSubtable(subtable []byte) []byte {
+	return append(appendUint16(appendUint16(nil, 1, 2), 0, 8), subtable...)
+}
+
+func sortedGlyphs[T any](m map[GlyphID]T) []GlyphID {
+	glyphs := make([]GlyphID, 0, len(m))
+
+	for g := range m {
+		glyphs = append(glyphs, g)
+	}
+
+	sort.Slice(glyphs, func(i int, j int) bool { return glyphs[i] < glyphs[j] })
+	return glyphs
+}
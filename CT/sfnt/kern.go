@@ -0,0 +1,442 @@
+package sfnt
+
+import (
+	"fmt"
+	"sort"
+)
+
+// KernTable is a parsed 'kern' table, which holds the kerning values of pairs
+// of glyphs.
+//
+// Both the Microsoft (version 0) and Apple (version 1.0) table layouts are
+// supported, with subtables in the ordered list (0), class table (2) and
+// compact class table (3) formats. Subtables are applied in order:
+//
+//   - values of regular subtables are added to the kerning accumulated so far
+//   - values of subtables with the override bit replace it
+//   - values of subtables with the minimum bit are lower bounds, the kerning
+//     accumulated so far is raised to the value if it's smaller
+//   - values of cross-stream subtables move the glyphs perpendicularly to the
+//     line, a value of -0x8000 resets the cross-stream offset to zero
+//
+// Vertical, variation and contextual (format 1) subtables are skipped and
+// reported in Diagnostics.
+//
+// https://learn.microsoft.com/en-us/typography/opentype/spec/kern
+//
+// https://developer.apple.com/fonts/TrueType-Reference-Manual/RM06/Chap6kern.html
+type KernTable struct {
+	// Diagnostics lists the subtables that were skipped when the table was
+	// parsed.
+	Diagnostics []Diagnostic
+
+	subtables []kernSubtable
+}
+
+type kernSubtable struct {
+	format      int
+	crossStream bool
+	minimum     bool
+	override    bool
+
+	// The bytes of the subtable, including its header, offsets of format 2
+	// subtables are relative to the beginning of the subtable.
+	data data
+
+	// The offset of the format specific data, after the subtable header.
+	body int
+
+	// Format 0
+	pairs  data
+	nPairs int
+
+	// Format 2
+	left  kernClassTable
+	right kernClassTable
+	array int
+
+	// Format 3
+	glyphCount int
+	values     data
+	leftClass  data
+	rightClass data
+	kernIndex  data
+	valueCount int
+	leftCount  int
+	rightCount int
+}
+
+type kernClassTable struct {
+	firstGlyph int
+	values     data
+}
+
+// Coverage bits of the Microsoft subtables.
+const (
+	kernHorizontal  = 1 << 0
+	kernMinimum     = 1 << 1
+	kernCrossStream = 1 << 2
+	kernOverride    = 1 << 3
+)
+
+// Coverage bits of the Apple subtables.
+const (
+	kernAppleVertical    = 0x8000
+	kernAppleCrossStream = 0x4000
+	kernAppleVariation   = 0x2000
+	kernAppleFormatMask  = 0x00FF
+)
+
+// kernResetCrossStream is the value of cross-stream kerning pairs that reset
+// the cross-stream offset to the baseline.
+const kernResetCrossStream = -0x8000
+
+// ParseKern parses the content of a 'kern' table.
+//
+// The function returns an error wrapping ErrInvalidTable if the table header
+// cannot be read, subtables that are malformed or not supported are skipped
+// and reported in the Diagnostics field of the returned table.
+func ParseKern(b []byte) (*KernTable, error) {
+	d := data(b)
+	t := &KernTable{}
+
+	if !d.has(0, 4) {
+		return nil, invalidTable("kern", "truncated header")
+	}
+
+	switch {
+	case d.u16(0) == 0:
+		t.parseMicrosoft(d)
+	case d.u32(0) == 0x00010000 && d.has(0, 8):
+		t.parseApple(d)
+	default:
+		return nil, invalidTable("kern", "unsupported version %#x", d.u32(0))
+	}
+
+	return t, nil
+}
+
+func (t *KernTable) parseMicrosoft(d data) {
+	n := int(d.u16(2))
+	off := 4
+
+	for i := 0; i != n; i++ {
+		if !d.has(off, 6) {
+			t.diagnose(i, "truncated subtable header")
+			return
+		}
+
+		version := d.u16(off)
+		length := int(d.u16(off + 2))
+		coverage := d.u16(off + 4)
+
+		s := kernSubtable{
+			format:      int(coverage >> 8),
+			crossStream: (coverage & kernCrossStream) != 0,
+			minimum:     (coverage & kernMinimum) != 0,
+			override:    (coverage & kernOverride) != 0,
+			body:        6,
+		}
+
+		// The length field is only 16 bits wide, fonts with large format 0
+		// subtables store it modulo 65536 so it is computed from the number
+		// of pairs instead.
+		if s.format == 0 && d.has(off+6, 2) {
+			if size := 6 + 8 + 6*int(d.u16(off+6)); size > length && size%0x10000 == length {
+				length = size
+			}
+		}
+
+		if length < 6 {
+			t.diagnose(i, fmt.Sprintf("invalid subtable length: %d", length))
+			return
+		}
+
+		if !d.has(off, length) {
+			t.diagnose(i, fmt.Sprintf("subtable of %d bytes is truncated to %d bytes", length, len(d)-off))
+			return
+		}
+
+		s.data = d.slice(off, length)
+		off += length
+
+		switch {
+		case version != 0:
+			t.diagnose(i, fmt.Sprintf("unsupported subtable version: %d", version))
+		case (coverage & kernHorizontal) == 0:
+			t.diagnose(i, "vertical kerning is not supported")
+		default:
+			t.add(i, s)
+		}
+	}
+}
+
+func (t *KernTable) parseApple(d data) {
+	n := int(d.u32(4))
+	off := 8
+
+	for i := 0; i != n; i++ {
+		if !d.has(off, 8) {
+			t.diagnose(i, "truncated subtable header")
+			return
+		}
+
+		length := int(d.u32(off))
+		coverage := d.u16(off + 4)
+
+		s := kernSubtable{
+			format:      int(coverage & kernAppleFormatMask),
+			crossStream: (coverage & kernAppleCrossStream) != 0,
+			body:        8,
+		}
+
+		if length < 8 {
+			t.diagnose(i, fmt.Sprintf("invalid subtable length: %d", length))
+			return
+		}
+
+		if !d.has(off, length) {
+			t.diagnose(i, fmt.Sprintf("subtable of %d bytes is truncated to %d bytes", length, len(d)-off))
+			return
+		}
+
+		s.data = d.slice(off, length)
+		off += length
+
+		switch {
+		case (coverage & kernAppleVertical) != 0:
+			t.diagnose(i, "vertical kerning is not supported")
+		case (coverage & kernAppleVariation) != 0:
+			t.diagnose(i, "variation kerning is not supported")
+		default:
+			t.add(i, s)
+		}
+	}
+}
+
+func (t *KernTable) add(i int, s kernSubtable) {
+	var err error
+
+	switch s.format {
+	case 0:
+		err = s.parseFormat0()
+	case 1:
+		err = fmt.Errorf("contextual kerning (format 1) is not supported")
+	case 2:
+		err = s.parseFormat2()
+	case 3:
+		err = s.parseFormat3()
+	default:
+		err = fmt.Errorf("unsupported subtable format: %d", s.format)
+	}
+
+	if err != nil {
+		t.diagnose(i, err.Error())
+		return
+	}
+
+	t.subtables = append(t.subtables, s)
+}
+
+func (t *KernTable) diagnose(i int, msg string) {
+	t.Diagnostics = append(t.Diagnostics, Diagnostic{
+		Table:    "kern",
+		Subtable: i,
+		Message:  msg,
+	})
+}
+
+func (s *kernSubtable) parseFormat0() error {
+	d, h := s.data, s.body
+
+	if !d.has(h, 8) {
+		return fmt.Errorf("truncated format 0 header")
+	}
+
+	s.nPairs = int(d.u16(h))
+
+	if s.pairs = d.slice(h+8, 6*s.nPairs); s.pairs == nil {
+		return fmt.Errorf("truncated list of %d kerning pairs", s.nPairs)
+	}
+
+	return nil
+}
+
+func (s *kernSubtable) parseFormat2() error {
+	d, h := s.data, s.body
+
+	if !d.has(h, 8) {
+		return fmt.Errorf("truncated format 2 header")
+	}
+
+	var err error
+	s.array = int(d.u16(h + 6))
+
+	if s.left, err = parseKernClassTable(d, int(d.u16(h+2))); err != nil {
+		return fmt.Errorf("left class table: %v", err)
+	}
+
+	if s.right, err = parseKernClassTable(d, int(d.u16(h+4))); err != nil {
+		return fmt.Errorf("right class table: %v", err)
+	}
+
+	return nil
+}
+
+func parseKernClassTable(d data, off int) (kernClassTable, error) {
+	if !d.has(off, 4) {
+		return kernClassTable{}, fmt.Errorf("offset out of bounds: %d", off)
+	}
+
+	n := int(d.u16(off + 2))
+	c := kernClassTable{
+		firstGlyph: int(d.u16(off)),
+		values:     d.slice(off+4, 2*n),
+	}
+
+	if c.values == nil {
+		return c, fmt.Errorf("truncated table of %d glyphs", n)
+	}
+
+	return c, nil
+}
+
+func (s *kernSubtable) parseFormat3() error {
+	d, h := s.data, s.body
+
+	if !d.has(h, 6) {
+		return fmt.Errorf("truncated format 3 header")
+	}
+
+	s.glyphCount = int(d.u16(h))
+	s.valueCount = int(d.u8(h + 2))
+	s.leftCount = int(d.u8(h + 3))
+	s.rightCount = int(d.u8(h + 4))
+
+	off := h + 6
+	next := func(n int) data {
+		b := d.slice(off, n)
+		off += n
+		return b
+	}
+
+	s.values = next(2 * s.valueCount)
+	s.leftClass = next(s.glyphCount)
+	s.rightClass = next(s.glyphCount)
+	s.kernIndex = next(s.leftCount * s.rightCount)
+
+	if s.values == nil || s.leftClass == nil || s.rightClass == nil || s.kernIndex == nil {
+		return fmt.Errorf("truncated format 3 subtable")
+	}
+
+	return nil
+}
+
+// lookup returns the kerning value of the pair of glyphs in the subtable, the
+// boolean is false if the subtable has no value for the pair.
+func (s *kernSubtable) lookup(left GlyphID, right GlyphID) (int, bool) {
+	switch s.format {
+	case 0:
+		key := uint32(left)<<16 | uint32(right)
+		i := sort.Search(s.nPairs, func(i int) bool { return s.pairs.u32(6*i) >= key })
+
+		if i < s.nPairs && s.pairs.u32(6*i) == key {
+			return int(s.pairs.i16(6*i + 4)), true
+		}
+
+	case 2:
+		l, okl := s.left.lookup(left)
+		r, okr := s.right.lookup(right)
+
+		// The left class values are offsets of the rows from the beginning
+		// of the subtable, and the right class values are offsets of the
+		// columns within a row.
+		if off := l + r; okl && okr && off >= s.array && s.data.has(off, 2) {
+			return int(s.data.i16(off)), true
+		}
+
+	case 3:
+		if int(left) >= s.glyphCount || int(right) >= s.glyphCount {
+			break
+		}
+
+		l := int(s.leftClass.u8(int(left)))
+		r := int(s.rightClass.u8(int(right)))
+
+		if l >= s.leftCount || r >= s.rightCount {
+			break
+		}
+
+		if i := int(s.kernIndex.u8(l*s.rightCount + r)); i < s.valueCount {
+			return int(s.values.i16(2 * i)), true
+		}
+	}
+
+	return 0, false
+}
+
+func (c *kernClassTable) lookup(glyph GlyphID) (int, bool) {
+	i := int(glyph) - c.firstGlyph
+
+	if i < 0 || 2*i >= len(c.values) {
+		return 0, false
+	}
+
+	return int(c.values.u16(2 * i)), true
+}
+
+// Pair returns the kerning values of the pair of glyphs passed as arguments,
+// in font units.
+//
+// The advance is the adjustment of the space between the glyphs along the
+// line, the cross-stream offset is perpendicular to the line.
+//
+// Calling the method on a nil table returns zero values, which makes it
+// convenient to use with fonts that have no 'kern' table.
+func (t *KernTable) Pair(left GlyphID, right GlyphID) (advance int, crossStream int) {
+	if t == nil {
+		return
+	}
+
+	for i := range t.subtables {
+		s := &t.subtables[i]
+		v, ok := s.lookup(left, right)
+
+		if !ok {
+			continue
+		}
+
+		if s.crossStream {
+			if v == kernResetCrossStream {
+				crossStream = 0
+			} else {
+				crossStream = s.combine(crossStream, v)
+			}
+		} else {
+			advance = s.combine(advance, v)
+		}
+	}
+
+	return
+}
+
+// Kern returns the adjustment of the space between the pair of glyphs passed
+// as arguments, in font units.
+func (t *KernTable) Kern(left GlyphID, right GlyphID) int {
+	advance, _ := t.Pair(left, right)
+	return advance
+}
+
+func (s *kernSubtable) combine(kern int, value int) int {
+	switch {
+	case s.override:
+		return value
+	case s.minimum:
+		if kern < value {
+			return value
+		}
+		return kern
+	default:
+		return kern + value
+	}
+}
@@ -0,0 +1,371 @@
+package sfnt
+
+import (
+	"encoding/binary"
+	"errors"
+	"os"
+	"testing"
+)
+
+func TestParseKernDejaVu(t *testing.T) {
+	b, err := os.ReadFile("testdata/DejaVuSansCondensed.kern")
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	k, err := ParseKern(b)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(k.Diagnostics) != 0 {
+		t.Error("unexpected diagnostics:", k.Diagnostics)
+	}
+
+	tests := []struct {
+		pair  string
+		left  GlyphID
+		right GlyphID
+		kern  int
+	}{
+		{"AV", 36, 57, -131},
+		{"VA", 57, 36, -131},
+		{"AT", 36, 55, -159},
+		{"To", 55, 82, -348},
+		{"Yo", 60, 82, -272},
+		{"LT", 47, 55, -282},
+		{"Av", 36, 89, -120},
+		{"F.", 41, 17, -329},
+		{"Ty", 55, 92, -319},
+		{"AA", 36, 36, 57},
+		{"ab", 68, 69, 0},
+		{"r.", 85, 17, -188},
+		{"ΑΥ", 807, 826, 0},
+
+		// Looking pairs up by character code instead of glyph index gives
+		// no kerning.
+		{"AV", 'A', 'V', 0},
+	}
+
+	for _, test := range tests {
+		if kern := k.Kern(test.left, test.right); kern != test.kern {
+			t.Errorf("%s (%d, %d): invalid kerning: %d != %d", test.pair, test.left, test.right, kern, test.kern)
+		}
+	}
+}
+
+func TestParseKernError(t *testing.T) {
+	tests := [][]byte{
+		nil,
+		{0, 0},
+		{0, 2, 0, 0},
+		{0, 1, 0, 0, 0, 0, 0},
+	}
+
+	for _, test := range tests {
+		if _, err := ParseKern(test); !errors.Is(err, ErrInvalidTable) {
+			t.Errorf("%v: invalid error: %v", test, err)
+		}
+	}
+}
+
+func TestKernNilTable(t *testing.T) {
+	var k *KernTable
+
+	if advance, crossStream := k.Pair(1, 2); advance != 0 || crossStream != 0 {
+		t.Error("non-zero kerning returned by a nil table:", advance, crossStream)
+	}
+}
+
+func TestKernSubtableFormats(t *testing.T) {
+	pairs := kernFormat0(kernPair{1, 2, -10}, kernPair{1, 3, 20}, kernPair{4, 2, -30})
+	classes := kernFormat2(6)
+	classesApple := kernFormat2(8)
+	compact := kernFormat3()
+
+	tests := []struct {
+		name  string
+		table []byte
+		left  GlyphID
+		right GlyphID
+		kern  int
+	}{
+		{"format 0", microsoftKern(kernSubtableMS(0, 0x1, pairs)), 1, 2, -10},
+		{"format 0", microsoftKern(kernSubtableMS(0, 0x1, pairs)), 1, 3, 20},
+		{"format 0", microsoftKern(kernSubtableMS(0, 0x1, pairs)), 4, 2, -30},
+		{"format 0", microsoftKern(kernSubtableMS(0, 0x1, pairs)), 2, 1, 0},
+		{"format 0 (apple)", appleKern(kernSubtableApple(0, 0, pairs)), 1, 3, 20},
+		{"format 2", microsoftKern(kernSubtableMS(2, 0x1, classes)), 10, 20, 0},
+		{"format 2", microsoftKern(kernSubtableMS(2, 0x1, classes)), 10, 21, -50},
+		{"format 2", microsoftKern(kernSubtableMS(2, 0x1, classes)), 11, 20, -60},
+		{"format 2", microsoftKern(kernSubtableMS(2, 0x1, classes)), 11, 21, 70},
+		{"format 2", microsoftKern(kernSubtableMS(2, 0x1, classes)), 9, 21, 0},
+		{"format 2", microsoftKern(kernSubtableMS(2, 0x1, classes)), 11, 22, 0},
+		{"format 2 (apple)", appleKern(kernSubtableApple(2, 0, classesApple)), 11, 21, 70},
+		{"format 3", appleKern(kernSubtableApple(3, 0, compact)), 0, 1, -40},
+		{"format 3", appleKern(kernSubtableApple(3, 0, compact)), 1, 0, 15},
+		{"format 3", appleKern(kernSubtableApple(3, 0, compact)), 2, 2, 0},
+		{"format 3", appleKern(kernSubtableApple(3, 0, compact)), 3, 0, 0},
+	}
+
+	for _, test := range tests {
+		k, err := ParseKern(test.table)
+
+		if err != nil {
+			t.Errorf("%s: %v", test.name, err)
+			continue
+		}
+
+		if len(k.Diagnostics) != 0 {
+			t.Errorf("%s: unexpected diagnostics: %v", test.name, k.Diagnostics)
+		}
+
+		if kern := k.Kern(test.left, test.right); kern != test.kern {
+			t.Errorf("%s (%d, %d): invalid kerning: %d != %d", test.name, test.left, test.right, kern, test.kern)
+		}
+	}
+}
+
+func TestKernSubtableCoverage(t *testing.T) {
+	a := kernFormat0(kernPair{1, 2, -10})
+	b := kernFormat0(kernPair{1, 2, -25})
+	c := kernFormat0(kernPair{1, 2, -20})
+	r := kernFormat0(kernPair{1, 2, -0x8000})
+
+	tests := []struct {
+		name        string
+		table       []byte
+		advance     int
+		crossStream int
+	}{
+		{"accumulate", microsoftKern(kernSubtableMS(0, 0x1, a), kernSubtableMS(0, 0x1, b)), -35, 0},
+		{"override", microsoftKern(kernSubtableMS(0, 0x1, a), kernSubtableMS(0, 0x9, b)), -25, 0},
+		{"minimum", microsoftKern(kernSubtableMS(0, 0x1, b), kernSubtableMS(0, 0x3, c)), -20, 0},
+		{"minimum not reached", microsoftKern(kernSubtableMS(0, 0x1, a), kernSubtableMS(0, 0x3, c)), -10, 0},
+		{"cross-stream", microsoftKern(kernSubtableMS(0, 0x1, a), kernSubtableMS(0, 0x5, b)), -10, -25},
+		{"cross-stream reset", microsoftKern(kernSubtableMS(0, 0x5, b), kernSubtableMS(0, 0x5, r)), 0, 0},
+		{"cross-stream (apple)", appleKern(kernSubtableApple(0, 0x4000, a), kernSubtableApple(0, 0, b)), -25, -10},
+		{"vertical", microsoftKern(kernSubtableMS(0, 0x0, a), kernSubtableMS(0, 0x1, b)), -25, 0},
+		{"vertical (apple)", appleKern(kernSubtableApple(0, 0x8000, a), kernSubtableApple(0, 0, b)), -25, 0},
+		{"variation (apple)", appleKern(kernSubtableApple(0, 0x2000, a)), 0, 0},
+	}
+
+	for _, test := range tests {
+		k, err := ParseKern(test.table)
+
+		if err != nil {
+			t.Errorf("%s: %v", test.name, err)
+			continue
+		}
+
+		if advance, crossStream := k.Pair(1, 2); advance != test.advance || crossStream != test.crossStream {
+			t.Errorf("%s: invalid kerning: (%d, %d) != (%d, %d)", test.name, advance, crossStream, test.advance, test.crossStream)
+		}
+	}
+}
+
+func TestKernDiagnostics(t *testing.T) {
+	pairs := kernFormat0(kernPair{1, 2, -10})
+
+	tests := []struct {
+		name        string
+		table       []byte
+		diagnostics []Diagnostic
+	}{
+		{
+			name:  "vertical",
+			table: microsoftKern(kernSubtableMS(0, 0x0, pairs), kernSubtableMS(0, 0x1, pairs)),
+			diagnostics: []Diagnostic{
+				{"kern", 0, "vertical kerning is not supported"},
+			},
+		},
+		{
+			name:  "format 1",
+			table: appleKern(kernSubtableApple(0, 0, pairs), kernSubtableApple(1, 0, make([]byte, 10))),
+			diagnostics: []Diagnostic{
+				{"kern", 1, "contextual kerning (format 1) is not supported"},
+			},
+		},
+		{
+			name:  "unknown format",
+			table: microsoftKern(kernSubtableMS(5, 0x1, pairs)),
+			diagnostics: []Diagnostic{
+				{"kern", 0, "unsupported subtable format: 5"},
+			},
+		},
+		{
+			name:  "truncated",
+			table: microsoftKern(kernSubtableMS(0, 0x1, pairs))[:20],
+			diagnostics: []Diagnostic{
+				{"kern", 0, "subtable of 20 bytes is truncated to 16 bytes"},
+			},
+		},
+		{
+			name:  "missing subtables",
+			table: []byte{0, 0, 0, 2},
+			diagnostics: []Diagnostic{
+				{"kern", 0, "truncated subtable header"},
+			},
+		},
+	}
+
+	for _, test := range tests {
+		k, err := ParseKern(test.table)
+
+		if err != nil {
+			t.Errorf("%s: %v", test.name, err)
+			continue
+		}
+
+		if len(k.Diagnostics) != len(test.diagnostics) {
+			t.Errorf("%s: invalid diagnostics: %v", test.name, k.Diagnostics)
+			continue
+		}
+
+		for i, d := range k.Diagnostics {
+			if d != test.diagnostics[i] {
+				t.Errorf("%s: invalid diagnostic: %v != %v", test.name, d, test.diagnostics[i])
+			}
+		}
+	}
+}
+
+func TestKernLargeSubtable(t *testing.T) {
+	// 11000 pairs make a subtable larger than 65535 bytes, which has its
+	// length field overflow.
+	pairs := make([]kernPair, 11000)
+
+	for i := range pairs {
+		pairs[i] = kernPair{GlyphID(i / 100), GlyphID(i % 100), int16(i % 7)}
+	}
+
+	k, err := ParseKern(microsoftKern(kernSubtableMS(0, 0x1, kernFormat0(pairs...))))
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(k.Diagnostics) != 0 {
+		t.Error("unexpected diagnostics:", k.Diagnostics)
+	}
+
+	if kern := k.Kern(109, 99); kern != 10999%7 {
+		t.Error("invalid kerning of the last pair:", kern)
+	}
+}
+
+func TestDiagnosticString(t *testing.T) {
+	tests := []struct {
+		d Diagnostic
+		s string
+	}{
+		{Diagnostic{"kern", 1, "vertical kerning is not supported"}, "sfnt: kern: subtable 1: vertical kerning is not supported"},
+		{Diagnostic{"GPOS", -1, "no lookups"}, "sfnt: GPOS: no lookups"},
+	}
+
+	for _, test := range tests {
+		if s := test.d.String(); s != test.s {
+			t.Errorf("%q != %q", s, test.s)
+		}
+	}
+}
+
+type kernPair struct {
+	left  GlyphID
+	right GlyphID
+	value int16
+}
+
+// kernFormat0 returns the body of a format 0 subtable containing the pairs,
+// which must be sorted.
+func kernFormat0(pairs ...kernPair) []byte {
+	b := make([]byte, 8, 8+6*len(pairs))
+	binary.BigEndian.PutUint16(b, uint16(len(pairs)))
+
+	for _, p := range pairs {
+		b = appendUint16(b, uint16(p.left), uint16(p.right), uint16(p.value))
+	}
+
+	return b
+}
+
+// kernFormat2 returns the body of a format 2 subtable where glyphs 10 and 11
+// are in the left classes and glyphs 20 and 21 in the right classes, offsets
+// are relative to the beginning of the subtable so they depend on the size of
+// its header.
+func kernFormat2(header int) []byte {
+	const (
+		rowWidth = 4
+		left     = 8
+		right    = left + 8
+		array    = right + 8
+	)
+
+	b := appendUint16(nil, rowWidth, uint16(header+left), uint16(header+right), uint16(header+array))
+
+	// The left class table, the values are offsets of the rows.
+	b = appendUint16(b, 10, 2, uint16(header+array), uint16(header+array+rowWidth))
+
+	// The right class table, the values are offsets of the columns.
+	b = appendUint16(b, 20, 2, 0, 2)
+
+	// The kerning values.
+	return appendUint16(b, 0, uint16(0x10000-50), uint16(0x10000-60), 70)
+}
+
+// kernFormat3 returns the body of a format 3 subtable covering glyphs 0 to 2.
+func kernFormat3() []byte {
+	b := appendUint16(nil, 3)
+	b = append(b, 3, 2, 2, 0)
+	b = appendUint16(b, 0, uint16(0x10000-40), 15)
+	b = append(b, 0, 1, 1) // left classes
+	b = append(b, 0, 1, 1) // right classes
+	b = append(b, 0, 1, 2, 0)
+	return b
+}
+
+func microsoftKern(subtables ...[]byte) []byte {
+	b := appendUint16(nil, 0, uint16(len(subtables)))
+
+	for _, s := range subtables {
+		b = append(b, s...)
+	}
+
+	return b
+}
+
+func kernSubtableMS(format int, coverage uint16, body []byte) []byte {
+	return append(appendUint16(nil, 0, uint16(6+len(body)), uint16(format<<8)|coverage), body...)
+}
+
+func appleKern(subtables ...[]byte) []byte {
+	b := appendUint32(nil, 0x00010000, uint32(len(subtables)))
+
+	for _, s := range subtables {
+		b = append(b, s...)
+	}
+
+	return b
+}
+
+func kernSubtableApple(format int, coverage uint16, body []byte) []byte {
+	b := appendUint32(nil, uint32(8+len(body)))
+	b = appendUint16(b, coverage|uint16(format), 0)
+	return append(b, body...)
+}
+
+func appendUint16(b []byte, values ...uint16) []byte {
+	for _, v := range values {
+		b = append(b, byte(v>>8), byte(v))
+	}
+	return b
+}
+
+func appendUint32(b []byte, values ...uint32) []byte {
+	for _, v := range values {
+		b = append(b, byte(v>>24), byte(v>>16), byte(v>>8), byte(v))
+	}
+	return b
+}
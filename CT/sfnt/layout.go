@@ -0,0 +1,193 @@
+package sfnt
+
+import (
+	"fmt"
+	"sort"
+)
+
+// This file contains the structures shared by the OpenType layout tables
+// (GPOS, GSUB, GDEF).
+//
+// https://learn.microsoft.com/en-us/typography/opentype/spec/chapter2
+
+// coverage is a parsed coverage table, which maps glyphs to their index in the
+// arrays of the subtable that references it.
+type coverage struct {
+	format int
+	count  int
+	data   data
+}
+
+func parseCoverage(d data, off int) (coverage, error) {
+	if !d.has(off, 4) {
+		return coverage{}, fmt.Errorf("coverage table offset out of bounds: %d", off)
+	}
+
+	c := coverage{
+		format: int(d.u16(off)),
+		count:  int(d.u16(off + 2)),
+	}
+
+	switch c.format {
+	case 1:
+		c.data = d.slice(off+4, 2*c.count)
+	case 2:
+		c.data = d.slice(off+4, 6*c.count)
+	default:
+		return c, fmt.Errorf("unsupported coverage format: %d", c.format)
+	}
+
+	if c.data == nil {
+		return c, fmt.Errorf("truncated coverage table of format %d", c.format)
+	}
+
+	return c, nil
+}
+
+// index returns the coverage index of glyph, the boolean is false if the glyph
+// isn't covered.
+func (c *coverage) index(glyph GlyphID) (int, bool) {
+	g := uint16(glyph)
+
+	switch c.format {
+	case 1:
+		i := sort.Search(c.count, func(i int) bool { return c.data.u16(2*i) >= g })
+
+		if i < c.count && c.data.u16(2*i) == g {
+			return i, true
+		}
+
+	case 2:
+		i := sort.Search(c.count, func(i int) bool { return c.data.u16(6*i+2) >= g })
+
+		if i < c.count && c.data.u16(6*i) <= g {
+			return int(c.data.u16(6*i+4)) + int(g-c.data.u16(6*i)), true
+		}
+	}
+
+	return 0, false
+}
+
+// classDef is a parsed class definition table, which maps glyphs to classes.
+type classDef struct {
+	format int
+	first  uint16
+	count  int
+	data   data
+}
+
+func parseClassDef(d data, off int) (classDef, error) {
+	if !d.has(off, 4) {
+		return classDef{}, fmt.Errorf("class definition table offset out of bounds: %d", off)
+	}
+
+	c := classDef{format: int(d.u16(off))}
+
+	switch c.format {
+	case 1:
+		c.first = d.u16(off + 2)
+		c.count = int(d.u16(off + 4))
+		c.data = d.slice(off+6, 2*c.count)
+	case 2:
+		c.count = int(d.u16(off + 2))
+		c.data = d.slice(off+4, 6*c.count)
+	default:
+		return c, fmt.Errorf("unsupported class definition format: %d", c.format)
+	}
+
+	if c.data == nil {
+		return c, fmt.Errorf("truncated class definition table of format %d", c.format)
+	}
+
+	return c, nil
+}
+
+// class returns the class of glyph, glyphs that aren't listed in the table are
+// in class 0.
+func (c *classDef) class(glyph GlyphID) int {
+	g := uint16(glyph)
+
+	switch c.format {
+	case 1:
+		if g >= c.first && int(g-c.first) < c.count {
+			return int(c.data.u16(2 * int(g-c.first)))
+		}
+
+	case 2:
+		i := sort.Search(c.count, func(i int) bool { return c.data.u16(6*i+2) >= g })
+
+		if i < c.count && c.data.u16(6*i) <= g {
+			return int(c.data.u16(6*i + 4))
+		}
+	}
+
+	return 0
+}
+
+// tagRecords is a list of records made of a tag followed by an offset, like
+// the records of script, language system and feature lists.
+type tagRecords struct {
+	count int
+	data  data
+}
+
+func parseTagRecords(d data, off int) (tagRecords, error) {
+	if !d.has(off, 2) {
+		return tagRecords{}, fmt.Errorf("list offset out of bounds: %d", off)
+	}
+
+	r := tagRecords{count: int(d.u16(off))}
+
+	if r.data = d.slice(off+2, 6*r.count); r.data == nil {
+		return r, fmt.Errorf("truncated list of %d records", r.count)
+	}
+
+	return r, nil
+}
+
+func (r tagRecords) tag(i int) string {
+	return string(r.data[6*i : 6*i+4])
+}
+
+func (r tagRecords) offset(i int) int {
+	return int(r.data.u16(6*i + 4))
+}
+
+// find returns the offset of the record with the given tag, the boolean is
+// false if there is no such record.
+func (r tagRecords) find(tag string) (int, bool) {
+	for i := 0; i != r.count; i++ {
+		if r.tag(i) == tag {
+			return r.offset(i), true
+		}
+	}
+	return 0, false
+}
+
+func (r tagRecords) tags() []string {
+	tags := make([]string, r.count)
+
+	for i := range tags {
+		tags[i] = r.tag(i)
+	}
+
+	return tags
+}
+
+// u16Array reads the array of n 16 bits values at off, it returns nil if the
+// array is out of bounds.
+func (d data) u16Array(off int, n int) []int {
+	b := d.slice(off, 2*n)
+
+	if b == nil {
+		return nil
+	}
+
+	values := make([]int, n)
+
+	for i := range values {
+		values[i] = int(b.u16(2 * i))
+	}
+
+	return values
+}
@@ -0,0 +1,187 @@
+package sfnt
+
+// Head holds the values of the 'head' table that are needed to read the other
+// tables of a font.
+//
+// https://learn.microsoft.com/en-us/typography/opentype/spec/head
+type Head struct {
+	// The number of font units in an em, the values of the other tables are
+	// expressed in font units.
+	UnitsPerEm int
+
+	// The bounding box of all the glyphs of the font.
+	XMin, YMin, XMax, YMax int
+
+	// The format of the 'loca' table, 0 for 16 bits offsets and 1 for 32 bits
+	// offsets.
+	IndexToLocFormat int
+}
+
+// ParseHead parses the content of a 'head' table.
+func ParseHead(b []byte) (Head, error) {
+	d := data(b)
+
+	if !d.has(0, 54) {
+		return Head{}, invalidTable("head", "truncated table")
+	}
+
+	h := Head{
+		UnitsPerEm:       int(d.u16(18)),
+		XMin:             int(d.i16(36)),
+		YMin:             int(d.i16(38)),
+		XMax:             int(d.i16(40)),
+		YMax:             int(d.i16(42)),
+		IndexToLocFormat: int(d.i16(50)),
+	}
+
+	if h.UnitsPerEm < 16 || h.UnitsPerEm > 16384 {
+		return h, invalidTable("head", "invalid number of units per em: %d", h.UnitsPerEm)
+	}
+
+	if h.IndexToLocFormat != 0 && h.IndexToLocFormat != 1 {
+		return h, invalidTable("head", "invalid 'loca' format: %d", h.IndexToLocFormat)
+	}
+
+	return h, nil
+}
+
+// Hhea holds the values of the 'hhea' table, which describes the horizontal
+// layout of a font.
+//
+// https://learn.microsoft.com/en-us/typography/opentype/spec/hhea
+type Hhea struct {
+	// The distances from the baseline to the top and bottom of the lines, the
+	// descender is usually negative.
+	Ascender, Descender int
+
+	// The space to leave between lines, in addition to the ascender and
+	// descender.
+	LineGap int
+
+	// The number of advances in the 'hmtx' table.
+	NumberOfHMetrics int
+}
+
+// ParseHhea parses the content of a 'hhea' table.
+func ParseHhea(b []byte) (Hhea, error) {
+	d := data(b)
+
+	if !d.has(0, 36) {
+		return Hhea{}, invalidTable("hhea", "truncated table")
+	}
+
+	return Hhea{
+		Ascender:         int(d.i16(4)),
+		Descender:        int(d.i16(6)),
+		LineGap:          int(d.i16(8)),
+		NumberOfHMetrics: int(d.u16(34)),
+	}, nil
+}
+
+// ParseMaxp parses the content of a 'maxp' table and returns the number of
+// glyphs in the font.
+//
+// https://learn.microsoft.com/en-us/typography/opentype/spec/maxp
+func ParseMaxp(b []byte) (int, error) {
+	d := data(b)
+
+	if !d.has(0, 6) {
+		return 0, invalidTable("maxp", "truncated table")
+	}
+
+	return int(d.u16(4)), nil
+}
+
+// Hmtx is a parsed 'hmtx' table, which holds the advances and left side
+// bearings of the glyphs.
+//
+// https://learn.microsoft.com/en-us/typography/opentype/spec/hmtx
+type Hmtx struct {
+	metrics  data
+	bearings data
+	count    int
+}
+
+// ParseHmtx parses the content of a 'hmtx' table, metrics is the number of
+// advances given by the 'hhea' table and glyphs the number of glyphs given by
+// the 'maxp' table.
+func ParseHmtx(b []byte, metrics int, glyphs int) (*Hmtx, error) {
+	d := data(b)
+
+	if metrics < 1 || metrics > glyphs {
+		return nil, invalidTable("hmtx", "invalid number of metrics: %d for %d glyphs", metrics, glyphs)
+	}
+
+	h := &Hmtx{
+		metrics: d.slice(0, 4*metrics),
+		count:   metrics,
+	}
+
+	if h.metrics == nil {
+		return nil, invalidTable("hmtx", "truncated list of %d metrics", metrics)
+	}
+
+	// Fonts commonly omit the trailing bearings, they are read as zero.
+	h.bearings = d[4*metrics:]
+	return h, nil
+}
+
+// Advance returns the advance of the glyph in font units, glyphs that are past
+// the last metric share its advance.
+func (h *Hmtx) Advance(glyph GlyphID) int {
+	i := int(glyph)
+
+	if i >= h.count {
+		i = h.count - 1
+	}
+
+	return int(h.metrics.u16(4 * i))
+}
+
+// LeftSideBearing returns the left side bearing of the glyph in font units.
+func (h *Hmtx) LeftSideBearing(glyph GlyphID) int {
+	i := int(glyph)
+
+	if i < h.count {
+		return int(h.metrics.i16(4*i + 2))
+	}
+
+	return int(h.bearings.i16(2 * (i - h.count)))
+}
+
+// OS2 holds the values of the 'OS/2' table that describe the vertical metrics
+// of a font. The heights are zero if the version of the table is older than 2.
+//
+// https://learn.microsoft.com/en-us/typography/opentype/spec/os2
+type OS2 struct {
+	Version int
+
+	// The typographic ascender, descender and line gap.
+	TypoAscender, TypoDescender, TypoLineGap int
+
+	// The height of the lowercase and uppercase letters.
+	XHeight, CapHeight int
+}
+
+// ParseOS2 parses the content of an 'OS/2' table.
+func ParseOS2(b []byte) (OS2, error) {
+	d := data(b)
+
+	if !d.has(0, 78) {
+		return OS2{}, invalidTable("OS/2", "truncated table")
+	}
+
+	t := OS2{
+		Version:       int(d.u16(0)),
+		TypoAscender:  int(d.i16(68)),
+		TypoDescender: int(d.i16(70)),
+		TypoLineGap:   int(d.i16(72)),
+	}
+
+	if t.Version >= 2 && d.has(0, 96) {
+		t.XHeight = int(d.i16(86))
+		t.CapHeight = int(d.i16(88))
+	}
+
+	return t, nil
+}
@@ -0,0 +1,120 @@
+package sfnt
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestParseMetrics(t *testing.T) {
+	tests := []struct {
+		name     string
+		head     Head
+		hhea     Hhea
+		os2      OS2
+		glyphs   int
+		advances []int
+		bearings []int
+	}{
+		{
+			name:     "glyfTest.ttf",
+			head:     Head{UnitsPerEm: 2048, XMin: 0, YMin: 0, XMax: 1676, YMax: 1984, IndexToLocFormat: 0},
+			hhea:     Hhea{Ascender: 1984, Descender: 0, LineGap: 184, NumberOfHMetrics: 6},
+			os2:      OS2{Version: 4, TypoAscender: 1638, TypoDescender: -410, TypoLineGap: 184},
+			glyphs:   10,
+			advances: []int{748, 0, 682, 1228, 819, 400, 400, 400, 400, 400},
+			bearings: []int{68, 0, 0, 205, 205, 0, 0, 0, 0, 0},
+		},
+		{
+			name:     "CFFTest.otf",
+			head:     Head{UnitsPerEm: 1000, XMin: 100, YMin: 0, XMax: 871, YMax: 800, IndexToLocFormat: 0},
+			hhea:     Hhea{Ascender: 800, Descender: 0, LineGap: 90, NumberOfHMetrics: 5},
+			os2:      OS2{Version: 4, TypoAscender: 800, TypoDescender: -200, TypoLineGap: 90, CapHeight: 793},
+			glyphs:   5,
+			advances: []int{500, 600, 400, 1000, 600},
+			bearings: []int{0, 100, 100, 125, 137},
+		},
+	}
+
+	for _, test := range tests {
+		f := loadFont(t, test.name)
+
+		if head, err := ParseHead(f.Table("head")); err != nil || head != test.head {
+			t.Errorf("%s: invalid 'head' table: %+v, %v", test.name, head, err)
+		}
+
+		if hhea, err := ParseHhea(f.Table("hhea")); err != nil || hhea != test.hhea {
+			t.Errorf("%s: invalid 'hhea' table: %+v, %v", test.name, hhea, err)
+		}
+
+		if os2, err := ParseOS2(f.Table("OS/2")); err != nil || os2 != test.os2 {
+			t.Errorf("%s: invalid 'OS/2' table: %+v, %v", test.name, os2, err)
+		}
+
+		glyphs, err := ParseMaxp(f.Table("maxp"))
+
+		if err != nil || glyphs != test.glyphs {
+			t.Errorf("%s: invalid number of glyphs: %d, %v", test.name, glyphs, err)
+			continue
+		}
+
+		hmtx, err := ParseHmtx(f.Table("hmtx"), test.hhea.NumberOfHMetrics, glyphs)
+
+		if err != nil {
+			t.Errorf("%s: %v", test.name, err)
+			continue
+		}
+
+		for i := range test.advances {
+			g := GlyphID(i)
+
+			if a, b := hmtx.Advance(g), hmtx.LeftSideBearing(g); a != test.advances[i] || b != test.bearings[i] {
+				t.Errorf("%s: glyph %d: invalid metrics: %d, %d != %d, %d", test.name, i, a, b, test.advances[i], test.bearings[i])
+			}
+		}
+	}
+}
+
+func TestParseHmtxShortMetrics(t *testing.T) {
+	// Two full metrics followed by the bearings of two glyphs, the last one
+	// is missing and read as zero.
+	b := appendUint16(nil, 500, 10, 600, 20, 30)
+	hmtx, err := ParseHmtx(b, 2, 5)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	advances := []int{500, 600, 600, 600, 600}
+	bearings := []int{10, 20, 30, 0, 0}
+
+	for i := range advances {
+		g := GlyphID(i)
+
+		if a, b := hmtx.Advance(g), hmtx.LeftSideBearing(g); a != advances[i] || b != bearings[i] {
+			t.Errorf("glyph %d: invalid metrics: %d, %d != %d, %d", i, a, b, advances[i], bearings[i])
+		}
+	}
+}
+
+func TestParseMetricsError(t *testing.T) {
+	f := loadFont(t, "glyfTest.ttf")
+	head := append([]byte(nil), f.Table("head")...)
+	head[18], head[19] = 0, 1
+
+	tests := []error{
+		func() error { _, err := ParseHead(f.Table("head")[:53]); return err }(),
+		func() error { _, err := ParseHead(head); return err }(),
+		func() error { _, err := ParseHhea(f.Table("hhea")[:35]); return err }(),
+		func() error { _, err := ParseMaxp(nil); return err }(),
+		func() error { _, err := ParseOS2(f.Table("OS/2")[:70]); return err }(),
+		func() error { _, err := ParseHmtx(f.Table("hmtx"), 0, 10); return err }(),
+		func() error { _, err := ParseHmtx(f.Table("hmtx"), 11, 10); return err }(),
+		func() error { _, err := ParseHmtx(f.Table("hmtx")[:23], 6, 10); return err }(),
+	}
+
+	for i, err := range tests {
+		if !errors.Is(err, ErrInvalidTable) {
+			t.Errorf("#%d: invalid error: %v", i, err)
+		}
+	}
+}
@@ -0,0 +1,120 @@
+package sfnt
+
+// SegmentOp is an enumeration of the operations of the segments that glyph
+// outlines are made of.
+type SegmentOp uint8
+
+// These constants are all the possible values of the SegmentOp enumeration.
+const (
+	SegmentMoveTo SegmentOp = iota
+	SegmentLineTo
+	SegmentQuadTo
+	SegmentCubeTo
+)
+
+// Point is a point of a glyph outline, in font units with the y-axis pointing
+// up. Coordinates may be fractional when composite glyphs are scaled.
+type Point struct {
+	X float32
+	Y float32
+}
+
+// Segment is a segment of a glyph outline, the number of points used in Args
+// is 1 for moves and lines, 2 for quadratic curves and 3 for cubic curves. The
+// last point is the end of the segment, the others are control points.
+type Segment struct {
+	Op   SegmentOp
+	Args [3]Point
+}
+
+// Outline is the outline of a glyph, made of closed contours which each start
+// with a SegmentMoveTo and end at the point where they started.
+type Outline []Segment
+
+// Outliner is implemented by the tables that hold the outlines of the glyphs
+// of a font, like GlyfTable and CFFTable.
+type Outliner interface {
+	// Outline returns the outline of the glyph, which is empty for glyphs that
+	// have no ink.
+	Outline(glyph GlyphID) (Outline, error)
+}
+
+// Bounds returns the smallest box that contains all the points of the outline,
+// including the control points of curves. The boolean is false if the outline
+// is empty.
+func (o Outline) Bounds() (min Point, max Point, ok bool) {
+	for _, s := range o {
+		for _, p := range s.Args[:s.Op.points()] {
+			if !ok {
+				min, max, ok = p, p, true
+				continue
+			}
+			min.X, min.Y = min32(min.X, p.X), min32(min.Y, p.Y)
+			max.X, max.Y = max32(max.X, p.X), max32(max.Y, p.Y)
+		}
+	}
+	return
+}
+
+// points returns the number of points used by segments of the operation.
+func (op SegmentOp) points() int {
+	switch op {
+	case SegmentQuadTo:
+		return 2
+	case SegmentCubeTo:
+		return 3
+	default:
+		return 1
+	}
+}
+
+func min32(a float32, b float32) float32 {
+	if a < b {
+		return a
+	}
+	return b
+}
+
+func max32(a float32, b float32) float32 {
+	if a > b {
+		return a
+	}
+	return b
+}
+
+// outlineBuilder appends segments to an outline, closing contours with a line
+// back to their starting point when needed.
+type outlineBuilder struct {
+	outline Outline
+	start   Point
+	current Point
+	open    bool
+}
+
+func (b *outlineBuilder) moveTo(p Point) {
+	b.closePath()
+	b.outline = append(b.outline, Segment{Op: SegmentMoveTo, Args: [3]Point{p}})
+	b.start, b.current, b.open = p, p, true
+}
+
+func (b *outlineBuilder) lineTo(p Point) {
+	b.outline = append(b.outline, Segment{Op: SegmentLineTo, Args: [3]Point{p}})
+	b.current = p
+}
+
+func (b *outlineBuilder) quadTo(c Point, p Point) {
+	b.outline = append(b.outline, Segment{Op: SegmentQuadTo, Args: [3]Point{c, p}})
+	b.current = p
+}
+
+func (b *outlineBuilder) cubeTo(c1 Point, c2 Point, p Point) {
+	b.outline = append(b.outline, Segment{Op: SegmentCubeTo, Args: [3]Point{c1, c2, p}})
+	b.current = p
+}
+
+func (b *outlineBuilder) closePath() {
+	if b.open && b.current != b.start {
+		b.lineTo(b.start)
+	}
+	b.open = false
+}
@@ -0,0 +1,95 @@
+package sfnt
+
+import "testing"
+
+func moveTo(x, y float32) Segment {
+	return Segment{Op: SegmentMoveTo, Args: [3]Point{{x, y}}}
+}
+
+func lineTo(x, y float32) Segment {
+	return Segment{Op: SegmentLineTo, Args: [3]Point{{x, y}}}
+}
+
+func quadTo(cx, cy, x, y float32) Segment {
+	return Segment{Op: SegmentQuadTo, Args: [3]Point{{cx, cy}, {x, y}}}
+}
+
+func cubeTo(c1x, c1y, c2x, c2y, x, y float32) Segment {
+	return Segment{Op: SegmentCubeTo, Args: [3]Point{{c1x, c1y}, {c2x, c2y}, {x, y}}}
+}
+
+func rectangle(x0, y0, x1, y1 float32) Outline {
+	return Outline{moveTo(x0, y0), lineTo(x0, y1), lineTo(x1, y1), lineTo(x1, y0), lineTo(x0, y0)}
+}
+
+func TestOutlineBounds(t *testing.T) {
+	tests := []struct {
+		outline Outline
+		min     Point
+		max     Point
+		ok      bool
+	}{
+		{
+			outline: nil,
+		},
+		{
+			outline: Outline{moveTo(1, 2)},
+			min:     Point{1, 2},
+			max:     Point{1, 2},
+			ok:      true,
+		},
+		{
+			outline: rectangle(-1, -2, 3, 4),
+			min:     Point{-1, -2},
+			max:     Point{3, 4},
+			ok:      true,
+		},
+		{
+			// The control points are part of the bounds.
+			outline: Outline{moveTo(0, 0), quadTo(5, 10, 10, 0), cubeTo(10, -3, -2, -3, 0, 0)},
+			min:     Point{-2, -3},
+			max:     Point{10, 10},
+			ok:      true,
+		},
+	}
+
+	for _, test := range tests {
+		if min, max, ok := test.outline.Bounds(); min != test.min || max != test.max || ok != test.ok {
+			t.Errorf("%v: invalid bounds: %v, %v, %t", test.outline, min, max, ok)
+		}
+	}
+}
+
+func TestOutlineBuilder(t *testing.T) {
+	b := &outlineBuilder{}
+	b.moveTo(Point{0, 0})
+	b.lineTo(Point{1, 0})
+	b.lineTo(Point{1, 1})
+	b.moveTo(Point{2, 2})
+	b.quadTo(Point{3, 3}, Point{2, 2})
+	b.closePath()
+	b.closePath()
+
+	expected := Outline{
+		moveTo(0, 0), lineTo(1, 0), lineTo(1, 1), lineTo(0, 0),
+		moveTo(2, 2), quadTo(3, 3, 2, 2),
+	}
+
+	if !equalOutlines(b.outline, expected) {
+		t.Errorf("invalid outline:\n%v\n%v", b.outline, expected)
+	}
+}
+
+func equalOutlines(a Outline, b Outline) bool {
+	if len(a) != len(b) {
+		return false
+	}
+
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+
+	return true
+}
@@ -0,0 +1,65 @@
+package sfnt
+
+import "unicode"
+
+// The OpenType tags of the scripts that ScriptForRune recognizes.
+//
+// https://learn.microsoft.com/en-us/typography/opentype/spec/scripttags
+var scriptTags = [...]struct {
+	table *unicode.RangeTable
+	tag   string
+}{
+	{unicode.Latin, "latn"},
+	{unicode.Greek, "grek"},
+	{unicode.Cyrillic, "cyrl"},
+	{unicode.Armenian, "armn"},
+	{unicode.Hebrew, "hebr"},
+	{unicode.Arabic, "arab"},
+	{unicode.Syriac, "syrc"},
+	{unicode.Thaana, "thaa"},
+	{unicode.Devanagari, "dev2"},
+	{unicode.Bengali, "bng2"},
+	{unicode.Gurmukhi, "gur2"},
+	{unicode.Gujarati, "gjr2"},
+	{unicode.Tamil, "tml2"},
+	{unicode.Telugu, "tel2"},
+	{unicode.Kannada, "knd2"},
+	{unicode.Malayalam, "mlm2"},
+	{unicode.Thai, "thai"},
+	{unicode.Lao, "lao "},
+	{unicode.Tibetan, "tibt"},
+	{unicode.Georgian, "geor"},
+	{unicode.Hangul, "hang"},
+	{unicode.Ethiopic, "ethi"},
+	{unicode.Khmer, "khmr"},
+	{unicode.Hiragana, "kana"},
+	{unicode.Katakana, "kana"},
+	{unicode.Han, "hani"},
+}
+
+// ScriptForRune returns the OpenType tag of the script that r belongs to, or
+// "DFLT" if r is shared by multiple scripts (like digits and punctuation) or
+// belongs to a script that the function doesn't know.
+//
+// The tags of the Indic scripts are the ones of their new shaping model, for
+// example "dev2" for Devanagari.
+func ScriptForRune(r rune) string {
+	for _, s := range scriptTags {
+		if unicode.Is(s.table, r) {
+			return s.tag
+		}
+	}
+	return "DFLT"
+}
+
+// ScriptForRunes returns the script of the first rune that belongs to a known
+// script, or "DFLT" if none of the runes do. It's the script used to select the
+// kerning of a run of text.
+func ScriptForRunes(runes []rune) string {
+	for _, r := range runes {
+		if script := ScriptForRune(r); script != "DFLT" {
+			return script
+		}
+	}
+	return "DFLT"
+}
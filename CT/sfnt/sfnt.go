@@ -0,0 +1,72 @@
+// Package sfnt implements parsers for the tables of TrueType and OpenType font
+// files (the sfnt container format), in pure Go.
+//
+// The parsers work on the raw bytes of a table, as returned by CTFontCopyTable
+// or read from a font file, so they don't depend on Core Text and can be used
+// and tested on any platform.
+//
+// Parsers never print anything, parts of a table that they don't support or
+// that are malformed are skipped and reported as diagnostics.
+package sfnt
+
+import (
+	"errors"
+	"fmt"
+)
+
+// GlyphID is the index of a glyph in a font.
+type GlyphID uint16
+
+// Kerner is implemented by the tables that provide the kerning of pairs of
+// glyphs, like KernTable and GPOSKerning.
+type Kerner interface {
+	// Kern returns the adjustment of the space between the pair of glyphs
+	// passed as arguments, in font units.
+	Kern(left GlyphID, right GlyphID) int
+}
+
+// ErrInvalidTable is returned when the header of a table is malformed or
+// truncated, to the point where no data can be read from it.
+var ErrInvalidTable = errors.New("sfnt: invalid table")
+
+// Diagnostic describes a part of a table that a parser skipped, because it was
+// malformed or used a feature that isn't supported.
+type Diagnostic struct {
+	// The tag of the table that the diagnostic was produced for.
+	Table string
+
+	// The index of the subtable that was skipped, or -1 if the diagnostic
+	// isn't related to a specific subtable.
+	Subtable int
+
+	// A description of the problem.
+	Message string
+}
+
+// String satisfies the fmt.Stringer interface.
+func (d Diagnostic) String() string {
+	if d.Subtable < 0 {
+		return fmt.Sprintf("sfnt: %s: %s", d.Table, d.Message)
+	}
+	return fmt.Sprintf("sfnt: %s: subtable %d: %s", d.Table, d.Subtable, d.Message)
+}
+
+func invalidTable(table string, format string, args ...interface{}) error {
+	return fmt.Errorf("%w: %s: %s", ErrInvalidTable, table, fmt.Sprintf(format, args...))
+}
+
+// NewKerner returns the source of kerning values of a font for the given
+// script, which is the 'kern' feature of the GPOS table if it has one for the
+// script, or the legacy 'kern' table otherwise.
+//
+// Both tables may be nil, the returned value is never nil and returns zero for
+// all pairs of glyphs if the font has no kerning.
+func NewKerner(gpos *GPOSTable, kern *KernTable, script string) Kerner {
+	if k := gpos.Kerning(script, ""); k != nil {
+		return k
+	}
+
+	// The methods of KernTable work on nil tables, which is what ParseKern
+	// returns for fonts that have no 'kern' table.
+	return kern
+}
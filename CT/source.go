@@ -0,0 +1,49 @@
+package CT
+
+import (
+	"image"
+
+	"github.com/go-vu/cocoa/CG"
+)
+
+// FontSource is the interface of the sources of font metrics and glyphs that
+// text layout and rendering code is written against, it's made of the metric
+// and drawing methods of FontRef.
+//
+// Values are in points, positions and bounds follow the conventions of
+// FontRef: bounds are in the Quartz space, relative to the origin of the
+// glyph, while the origin passed to GlyphDraw is in the coordinate space of
+// the image, which has its origin in the top-left corner.
+//
+// FontRef is the implementation of the interface backed by Core Text, the
+// fontfile package provides an implementation that reads font files in pure
+// Go, and can be used on any platform.
+type FontSource interface {
+	// GetAscent returns the distance from the baseline to the top of the
+	// lines.
+	GetAscent() CG.Float
+
+	// GetDescent returns the distance from the baseline to the bottom of the
+	// lines, as a positive value.
+	GetDescent() CG.Float
+
+	// GetLeading returns the space to leave between lines.
+	GetLeading() CG.Float
+
+	// GlyphDraw draws the glyph of the rune into the alpha image, at the given
+	// position. It returns false if the font has no glyph for the rune.
+	GlyphDraw(char rune, origin CG.Point, alpha *image.Alpha) bool
+
+	// GlyphAdvance returns the advance of the glyph of the rune, or zero if
+	// the font has no glyph for the rune.
+	GlyphAdvance(char rune) CG.Float
+
+	// GlyphBounds returns the advance and bounds of the glyph of the rune,
+	// both are zero if the font has no glyph for the rune or if the glyph has
+	// no ink.
+	GlyphBounds(char rune) (advance CG.Float, bounds CG.Rect)
+
+	// Kern returns the adjustment of the space between the glyphs of the two
+	// runes.
+	Kern(char0 rune, char1 rune) CG.Float
+}
@@ -0,0 +1,234 @@
+package CT
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+)
+
+// FontSymbolicTraits is an enumeration representing the style attributes of a font.
+//
+// The lower 16 bits are the symbolic traits of the font, the upper 4 bits hold
+// its stylistic class (see FontStylisticClass).
+//
+// https://developer.apple.com/library/mac/documentation/Carbon/Reference/CTFontDescriptorRef/#//apple_ref/c/tdef/CTFontSymbolicTraits
+type FontSymbolicTraits uint32
+
+// These constants are all the possible values of the FontSymbolicTraits
+// enumeration.
+const (
+	FontItalicTrait      FontSymbolicTraits = 1 << 0
+	FontBoldTrait        FontSymbolicTraits = 1 << 1
+	FontExpandedTrait    FontSymbolicTraits = 1 << 5
+	FontCondensedTrait   FontSymbolicTraits = 1 << 6
+	FontMonoSpaceTrait   FontSymbolicTraits = 1 << 10
+	FontVerticalTrait    FontSymbolicTraits = 1 << 11
+	FontUIOptimizedTrait FontSymbolicTraits = 1 << 12
+	FontColorGlyphsTrait FontSymbolicTraits = 1 << 13
+	FontCompositeTrait   FontSymbolicTraits = 1 << 14
+	FontClassMaskTrait   FontSymbolicTraits = 0xF << FontClassMaskShift
+)
+
+// FontClassMaskShift is the position of the stylistic class in the
+// FontSymbolicTraits bit field.
+//
+// https://developer.apple.com/library/mac/documentation/Carbon/Reference/CTFontDescriptorRef/#//apple_ref/c/econst/kCTFontClassMaskShift
+const FontClassMaskShift = 28
+
+// FontStylisticClass is an enumeration representing the stylistic class of a
+// font, its values are stored in the bits of FontSymbolicTraits selected by
+// FontClassMaskTrait.
+//
+// https://developer.apple.com/library/mac/documentation/Carbon/Reference/CTFontDescriptorRef/#//apple_ref/c/tdef/CTFontStylisticClass
+type FontStylisticClass uint32
+
+// These constants are all the possible values of the FontStylisticClass
+// enumeration.
+const (
+	FontClassUnknown            FontStylisticClass = 0 << FontClassMaskShift
+	FontClassOldStyleSerifs     FontStylisticClass = 1 << FontClassMaskShift
+	FontClassTransitionalSerifs FontStylisticClass = 2 << FontClassMaskShift
+	FontClassModernSerifs       FontStylisticClass = 3 << FontClassMaskShift
+	FontClassClarendonSerifs    FontStylisticClass = 4 << FontClassMaskShift
+	FontClassSlabSerifs         FontStylisticClass = 5 << FontClassMaskShift
+	FontClassFreeformSerifs     FontStylisticClass = 7 << FontClassMaskShift
+	FontClassSansSerif          FontStylisticClass = 8 << FontClassMaskShift
+	FontClassOrnamentals        FontStylisticClass = 9 << FontClassMaskShift
+	FontClassScripts            FontStylisticClass = 10 << FontClassMaskShift
+	FontClassSymbolic           FontStylisticClass = 12 << FontClassMaskShift
+)
+
+var fontTraitNames = [...]struct {
+	trait FontSymbolicTraits
+	name  string
+}{
+	{FontItalicTrait, "Italic"},
+	{FontBoldTrait, "Bold"},
+	{FontExpandedTrait, "Expanded"},
+	{FontCondensedTrait, "Condensed"},
+	{FontMonoSpaceTrait, "MonoSpace"},
+	{FontVerticalTrait, "Vertical"},
+	{FontUIOptimizedTrait, "UIOptimized"},
+	{FontColorGlyphsTrait, "ColorGlyphs"},
+	{FontCompositeTrait, "Composite"},
+}
+
+var fontClassNames = [...]struct {
+	class FontStylisticClass
+	name  string
+}{
+	{FontClassUnknown, "Unknown"},
+	{FontClassOldStyleSerifs, "OldStyleSerifs"},
+	{FontClassTransitionalSerifs, "TransitionalSerifs"},
+	{FontClassModernSerifs, "ModernSerifs"},
+	{FontClassClarendonSerifs, "ClarendonSerifs"},
+	{FontClassSlabSerifs, "SlabSerifs"},
+	{FontClassFreeformSerifs, "FreeformSerifs"},
+	{FontClassSansSerif, "SansSerif"},
+	{FontClassOrnamentals, "Ornamentals"},
+	{FontClassScripts, "Scripts"},
+	{FontClassSymbolic, "Symbolic"},
+}
+
+// Has returns true if all the traits set in t are also set in traits.
+func (traits FontSymbolicTraits) Has(t FontSymbolicTraits) bool {
+	return (traits & t) == t
+}
+
+// With returns a copy of traits where the traits set in t are also set.
+func (traits FontSymbolicTraits) With(t FontSymbolicTraits) FontSymbolicTraits {
+	return traits | t
+}
+
+// Without returns a copy of traits where the traits set in t are cleared.
+func (traits FontSymbolicTraits) Without(t FontSymbolicTraits) FontSymbolicTraits {
+	return traits &^ t
+}
+
+// StylisticClass returns the stylistic class stored in traits.
+func (traits FontSymbolicTraits) StylisticClass() FontStylisticClass {
+	return FontStylisticClass(traits & FontClassMaskTrait)
+}
+
+// WithStylisticClass returns a copy of traits where the stylistic class is
+// replaced by class.
+func (traits FontSymbolicTraits) WithStylisticClass(class FontStylisticClass) FontSymbolicTraits {
+	return traits.Without(FontClassMaskTrait) | (FontSymbolicTraits(class) & FontClassMaskTrait)
+}
+
+// String satisfies the fmt.Stringer interface.
+//
+// The traits are represented by their names separated by '|', followed by the
+// name of the stylistic class if it's not FontClassUnknown, bits that have no
+// name are represented in hexadecimal. Zero traits are represented by "None".
+func (traits FontSymbolicTraits) String() string {
+	if traits == 0 {
+		return "None"
+	}
+
+	s := &bytes.Buffer{}
+	write := func(name string) {
+		if s.Len() != 0 {
+			s.WriteByte('|')
+		}
+		s.WriteString(name)
+	}
+
+	rest := traits.Without(FontClassMaskTrait)
+
+	for _, t := range fontTraitNames {
+		if rest.Has(t.trait) {
+			write(t.name)
+			rest = rest.Without(t.trait)
+		}
+	}
+
+	if class := traits.StylisticClass(); class != FontClassUnknown {
+		write(class.String())
+	}
+
+	if rest != 0 {
+		write(fmt.Sprintf("0x%x", uint32(rest)))
+	}
+
+	return s.String()
+}
+
+// String satisfies the fmt.Stringer interface.
+func (class FontStylisticClass) String() string {
+	for _, c := range fontClassNames {
+		if c.class == class {
+			return c.name
+		}
+	}
+	return fmt.Sprintf("FontStylisticClass(%d)", uint32(class>>FontClassMaskShift))
+}
+
+// ParseFontSymbolicTraits parses a list of trait names separated by '|' and
+// returns the traits they represent, it accepts the strings returned by the
+// String method of FontSymbolicTraits.
+//
+// Names are matched without regard to case and may be the name of a stylistic
+// class, at most one stylistic class can be given. Spaces around the names are
+// ignored, and an empty string or "None" is parsed as zero traits.
+func ParseFontSymbolicTraits(s string) (FontSymbolicTraits, error) {
+	traits := FontSymbolicTraits(0)
+	class := false
+
+	if s = strings.TrimSpace(s); len(s) == 0 {
+		return 0, nil
+	}
+
+	for _, name := range strings.Split(s, "|") {
+		name = strings.TrimSpace(name)
+
+		if strings.EqualFold(name, "None") {
+			continue
+		}
+
+		if t, ok := parseFontTrait(name); ok {
+			traits = traits.With(t)
+			continue
+		}
+
+		if c, ok := parseFontStylisticClass(name); ok {
+			if class {
+				return 0, fmt.Errorf("CT: more than one stylistic class in font traits: %q", s)
+			}
+			class = true
+			traits = traits.WithStylisticClass(c)
+			continue
+		}
+
+		return 0, fmt.Errorf("CT: unknown font trait %q in %q", name, s)
+	}
+
+	return traits, nil
+}
+
+// ParseFontStylisticClass returns the stylistic class named by s, matched
+// without regard to case.
+func ParseFontStylisticClass(s string) (FontStylisticClass, error) {
+	if c, ok := parseFontStylisticClass(strings.TrimSpace(s)); ok {
+		return c, nil
+	}
+	return 0, fmt.Errorf("CT: unknown font stylistic class: %q", s)
+}
+
+func parseFontTrait(name string) (FontSymbolicTraits, bool) {
+	for _, t := range fontTraitNames {
+		if strings.EqualFold(t.name, name) {
+			return t.trait, true
+		}
+	}
+	return 0, false
+}
+
+func parseFontStylisticClass(name string) (FontStylisticClass, bool) {
+	for _, c := range fontClassNames {
+		if strings.EqualFold(c.name, name) {
+			return c.class, true
+		}
+	}
+	return 0, false
+}
@@ -0,0 +1,136 @@
+package CT
+
+import "testing"
+
+func TestFontSymbolicTraitsValues(t *testing.T) {
+	// The values of the kCTFont*Trait constants in CTFontTraits.h.
+	tests := []struct {
+		trait FontSymbolicTraits
+		value uint32
+	}{
+		{FontItalicTrait, 1 << 0},
+		{FontBoldTrait, 1 << 1},
+		{FontExpandedTrait, 1 << 5},
+		{FontCondensedTrait, 1 << 6},
+		{FontMonoSpaceTrait, 1 << 10},
+		{FontVerticalTrait, 1 << 11},
+		{FontUIOptimizedTrait, 1 << 12},
+		{FontColorGlyphsTrait, 1 << 13},
+		{FontCompositeTrait, 1 << 14},
+		{FontClassMaskTrait, 0xF0000000},
+	}
+
+	for _, test := range tests {
+		if uint32(test.trait) != test.value {
+			t.Errorf("invalid value of %v trait: %#x != %#x", test.trait, uint32(test.trait), test.value)
+		}
+	}
+}
+
+func TestFontSymbolicTraitsHas(t *testing.T) {
+	traits := FontBoldTrait.With(FontItalicTrait)
+
+	if !traits.Has(FontBoldTrait) || !traits.Has(FontItalicTrait) || !traits.Has(FontBoldTrait|FontItalicTrait) {
+		t.Error("traits are missing from", traits)
+	}
+
+	if traits.Has(FontMonoSpaceTrait) || traits.Has(FontBoldTrait|FontMonoSpaceTrait) {
+		t.Error("unexpected traits found in", traits)
+	}
+
+	if traits = traits.Without(FontItalicTrait); traits != FontBoldTrait {
+		t.Error("invalid traits after removing italic:", traits)
+	}
+
+	if FontBoldTrait == FontItalicTrait || FontMonoSpaceTrait == FontCondensedTrait || FontExpandedTrait == FontItalicTrait {
+		t.Error("distinct traits have the same value")
+	}
+}
+
+func TestFontSymbolicTraitsStylisticClass(t *testing.T) {
+	traits := FontBoldTrait.WithStylisticClass(FontClassSansSerif)
+
+	if c := traits.StylisticClass(); c != FontClassSansSerif {
+		t.Error("invalid stylistic class:", c)
+	}
+
+	if traits = traits.WithStylisticClass(FontClassScripts); traits != FontBoldTrait|FontSymbolicTraits(FontClassScripts) {
+		t.Error("invalid traits after replacing the stylistic class:", traits)
+	}
+
+	if traits = traits.WithStylisticClass(FontClassUnknown); traits != FontBoldTrait {
+		t.Error("invalid traits after clearing the stylistic class:", traits)
+	}
+}
+
+func TestFontSymbolicTraitsString(t *testing.T) {
+	tests := []struct {
+		traits FontSymbolicTraits
+		s      string
+	}{
+		{0, "None"},
+		{FontItalicTrait, "Italic"},
+		{FontBoldTrait | FontItalicTrait, "Italic|Bold"},
+		{FontMonoSpaceTrait.WithStylisticClass(FontClassModernSerifs), "MonoSpace|ModernSerifs"},
+		{FontSymbolicTraits(FontClassSymbolic), "Symbolic"},
+		{FontBoldTrait | 1<<20, "Bold|0x100000"},
+	}
+
+	for _, test := range tests {
+		if s := test.traits.String(); s != test.s {
+			t.Errorf("invalid string representation of %#x: %q != %q", uint32(test.traits), s, test.s)
+		}
+	}
+}
+
+func TestParseFontSymbolicTraits(t *testing.T) {
+	tests := []struct {
+		s      string
+		traits FontSymbolicTraits
+	}{
+		{"", 0},
+		{"None", 0},
+		{"Bold", FontBoldTrait},
+		{"italic | BOLD", FontBoldTrait | FontItalicTrait},
+		{"Condensed|SlabSerifs", FontCondensedTrait.WithStylisticClass(FontClassSlabSerifs)},
+		{"MonoSpace|ModernSerifs", FontMonoSpaceTrait.WithStylisticClass(FontClassModernSerifs)},
+	}
+
+	for _, test := range tests {
+		traits, err := ParseFontSymbolicTraits(test.s)
+
+		if err != nil {
+			t.Errorf("parsing %q: %v", test.s, err)
+		} else if traits != test.traits {
+			t.Errorf("parsing %q: %v != %v", test.s, traits, test.traits)
+		}
+	}
+}
+
+func TestParseFontSymbolicTraitsError(t *testing.T) {
+	for _, s := range []string{"Heavy", "Bold|", "SansSerif|Scripts", "0x100000"} {
+		if traits, err := ParseFontSymbolicTraits(s); err == nil {
+			t.Errorf("parsing %q did not fail: %v", s, traits)
+		}
+	}
+}
+
+func TestParseFontStylisticClass(t *testing.T) {
+	for _, c := range fontClassNames {
+		class, err := ParseFontStylisticClass(c.class.String())
+
+		if err != nil {
+			t.Error(err)
+		} else if class != c.class {
+			t.Errorf("invalid stylistic class parsed from %q: %v", c.name, class)
+		}
+	}
+
+	if _, err := ParseFontStylisticClass("Gothic"); err == nil {
+		t.Error("parsing an unknown stylistic class did not fail")
+	}
+
+	if s := FontStylisticClass(6 << FontClassMaskShift).String(); s != "FontStylisticClass(6)" {
+		t.Error("invalid string representation of an unknown stylistic class:", s)
+	}
+}
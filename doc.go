@@ -1,5 +1,3 @@
-// +build darwin
-
 // Package cocoa provides functions and types that are shared between go-vu
 // drivers for Apple platforms.
 //